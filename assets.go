@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 // FileLoader implements support for loading and releasing file resources.
@@ -49,6 +50,19 @@ type Formats struct {
 
 	// root is the directory which is prepended to every resource url internally.
 	root string
+
+	// loaded maps the paths of the loaded files to the urls and roots they were loaded with.
+	loaded map[string]loadedFile
+	// reloader is set while hot reloading is enabled.
+	reloader *hotReloader
+
+	// cache tracks the resident resources, and budgets limits the memory used by each category of them.
+	cache   map[string]*cacheEntry
+	budgets map[string]int64
+	uses    uint64
+
+	// mu guards formats, loaded, reloader and the cache, which are read by the workers of LoadAsync and hot reloading
+	mu sync.RWMutex
 }
 
 // SetRoot can be used to change the default directory from `assets` to whatever you want.
@@ -69,9 +83,19 @@ func (formats *Formats) GetRoot() string {
 
 // Register registers a resource loader for the given file format.
 func (formats *Formats) Register(ext string, loader FileLoader) {
+	formats.mu.Lock()
+	defer formats.mu.Unlock()
 	formats.formats[ext] = loader
 }
 
+// loader returns the FileLoader registered for the extension.
+func (formats *Formats) loader(ext string) (FileLoader, bool) {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	loader, ok := formats.formats[ext]
+	return loader, ok
+}
+
 // getExt returns the extension of the file(including extensions with `.` in them) from the given url.
 func getExt(path string) string {
 	ext := ""
@@ -86,7 +110,7 @@ func getExt(path string) string {
 // load loads the given resource into memory.
 func (formats *Formats) load(url string) error {
 	ext := getExt(url)
-	if loader, ok := Files.formats[ext]; ok {
+	if loader, ok := formats.loader(ext); ok {
 		f, err := openFile(filepath.Join(formats.root, url))
 		if err != nil {
 			return fmt.Errorf("unable to open resource: %s", err)
@@ -99,7 +123,13 @@ func (formats *Formats) load(url string) error {
 			rl.SetRoot(formats.GetRoot())
 		}
 
-		return loader.Load(url, f)
+		size := readerSize(f)
+		if err := loader.Load(url, f); err != nil {
+			return err
+		}
+		formats.track(url, formats.root)
+		formats.resident(url, loader, size)
+		return nil
 	}
 	return fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url)
 }
@@ -118,21 +148,40 @@ func (formats *Formats) Load(urls ...string) error {
 // LoadReaderData loads a resource when you already have the reader for it.
 func (formats *Formats) LoadReaderData(url string, f io.Reader) error {
 	ext := getExt(url)
-	if loader, ok := Files.formats[ext]; ok {
+	if loader, ok := formats.loader(ext); ok {
 		// This specific loader needs to be given the root
 		rl, ok := loader.(FileLoaderRooter)
 		if ok {
 			rl.SetRoot(formats.GetRoot())
 		}
-		return loader.Load(url, f)
+
+		size := readerSize(f)
+		counter := &countingReader{Reader: f}
+		if err := loader.Load(url, counter); err != nil {
+			return err
+		}
+		if size < 0 {
+			size = counter.n
+		}
+		formats.resident(url, loader, size)
+		return nil
 	}
 	return fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url)
 }
 
+// Open opens the file at the given url, relative to the root, without loading it as a resource. This can be used by
+// `FileLoader`s that need the raw contents of files referenced by the file they load. The caller has to close the
+// returned file.
+func (formats *Formats) Open(url string) (io.ReadCloser, error) {
+	return openFile(filepath.Join(formats.root, url))
+}
+
 // Unload releases the given resource from memory.
 func (formats *Formats) Unload(url string) error {
 	ext := getExt(url)
-	if loader, ok := Files.formats[ext]; ok {
+	if loader, ok := formats.loader(ext); ok {
+		formats.untrack(url)
+		formats.uncache(url)
 		return loader.Unload(url)
 	}
 	return fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url)
@@ -141,7 +190,8 @@ func (formats *Formats) Unload(url string) error {
 // Resource returns the given resource, and an error if it didn't succeed.
 func (formats *Formats) Resource(url string) (Resource, error) {
 	ext := getExt(url)
-	if loader, ok := Files.formats[ext]; ok {
+	if loader, ok := formats.loader(ext); ok {
+		formats.used(url)
 		return loader.Resource(url)
 	}
 	return nil, fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url)
@@ -0,0 +1,279 @@
+package engo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// AsyncFileLoader is implemented by FileLoaders that can decode resources on worker goroutines when they are loaded
+// with `Formats.LoadAsync`. The other FileLoaders only read the files on the workers, their Load method is called on
+// the main thread.
+type AsyncFileLoader interface {
+	FileLoader
+
+	// Decode decodes the resource. It is called on a worker goroutine, so it must neither make OpenGL calls nor
+	// modify the FileLoader. The returned function is called on the main thread to finish loading, for example to
+	// upload textures to the GPU and store the resource.
+	Decode(url string, data io.Reader) (finish func() error, err error)
+}
+
+// LoadProgressMessage is dispatched through the Mailbox, on the main thread, every time a file of a
+// `Formats.LoadAsync` call has been loaded.
+type LoadProgressMessage struct {
+	Task *LoadTask
+	// URL is the file that has been loaded, and Err the error that occurred while loading it, if any.
+	URL string
+	Err error
+
+	// Files is the amount of files that have been loaded out of TotalFiles.
+	Files, TotalFiles int
+	// Bytes is the amount of bytes that have been read out of TotalBytes. The size of a file is only known once it
+	// has been opened, until then it is not part of TotalBytes.
+	Bytes, TotalBytes int64
+	// Done is set when all files have been loaded.
+	Done bool
+}
+
+// Type returns the type of the message, "LoadProgressMessage"
+func (LoadProgressMessage) Type() string { return "LoadProgressMessage" }
+
+// LoadTask tracks the progress of a `Formats.LoadAsync` call.
+type LoadTask struct {
+	mu       sync.Mutex
+	progress LoadProgressMessage
+	err      error
+	done     func(*LoadTask)
+}
+
+// Progress returns the progress of the task, as reported by its last LoadProgressMessage.
+func (t *LoadTask) Progress() LoadProgressMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+// Done reports whether all files have been loaded.
+func (t *LoadTask) Done() bool {
+	return t.Progress().Done
+}
+
+// Err returns the first error that occurred while loading the files.
+func (t *LoadTask) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *LoadTask) addBytes(read, total int64) {
+	t.mu.Lock()
+	t.progress.Bytes += read
+	t.progress.TotalBytes += total
+	t.mu.Unlock()
+}
+
+// fileDone reports a loaded file, it's called on the main thread.
+func (t *LoadTask) fileDone(url string, err error) {
+	t.mu.Lock()
+	t.progress.Files++
+	t.progress.URL = url
+	t.progress.Err = err
+	t.progress.Done = t.progress.Files >= t.progress.TotalFiles
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	msg := t.progress
+	t.mu.Unlock()
+
+	if Mailbox != nil {
+		Mailbox.Dispatch(msg)
+	}
+	if msg.Done && t.done != nil {
+		t.done(t)
+	}
+}
+
+// LoadAsync starts loading the given resources on worker goroutines, and returns immediately. The progress is
+// reported through LoadProgressMessages, and the resources are available through `Formats.Resource` once they have
+// been reported as loaded.
+//
+// Files are read and decoded by the workers, everything that has to happen on the main thread, such as uploading
+// textures, is done at the start of the next frames.
+func (formats *Formats) LoadAsync(urls ...string) *LoadTask {
+	return formats.loadAsync(urls, nil)
+}
+
+func (formats *Formats) loadAsync(urls []string, done func(*LoadTask)) *LoadTask {
+	task := &LoadTask{done: done}
+	task.progress.Task = task
+	task.progress.TotalFiles = len(urls)
+	if len(urls) == 0 {
+		runOnMainThread(func() {
+			task.mu.Lock()
+			task.progress.Done = true
+			msg := task.progress
+			task.mu.Unlock()
+			if Mailbox != nil {
+				Mailbox.Dispatch(msg)
+			}
+			if done != nil {
+				done(task)
+			}
+		})
+		return task
+	}
+
+	queue := make(chan string, len(urls))
+	for _, url := range urls {
+		queue <- url
+	}
+	close(queue)
+
+	workers := runtime.NumCPU()
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+	root := formats.root
+	for i := 0; i < workers; i++ {
+		go func() {
+			for url := range queue {
+				formats.loadWorker(task, root, url)
+			}
+		}()
+	}
+	return task
+}
+
+// loadWorker reads and decodes a single file on a worker goroutine.
+func (formats *Formats) loadWorker(task *LoadTask, root, url string) {
+	fail := func(err error) {
+		runOnMainThread(func() { task.fileDone(url, err) })
+	}
+
+	ext := getExt(url)
+	loader, ok := formats.loader(ext)
+	if !ok {
+		fail(fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url))
+		return
+	}
+
+	f, err := openFile(filepath.Join(root, url))
+	if err != nil {
+		fail(fmt.Errorf("unable to open resource: %s", err))
+		return
+	}
+	size := readerSize(f)
+	if size >= 0 {
+		task.addBytes(0, size)
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if size < 0 {
+		task.addBytes(0, int64(len(data)))
+	}
+	task.addBytes(int64(len(data)), 0)
+	if err != nil {
+		fail(fmt.Errorf("unable to read resource: %s", err))
+		return
+	}
+
+	if al, ok := loader.(AsyncFileLoader); ok {
+		finish, err := al.Decode(url, bytes.NewReader(data))
+		if err != nil {
+			fail(err)
+			return
+		}
+		runOnMainThread(func() {
+			err := finish()
+			if err == nil {
+				formats.track(url, root)
+				formats.resident(url, loader, int64(len(data)))
+			}
+			task.fileDone(url, err)
+		})
+		return
+	}
+
+	runOnMainThread(func() {
+		// This specific loader needs to be given the root
+		if rl, ok := loader.(FileLoaderRooter); ok {
+			rl.SetRoot(root)
+		}
+		err := loader.Load(url, bytes.NewReader(data))
+		if err == nil {
+			formats.track(url, root)
+			formats.resident(url, loader, int64(len(data)))
+		}
+		task.fileDone(url, err)
+	})
+}
+
+// readerSize returns the size of the opened file, or -1 if it's unknown.
+func readerSize(r io.Reader) int64 {
+	switch f := r.(type) {
+	case *os.File:
+		if info, err := f.Stat(); err == nil {
+			return info.Size()
+		}
+	case *bytes.Reader:
+		return int64(f.Len())
+	case io.Seeker:
+		cur, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := f.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - cur
+	}
+	return -1
+}
+
+// LoadScene shows the loading Scene while the given files are loaded asynchronously, and switches to the next Scene
+// once all of them have been loaded. The loading Scene can listen for LoadProgressMessages to display the progress.
+// The files are already loaded when the Preload method of the next Scene is called.
+//
+// If a file could not be loaded, the loading Scene stays active. The error is reported by the LoadProgressMessage
+// of that file and by the returned LoadTask.
+func LoadScene(loading, next Scene, urls ...string) *LoadTask {
+	SetScene(loading, false)
+	return Files.loadAsync(urls, func(t *LoadTask) {
+		if t.Err() == nil {
+			SetScene(next, false)
+		}
+	})
+}
+
+var (
+	mainThreadMutex sync.Mutex
+	mainThreadCalls []func()
+)
+
+// runOnMainThread queues the function to be called at the start of the next frame.
+func runOnMainThread(f func()) {
+	mainThreadMutex.Lock()
+	mainThreadCalls = append(mainThreadCalls, f)
+	mainThreadMutex.Unlock()
+}
+
+// runMainThreadCalls calls the functions queued by runOnMainThread.
+func runMainThreadCalls() {
+	mainThreadMutex.Lock()
+	calls := mainThreadCalls
+	mainThreadCalls = nil
+	mainThreadMutex.Unlock()
+
+	for _, f := range calls {
+		f()
+	}
+}
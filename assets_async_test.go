@@ -0,0 +1,166 @@
+package engo
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// asyncTestLoader records the resources it decoded and finished.
+type asyncTestLoader struct {
+	mu       sync.Mutex
+	decoded  map[string]string
+	finished map[string]bool
+}
+
+func (l *asyncTestLoader) Load(url string, data io.Reader) error {
+	finish, err := l.Decode(url, data)
+	if err != nil {
+		return err
+	}
+	return finish()
+}
+
+func (l *asyncTestLoader) Decode(url string, data io.Reader) (func() error, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.decoded[url] = string(b)
+	l.mu.Unlock()
+	return func() error {
+		l.finished[url] = true
+		return nil
+	}, nil
+}
+
+func (l *asyncTestLoader) Unload(url string) error {
+	delete(l.finished, url)
+	return nil
+}
+
+func (l *asyncTestLoader) Resource(url string) (Resource, error) {
+	return testResource{url: url}, nil
+}
+
+// waitForTask runs the main thread calls until the task is done.
+func waitForTask(t *testing.T, task *LoadTask) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !task.Done() {
+		if time.Now().After(deadline) {
+			t.Fatal("LoadTask did not finish in time")
+		}
+		runMainThreadCalls()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func asyncTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir(".", "testing")
+	if err != nil {
+		t.Fatalf("failed to create temp directory for testing, error: %v", err)
+	}
+	files := map[string]string{"first.asynctest": "12345", "second.asynctest": "123", "plain.test": "1234567890"}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file, error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestFilesLoadAsync(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	loader := &asyncTestLoader{decoded: make(map[string]string), finished: make(map[string]bool)}
+	Files.Register(".asynctest", loader)
+	Files.Register(".test", &testLoader{})
+
+	dir := asyncTestDir(t)
+	defer os.RemoveAll(dir)
+	Files.SetRoot(dir)
+
+	var msgs []LoadProgressMessage
+	Mailbox.Listen("LoadProgressMessage", func(msg Message) {
+		msgs = append(msgs, msg.(LoadProgressMessage))
+	})
+
+	task := Files.LoadAsync("first.asynctest", "second.asynctest", "plain.test")
+	waitForTask(t, task)
+
+	if len(msgs) != 3 {
+		t.Fatalf("%d progress messages were dispatched, expected 3", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg.Files != i+1 || msg.TotalFiles != 3 || msg.Task != task {
+			t.Errorf("Progress message %d reported %d of %d files", i, msg.Files, msg.TotalFiles)
+		}
+		if msg.Done != (i == 2) {
+			t.Errorf("Progress message %d had Done set to %v", i, msg.Done)
+		}
+	}
+	if p := task.Progress(); p.Bytes != 18 || p.TotalBytes != 18 {
+		t.Errorf("Progress reported %d of %d bytes, expected 18 of 18", p.Bytes, p.TotalBytes)
+	}
+	if loader.decoded["first.asynctest"] != "12345" || !loader.finished["first.asynctest"] || !loader.finished["second.asynctest"] {
+		t.Errorf("AsyncFileLoader did not decode and finish the files: %v %v", loader.decoded, loader.finished)
+	}
+	if task.Err() != nil {
+		t.Errorf("LoadTask returned an error: %v", task.Err())
+	}
+
+	task = Files.LoadAsync("first.asynctest", "missing.asynctest")
+	waitForTask(t, task)
+	if task.Err() == nil {
+		t.Error("Loading a missing file did not return an error")
+	}
+}
+
+type loadingTestScene struct {
+	name     string
+	progress []LoadProgressMessage
+}
+
+func (*loadingTestScene) Preload() {}
+
+func (s *loadingTestScene) Setup(Updater) {
+	Mailbox.Listen("LoadProgressMessage", func(msg Message) {
+		s.progress = append(s.progress, msg.(LoadProgressMessage))
+	})
+}
+
+func (s *loadingTestScene) Type() string { return s.name }
+
+func TestLoadScene(t *testing.T) {
+	resetScenes()
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	Files.Register(".asynctest", &asyncTestLoader{decoded: make(map[string]string), finished: make(map[string]bool)})
+
+	dir := asyncTestDir(t)
+	defer os.RemoveAll(dir)
+	Files.SetRoot(dir)
+
+	loading := &loadingTestScene{name: "loadingScene"}
+	next := &loadingTestScene{name: "loadedScene"}
+	task := LoadScene(loading, next, "first.asynctest", "second.asynctest")
+	if CurrentScene() != loading {
+		t.Fatalf("CurrentScene was %v while loading, expected the loading scene", CurrentScene().Type())
+	}
+	waitForTask(t, task)
+	if CurrentScene() != next {
+		t.Errorf("CurrentScene was %v after loading, expected the next scene", CurrentScene().Type())
+	}
+	if len(loading.progress) != 2 {
+		t.Errorf("Loading scene received %d progress messages, expected 2", len(loading.progress))
+	}
+
+	task = LoadScene(loading, next, "missing.asynctest")
+	waitForTask(t, task)
+	if CurrentScene() != loading {
+		t.Errorf("CurrentScene was %v after failing to load, expected the loading scene", CurrentScene().Type())
+	}
+}
@@ -0,0 +1,312 @@
+package engo
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+const (
+	// CategoryTexture is the memory budget category of images uploaded to the GPU.
+	CategoryTexture = "texture"
+	// CategoryAudio is the memory budget category of sounds and music.
+	CategoryAudio = "audio"
+	// CategoryFont is the memory budget category of fonts.
+	CategoryFont = "font"
+	// CategoryLevel is the memory budget category of levels and maps.
+	CategoryLevel = "level"
+	// CategoryOther is the memory budget category of resources that don't report one.
+	CategoryOther = "other"
+)
+
+// ResourceSizer is an optional interface for Resources that know how much memory they use. The size of the file is
+// used for the other Resources.
+type ResourceSizer interface {
+	// Size returns the approximate amount of bytes used by the resource.
+	Size() int64
+}
+
+// ResourceCategorizer is an optional interface for Resources to choose the memory budget they count towards. The other
+// Resources count towards CategoryOther.
+type ResourceCategorizer interface {
+	// Category returns the memory budget category of the resource, such as CategoryTexture.
+	Category() string
+}
+
+// ResourceStats describes a resident resource.
+type ResourceStats struct {
+	URL      string
+	Category string
+	// Size is the approximate amount of bytes used by the resource.
+	Size int64
+	// References is the amount of Handles to the resource that have not been released yet.
+	References int
+	// Managed is set for resources that have been acquired through Handles, they are unloaded automatically once
+	// they are not referenced anymore. The other resources have been loaded and have to be unloaded manually.
+	Managed bool
+}
+
+// Handle is a reference to a resource acquired with `Formats.Acquire`. The resource stays loaded at least until all
+// of its Handles have been released.
+type Handle struct {
+	formats  *Formats
+	url      string
+	owner    *sceneWrapper
+	released bool
+}
+
+// cacheEntry tracks a resident resource.
+type cacheEntry struct {
+	ResourceStats
+	// lastUsed orders the resources from the least to the most recently used
+	lastUsed uint64
+}
+
+// Acquire loads the resource if needed, and returns a Handle to it. The Handle belongs to the current Scene: it's
+// released when the Scene is removed from the stack, and the Scene is preloaded again the next time it's used. Handles
+// acquired before a Scene is set are never released automatically.
+//
+// Once all Handles to a resource loaded by Acquire have been released, it's unloaded, unless the memory budget of its
+// category has room for it. Such resources are unloaded once the budget is exceeded, starting with the least recently
+// used ones. Resources that were already loaded with Load have to be unloaded manually.
+func (formats *Formats) Acquire(url string) (*Handle, error) {
+	formats.mu.RLock()
+	_, resident := formats.cache[url]
+	formats.mu.RUnlock()
+	if !resident {
+		if err := formats.load(url); err != nil {
+			return nil, err
+		}
+	}
+
+	formats.mu.Lock()
+	e, ok := formats.cache[url]
+	if !ok {
+		formats.mu.Unlock()
+		return nil, errors.New("resource was unloaded while it was acquired: " + url)
+	}
+	e.References++
+	if !resident {
+		e.Managed = true
+	}
+	formats.touch(e)
+	formats.mu.Unlock()
+
+	h := &Handle{formats: formats, url: url}
+	if currentScene != nil {
+		sceneMutex.RLock()
+		h.owner = scenes[currentScene.Type()]
+		sceneMutex.RUnlock()
+	}
+	if h.owner != nil {
+		h.owner.handles = append(h.owner.handles, h)
+	}
+	return h, nil
+}
+
+// URL returns the url of the resource.
+func (h *Handle) URL() string {
+	return h.url
+}
+
+// Resource returns the resource, and marks it as recently used.
+func (h *Handle) Resource() (Resource, error) {
+	if h.released {
+		return nil, errors.New("resource handle has been released: " + h.url)
+	}
+	return h.formats.Resource(h.url)
+}
+
+// Release gives up the reference to the resource. Releasing a Handle more than once has no effect.
+func (h *Handle) Release() {
+	if h.released {
+		return
+	}
+	h.released = true
+
+	if h.owner != nil {
+		for i, other := range h.owner.handles {
+			if other == h {
+				h.owner.handles = append(h.owner.handles[:i], h.owner.handles[i+1:]...)
+				break
+			}
+		}
+	}
+
+	h.formats.mu.Lock()
+	e, ok := h.formats.cache[h.url]
+	if ok && e.References > 0 {
+		e.References--
+	}
+	h.formats.mu.Unlock()
+	if ok {
+		h.formats.evict(e.Category)
+	}
+}
+
+// SetBudget sets the amount of bytes the resources of the category may use. Resources that are not referenced by
+// Handles anymore stay loaded within the budget. A budget of 0, the default, unloads them immediately.
+//
+// The budget can be exceeded by resources that are still referenced, or that have been loaded without Handles.
+func (formats *Formats) SetBudget(category string, bytes int64) {
+	formats.mu.Lock()
+	if formats.budgets == nil {
+		formats.budgets = make(map[string]int64)
+	}
+	formats.budgets[category] = bytes
+	formats.mu.Unlock()
+
+	formats.evict(category)
+}
+
+// Budget returns the amount of bytes the resources of the category may use.
+func (formats *Formats) Budget(category string) int64 {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	return formats.budgets[category]
+}
+
+// MemoryUsage returns the amount of bytes used by the resident resources of the category.
+func (formats *Formats) MemoryUsage(category string) int64 {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	return formats.usage(category)
+}
+
+// Stats lists the resident resources, sorted by category and url.
+func (formats *Formats) Stats() []ResourceStats {
+	formats.mu.RLock()
+	stats := make([]ResourceStats, 0, len(formats.cache))
+	for _, e := range formats.cache {
+		stats = append(stats, e.ResourceStats)
+	}
+	formats.mu.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Category != stats[j].Category {
+			return stats[i].Category < stats[j].Category
+		}
+		return stats[i].URL < stats[j].URL
+	})
+	return stats
+}
+
+// resident records the resource has been loaded, fileSize is the size of its file or -1 if it's unknown.
+func (formats *Formats) resident(url string, loader FileLoader, fileSize int64) {
+	stats := ResourceStats{URL: url, Category: CategoryOther, Size: fileSize}
+	if stats.Size < 0 {
+		stats.Size = 0
+	}
+	if res, err := loader.Resource(url); err == nil {
+		if s, ok := res.(ResourceSizer); ok {
+			stats.Size = s.Size()
+		}
+		if c, ok := res.(ResourceCategorizer); ok {
+			stats.Category = c.Category()
+		}
+	}
+
+	formats.mu.Lock()
+	if formats.cache == nil {
+		formats.cache = make(map[string]*cacheEntry)
+	}
+	e, ok := formats.cache[url]
+	if !ok {
+		e = &cacheEntry{}
+		formats.cache[url] = e
+	}
+	stats.References, stats.Managed = e.References, e.Managed
+	e.ResourceStats = stats
+	formats.touch(e)
+	formats.mu.Unlock()
+
+	formats.evict(stats.Category)
+}
+
+// touch marks the entry as the most recently used, formats.mu has to be held.
+func (formats *Formats) touch(e *cacheEntry) {
+	formats.uses++
+	e.lastUsed = formats.uses
+}
+
+// usage returns the memory used by the category, formats.mu has to be held.
+func (formats *Formats) usage(category string) int64 {
+	var total int64
+	for _, e := range formats.cache {
+		if e.Category == category {
+			total += e.Size
+		}
+	}
+	return total
+}
+
+// evict unloads the least recently used resources of the category that are not referenced anymore, until it fits
+// within its budget.
+func (formats *Formats) evict(category string) {
+	for {
+		formats.mu.RLock()
+		budget, usage := formats.budgets[category], formats.usage(category)
+		var lru *cacheEntry
+		// Without a budget, resources are unloaded as soon as they are not referenced anymore
+		if budget <= 0 || usage > budget {
+			for _, e := range formats.cache {
+				if e.Category != category || !e.Managed || e.References > 0 {
+					continue
+				}
+				if lru == nil || e.lastUsed < lru.lastUsed {
+					lru = e
+				}
+			}
+		}
+		formats.mu.RUnlock()
+
+		if lru == nil {
+			return
+		}
+		formats.Unload(lru.URL)
+		formats.uncache(lru.URL)
+	}
+}
+
+// uncache forgets the resource after it has been unloaded.
+func (formats *Formats) uncache(url string) {
+	formats.mu.Lock()
+	delete(formats.cache, url)
+	formats.mu.Unlock()
+}
+
+// used marks the resource as recently used.
+func (formats *Formats) used(url string) {
+	formats.mu.Lock()
+	if e, ok := formats.cache[url]; ok {
+		formats.touch(e)
+	}
+	formats.mu.Unlock()
+}
+
+// releaseHandles releases the Handles acquired by the Scene. The Scene is preloaded again the next time it's used, since
+// its resources may have been unloaded.
+func releaseHandles(w *sceneWrapper) {
+	if w == nil || len(w.handles) == 0 {
+		return
+	}
+	handles := w.handles
+	w.handles = nil
+	for _, h := range handles {
+		h.owner = nil
+		h.Release()
+	}
+	w.preload = true
+}
+
+// countingReader counts the bytes read from a reader whose size is unknown.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
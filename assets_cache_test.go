@@ -0,0 +1,245 @@
+package engo
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cacheTestLoader counts how often its files are loaded, and which of them are loaded.
+type cacheTestLoader struct {
+	loads  map[string]int
+	loaded map[string]bool
+}
+
+func (l *cacheTestLoader) Load(url string, data io.Reader) error {
+	if _, err := ioutil.ReadAll(data); err != nil {
+		return err
+	}
+	l.loads[url]++
+	l.loaded[url] = true
+	return nil
+}
+
+func (l *cacheTestLoader) Unload(url string) error {
+	delete(l.loaded, url)
+	return nil
+}
+
+func (l *cacheTestLoader) Resource(url string) (Resource, error) {
+	return cacheTestResource{url: url}, nil
+}
+
+type cacheTestResource struct {
+	url string
+}
+
+func (r cacheTestResource) URL() string { return r.url }
+
+func (cacheTestResource) Category() string { return "cachetest" }
+
+// cacheTestScene acquires its files when it's set up.
+type cacheTestScene struct {
+	name    string
+	urls    []string
+	handles []*Handle
+	setups  int
+	t       *testing.T
+}
+
+func (s *cacheTestScene) Preload() {
+	s.handles = nil
+	for _, url := range s.urls {
+		h, err := Files.Acquire(url)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		s.handles = append(s.handles, h)
+	}
+}
+
+func (s *cacheTestScene) Setup(Updater) { s.setups++ }
+
+func (s *cacheTestScene) Type() string { return s.name }
+
+func cacheTestDir(t *testing.T, files map[string]string) (string, *cacheTestLoader) {
+	dir, err := ioutil.TempDir(".", "testing")
+	if err != nil {
+		t.Fatalf("failed to create temp directory for testing, error: %v", err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file, error: %v", err)
+		}
+	}
+	loader := &cacheTestLoader{loads: make(map[string]int), loaded: make(map[string]bool)}
+	Files.Register(".cachetest", loader)
+	Files.SetRoot(dir)
+	return dir, loader
+}
+
+func TestFilesAcquire(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	dir, loader := cacheTestDir(t, map[string]string{"sprite.cachetest": "12345"})
+	defer os.RemoveAll(dir)
+
+	first, err := Files.Acquire("sprite.cachetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Files.Acquire("sprite.cachetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loader.loads["sprite.cachetest"] != 1 {
+		t.Errorf("Acquired resource was loaded %d times, expected once", loader.loads["sprite.cachetest"])
+	}
+	if res, err := first.Resource(); err != nil || res.URL() != "sprite.cachetest" {
+		t.Errorf("Handle returned resource %v, error %v", res, err)
+	}
+
+	stats := Files.Stats()
+	found := false
+	for _, s := range stats {
+		if s.URL == "sprite.cachetest" {
+			found = true
+			if s.References != 2 || s.Size != 5 || s.Category != "cachetest" || !s.Managed {
+				t.Errorf("Stats of the acquired resource were %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("Stats did not list the acquired resource")
+	}
+
+	first.Release()
+	first.Release()
+	if !loader.loaded["sprite.cachetest"] {
+		t.Error("Resource was unloaded while it was still referenced")
+	}
+	if _, err := first.Resource(); err == nil {
+		t.Error("Released handle still returned the resource")
+	}
+	second.Release()
+	if loader.loaded["sprite.cachetest"] {
+		t.Error("Resource was not unloaded once it was not referenced anymore")
+	}
+	if Files.MemoryUsage("cachetest") != 0 {
+		t.Errorf("MemoryUsage was %d after unloading, expected 0", Files.MemoryUsage("cachetest"))
+	}
+}
+
+func TestFilesBudget(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	dir, loader := cacheTestDir(t, map[string]string{"a.cachetest": "12345", "b.cachetest": "12345", "c.cachetest": "12345"})
+	defer os.RemoveAll(dir)
+
+	Files.SetBudget("cachetest", 10)
+	defer Files.SetBudget("cachetest", 0)
+
+	var handles []*Handle
+	for _, url := range []string{"a.cachetest", "b.cachetest", "c.cachetest"} {
+		h, err := Files.Acquire(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles = append(handles, h)
+	}
+	if Files.MemoryUsage("cachetest") != 15 || len(loader.loaded) != 3 {
+		t.Errorf("Referenced resources exceeding the budget were unloaded")
+	}
+
+	// a is used last, so b is the least recently used
+	for _, h := range handles {
+		h.Release()
+	}
+	if len(loader.loaded) != 2 || loader.loaded["a.cachetest"] {
+		t.Fatalf("Resources %v were kept, expected b and c within the budget", loader.loaded)
+	}
+	Files.Resource("b.cachetest")
+	Files.SetBudget("cachetest", 5)
+	if len(loader.loaded) != 1 || !loader.loaded["b.cachetest"] {
+		t.Errorf("Resources %v were kept, expected the most recently used one", loader.loaded)
+	}
+
+	// Resources kept within the budget are not loaded again
+	h, err := Files.Acquire("b.cachetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loader.loads["b.cachetest"] != 1 {
+		t.Errorf("Cached resource was loaded %d times, expected once", loader.loads["b.cachetest"])
+	}
+	h.Release()
+	Files.SetBudget("cachetest", 0)
+	if len(loader.loaded) != 0 {
+		t.Errorf("Resources %v were kept without a budget", loader.loaded)
+	}
+}
+
+func TestSceneHandles(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	dir, loader := cacheTestDir(t, map[string]string{"menu.cachetest": "1", "shared.cachetest": "2", "level.cachetest": "3", "pause.cachetest": "4"})
+	defer os.RemoveAll(dir)
+
+	menu := &cacheTestScene{name: "cacheMenu", urls: []string{"menu.cachetest", "shared.cachetest"}, t: t}
+	level := &cacheTestScene{name: "cacheLevel", urls: []string{"shared.cachetest", "level.cachetest"}, t: t}
+	pause := &cacheTestScene{name: "cachePause", urls: []string{"pause.cachetest"}, t: t}
+
+	SetScene(menu, false)
+	SetScene(level, false)
+	if loader.loaded["menu.cachetest"] || !loader.loaded["shared.cachetest"] || !loader.loaded["level.cachetest"] {
+		t.Errorf("Resources %v were loaded after switching scenes, expected the shared and level ones", loader.loaded)
+	}
+	if loader.loads["shared.cachetest"] != 1 {
+		t.Errorf("Shared resource was loaded %d times, expected once", loader.loads["shared.cachetest"])
+	}
+
+	PushScene(pause, SceneFlags{}, Transition{})
+	if !loader.loaded["pause.cachetest"] || !loader.loaded["level.cachetest"] {
+		t.Errorf("Resources %v were loaded after pushing, expected the pause and level ones", loader.loaded)
+	}
+	if err := PopScene(Transition{}); err != nil {
+		t.Fatal(err)
+	}
+	if loader.loaded["pause.cachetest"] {
+		t.Error("Resources of the popped scene were not unloaded")
+	}
+
+	// The menu is preloaded again, since its resources were unloaded, but it keeps its World
+	SetScene(menu, false)
+	if !loader.loaded["menu.cachetest"] || loader.loaded["level.cachetest"] || loader.loads["menu.cachetest"] != 2 {
+		t.Errorf("Resources %v were loaded after switching back, expected the menu ones", loader.loaded)
+	}
+	if menu.setups != 1 {
+		t.Errorf("Menu was set up %d times, expected once", menu.setups)
+	}
+}
+
+func TestFilesAcquireLoaded(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	dir, loader := cacheTestDir(t, map[string]string{"manual.cachetest": "12345"})
+	defer os.RemoveAll(dir)
+
+	if err := Files.Load("manual.cachetest"); err != nil {
+		t.Fatal(err)
+	}
+	h, err := Files.Acquire("manual.cachetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range Files.Stats() {
+		if s.URL == "manual.cachetest" && s.Managed {
+			t.Errorf("Resource loaded manually was managed after being acquired")
+		}
+	}
+	h.Release()
+	if !loader.loaded["manual.cachetest"] {
+		t.Error("Resource loaded manually was unloaded once its Handle was released")
+	}
+	if err := Files.Unload("manual.cachetest"); err != nil {
+		t.Fatal(err)
+	}
+}
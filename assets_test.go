@@ -194,3 +194,17 @@ func TestFilesResourceNoFileLoader(t *testing.T) {
 		t.Errorf("wrong error returned retrieving a resource without an associated file loader. want: %v, got: %v", expected, err.Error())
 	}
 }
+
+func TestFormatsOwnLoaders(t *testing.T) {
+	formats := &Formats{formats: make(map[string]FileLoader)}
+	formats.Register(".own", &testLoader{})
+	if err := formats.LoadReaderData("own.own", bytes.NewReader([]byte("testing"))); err != nil {
+		t.Errorf("unable to load with the loader of the Formats. error: %v", err)
+	}
+	if _, err := formats.Resource("own.own"); err != nil {
+		t.Errorf("unable to fetch a resource with the loader of the Formats. error: %v", err)
+	}
+	if _, err := Files.Resource("own.own"); err == nil {
+		t.Error("loader of another Formats was used by Files")
+	}
+}
@@ -1,5 +1,7 @@
 package engo
 
+import "github.com/EngoEngine/engo/math"
+
 // An Axis is an input which is a spectrum of values. An example of this is the horizontal movement in a game, or how far a joystick is pressed.
 type Axis struct {
 	// Name represents the name of the axis (Horizontal, Vertical)
@@ -90,8 +92,12 @@ func (am *AxisMouse) Value() float32 {
 	return diff
 }
 
+// AxisGamepad is an axis of a Gamepad, such as a stick or a trigger.
 type AxisGamepad struct {
-	value float32
+	value    float32
+	deadZone float32
+	// stick is the other axis of the stick, the dead zone of a stick is a circle rather than a square
+	stick *AxisGamepad
 }
 
 func (ag *AxisGamepad) set(v float32) {
@@ -99,7 +105,64 @@ func (ag *AxisGamepad) set(v float32) {
 }
 
 // Value returns the amount and direction the axis is "tilted" from -1 to 1
-// 0 being Neutral.
+// 0 being Neutral. Values within the dead zone are 0.
 func (ag *AxisGamepad) Value() float32 {
+	if ag.deadZone <= 0 {
+		return ag.value
+	}
+	if ag.stick != nil {
+		return ag.stickValue()
+	}
+	v := ag.value
+	if v < 0 {
+		v = -v
+	}
+	if v <= ag.deadZone {
+		return AxisNeutral
+	}
+	// The remaining range is rescaled, so that the value still goes from the edge of the dead zone to 1
+	v = (v - ag.deadZone) / (1 - ag.deadZone)
+	if v > AxisMax {
+		v = AxisMax
+	}
+	if ag.value < 0 {
+		return -v
+	}
+	return v
+}
+
+// stickValue returns the value of the axis of a stick, whose dead zone applies to how far the stick is tilted in any
+// direction.
+func (ag *AxisGamepad) stickValue() float32 {
+	tilt := math.Sqrt(ag.value*ag.value + ag.stick.value*ag.stick.value)
+	if tilt <= ag.deadZone {
+		return AxisNeutral
+	}
+	// The remaining range is rescaled, so that the tilt still goes from the edge of the dead zone to 1
+	scaled := (tilt - ag.deadZone) / (1 - ag.deadZone)
+	if scaled > AxisMax {
+		scaled = AxisMax
+	}
+	return ag.value * scaled / tilt
+}
+
+// Raw returns the value of the axis, ignoring the dead zone.
+func (ag *AxisGamepad) Raw() float32 {
 	return ag.value
 }
+
+// SetDeadZone sets how far the axis has to be tilted before its Value is not 0, from 0 to 1. Worn sticks often don't
+// return to exactly 0, which a small dead zone such as 0.15 hides.
+func (ag *AxisGamepad) SetDeadZone(deadZone float32) {
+	if deadZone < 0 {
+		deadZone = 0
+	} else if deadZone > 0.99 {
+		deadZone = 0.99
+	}
+	ag.deadZone = deadZone
+}
+
+// DeadZone returns the dead zone of the axis.
+func (ag *AxisGamepad) DeadZone() float32 {
+	return ag.deadZone
+}
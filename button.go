@@ -4,10 +4,16 @@ package engo
 type Button struct {
 	Triggers []Key
 	Name     string
+
+	// action is set for the Buttons of an ActionMap, the Triggers are its keys
+	action *inputAction
 }
 
 // JustPressed checks whether an input was pressed in the previous frame.
 func (b Button) JustPressed() bool {
+	if b.action != nil {
+		return b.action.state.JustPressed()
+	}
 	for _, trigger := range b.Triggers {
 		v := Input.keys.Get(trigger).JustPressed()
 		if v {
@@ -20,6 +26,9 @@ func (b Button) JustPressed() bool {
 
 // JustReleased checks whether an input was released in the previous frame.
 func (b Button) JustReleased() bool {
+	if b.action != nil {
+		return b.action.state.JustReleased()
+	}
 	for _, trigger := range b.Triggers {
 		v := Input.keys.Get(trigger).JustReleased()
 		if v {
@@ -32,6 +41,9 @@ func (b Button) JustReleased() bool {
 
 // Down checks whether the current input is being held down.
 func (b Button) Down() bool {
+	if b.action != nil {
+		return b.action.state.Down()
+	}
 	for _, trigger := range b.Triggers {
 		v := Input.keys.Get(trigger).Down()
 		if v {
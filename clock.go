@@ -34,6 +34,15 @@ type Clock struct {
 	startStamp int64
 
 	paused bool
+
+	// scale is the time scale, which is 1 until scaled is set. frozen is set while a freeze lasts for freezeFrames
+	// more frames
+	scale        float32
+	scaled       bool
+	frozen       bool
+	freezeFrames int
+
+	timelines map[string]*Timeline
 }
 
 // NewClock creates a new timer which allows you to measure ticks per seconds. Be sure to call `Tick()` whenever you
@@ -62,16 +71,67 @@ func (c *Clock) Tick() {
 		c.perSecond = c.counter
 		c.counter = 0
 	}
+
+	c.frozen, c.freezeFrames = tickFreeze(c.freezeFrames)
+	for _, t := range c.timelines {
+		t.frozen, t.freezeFrames = tickFreeze(t.freezeFrames)
+	}
 }
 
-// Delta is the amount of seconds between the last tick and the one before that
+// tickFreeze returns whether a frame is frozen, and the amount of frames that remain frozen after it.
+func tickFreeze(frames int) (bool, int) {
+	if frames <= 0 {
+		return false, 0
+	}
+	return true, frames - 1
+}
+
+// Delta is the amount of seconds between the last tick and the one before that, multiplied by the time scale. It is 0
+// while the clock is paused or frozen.
 func (c *Clock) Delta() float32 {
-	if c.paused {
+	if c.paused || c.frozen {
 		return 0
 	}
+	return float32(float64(c.deltaStamp) / float64(secondsInNano) * float64(c.TimeScale()))
+}
+
+// RealDelta is the amount of seconds between the last tick and the one before that, regardless of the time scale,
+// freezes and pausing.
+func (c *Clock) RealDelta() float32 {
 	return float32(float64(c.deltaStamp) / float64(secondsInNano))
 }
 
+// SetTimeScale sets the factor Delta is multiplied by. Values below 1 slow the game down, such as for bullet time,
+// and values above 1 speed it up.
+func (c *Clock) SetTimeScale(scale float32) {
+	if scale < 0 {
+		scale = 0
+	}
+	c.scale = scale
+	c.scaled = true
+}
+
+// TimeScale returns the factor Delta is multiplied by.
+func (c *Clock) TimeScale() float32 {
+	if !c.scaled {
+		return 1
+	}
+	return c.scale
+}
+
+// Freeze makes Delta 0 for the given amount of frames, starting with the next tick. This can be used for hit-stop
+// effects. Freezing while already frozen extends the freeze if it's longer.
+func (c *Clock) Freeze(frames int) {
+	if frames > c.freezeFrames {
+		c.freezeFrames = frames
+	}
+}
+
+// Frozen indicates whether the current frame is frozen.
+func (c *Clock) Frozen() bool {
+	return c.frozen
+}
+
 // Pause pauses the clock
 func (c *Clock) Pause() {
 	c.paused = true
@@ -82,6 +142,11 @@ func (c *Clock) Unpause() {
 	c.paused = false
 }
 
+// Paused indicates whether the clock is paused
+func (c *Clock) Paused() bool {
+	return c.paused
+}
+
 // FPS is the amount of frames per second, computed every time a tick occurs at least a second after the previous update
 func (c *Clock) FPS() float32 {
 	return float32(c.perSecond)
@@ -3,6 +3,8 @@ package engo
 import (
 	"testing"
 	"time"
+
+	"github.com/EngoEngine/ecs"
 )
 
 // testTime is the time interface where testTime.Now() is controllable using
@@ -129,3 +131,143 @@ func TestClockPause(t *testing.T) {
 		t.Error("Clock did not increase delta after unpausing")
 	}
 }
+
+func TestClockTimeScale(t *testing.T) {
+	theTimer = testTime{0}
+	clock := NewClock()
+	clock.SetTimeScale(0.5)
+	theTimer = testTime{100000000}
+	clock.Tick()
+	if clock.Delta() != 0.05 || clock.RealDelta() != 0.1 {
+		t.Errorf("Scaled clock had delta %v and real delta %v, expected 0.05 and 0.1", clock.Delta(), clock.RealDelta())
+	}
+	clock.SetTimeScale(-1)
+	if clock.TimeScale() != 0 || clock.Delta() != 0 {
+		t.Error("Clock accepted a negative time scale")
+	}
+}
+
+func TestClockZeroValue(t *testing.T) {
+	theTimer = testTime{0}
+	clock := &Clock{}
+	clock.Tick()
+	theTimer = testTime{100000000}
+	clock.Tick()
+	if clock.TimeScale() != 1 || clock.Delta() != 0.1 {
+		t.Errorf("Zero value clock had time scale %v and delta %v, expected 1 and 0.1", clock.TimeScale(), clock.Delta())
+	}
+}
+
+func TestClockFreeze(t *testing.T) {
+	theTimer = testTime{0}
+	clock := NewClock()
+	clock.Freeze(2)
+	for i := int64(1); i <= 3; i++ {
+		theTimer = testTime{i * 100000000}
+		clock.Tick()
+		if frozen := i <= 2; clock.Frozen() != frozen || (clock.Delta() == 0) != frozen {
+			t.Errorf("Frame %d was frozen: %v with delta %v, expected frozen: %v", i, clock.Frozen(), clock.Delta(), frozen)
+		}
+	}
+}
+
+func TestClockTimeline(t *testing.T) {
+	theTimer = testTime{0}
+	clock := NewClock()
+	gameplay := clock.Timeline("gameplay")
+	ui := clock.Timeline("ui")
+	ui.SetUnscaled(true)
+	if clock.Timeline("gameplay") != gameplay || gameplay.Name() != "gameplay" {
+		t.Error("Timeline did not return the same Timeline for the same name")
+	}
+
+	clock.SetTimeScale(0.5)
+	gameplay.SetTimeScale(0.5)
+	theTimer = testTime{400000000}
+	clock.Tick()
+	if gameplay.Delta() != 0.1 || ui.Delta() != 0.4 {
+		t.Errorf("Timelines had deltas %v and %v, expected 0.1 and 0.4", gameplay.Delta(), ui.Delta())
+	}
+
+	gameplay.Pause()
+	clock.Pause()
+	if gameplay.Delta() != 0 || ui.Delta() != 0.4 {
+		t.Errorf("Paused timelines had deltas %v and %v, expected 0 and 0.4", gameplay.Delta(), ui.Delta())
+	}
+	clock.Unpause()
+	gameplay.Unpause()
+
+	gameplay.Freeze(1)
+	theTimer = testTime{800000000}
+	clock.Tick()
+	if !gameplay.Frozen() || gameplay.Delta() != 0 || ui.Delta() != 0.4 {
+		t.Error("Freezing a timeline did not only stop that timeline")
+	}
+	theTimer = testTime{1200000000}
+	clock.Tick()
+	if gameplay.Frozen() || gameplay.Delta() != 0.1 {
+		t.Error("Timeline was still frozen after the freeze")
+	}
+}
+
+// timelineSystem records the deltas it was updated with.
+type timelineSystem struct {
+	timeline string
+	deltas   []float32
+	fixed    int
+}
+
+func (s *timelineSystem) Update(dt float32) { s.deltas = append(s.deltas, dt) }
+
+func (s *timelineSystem) FixedUpdate(float32) { s.fixed++ }
+
+func (*timelineSystem) Remove(ecs.BasicEntity) {}
+
+func (s *timelineSystem) Timeline() string { return s.timeline }
+
+type plainSystem struct {
+	deltas []float32
+}
+
+func (s *plainSystem) Update(dt float32) { s.deltas = append(s.deltas, dt) }
+
+func (*plainSystem) Remove(ecs.BasicEntity) {}
+
+type timelineScene struct {
+	gameplay *timelineSystem
+	plain    *plainSystem
+}
+
+func (*timelineScene) Preload() {}
+
+func (s *timelineScene) Setup(u Updater) {
+	s.gameplay = &timelineSystem{timeline: "gameplay"}
+	s.plain = &plainSystem{}
+	u.(*ecs.World).AddSystem(s.gameplay)
+	u.(*ecs.World).AddSystem(s.plain)
+}
+
+func (*timelineScene) Type() string { return "timelineScene" }
+
+func TestTimelineSystem(t *testing.T) {
+	scene := &timelineScene{}
+	Run(RunOptions{NoRun: true, HeadlessMode: true, FixedUpdateRate: 10}, scene)
+	theTimer = testTime{0}
+	Time = NewClock()
+	Time.Timeline("gameplay").SetTimeScale(0.5)
+	theTimer = testTime{200000000}
+	Time.Tick()
+
+	updateScenes(Time.Delta())
+	if scene.plain.deltas[0] != 0.2 || scene.gameplay.deltas[0] != 0.1 || scene.gameplay.fixed != 2 {
+		t.Errorf("Systems were updated with %v and %v, expected 0.2 and the gameplay delta 0.1", scene.plain.deltas[0], scene.gameplay.deltas[0])
+	}
+
+	Time.Timeline("gameplay").Pause()
+	updateScenes(Time.Delta())
+	if scene.plain.deltas[1] != 0.2 || scene.gameplay.deltas[1] != 0 || scene.gameplay.fixed != 2 {
+		t.Errorf("Paused timeline system was updated with %v and fixed updated %d times", scene.gameplay.deltas[1], scene.gameplay.fixed)
+	}
+	theTimer = realTime{}
+	Time = NewClock()
+}
@@ -55,6 +55,11 @@ func (p *Player) URL() string {
 	return p.url
 }
 
+// Category returns engo.CategoryAudio.
+func (p *Player) Category() string {
+	return engo.CategoryAudio
+}
+
 func newPlayer(src convert.ReadSeekCloser, url string) (*Player, error) {
 	p := &Player{
 		src:             src,
@@ -0,0 +1,388 @@
+package common
+
+import (
+	"github.com/EngoEngine/ecs"
+)
+
+// BehaviorStatus is the result of ticking a node of a BehaviorTree.
+type BehaviorStatus uint8
+
+const (
+	// BehaviorSuccess means the node has finished successfully
+	BehaviorSuccess BehaviorStatus = iota
+	// BehaviorFailure means the node has failed
+	BehaviorFailure
+	// BehaviorRunning means the node has not finished yet, and has to be
+	// ticked again
+	BehaviorRunning
+)
+
+// BehaviorNode is a node of a BehaviorTree.
+type BehaviorNode interface {
+	// Tick runs the node for a frame, and returns whether it has finished.
+	Tick(t *BehaviorTree, dt float32) BehaviorStatus
+	// Reset resets the node and its children, so it starts over the next time
+	// it is ticked. It is called when a running node is aborted.
+	Reset()
+}
+
+// BehaviorTree is a tree of BehaviorNodes, whose root is ticked every update.
+type BehaviorTree struct {
+	Root BehaviorNode
+	// Blackboard holds the data shared by the nodes
+	Blackboard Blackboard
+
+	status BehaviorStatus
+	time   float32
+}
+
+// NewBehaviorTree creates a BehaviorTree with the root node and an empty
+// Blackboard.
+func NewBehaviorTree(root BehaviorNode) *BehaviorTree {
+	return &BehaviorTree{Root: root, Blackboard: make(Blackboard)}
+}
+
+// Tick ticks the root node, and returns its status.
+func (t *BehaviorTree) Tick(dt float32) BehaviorStatus {
+	t.time += dt
+	if t.Root == nil {
+		t.status = BehaviorFailure
+		return t.status
+	}
+	t.status = t.Root.Tick(t, dt)
+	return t.status
+}
+
+// Status returns the status of the root node at the last tick.
+func (t *BehaviorTree) Status() BehaviorStatus {
+	return t.status
+}
+
+// Time returns the time in seconds the tree has been ticked for.
+func (t *BehaviorTree) Time() float32 {
+	return t.time
+}
+
+// Reset resets all nodes of the tree.
+func (t *BehaviorTree) Reset() {
+	if t.Root != nil {
+		t.Root.Reset()
+	}
+}
+
+// Sequence ticks its children one after the other. It fails as soon as one of
+// them fails, and succeeds when all of them have succeeded.
+type Sequence struct {
+	Children []BehaviorNode
+
+	current int
+}
+
+// Tick implements the BehaviorNode interface
+func (s *Sequence) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	return tickComposite(t, dt, s.Children, &s.current, BehaviorFailure)
+}
+
+// Reset implements the BehaviorNode interface
+func (s *Sequence) Reset() {
+	s.current = 0
+	resetAll(s.Children)
+}
+
+// Selector ticks its children one after the other. It succeeds as soon as one
+// of them succeeds, and fails when all of them have failed.
+type Selector struct {
+	Children []BehaviorNode
+
+	current int
+}
+
+// Tick implements the BehaviorNode interface
+func (s *Selector) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	return tickComposite(t, dt, s.Children, &s.current, BehaviorSuccess)
+}
+
+// Reset implements the BehaviorNode interface
+func (s *Selector) Reset() {
+	s.current = 0
+	resetAll(s.Children)
+}
+
+// tickComposite ticks the children from the current one, until one of them is
+// running or finishes with the status stop. It returns stop in that case, and
+// the opposite status when all children finished with it.
+func tickComposite(t *BehaviorTree, dt float32, children []BehaviorNode, current *int, stop BehaviorStatus) BehaviorStatus {
+	for ; *current < len(children); *current++ {
+		switch children[*current].Tick(t, dt) {
+		case BehaviorRunning:
+			return BehaviorRunning
+		case stop:
+			*current = 0
+			return stop
+		}
+	}
+	*current = 0
+	if stop == BehaviorFailure {
+		return BehaviorSuccess
+	}
+	return BehaviorFailure
+}
+
+// Parallel ticks all of its children every tick, until they have finished.
+type Parallel struct {
+	Children []BehaviorNode
+	// Successes is the number of children that have to succeed for the
+	// Parallel to succeed. All of them have to if it is 0. It fails as soon as
+	// that many can't succeed anymore.
+	Successes int
+
+	statuses []BehaviorStatus
+}
+
+// Tick implements the BehaviorNode interface
+func (p *Parallel) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	if len(p.statuses) != len(p.Children) {
+		p.statuses = make([]BehaviorStatus, len(p.Children))
+		for i := range p.statuses {
+			p.statuses[i] = BehaviorRunning
+		}
+	}
+	succeeded, failed := 0, 0
+	for i, c := range p.Children {
+		if p.statuses[i] == BehaviorRunning {
+			p.statuses[i] = c.Tick(t, dt)
+		}
+		switch p.statuses[i] {
+		case BehaviorSuccess:
+			succeeded++
+		case BehaviorFailure:
+			failed++
+		}
+	}
+
+	need := p.Successes
+	if need <= 0 || need > len(p.Children) {
+		need = len(p.Children)
+	}
+	switch {
+	case succeeded >= need:
+		p.Reset()
+		return BehaviorSuccess
+	case failed > len(p.Children)-need:
+		p.Reset()
+		return BehaviorFailure
+	}
+	return BehaviorRunning
+}
+
+// Reset implements the BehaviorNode interface
+func (p *Parallel) Reset() {
+	p.statuses = nil
+	resetAll(p.Children)
+}
+
+func resetAll(nodes []BehaviorNode) {
+	for _, n := range nodes {
+		n.Reset()
+	}
+}
+
+// Inverter succeeds when its child fails, and fails when it succeeds.
+type Inverter struct {
+	Child BehaviorNode
+}
+
+// Tick implements the BehaviorNode interface
+func (i *Inverter) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	switch i.Child.Tick(t, dt) {
+	case BehaviorSuccess:
+		return BehaviorFailure
+	case BehaviorFailure:
+		return BehaviorSuccess
+	}
+	return BehaviorRunning
+}
+
+// Reset implements the BehaviorNode interface
+func (i *Inverter) Reset() {
+	i.Child.Reset()
+}
+
+// Succeeder succeeds when its child has finished, even if it failed.
+type Succeeder struct {
+	Child BehaviorNode
+}
+
+// Tick implements the BehaviorNode interface
+func (s *Succeeder) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	if s.Child.Tick(t, dt) == BehaviorRunning {
+		return BehaviorRunning
+	}
+	return BehaviorSuccess
+}
+
+// Reset implements the BehaviorNode interface
+func (s *Succeeder) Reset() {
+	s.Child.Reset()
+}
+
+// Repeater ticks its child again every time it has finished. It is running
+// until the child has finished Count times, or forever if Count is 0.
+type Repeater struct {
+	Child BehaviorNode
+	// Count is the number of times the child is run
+	Count int
+	// UntilFailure is whether the Repeater stops and succeeds when the child
+	// fails.
+	UntilFailure bool
+
+	count int
+}
+
+// Tick implements the BehaviorNode interface
+func (r *Repeater) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	status := r.Child.Tick(t, dt)
+	if status == BehaviorRunning {
+		return BehaviorRunning
+	}
+	if r.UntilFailure && status == BehaviorFailure {
+		r.count = 0
+		return BehaviorSuccess
+	}
+	r.count++
+	if r.Count > 0 && r.count >= r.Count {
+		r.count = 0
+		return BehaviorSuccess
+	}
+	return BehaviorRunning
+}
+
+// Reset implements the BehaviorNode interface
+func (r *Repeater) Reset() {
+	r.count = 0
+	r.Child.Reset()
+}
+
+// Cooldown fails without ticking its child until Duration seconds have passed
+// since the child last finished.
+type Cooldown struct {
+	Child    BehaviorNode
+	Duration float32
+
+	ready float32
+}
+
+// Tick implements the BehaviorNode interface
+func (c *Cooldown) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	if t.Time() < c.ready {
+		return BehaviorFailure
+	}
+	status := c.Child.Tick(t, dt)
+	if status != BehaviorRunning {
+		c.ready = t.Time() + c.Duration
+	}
+	return status
+}
+
+// Reset implements the BehaviorNode interface. The cooldown keeps running.
+func (c *Cooldown) Reset() {
+	c.Child.Reset()
+}
+
+// Wait is running until Duration seconds have passed, and then succeeds.
+type Wait struct {
+	Duration float32
+
+	elapsed float32
+}
+
+// Tick implements the BehaviorNode interface
+func (w *Wait) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	w.elapsed += dt
+	if w.elapsed < w.Duration {
+		return BehaviorRunning
+	}
+	w.elapsed = 0
+	return BehaviorSuccess
+}
+
+// Reset implements the BehaviorNode interface
+func (w *Wait) Reset() {
+	w.elapsed = 0
+}
+
+// Action is a leaf node running a function.
+type Action struct {
+	Func func(t *BehaviorTree, dt float32) BehaviorStatus
+}
+
+// Tick implements the BehaviorNode interface
+func (a *Action) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	return a.Func(t, dt)
+}
+
+// Reset implements the BehaviorNode interface
+func (a *Action) Reset() {}
+
+// Condition is a leaf node which succeeds if its function returns true, and
+// fails otherwise.
+type Condition struct {
+	Func func(t *BehaviorTree) bool
+}
+
+// Tick implements the BehaviorNode interface
+func (c *Condition) Tick(t *BehaviorTree, dt float32) BehaviorStatus {
+	if c.Func(t) {
+		return BehaviorSuccess
+	}
+	return BehaviorFailure
+}
+
+// Reset implements the BehaviorNode interface
+func (c *Condition) Reset() {}
+
+// BehaviorTreeComponent holds the BehaviorTree of an entity.
+type BehaviorTreeComponent struct {
+	Tree *BehaviorTree
+}
+
+type behaviorTreeEntity struct {
+	*ecs.BasicEntity
+	*BehaviorTreeComponent
+}
+
+// BehaviorTreeSystem ticks the BehaviorTrees of its entities every update.
+type BehaviorTreeSystem struct {
+	entities []behaviorTreeEntity
+}
+
+// Add adds an entity to the BehaviorTreeSystem. To be added, the entity has to
+// have a basic and behavior tree component.
+func (s *BehaviorTreeSystem) Add(basic *ecs.BasicEntity, tree *BehaviorTreeComponent) {
+	s.entities = append(s.entities, behaviorTreeEntity{basic, tree})
+}
+
+// AddByInterface Provides a simple way to add an entity to the system that satisfies BehaviorTreeable. Any entity containing, BasicEntity and BehaviorTreeComponent anonymously, automatically does this.
+func (s *BehaviorTreeSystem) AddByInterface(i ecs.Identifier) {
+	o, _ := i.(BehaviorTreeable)
+	s.Add(o.GetBasicEntity(), o.GetBehaviorTreeComponent())
+}
+
+// Remove removes an entity from the BehaviorTreeSystem.
+func (s *BehaviorTreeSystem) Remove(basic ecs.BasicEntity) {
+	for i, e := range s.entities {
+		if e.BasicEntity.ID() == basic.ID() {
+			s.entities = append(s.entities[:i], s.entities[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update ticks the BehaviorTrees of the entities.
+func (s *BehaviorTreeSystem) Update(dt float32) {
+	for _, e := range s.entities {
+		if e.Tree != nil {
+			e.Tree.Tick(dt)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// BehaviorTreeResource contains the definition of a behavior tree from a
+// `.bt.json` file. A BehaviorTree is created from it for every entity with
+// New, as the nodes of a tree keep their state.
+type BehaviorTreeResource struct {
+	Definition *BehaviorTreeDefinition
+	url        string
+}
+
+// URL returns the file path for the BehaviorTreeResource.
+func (r BehaviorTreeResource) URL() string {
+	return r.url
+}
+
+// New creates a BehaviorTree from the definition using the factories of
+// BehaviorNodes.
+func (r BehaviorTreeResource) New() (*BehaviorTree, error) {
+	return BehaviorNodes.New(r.Definition)
+}
+
+// BehaviorTreeDefinition describes the nodes of a behavior tree.
+type BehaviorTreeDefinition struct {
+	Root BehaviorNodeDefinition `json:"root"`
+}
+
+// BehaviorNodeDefinition describes a node of a behavior tree.
+type BehaviorNodeDefinition struct {
+	// Type is the name the factory of the node is registered with
+	Type string `json:"type"`
+	// Params are the parameters passed to the factory
+	Params Properties `json:"-"`
+	// Children are the children of the node
+	Children []BehaviorNodeDefinition `json:"children"`
+}
+
+// UnmarshalJSON converts the params of the node to Properties. Numbers become
+// int or float properties, objects class and arrays array properties with the
+// elements as members.
+func (d *BehaviorNodeDefinition) UnmarshalJSON(data []byte) error {
+	var node struct {
+		Type     string                   `json:"type"`
+		Params   map[string]interface{}   `json:"params"`
+		Children []BehaviorNodeDefinition `json:"children"`
+	}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	if node.Type == "" {
+		return errors.New("node without type")
+	}
+	d.Type = node.Type
+	d.Params = jsonProperties(node.Params)
+	d.Children = node.Children
+	return nil
+}
+
+// jsonProperties converts the values of a JSON object to Properties, sorted by
+// name.
+func jsonProperties(values map[string]interface{}) Properties {
+	var ret Properties
+	for name, v := range values {
+		if prop, ok := jsonProperty(name, v); ok {
+			ret = append(ret, prop)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// jsonProperty converts a JSON value to a Property. The members of arrays are
+// named by their index, and stay in the order of the array.
+func jsonProperty(name string, v interface{}) (Property, bool) {
+	prop := Property{Name: name}
+	switch v := v.(type) {
+	case float64:
+		if v == float64(int(v)) {
+			prop.Type, prop.Value = "int", strconv.Itoa(int(v))
+		} else {
+			prop.Type, prop.Value = "float", strconv.FormatFloat(v, 'g', -1, 32)
+		}
+	case bool:
+		prop.Type, prop.Value = "bool", strconv.FormatBool(v)
+	case string:
+		prop.Type, prop.Value = "string", v
+	case map[string]interface{}:
+		prop.Type, prop.Members = "class", jsonProperties(v)
+	case []interface{}:
+		prop.Type = "array"
+		for i, e := range v {
+			if member, ok := jsonProperty(strconv.Itoa(i), e); ok {
+				prop.Members = append(prop.Members, member)
+			}
+		}
+	default:
+		return prop, false
+	}
+	return prop, true
+}
+
+// ParseBehaviorTree parses the JSON definition of a behavior tree. Its root
+// node is the "root" of the JSON object, and every node has a "type", and
+// optional "params" and "children".
+func ParseBehaviorTree(data []byte) (*BehaviorTreeDefinition, error) {
+	var d BehaviorTreeDefinition
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	if d.Root.Type == "" {
+		return nil, errors.New("behavior tree without root")
+	}
+	return &d, nil
+}
+
+// BehaviorFactory creates a node of a behavior tree with the parameters and
+// children of its definition.
+type BehaviorFactory func(params Properties, children []BehaviorNode) (BehaviorNode, error)
+
+// BehaviorRegistry holds the factories creating the nodes of behavior trees
+// from their definitions, keyed by their type.
+type BehaviorRegistry struct {
+	mutex     sync.RWMutex
+	factories map[string]BehaviorFactory
+}
+
+// BehaviorNodes is the BehaviorRegistry used by BehaviorTreeResource.New. The
+// types "sequence", "selector", "parallel" (with the param "successes"),
+// "inverter", "succeeder", "repeater" (with "count" and "untilFailure"),
+// "cooldown" and "wait" (with "duration") are built in, the actions and
+// conditions of a game have to be registered.
+var BehaviorNodes = &BehaviorRegistry{}
+
+// Register sets the factory used for nodes of the type typ.
+func (r *BehaviorRegistry) Register(typ string, factory BehaviorFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.factories == nil {
+		r.factories = make(map[string]BehaviorFactory)
+	}
+	r.factories[typ] = factory
+}
+
+// Unregister removes the factory used for nodes of the type typ.
+func (r *BehaviorRegistry) Unregister(typ string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.factories, typ)
+}
+
+// Factory returns the factory registered for nodes of the type typ, or the
+// built in one if there is none.
+func (r *BehaviorRegistry) Factory(typ string) (BehaviorFactory, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if f, ok := r.factories[typ]; ok {
+		return f, true
+	}
+	f, ok := builtinBehaviors[typ]
+	return f, ok
+}
+
+// New creates a BehaviorTree from the definition.
+func (r *BehaviorRegistry) New(d *BehaviorTreeDefinition) (*BehaviorTree, error) {
+	root, err := r.node(d.Root)
+	if err != nil {
+		return nil, err
+	}
+	return NewBehaviorTree(root), nil
+}
+
+func (r *BehaviorRegistry) node(d BehaviorNodeDefinition) (BehaviorNode, error) {
+	f, ok := r.Factory(d.Type)
+	if !ok {
+		return nil, fmt.Errorf("no factory for behavior node %q", d.Type)
+	}
+	children := make([]BehaviorNode, len(d.Children))
+	for i, c := range d.Children {
+		n, err := r.node(c)
+		if err != nil {
+			return nil, err
+		}
+		children[i] = n
+	}
+	n, err := f(d.Params, children)
+	if err != nil {
+		return nil, fmt.Errorf("behavior node %q: %v", d.Type, err)
+	}
+	return n, nil
+}
+
+var builtinBehaviors = map[string]BehaviorFactory{
+	"sequence": func(_ Properties, children []BehaviorNode) (BehaviorNode, error) {
+		return &Sequence{Children: children}, nil
+	},
+	"selector": func(_ Properties, children []BehaviorNode) (BehaviorNode, error) {
+		return &Selector{Children: children}, nil
+	},
+	"parallel": func(params Properties, children []BehaviorNode) (BehaviorNode, error) {
+		successes, _ := params.Int("successes")
+		return &Parallel{Children: children, Successes: successes}, nil
+	},
+	"inverter": func(_ Properties, children []BehaviorNode) (BehaviorNode, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("needs one child, has %d", len(children))
+		}
+		return &Inverter{Child: children[0]}, nil
+	},
+	"succeeder": func(_ Properties, children []BehaviorNode) (BehaviorNode, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("needs one child, has %d", len(children))
+		}
+		return &Succeeder{Child: children[0]}, nil
+	},
+	"repeater": func(params Properties, children []BehaviorNode) (BehaviorNode, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("needs one child, has %d", len(children))
+		}
+		count, _ := params.Int("count")
+		untilFailure, _ := params.Bool("untilFailure")
+		return &Repeater{Child: children[0], Count: count, UntilFailure: untilFailure}, nil
+	},
+	"cooldown": func(params Properties, children []BehaviorNode) (BehaviorNode, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("needs one child, has %d", len(children))
+		}
+		duration, _ := params.Float("duration")
+		return &Cooldown{Child: children[0], Duration: math.Max(duration, 0)}, nil
+	},
+	"wait": func(params Properties, children []BehaviorNode) (BehaviorNode, error) {
+		duration, _ := params.Float("duration")
+		return &Wait{Duration: duration}, nil
+	},
+}
+
+// behaviorTreeLoader is responsible for managing `.bt.json` files within
+// `engo.Files`
+type behaviorTreeLoader struct {
+	trees map[string]BehaviorTreeResource
+}
+
+// Load parses the behavior tree definition
+func (l *behaviorTreeLoader) Load(url string, data io.Reader) error {
+	d, err := l.parse(url, data)
+	if err != nil {
+		return err
+	}
+	l.trees[url] = BehaviorTreeResource{Definition: d, url: url}
+	return nil
+}
+
+// Reload replaces the definition that is already used for the file. Trees
+// that were created from it are not changed.
+func (l *behaviorTreeLoader) Reload(url string, data io.Reader) error {
+	d, err := l.parse(url, data)
+	if err != nil {
+		return err
+	}
+	if res, ok := l.trees[url]; ok {
+		*res.Definition = *d
+		return nil
+	}
+	l.trees[url] = BehaviorTreeResource{Definition: d, url: url}
+	return nil
+}
+
+func (l *behaviorTreeLoader) parse(url string, data io.Reader) (*BehaviorTreeDefinition, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	d, err := ParseBehaviorTree(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse behavior tree %q: %v", url, err)
+	}
+	return d, nil
+}
+
+// Unload removes the preloaded definition from the cache
+func (l *behaviorTreeLoader) Unload(url string) error {
+	delete(l.trees, url)
+	return nil
+}
+
+// Resource retrieves the preloaded definition, passed as a
+// `BehaviorTreeResource`
+func (l *behaviorTreeLoader) Resource(url string) (engo.Resource, error) {
+	res, ok := l.trees[url]
+	if !ok {
+		return nil, fmt.Errorf("resource not loaded by `FileLoader`: %q", url)
+	}
+
+	return res, nil
+}
+
+func init() {
+	engo.Files.Register(".bt.json", &behaviorTreeLoader{trees: make(map[string]BehaviorTreeResource)})
+}
@@ -0,0 +1,193 @@
+package common
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+// scripted returns an action returning the statuses one after the other, and
+// the last one from then on.
+func scripted(statuses ...BehaviorStatus) (*Action, *int) {
+	ticks := 0
+	return &Action{Func: func(*BehaviorTree, float32) BehaviorStatus {
+		s := statuses[len(statuses)-1]
+		if ticks < len(statuses) {
+			s = statuses[ticks]
+		}
+		ticks++
+		return s
+	}}, &ticks
+}
+
+func TestBehaviorTreeComposites(t *testing.T) {
+	a, aTicks := scripted(BehaviorRunning, BehaviorSuccess)
+	b, bTicks := scripted(BehaviorFailure)
+	tree := NewBehaviorTree(&Sequence{Children: []BehaviorNode{a, b}})
+	if s := tree.Tick(1); s != BehaviorRunning || *bTicks != 0 {
+		t.Errorf("Sequence was %v, expected it to run its first child", s)
+	}
+	if s := tree.Tick(1); s != BehaviorFailure || *aTicks != 2 || *bTicks != 1 {
+		t.Errorf("Sequence was %v, expected it to fail with its second child", s)
+	}
+
+	c, _ := scripted(BehaviorFailure)
+	d, dTicks := scripted(BehaviorSuccess)
+	e, eTicks := scripted(BehaviorSuccess)
+	tree.Root = &Selector{Children: []BehaviorNode{c, d, e}}
+	if s := tree.Tick(1); s != BehaviorSuccess || *dTicks != 1 || *eTicks != 0 {
+		t.Errorf("Selector was %v, expected it to succeed with its second child", s)
+	}
+
+	f, fTicks := scripted(BehaviorRunning, BehaviorSuccess)
+	g, gTicks := scripted(BehaviorSuccess)
+	h, _ := scripted(BehaviorFailure)
+	tree.Root = &Parallel{Children: []BehaviorNode{f, g, h}, Successes: 2}
+	if s := tree.Tick(1); s != BehaviorRunning {
+		t.Errorf("Parallel was %v, expected it to run", s)
+	}
+	if s := tree.Tick(1); s != BehaviorSuccess || *fTicks != 2 || *gTicks != 1 {
+		t.Errorf("Parallel was %v, expected it to succeed with two children", s)
+	}
+	tree.Root.(*Parallel).Successes = 0
+	if s := tree.Tick(1); s != BehaviorFailure {
+		t.Errorf("Parallel was %v, expected it to fail when not all children can succeed", s)
+	}
+}
+
+func TestBehaviorTreeDecorators(t *testing.T) {
+	tree := NewBehaviorTree(nil)
+	a, _ := scripted(BehaviorSuccess)
+	if s := (&Inverter{Child: a}).Tick(tree, 1); s != BehaviorFailure {
+		t.Errorf("Inverter was %v, expected it to fail", s)
+	}
+	b, _ := scripted(BehaviorFailure)
+	if s := (&Succeeder{Child: b}).Tick(tree, 1); s != BehaviorSuccess {
+		t.Errorf("Succeeder was %v, expected it to succeed", s)
+	}
+
+	c, cTicks := scripted(BehaviorSuccess)
+	r := &Repeater{Child: c, Count: 3}
+	for i := 0; i < 2; i++ {
+		if s := r.Tick(tree, 1); s != BehaviorRunning {
+			t.Errorf("Repeater was %v, expected it to run", s)
+		}
+	}
+	if s := r.Tick(tree, 1); s != BehaviorSuccess || *cTicks != 3 {
+		t.Errorf("Repeater was %v after %d ticks, expected it to succeed", s, *cTicks)
+	}
+	d, _ := scripted(BehaviorSuccess, BehaviorFailure)
+	r = &Repeater{Child: d, UntilFailure: true}
+	if r.Tick(tree, 1) != BehaviorRunning || r.Tick(tree, 1) != BehaviorSuccess {
+		t.Error("Repeater did not succeed when its child failed")
+	}
+
+	e, eTicks := scripted(BehaviorSuccess)
+	tree.Root = &Cooldown{Child: e, Duration: 2}
+	tree.Tick(1)
+	if s := tree.Tick(1); s != BehaviorFailure || *eTicks != 1 {
+		t.Errorf("Cooldown was %v, expected it to fail during the cooldown", s)
+	}
+	if s := tree.Tick(1); s != BehaviorSuccess || *eTicks != 2 {
+		t.Errorf("Cooldown was %v, expected it to tick its child after the cooldown", s)
+	}
+
+	w := &Wait{Duration: 1}
+	if w.Tick(tree, 0.6) != BehaviorRunning || w.Tick(tree, 0.6) != BehaviorSuccess {
+		t.Error("Wait did not succeed after its duration")
+	}
+}
+
+var testBehaviorTree = `{
+ "root": {"type": "selector", "children": [
+  {"type": "sequence", "children": [
+   {"type": "isHungry"},
+   {"type": "eat", "params": {"amount": 3, "food": {"name": "apple"}}}
+  ]},
+  {"type": "repeater", "params": {"count": 2}, "children": [{"type": "wait", "params": {"duration": 0.5}}]}
+ ]}
+}`
+
+func TestBehaviorTreeFile(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	BehaviorNodes.Register("isHungry", func(Properties, []BehaviorNode) (BehaviorNode, error) {
+		return &Condition{Func: func(t *BehaviorTree) bool {
+			hunger, _ := t.Blackboard.Int("hunger")
+			return hunger > 0
+		}}, nil
+	})
+	defer BehaviorNodes.Unregister("isHungry")
+
+	if err := engo.Files.LoadReaderData("test.bt.json", bytes.NewBufferString(testBehaviorTree)); err != nil {
+		t.Fatalf("Unable to load behavior tree. Error was: %v", err)
+	}
+	res, err := engo.Files.Resource("test.bt.json")
+	if err != nil {
+		t.Fatalf("Unable to get behavior tree resource. Error was: %v", err)
+	}
+	if _, err := res.(BehaviorTreeResource).New(); err == nil {
+		t.Error("Tree was created although the eat node has no factory")
+	}
+
+	BehaviorNodes.Register("eat", func(params Properties, _ []BehaviorNode) (BehaviorNode, error) {
+		amount, _ := params.Int("amount")
+		food, _ := params.Class("food")
+		name, _ := food.Value("name")
+		return &Action{Func: func(t *BehaviorTree, _ float32) BehaviorStatus {
+			hunger, _ := t.Blackboard.Int("hunger")
+			t.Blackboard.Set("hunger", hunger-amount)
+			t.Blackboard.Set("ate", name)
+			return BehaviorSuccess
+		}}, nil
+	})
+	defer BehaviorNodes.Unregister("eat")
+	tree, err := res.(BehaviorTreeResource).New()
+	if err != nil {
+		t.Fatalf("Unable to create behavior tree. Error was: %v", err)
+	}
+
+	sys := &BehaviorTreeSystem{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, &BehaviorTreeComponent{Tree: tree})
+	tree.Blackboard.Set("hunger", 5)
+	sys.Update(0.1)
+	if hunger, _ := tree.Blackboard.Int("hunger"); hunger != 2 || tree.Status() != BehaviorSuccess {
+		t.Errorf("Hunger was %d, expected the eat action to run", hunger)
+	}
+	if ate, _ := tree.Blackboard.String("ate"); ate != "apple" {
+		t.Errorf("Eat action ate %q, expected the food of its params", ate)
+	}
+	sys.Update(0.1)
+	sys.Update(0.5)
+	if tree.Status() != BehaviorRunning {
+		t.Errorf("Tree was %v, expected it to wait", tree.Status())
+	}
+	sys.Update(0.5)
+	if tree.Status() != BehaviorSuccess {
+		t.Errorf("Tree was %v, expected it to have waited twice", tree.Status())
+	}
+
+	d, err := ParseBehaviorTree([]byte(`{"root": {"type": "patrol", "params": {"path": [0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11]}}}`))
+	if err != nil {
+		t.Fatalf("Unable to parse behavior tree. Error was: %v", err)
+	}
+	path, _ := d.Root.Params.Get("path")
+	if len(path.Members) != 12 {
+		t.Fatalf("Array param had %d elements, expected 12", len(path.Members))
+	}
+	for i, p := range path.Members {
+		if p.Value != strconv.Itoa(i) {
+			t.Errorf("Element %d of the array param was %q, expected the elements in order", i, p.Value)
+		}
+	}
+
+	if _, err := ParseBehaviorTree([]byte(`{"root": {"children": []}}`)); err == nil {
+		t.Error("Behavior tree with a node without type was parsed")
+	}
+	if _, err := BehaviorNodes.New(&BehaviorTreeDefinition{Root: BehaviorNodeDefinition{Type: "inverter"}}); err == nil {
+		t.Error("Inverter without child was created")
+	}
+}
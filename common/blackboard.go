@@ -0,0 +1,49 @@
+package common
+
+// Blackboard holds the data shared by the states of a StateMachine or the nodes
+// of a BehaviorTree, keyed by name.
+type Blackboard map[string]interface{}
+
+// Get returns the value with the given name.
+func (b Blackboard) Get(name string) (interface{}, bool) {
+	v, ok := b[name]
+	return v, ok
+}
+
+// Set sets the value with the given name.
+func (b Blackboard) Set(name string, value interface{}) {
+	b[name] = value
+}
+
+// Delete removes the value with the given name.
+func (b Blackboard) Delete(name string) {
+	delete(b, name)
+}
+
+// Int returns the value with the given name as an int. It returns false if
+// there is no such value or it is not an int.
+func (b Blackboard) Int(name string) (int, bool) {
+	v, ok := b[name].(int)
+	return v, ok
+}
+
+// Float returns the value with the given name as a float32. It returns false
+// if there is no such value or it is not a float32.
+func (b Blackboard) Float(name string) (float32, bool) {
+	v, ok := b[name].(float32)
+	return v, ok
+}
+
+// Bool returns the value with the given name as a bool. It returns false if
+// there is no such value or it is not a bool.
+func (b Blackboard) Bool(name string) (bool, bool) {
+	v, ok := b[name].(bool)
+	return v, ok
+}
+
+// String returns the value with the given name as a string. It returns false
+// if there is no such value or it is not a string.
+func (b Blackboard) String(name string) (string, bool) {
+	v, ok := b[name].(string)
+	return v, ok
+}
@@ -0,0 +1,443 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BMFont is a bitmap font in the AngelCode BMFont format, as exported by tools such as BMFont and Hiero.
+type BMFont struct {
+	// Face is the name of the font.
+	Face string
+	// Size is the size of the font in pixels.
+	Size int
+	// LineHeight is the distance in pixels between each line of text.
+	LineHeight int
+	// Base is the distance in pixels from the top of a line to the baseline of the characters.
+	Base int
+	// Pages are the file names of the page textures, relative to the .fnt file.
+	Pages []string
+	// Chars are the characters in the font.
+	Chars map[rune]BMFontChar
+	// Kernings contains the adjustment in pixels to the distance between pairs of characters, where the first is
+	// followed by the second.
+	Kernings map[[2]rune]int
+
+	// pages are the decoded page textures, in the same order as Pages.
+	pages []image.Image
+}
+
+// BMFontChar is a single character of a BMFont.
+type BMFontChar struct {
+	// ID is the character.
+	ID rune
+	// X, Y, Width and Height are the location of the character in its page texture.
+	X, Y, Width, Height int
+	// XOffset and YOffset are the offset from the current position to where the character is drawn.
+	XOffset, YOffset int
+	// XAdvance is how much the current position moves after drawing the character.
+	XAdvance int
+	// Page is the index of the page texture containing the character.
+	Page int
+	// Channel is a bitmask of the color channels of the page texture containing the character. 1 is blue, 2 is
+	// green, 4 is red, 8 is alpha and 15 is all channels.
+	Channel int
+}
+
+// ParseBMFont parses a BMFont descriptor in the text, XML or binary format. The page textures are not loaded.
+func ParseBMFont(data []byte) (*BMFont, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("BMF")):
+		return parseBMFontBinary(data)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseBMFontXML(trimmed)
+	default:
+		return parseBMFontText(data)
+	}
+}
+
+func newBMFont() *BMFont {
+	return &BMFont{
+		Chars:    make(map[rune]BMFontChar),
+		Kernings: make(map[[2]rune]int),
+	}
+}
+
+// setPage sets the file name of the page with the given id.
+func (f *BMFont) setPage(id int, file string) error {
+	if id < 0 {
+		return fmt.Errorf("bmfont: invalid page id %d", id)
+	}
+	for len(f.Pages) <= id {
+		f.Pages = append(f.Pages, "")
+	}
+	f.Pages[id] = file
+	return nil
+}
+
+// parseBMFontText parses the text format, where every line is a tag followed by key=value pairs.
+func parseBMFontText(data []byte) (*BMFont, error) {
+	fnt := newBMFont()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		tag, attrs := splitBMFontLine(scanner.Text())
+		atoi := func(key string) int {
+			i, _ := strconv.Atoi(attrs[key])
+			return i
+		}
+
+		switch tag {
+		case "info":
+			fnt.Face = attrs["face"]
+			fnt.Size = abs(atoi("size"))
+		case "common":
+			fnt.LineHeight = atoi("lineHeight")
+			fnt.Base = atoi("base")
+		case "page":
+			if err := fnt.setPage(atoi("id"), attrs["file"]); err != nil {
+				return nil, err
+			}
+		case "char":
+			c := BMFontChar{
+				ID:       rune(atoi("id")),
+				X:        atoi("x"),
+				Y:        atoi("y"),
+				Width:    atoi("width"),
+				Height:   atoi("height"),
+				XOffset:  atoi("xoffset"),
+				YOffset:  atoi("yoffset"),
+				XAdvance: atoi("xadvance"),
+				Page:     atoi("page"),
+				Channel:  atoi("chnl"),
+			}
+			fnt.Chars[c.ID] = c
+		case "kerning":
+			fnt.Kernings[[2]rune{rune(atoi("first")), rune(atoi("second"))}] = atoi("amount")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if fnt.LineHeight == 0 {
+		return nil, errors.New("bmfont: missing common line")
+	}
+	return fnt, nil
+}
+
+// splitBMFontLine splits a line of the text format into its tag and attributes. Values may be quoted.
+func splitBMFontLine(line string) (string, map[string]string) {
+	attrs := make(map[string]string)
+	line = strings.TrimSpace(line)
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, attrs
+	}
+	tag, rest := line[:i], line[i:]
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		attrs[key] = value
+	}
+	return tag, attrs
+}
+
+type bmfontXML struct {
+	Info struct {
+		Face string `xml:"face,attr"`
+		Size int    `xml:"size,attr"`
+	} `xml:"info"`
+	Common struct {
+		LineHeight int `xml:"lineHeight,attr"`
+		Base       int `xml:"base,attr"`
+	} `xml:"common"`
+	Pages []struct {
+		ID   int    `xml:"id,attr"`
+		File string `xml:"file,attr"`
+	} `xml:"pages>page"`
+	Chars []struct {
+		ID       int `xml:"id,attr"`
+		X        int `xml:"x,attr"`
+		Y        int `xml:"y,attr"`
+		Width    int `xml:"width,attr"`
+		Height   int `xml:"height,attr"`
+		XOffset  int `xml:"xoffset,attr"`
+		YOffset  int `xml:"yoffset,attr"`
+		XAdvance int `xml:"xadvance,attr"`
+		Page     int `xml:"page,attr"`
+		Channel  int `xml:"chnl,attr"`
+	} `xml:"chars>char"`
+	Kernings []struct {
+		First  int `xml:"first,attr"`
+		Second int `xml:"second,attr"`
+		Amount int `xml:"amount,attr"`
+	} `xml:"kernings>kerning"`
+}
+
+// parseBMFontXML parses the XML format.
+func parseBMFontXML(data []byte) (*BMFont, error) {
+	var x bmfontXML
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	fnt := newBMFont()
+	fnt.Face = x.Info.Face
+	fnt.Size = abs(x.Info.Size)
+	fnt.LineHeight = x.Common.LineHeight
+	fnt.Base = x.Common.Base
+	for _, p := range x.Pages {
+		if err := fnt.setPage(p.ID, p.File); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range x.Chars {
+		fnt.Chars[rune(c.ID)] = BMFontChar{
+			ID:       rune(c.ID),
+			X:        c.X,
+			Y:        c.Y,
+			Width:    c.Width,
+			Height:   c.Height,
+			XOffset:  c.XOffset,
+			YOffset:  c.YOffset,
+			XAdvance: c.XAdvance,
+			Page:     c.Page,
+			Channel:  c.Channel,
+		}
+	}
+	for _, k := range x.Kernings {
+		fnt.Kernings[[2]rune{rune(k.First), rune(k.Second)}] = k.Amount
+	}
+	return fnt, nil
+}
+
+// parseBMFontBinary parses the binary format, version 3.
+func parseBMFontBinary(data []byte) (*BMFont, error) {
+	if len(data) < 4 || data[3] != 3 {
+		return nil, errors.New("bmfont: unsupported binary version")
+	}
+	le := binary.LittleEndian
+	fnt := newBMFont()
+	var pageCount int
+
+	data = data[4:]
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, errors.New("bmfont: unexpected end of block header")
+		}
+		blockType := data[0]
+		size := int(le.Uint32(data[1:5]))
+		data = data[5:]
+		if len(data) < size {
+			return nil, fmt.Errorf("bmfont: block %d is %d bytes, only %d left", blockType, size, len(data))
+		}
+		block := data[:size]
+		data = data[size:]
+
+		switch blockType {
+		case 1: // info
+			if len(block) < 14 {
+				return nil, errors.New("bmfont: info block too short")
+			}
+			fnt.Size = abs(int(int16(le.Uint16(block[0:2]))))
+			fnt.Face = string(bytes.TrimRight(block[14:], "\x00"))
+		case 2: // common
+			if len(block) < 15 {
+				return nil, errors.New("bmfont: common block too short")
+			}
+			fnt.LineHeight = int(le.Uint16(block[0:2]))
+			fnt.Base = int(le.Uint16(block[2:4]))
+			pageCount = int(le.Uint16(block[8:10]))
+		case 3: // pages
+			for _, name := range bytes.Split(bytes.TrimRight(block, "\x00"), []byte{0}) {
+				fnt.Pages = append(fnt.Pages, string(name))
+			}
+		case 4: // chars
+			for ; len(block) >= 20; block = block[20:] {
+				c := BMFontChar{
+					ID:       rune(le.Uint32(block[0:4])),
+					X:        int(le.Uint16(block[4:6])),
+					Y:        int(le.Uint16(block[6:8])),
+					Width:    int(le.Uint16(block[8:10])),
+					Height:   int(le.Uint16(block[10:12])),
+					XOffset:  int(int16(le.Uint16(block[12:14]))),
+					YOffset:  int(int16(le.Uint16(block[14:16]))),
+					XAdvance: int(int16(le.Uint16(block[16:18]))),
+					Page:     int(block[18]),
+					Channel:  int(block[19]),
+				}
+				fnt.Chars[c.ID] = c
+			}
+		case 5: // kerning pairs
+			for ; len(block) >= 10; block = block[10:] {
+				first := rune(le.Uint32(block[0:4]))
+				second := rune(le.Uint32(block[4:8]))
+				fnt.Kernings[[2]rune{first, second}] = int(int16(le.Uint16(block[8:10])))
+			}
+		}
+	}
+
+	if pageCount != len(fnt.Pages) {
+		return nil, fmt.Errorf("bmfont: expected %d pages, found %d", pageCount, len(fnt.Pages))
+	}
+	return fnt, nil
+}
+
+// atlasImage repacks the characters of the BMFont into an image laid out the way a FontAtlas expects, where every
+// character is placed in a cell as wide as its advance and as high as a line. Parts of characters that extend beyond
+// their cell are kept in the padding around it.
+func (f *BMFont) atlasImage() (FontAtlas, *image.NRGBA) {
+	var pad int
+	ids := make([]rune, 0, len(f.Chars))
+	for id, c := range f.Chars {
+		ids = append(ids, id)
+		for _, overhang := range []int{-c.XOffset, c.XOffset + c.Width - c.XAdvance, -c.YOffset, c.YOffset + c.Height - f.LineHeight} {
+			if overhang > pad {
+				pad = overhang
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// The slices are as long as the amount of characters, the ones beyond them are stored separately
+	count := len(ids)
+	atlas := FontAtlas{
+		XLocation: make([]float32, count),
+		YLocation: make([]float32, count),
+		Width:     make([]float32, count),
+		Height:    make([]float32, count),
+		Padding:   float32(pad),
+		Kerning:   make(map[[2]rune]float32, len(f.Kernings)),
+	}
+	for pair, amount := range f.Kernings {
+		atlas.Kerning[pair] = float32(amount)
+	}
+
+	cellHeight := float32(f.LineHeight)
+	p := float32(pad)
+	currentX := float32(0)
+	currentY := float32(0)
+
+	for _, id := range ids {
+		c := f.Chars[id]
+		atlas.setGlyph(id, currentX+p, currentY+p, float32(c.XAdvance), cellHeight)
+
+		currentX += float32(c.XAdvance) + 2*p
+		if currentX > atlas.TotalWidth {
+			atlas.TotalWidth = currentX
+		}
+
+		if currentX > 1024 {
+			currentX = 0
+			currentY += cellHeight + 2*p
+		}
+	}
+	atlas.TotalHeight = currentY
+	if currentX > 0 {
+		atlas.TotalHeight += cellHeight + 2*p
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(math.Ceil(float64(atlas.TotalWidth))), int(math.Ceil(float64(atlas.TotalHeight)))))
+	for id, c := range f.Chars {
+		if c.Page < 0 || c.Page >= len(f.pages) || f.pages[c.Page] == nil {
+			continue
+		}
+		page := f.pages[c.Page]
+		src := image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height).Add(page.Bounds().Min)
+		x, y, _, _ := atlas.Glyph(id)
+		dst := image.Rect(0, 0, c.Width, c.Height).Add(image.Pt(int(x)+c.XOffset, int(y)+c.YOffset))
+
+		if c.Channel == 0 || c.Channel == 15 {
+			draw.Draw(img, dst, page, src.Min, draw.Src)
+			continue
+		}
+
+		// The character is stored in a single channel, which is used as the alpha of a white character
+		for y := 0; y < c.Height; y++ {
+			for x := 0; x < c.Width; x++ {
+				r, g, b, a := page.At(src.Min.X+x, src.Min.Y+y).RGBA()
+				var v uint32
+				switch {
+				case c.Channel&8 != 0:
+					v = a
+				case c.Channel&4 != 0:
+					v = r
+				case c.Channel&2 != 0:
+					v = g
+				default:
+					v = b
+				}
+				img.SetNRGBA(dst.Min.X+x, dst.Min.Y+y, color.NRGBA{255, 255, 255, uint8(v >> 8)})
+			}
+		}
+	}
+
+	return atlas, img
+}
+
+// textDimensions returns the width, the height and the baseline of the text written out in the BMFont, like
+// Font.TextDimensions does. Characters that are not in the BMFont are skipped.
+func (f *BMFont) textDimensions(text string) (int, int, int) {
+	var width int
+	var prev rune
+	for _, char := range text {
+		c, ok := f.Chars[char]
+		if !ok {
+			continue
+		}
+		if prev != 0 {
+			width += f.Kernings[[2]rune{prev, char}]
+		}
+		width += c.XAdvance
+		prev = char
+	}
+	return width, f.LineHeight, f.Base
+}
+
+// generateFontAtlas creates the FontAtlas and uploads its texture.
+func (f *BMFont) generateFontAtlas() FontAtlas {
+	atlas, img := f.atlasImage()
+	atlas.Texture = NewTextureSingle(NewImageObject(img)).id
+	return atlas
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
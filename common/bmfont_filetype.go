@@ -0,0 +1,107 @@
+package common
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"path"
+
+	"github.com/EngoEngine/engo"
+)
+
+// BMFontResource is the resource passed by the `engo.Files.Resource` method in the case of `.fnt` files. Use it by
+// setting the `URL` of a `Font` to the `.fnt` file and calling `CreatePreloaded`.
+type BMFontResource struct {
+	Font *BMFont
+	url  string
+}
+
+// URL returns the file path for the BMFontResource.
+func (r BMFontResource) URL() string {
+	return r.url
+}
+
+// Size returns the amount of bytes used by the page textures.
+func (r BMFontResource) Size() int64 {
+	var size int64
+	for _, page := range r.Font.pages {
+		b := page.Bounds()
+		size += int64(b.Dx()) * int64(b.Dy()) * 4
+	}
+	return size
+}
+
+// Category returns engo.CategoryFont.
+func (r BMFontResource) Category() string {
+	return engo.CategoryFont
+}
+
+// bmfontLoader is responsible for managing `.fnt` files within `engo.Files`
+type bmfontLoader struct {
+	fonts map[string]BMFontResource
+}
+
+// Load parses the BMFont descriptor and decodes the page textures it references
+func (l *bmfontLoader) Load(url string, data io.Reader) error {
+	finish, err := l.Decode(url, data)
+	if err != nil {
+		return err
+	}
+	return finish()
+}
+
+// Decode parses the BMFont descriptor and decodes its pages, the returned function stores the font in the cache
+func (l *bmfontLoader) Decode(url string, data io.Reader) (func() error, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fnt, err := ParseBMFont(b)
+	if err != nil {
+		return nil, err
+	}
+
+	fnt.pages = make([]image.Image, len(fnt.Pages))
+	for i, page := range fnt.Pages {
+		if page == "" {
+			continue
+		}
+		f, err := engo.Files.Open(path.Join(path.Dir(url), page))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open page %q of %q: %v", page, url, err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode page %q of %q: %v", page, url, err)
+		}
+		fnt.pages[i] = img
+	}
+
+	return func() error {
+		l.fonts[url] = BMFontResource{Font: fnt, url: url}
+		return nil
+	}, nil
+}
+
+// Unload removes the preloaded font from the cache
+func (l *bmfontLoader) Unload(url string) error {
+	delete(l.fonts, url)
+	return nil
+}
+
+// Resource retrieves the preloaded font, passed as a `BMFontResource`
+func (l *bmfontLoader) Resource(url string) (engo.Resource, error) {
+	fnt, ok := l.fonts[url]
+	if !ok {
+		return nil, fmt.Errorf("resource not loaded by `FileLoader`: %q", url)
+	}
+
+	return fnt, nil
+}
+
+func init() {
+	engo.Files.Register(".fnt", &bmfontLoader{fonts: make(map[string]BMFontResource)})
+}
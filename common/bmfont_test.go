@@ -0,0 +1,146 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/stretchr/testify/assert"
+)
+
+const bmfontXMLData = `<?xml version="1.0"?>
+<font>
+  <info face="Test Font" size="8" bold="0" italic="0" charset="" unicode="1" stretchH="100" smooth="1" aa="1" padding="0,0,0,0" spacing="1,1"/>
+  <common lineHeight="8" base="6" scaleW="16" scaleH="8" pages="1" packed="0"/>
+  <pages>
+    <page id="0" file="bmfont_0.png" />
+  </pages>
+  <chars count="2">
+    <char id="65" x="0" y="0" width="4" height="6" xoffset="0" yoffset="1" xadvance="5" page="0" chnl="15" />
+    <char id="66" x="8" y="0" width="4" height="6" xoffset="-1" yoffset="1" xadvance="4" page="0" chnl="15" />
+  </chars>
+  <kernings count="1">
+    <kerning first="65" second="66" amount="-1" />
+  </kernings>
+</font>`
+
+func bmfontBinaryData() []byte {
+	buf := &bytes.Buffer{}
+	le := binary.LittleEndian
+	block := func(typ byte, data []byte) {
+		buf.WriteByte(typ)
+		binary.Write(buf, le, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	buf.WriteString("BMF")
+	buf.WriteByte(3)
+
+	info := &bytes.Buffer{}
+	binary.Write(info, le, int16(8))
+	info.Write(make([]byte, 12))
+	info.WriteString("Test Font\x00")
+	block(1, info.Bytes())
+
+	common := &bytes.Buffer{}
+	binary.Write(common, le, []uint16{8, 6, 16, 8, 1})
+	common.Write(make([]byte, 5))
+	block(2, common.Bytes())
+
+	block(3, []byte("bmfont_0.png\x00"))
+
+	chars := &bytes.Buffer{}
+	binary.Write(chars, le, uint32(65))
+	binary.Write(chars, le, []uint16{0, 0, 4, 6})
+	binary.Write(chars, le, []int16{0, 1, 5})
+	chars.Write([]byte{0, 15})
+	binary.Write(chars, le, uint32(66))
+	binary.Write(chars, le, []uint16{8, 0, 4, 6})
+	binary.Write(chars, le, []int16{-1, 1, 4})
+	chars.Write([]byte{0, 15})
+	block(4, chars.Bytes())
+
+	kernings := &bytes.Buffer{}
+	binary.Write(kernings, le, []uint32{65, 66})
+	binary.Write(kernings, le, int16(-1))
+	block(5, kernings.Bytes())
+
+	return buf.Bytes()
+}
+
+func assertTestBMFont(t *testing.T, fnt *BMFont) {
+	assert.Equal(t, "Test Font", fnt.Face)
+	assert.Equal(t, 8, fnt.Size)
+	assert.Equal(t, 8, fnt.LineHeight)
+	assert.Equal(t, 6, fnt.Base)
+	assert.Equal(t, []string{"bmfont_0.png"}, fnt.Pages)
+	assert.Equal(t, BMFontChar{ID: 'B', X: 8, Width: 4, Height: 6, XOffset: -1, YOffset: 1, XAdvance: 4, Channel: 15}, fnt.Chars['B'])
+	assert.Equal(t, map[[2]rune]int{{'A', 'B'}: -1}, fnt.Kernings)
+}
+
+func TestParseBMFontXML(t *testing.T) {
+	fnt, err := ParseBMFont([]byte(bmfontXMLData))
+	assert.NoError(t, err)
+	assertTestBMFont(t, fnt)
+}
+
+func TestParseBMFontBinary(t *testing.T) {
+	fnt, err := ParseBMFont(bmfontBinaryData())
+	assert.NoError(t, err)
+	assertTestBMFont(t, fnt)
+
+	_, err = ParseBMFont([]byte("BMF\x02"))
+	assert.Error(t, err, "only version 3 is supported")
+}
+
+func TestBMFontLoader(t *testing.T) {
+	engo.Files.SetRoot("testdata")
+	defer engo.Files.SetRoot("")
+	if !assert.NoError(t, engo.Files.Load("bmfont.fnt")) {
+		return
+	}
+	res, err := engo.Files.Resource("bmfont.fnt")
+	assert.NoError(t, err)
+	fnt := res.(BMFontResource).Font
+	assert.Len(t, fnt.Chars, 3)
+	assert.Equal(t, 8, fnt.Size)
+	assert.Equal(t, -1, fnt.Kernings[[2]rune{'A', 'B'}])
+
+	atlas, img := fnt.atlasImage()
+	// 'B' extends one pixel to the left of its cell
+	assert.Equal(t, float32(1), atlas.Padding)
+	assert.Len(t, atlas.Width, 3, "the slices should be as long as the amount of characters")
+	x, y, width, height := atlas.Glyph('A')
+	assert.Equal(t, float32(5), width)
+	assert.Equal(t, float32(8), height)
+	assert.Equal(t, float32(-1), atlas.kerning('A', 'B'))
+
+	ax, ay := int(x), int(y)
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, img.NRGBAAt(ax, ay+1))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(ax, ay), "yoffset should move the character down")
+	x, y, _, _ = atlas.Glyph('B')
+	bx, by := int(x), int(y)
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, img.NRGBAAt(bx-1, by+1), "xoffset should move the character into the padding")
+
+	f := &Font{URL: "bmfont.fnt"}
+	assert.NoError(t, f.CreatePreloaded())
+	textWidth, textHeight, base := f.TextDimensions("AB?")
+	assert.Equal(t, 8, textWidth, "the width should be the advances with the kerning, without the missing character")
+	assert.Equal(t, 8, textHeight)
+	assert.Equal(t, 6, base)
+	assert.Equal(t, Texture{}, f.Render("AB"))
+
+	assert.NoError(t, engo.Files.Unload("bmfont.fnt"))
+}
+
+func TestTextLayoutKerning(t *testing.T) {
+	atlas := testAtlas()
+	atlas.Kerning = map[[2]rune]float32{{'A', 'B'}: -2}
+	layout := layoutText(Text{Font: &Font{Size: 10}, Text: "ABA"}, atlas)
+
+	assert.Equal(t, float32(8), layout.glyphs[1].x)
+	assert.Equal(t, float32(18), layout.glyphs[2].x)
+	assert.Equal(t, float32(28), layout.width)
+}
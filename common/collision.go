@@ -206,6 +206,37 @@ func (sc SpaceComponent) Corners() (points [4]engo.Point) {
 	return
 }
 
+// Outline returns the faces of the hitboxes of the `SpaceComponent`, taking into account its rotation. Ellipses are
+// approximated as polygons. If there are no hitboxes, the four sides of its rectangle are returned.
+func (sc SpaceComponent) Outline() []engo.Line {
+	if len(sc.hitboxes) == 0 {
+		c := sc.Corners()
+		return []engo.Line{
+			{P1: c[0], P2: c[1]},
+			{P1: c[1], P2: c[3]},
+			{P1: c[3], P2: c[2]},
+			{P1: c[2], P2: c[0]},
+		}
+	}
+	sin, cos := math.Sincos(sc.Rotation * math.Pi / 180)
+	point := func(p engo.Point) engo.Point {
+		return engo.Point{
+			X: sc.Position.X + p.X*cos - p.Y*sin,
+			Y: sc.Position.Y + p.Y*cos + p.X*sin,
+		}
+	}
+	var lines []engo.Line
+	for _, hb := range sc.hitboxes {
+		if !engo.FloatEqual(hb.Ellipse.Rx, 0) || !engo.FloatEqual(hb.Ellipse.Ry, 0) {
+			hb.PolygonEllipse()
+		}
+		for _, l := range hb.Lines {
+			lines = append(lines, engo.Line{P1: point(l.P1), P2: point(l.P2)})
+		}
+	}
+	return lines
+}
+
 // Contains indicates whether or not the given point is within the shape defined by this `SpaceComponent`.
 // If it's on the border, it is considered "not within".
 // If there is no shape defined, then this uses a rectangular area defined by the
@@ -19,4 +19,6 @@
 // sprite sheets
 //
 // TMX maps
+//
+// localization
 package common
@@ -23,13 +23,30 @@ var (
 // Font keeps track of a specific Font. Fonts are explicit instances of a font file,
 // including the Size and Color. A separate font will have to be generated to get
 // different sizes and colors of the same font file.
+//
+// If SDF is set, the FontAtlas of the Font is a signed distance field, which stays crisp
+// when the Text is scaled or zoomed, and supports `TextEffects`. The BG color is not used
+// for signed distance field atlases. The distance is stored in a single channel, so sharp
+// corners get slightly rounded when the Text is scaled up a lot: multi-channel distance
+// fields (MSDF) are not supported.
+//
+// A Font can also be created from a preloaded BMFont (.fnt) file, in which case it is measured
+// with the metrics of the BMFont and can be used to draw a Text, but not to Render a Texture.
+// The colors of the page textures are used instead of FG and BG.
+//
+// Characters that are not in the font are taken from the Fallback font, which may have a
+// Fallback of its own. The Size and colors of the first font are used for all of them.
 type Font struct {
-	URL  string
-	Size float64
-	BG   color.Color
-	FG   color.Color
-	TTF  *truetype.Font
-	face font.Face
+	URL      string
+	Size     float64
+	BG       color.Color
+	FG       color.Color
+	SDF      bool
+	Fallback *Font
+	TTF      *truetype.Font
+	face     font.Face
+
+	bitmap *BMFont
 }
 
 // Create is for loading fonts from the disk, given a location
@@ -61,6 +78,14 @@ func (f *Font) CreatePreloaded() error {
 		return err
 	}
 
+	if bm, ok := fontres.(BMFontResource); ok {
+		f.bitmap = bm.Font
+		if f.Size == 0 {
+			f.Size = float64(bm.Font.Size)
+		}
+		return nil
+	}
+
 	fnt, ok := fontres.(FontResource)
 	if !ok {
 		return fmt.Errorf("preloaded font is not of type `*truetype.Font`: %s", f.URL)
@@ -78,10 +103,16 @@ func (f *Font) CreatePreloaded() error {
 // TextDimensions returns the total width, total height and total line size
 // of the input string written out in the Font.
 func (f *Font) TextDimensions(text string) (int, int, int) {
+	if f.TTF == nil {
+		if f.bitmap != nil {
+			return f.bitmap.textDimensions(text)
+		}
+		return 0, 0, 0
+	}
 	fnt := f.TTF
 	size := f.Size
 	var (
-		totalWidth  = fixed.Int26_6(0)
+		width       float32
 		totalHeight = fixed.Int26_6(size)
 		maxYBearing = fixed.Int26_6(0)
 	)
@@ -89,7 +120,6 @@ func (f *Font) TextDimensions(text string) (int, int, int) {
 
 	for _, char := range text {
 		idx := fnt.Index(char)
-		hm := fnt.HMetric(fupe, idx)
 		vm := fnt.VMetric(fupe, idx)
 		g := truetype.GlyphBuf{}
 		err := g.Load(fnt, fupe, idx, font.HintingNone)
@@ -97,7 +127,7 @@ func (f *Font) TextDimensions(text string) (int, int, int) {
 			log.Println(err)
 			return 0, 0, 0
 		}
-		totalWidth += hm.AdvanceWidth
+		width += f.advance(char)
 
 		yB := (vm.TopSideBearing * fixed.Int26_6(size)) / fupe
 		if yB > maxYBearing {
@@ -109,15 +139,33 @@ func (f *Font) TextDimensions(text string) (int, int, int) {
 		}
 	}
 
-	// Scale to actual pixel size
-	totalWidth *= fixed.Int26_6(size)
-	totalWidth /= fupe
+	return int(width), int(totalHeight), int(maxYBearing)
+}
 
-	return int(totalWidth), int(totalHeight), int(maxYBearing)
+// advance returns the distance in pixels from the start of the character to the start of the next one. Characters
+// that are not in the Font are measured with the first of its fallbacks that contains them.
+func (f *Font) advance(char rune) float32 {
+	ttf := f.TTF
+	for fnt := f; fnt != nil; fnt = fnt.Fallback {
+		if fnt.TTF != nil && fnt.TTF.Index(char) != 0 {
+			ttf = fnt.TTF
+			break
+		}
+	}
+	if ttf == nil {
+		return 0
+	}
+	fupe := ttf.FUnitsPerEm()
+	hm := ttf.HMetric(fixed.Int26_6(fupe), ttf.Index(char))
+	return float32(hm.AdvanceWidth) * float32(f.Size) / float32(fupe)
 }
 
 // RenderNRGBA returns an *image.NRGBA in the Font based on the input string.
 func (f *Font) RenderNRGBA(text string) *image.NRGBA {
+	if f.TTF == nil {
+		log.Println("unable to render text without a TrueType font:", f.URL)
+		return nil
+	}
 	width, height, yBearing := f.TextDimensions(text)
 	font := f.TTF
 	size := f.Size
@@ -162,88 +210,45 @@ func (f *Font) RenderNRGBA(text string) *image.NRGBA {
 	return nrgba
 }
 
-// Render returns a Texture in the Font based on the input string.
+// Render returns a Texture in the Font based on the input string. The Texture is empty if the text could not be
+// rendered.
 func (f *Font) Render(text string) Texture {
 	nrgba := f.RenderNRGBA(text)
+	if nrgba == nil {
+		return Texture{}
+	}
 
 	// Create texture
 	imObj := NewImageObject(nrgba)
 	return NewTextureSingle(imObj)
 }
 
-// generateFontAtlas generates the font atlas for this given font, using the first `c` Unicode characters.
+// generateFontAtlas generates the font atlas for this given font, using the first `c` Unicode characters. Other
+// characters are added to the atlas when they are first used.
+//
+// If the Font uses a signed distance field, the alpha channel of the atlas contains the distance to the edge of the
+// glyph, where 0.5 is the edge itself and 0 and 1 are `SDFSpread` pixels outside and inside the glyph respectively.
 func (f *Font) generateFontAtlas(c int) FontAtlas {
+	if f.bitmap != nil {
+		return f.bitmap.generateFontAtlas()
+	}
+
 	atlas := FontAtlas{
 		XLocation: make([]float32, c),
 		YLocation: make([]float32, c),
 		Width:     make([]float32, c),
 		Height:    make([]float32, c),
+		builder:   newAtlasBuilder(f),
 	}
-
-	currentX := float32(0)
-	currentY := float32(0)
-
-	// Default colors
-	if f.FG == nil {
-		f.FG = color.NRGBA{0, 0, 0, 0}
+	if f.SDF {
+		atlas.Padding = SDFSpread
 	}
-	if f.BG == nil {
-		f.BG = color.NRGBA{0, 0, 0, 0}
-	}
-
-	d := &font.Drawer{}
-	d.Src = image.NewUniform(f.FG)
-	d.Face = truetype.NewFace(f.TTF, &truetype.Options{
-		Size:    f.Size,
-		DPI:     dpi,
-		Hinting: font.HintingNone,
-	})
-
-	lineHeight := d.Face.Metrics().Height
-	lineBuffer := float32(lineHeight.Ceil()) / 2
-	xBuffer := float32(10)
-
-	for i := 0; i < c; i++ {
-		_, adv, ok := d.Face.GlyphBounds(rune(i))
-		if !ok {
-			continue
-		}
-		currentX += xBuffer
-
-		atlas.Width[i] = float32(adv.Ceil())
-		atlas.Height[i] = float32(lineHeight.Ceil()) + lineBuffer
-		atlas.XLocation[i] = currentX
-		atlas.YLocation[i] = currentY
-
-		currentX += float32(adv.Ceil()) + xBuffer
 
-		if currentX > atlas.TotalWidth {
-			atlas.TotalWidth = currentX
-		}
-
-		if currentX > 1024 || i >= c-1 {
-			currentX = 0
-			currentY += float32(lineHeight.Ceil()) + lineBuffer
-			atlas.TotalHeight += float32(lineHeight.Ceil()) + lineBuffer
-		}
-	}
-
-	// Create texture
-	actual := image.NewNRGBA(image.Rect(0, 0, int(atlas.TotalWidth), int(atlas.TotalHeight)))
-	draw.Draw(actual, actual.Bounds(), image.NewUniform(f.BG), image.ZP, draw.Src)
-	d.Dst = actual
-
-	for i := 0; i < c; i++ {
-		_, _, ok := d.Face.GlyphBounds(rune(i))
-		if !ok {
-			continue
-		}
-		d.Dot = fixed.P(int(atlas.XLocation[i]), int(atlas.YLocation[i]+float32(lineHeight.Ceil())))
-		d.DrawBytes([]byte{byte(i)})
+	chars := make([]rune, c)
+	for i := range chars {
+		chars[i] = rune(i)
 	}
-
-	imObj := NewImageObject(actual)
-	atlas.Texture = NewTextureSingle(imObj).id
+	atlas.builder.add(&atlas, chars)
 
 	return atlas
 }
@@ -255,6 +260,10 @@ func (f *Font) GenerateFontAtlas(c int) FontAtlas {
 }
 
 // A FontAtlas is a representation of some of the Font characters, as an image
+//
+// The location and size of the characters are indexed by character. Characters beyond the length of these slices,
+// such as the ones added to the atlas when they are first used, are stored separately, so that a single emoji doesn't
+// grow the slices to its code point. `Glyph` returns the ones of any character.
 type FontAtlas struct {
 	Texture *gl.Texture
 	// XLocation contains the X-coordinate of the starting position of all characters
@@ -271,6 +280,52 @@ type FontAtlas struct {
 	// TotalHeight is the total amount of pixels the `FontAtlas` is high; useful for determining the `Viewport`,
 	// which is relative to this value.
 	TotalHeight float32
+	// Padding is the amount of pixels around every character that are drawn as well. This is used by signed distance
+	// field atlases, so outlines and glows are not cut off at the edges of the characters.
+	Padding float32
+	// Kerning contains the adjustment in pixels to the distance between pairs of characters, where the first is
+	// followed by the second.
+	Kerning map[[2]rune]float32
+
+	// glyphs contains the characters beyond the length of the slices.
+	glyphs map[rune]atlasGlyph
+	// builder adds characters to atlases generated from TrueType fonts.
+	builder *atlasBuilder
+}
+
+// atlasGlyph is the location and size of a character in a FontAtlas.
+type atlasGlyph struct {
+	x, y, width, height float32
+}
+
+// Glyph returns the location and size of the character in the atlas, which are 0 if it's not part of it.
+func (a *FontAtlas) Glyph(char rune) (x, y, width, height float32) {
+	if char >= 0 && int(char) < len(a.Width) {
+		return a.XLocation[char], a.YLocation[char], a.Width[char], a.Height[char]
+	}
+	g := a.glyphs[char]
+	return g.x, g.y, g.width, g.height
+}
+
+// hasGlyph returns whether the character is part of the atlas.
+func (a *FontAtlas) hasGlyph(char rune) bool {
+	if char >= 0 && int(char) < len(a.Width) {
+		return true
+	}
+	_, ok := a.glyphs[char]
+	return ok
+}
+
+// setGlyph sets the location and size of the character.
+func (a *FontAtlas) setGlyph(char rune, x, y, width, height float32) {
+	if char >= 0 && int(char) < len(a.Width) {
+		a.XLocation[char], a.YLocation[char], a.Width[char], a.Height[char] = x, y, width, height
+		return
+	}
+	if a.glyphs == nil {
+		a.glyphs = make(map[rune]atlasGlyph)
+	}
+	a.glyphs[char] = atlasGlyph{x, y, width, height}
 }
 
 // Text represents a string drawn onto the screen, as used by the `TextShader`.
@@ -285,76 +340,107 @@ type Text struct {
 	// LetterSpacing is the amount of additional spacing there is between the characters, relative to the `Size` of
 	// the `Font`.
 	LetterSpacing float32
-	// RightToLeft is an experimental variable used to indicate that subsequent characters come to the left of the
-	// previous character.
+	// RightToLeft makes right-to-left the base direction of the Text, so lines start at the right side of the box.
+	// Runs of right-to-left characters, such as Hebrew and Arabic, are always drawn right-to-left, and Arabic letters
+	// are joined; RightToLeft only changes the order of the runs and the side the lines start at.
 	RightToLeft bool
-}
 
-// Texture returns nil because the Text is generated from a FontAtlas. This implements the common.Drawable interface.
-func (t Text) Texture() *gl.Texture { return nil }
+	// MaxWidth is the width in pixels of the box the Text is laid out in. If it is 0, the box is as wide as the
+	// widest line.
+	MaxWidth float32
+	// MaxHeight is the height in pixels of the box the Text is laid out in. Lines that do not fit within the box are
+	// not drawn. If it is 0, the box is as high as all lines together.
+	MaxHeight float32
+	// Wrap determines how lines that are wider than `MaxWidth` are broken up.
+	Wrap WrapMode
+	// Align is the horizontal alignment of the lines within the box.
+	Align TextAlign
+	// VerticalAlign is the vertical alignment of the lines within the box. It only has an effect if `MaxHeight` is set.
+	VerticalAlign VerticalAlign
+	// Ellipsis is appended to lines that are truncated, either because they are wider than `MaxWidth` or because the
+	// lines after them do not fit within `MaxHeight`. If it is empty, lines are not truncated horizontally.
+	Ellipsis string
+	// Typewriter enables the typewriter effect, where only the first `Revealed` glyphs are drawn. The layout is done
+	// for the entire Text, so words do not jump between lines while they are revealed.
+	Typewriter bool
+	// Revealed is the amount of glyphs that are drawn when `Typewriter` is enabled.
+	Revealed int
+	// Effects are the outline, drop shadow and glow drawn around the Text. They are only drawn if the `Font` uses a
+	// signed distance field atlas.
+	Effects TextEffects
+}
 
-// Width returns the width of the Text generated from a FontAtlas. This implements the common.Drawable interface.
-func (t Text) Width() float32 {
-	atlas, ok := atlasCache[*t.Font]
+// cachedAtlas returns the FontAtlas of the Font, generating it first if needed. Any of the characters that are not
+// in the atlas yet are added to it.
+func cachedAtlas(f *Font, chars []rune) FontAtlas {
+	atlas, ok := atlasCache[*f]
 	if !ok {
-		// Generate texture first
-		atlas = t.Font.generateFontAtlas(200)
-		atlasCache[*t.Font] = atlas
+		atlas = f.generateFontAtlas(UnicodeCap)
+		atlasCache[*f] = atlas
 	}
+	if atlas.builder != nil && len(atlas.builder.missing(chars)) > 0 {
+		atlas.builder.add(&atlas, chars)
+		atlasCache[*f] = atlas
+	}
+	return atlas
+}
 
-	var currentX float32
-	var greatestX float32
-
-	for _, char := range t.Text {
-		// TODO: this might not work for all characters
-		switch {
-		case char == '\n':
-			if currentX > greatestX {
-				greatestX = currentX
-			}
-			currentX = 0
-			continue
-		case char < 32: // all system stuff should be ignored
+// reloadFonts refreshes the Fonts of the Texts that were created from the url, after it has been modified and loaded
+// again. Their FontAtlases are generated again the next time they are drawn.
+func (rs *RenderSystem) reloadFonts(url string) {
+	for f, atlas := range atlasCache {
+		if f.URL != url {
 			continue
 		}
-
-		currentX += atlas.Width[char] + float32(t.Font.Size)*t.LetterSpacing
+		if atlas.Texture != nil && !engo.Headless() {
+			engo.Gl.DeleteTexture(atlas.Texture)
+		}
+		delete(atlasCache, f)
 	}
-	if currentX > greatestX {
-		return currentX
+	layoutCache = make(map[layoutKey]layoutEntry)
+
+	for _, e := range rs.entities {
+		var f *Font
+		switch t := e.Drawable.(type) {
+		case Text:
+			f = t.Font
+		case *Text:
+			f = t.Font
+		}
+		for ; f != nil; f = f.Fallback {
+			if f.URL == url {
+				f.CreatePreloaded()
+			}
+		}
 	}
-	return greatestX
 }
 
-// Height returns the height the Text generated from a FontAtlas. This implements the common.Drawable interface.
+// Texture returns nil because the Text is generated from a FontAtlas. This implements the common.Drawable interface.
+func (t Text) Texture() *gl.Texture { return nil }
+
+// Width returns the width of the Text generated from a FontAtlas. This is `MaxWidth` if it is set, and the width of
+// the widest line otherwise. This implements the common.Drawable interface.
+func (t Text) Width() float32 {
+	return t.layout().width
+}
+
+// Height returns the height the Text generated from a FontAtlas. This is `MaxHeight` if it is set, and the height of
+// all lines together otherwise. This implements the common.Drawable interface.
 func (t Text) Height() float32 {
-	atlas, ok := atlasCache[*t.Font]
-	if !ok {
-		// Generate texture first
-		atlas = t.Font.generateFontAtlas(200)
-		atlasCache[*t.Font] = atlas
-	}
+	return t.layout().height
+}
 
-	var currentY float32
-	var totalY float32
-	var tallest float32
+// Bounds returns the smallest box containing all the glyphs of the Text after wrapping, truncating and aligning,
+// relative to the top-left of the Text. Glyphs hidden by the typewriter effect are included.
+func (t Text) Bounds() engo.AABB {
+	return t.layout().bounds
+}
 
-	for _, char := range t.Text {
-		// TODO: this might not work for all characters
-		switch {
-		case char == '\n':
-			totalY += tallest
-			tallest = float32(0)
-			continue
-		case char < 32: // all system stuff should be ignored
-			continue
-		}
-		currentY = atlas.Height[char] + t.LineSpacing*atlas.Height[char]
-		if currentY > tallest {
-			tallest = currentY
-		}
-	}
-	return totalY + tallest
+// GlyphCount returns the amount of glyphs that are drawn for the Text after wrapping and truncating. This is the
+// value `Revealed` has to reach for the typewriter effect to show the entire Text.
+func (t Text) GlyphCount() int {
+	t.Typewriter = false
+	return len(t.layout().glyphs)
 }
 
 // View returns 0, 0, 1, 1 because the Text is generated from a FontAtlas. This implements the common.Drawable interface.
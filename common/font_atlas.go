@@ -0,0 +1,228 @@
+package common
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/EngoEngine/engo"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// atlasBuilder keeps track of the image of a FontAtlas generated from TrueType fonts, so characters can be added to
+// the atlas the first time they are used.
+type atlasBuilder struct {
+	// fonts are the Font and its fallbacks, in the order they are searched for characters.
+	fonts []*truetype.Font
+	faces []font.Face
+	// sdfFaces are the faces used to rasterize the glyphs of a signed distance field atlas.
+	sdfFaces []font.Face
+	sdf      bool
+
+	fg, bg     color.Color
+	lineHeight fixed.Int26_6
+	cellHeight float32
+	// hpad and vpad are the amount of pixels left empty on either side of every character.
+	hpad, vpad float32
+
+	currentX, currentY float32
+	img                *image.NRGBA
+	added              map[rune]bool
+}
+
+// newAtlasBuilder prepares the faces of the Font and its fallbacks.
+func newAtlasBuilder(f *Font) *atlasBuilder {
+	// Default colors
+	if f.FG == nil {
+		f.FG = color.NRGBA{0, 0, 0, 0}
+	}
+	if f.BG == nil {
+		f.BG = color.NRGBA{0, 0, 0, 0}
+	}
+
+	b := &atlasBuilder{
+		sdf:   f.SDF,
+		fg:    f.FG,
+		bg:    f.BG,
+		hpad:  10,
+		added: make(map[rune]bool),
+	}
+	for fnt := f; fnt != nil; fnt = fnt.Fallback {
+		if fnt.TTF == nil {
+			continue
+		}
+		b.fonts = append(b.fonts, fnt.TTF)
+		b.faces = append(b.faces, truetype.NewFace(fnt.TTF, &truetype.Options{
+			Size:    f.Size,
+			DPI:     dpi,
+			Hinting: font.HintingNone,
+		}))
+		if b.sdf {
+			b.sdfFaces = append(b.sdfFaces, truetype.NewFace(fnt.TTF, &truetype.Options{
+				Size:    f.Size * sdfUpscale,
+				DPI:     dpi,
+				Hinting: font.HintingNone,
+			}))
+		}
+	}
+
+	if len(b.faces) > 0 {
+		b.lineHeight = b.faces[0].Metrics().Height
+	}
+	b.cellHeight = float32(b.lineHeight.Ceil()) + float32(b.lineHeight.Ceil())/2
+	if b.sdf {
+		b.hpad = SDFSpread
+		b.vpad = SDFSpread
+		// The color is stored in the atlas, the alpha channel contains the distance
+		r, g, bl, _ := f.FG.RGBA()
+		b.bg = color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), 0}
+	}
+	return b
+}
+
+// faceFor returns the index of the first font that contains the character. If none of them do, the first font is
+// used.
+func (b *atlasBuilder) faceFor(char rune) int {
+	for i, fnt := range b.fonts {
+		if fnt.Index(char) != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// missing returns the characters that have not been added to the atlas yet.
+func (b *atlasBuilder) missing(chars []rune) []rune {
+	var missing []rune
+	for _, char := range chars {
+		if char >= 0 && !b.added[char] {
+			missing = append(missing, char)
+		}
+	}
+	return missing
+}
+
+// add places the characters in the atlas, draws them and uploads the new texture.
+func (b *atlasBuilder) add(atlas *FontAtlas, chars []rune) {
+	chars = b.missing(chars)
+	if len(chars) == 0 || len(b.faces) == 0 {
+		return
+	}
+
+	rowHeight := b.cellHeight + 2*b.vpad
+	var placed []rune
+	var usedWidth float32
+	for _, char := range chars {
+		if b.added[char] {
+			continue
+		}
+		b.added[char] = true
+
+		adv, ok := b.faces[b.faceFor(char)].GlyphAdvance(char)
+		if !ok {
+			continue
+		}
+		b.currentX += b.hpad
+
+		atlas.setGlyph(char, b.currentX, b.currentY+b.vpad, float32(adv.Ceil()), b.cellHeight)
+
+		b.currentX += float32(adv.Ceil()) + b.hpad
+
+		if b.currentX > usedWidth {
+			usedWidth = b.currentX
+		}
+		if b.currentX > 1024 {
+			b.currentX = 0
+			b.currentY += rowHeight
+		}
+		placed = append(placed, char)
+	}
+
+	usedHeight := b.currentY
+	if b.currentX > 0 {
+		usedHeight += rowHeight
+	}
+
+	// The image only grows when it's full, and then doubles its height, so that adding a few characters at a time
+	// doesn't allocate a new texture every time
+	w, h := int(math.Ceil(float64(usedWidth))), int(math.Ceil(float64(usedHeight)))
+	grown := b.img == nil || w > b.img.Rect.Dx() || h > b.img.Rect.Dy()
+	if grown {
+		if b.img != nil {
+			if b.img.Rect.Dx() > w {
+				w = b.img.Rect.Dx()
+			}
+			if 2*b.img.Rect.Dy() > h {
+				h = 2 * b.img.Rect.Dy()
+			}
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(img, img.Bounds(), image.NewUniform(b.bg), image.ZP, draw.Src)
+		if b.img != nil {
+			draw.Draw(img, b.img.Bounds(), b.img, image.ZP, draw.Src)
+		}
+		b.img = img
+	}
+	atlas.TotalWidth, atlas.TotalHeight = float32(b.img.Rect.Dx()), float32(b.img.Rect.Dy())
+
+	for _, char := range placed {
+		if b.sdf {
+			b.drawDistanceField(atlas, char)
+		} else {
+			x, y, _, _ := atlas.Glyph(char)
+			d := &font.Drawer{
+				Dst:  b.img,
+				Src:  image.NewUniform(b.fg),
+				Face: b.faces[b.faceFor(char)],
+				Dot:  fixed.P(int(x), int(y)+b.lineHeight.Ceil()),
+			}
+			d.DrawString(string(char))
+		}
+	}
+
+	// A texture of the same size is updated in place, the gl backends don't all support updating part of it
+	if atlas.Texture == nil || grown {
+		if atlas.Texture != nil && !engo.Headless() {
+			engo.Gl.DeleteTexture(atlas.Texture)
+		}
+		atlas.Texture = NewTextureSingle(NewImageObject(b.img)).id
+		return
+	}
+	engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, atlas.Texture)
+	engo.Gl.TexImage2D(engo.Gl.TEXTURE_2D, 0, engo.Gl.RGBA, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, NewImageObject(b.img).Data())
+}
+
+// drawDistanceField rasterizes the character at a higher resolution and stores its signed distance field, including
+// the padding around it, in the alpha channel of the image.
+func (b *atlasBuilder) drawDistanceField(atlas *FontAtlas, char rune) {
+	pad := int(b.hpad)
+	x, y, width, _ := atlas.Glyph(char)
+	cellW := int(width) + 2*pad
+	cellH := int(b.cellHeight) + 2*pad
+
+	mask := image.NewAlpha(image.Rect(0, 0, cellW*sdfUpscale, cellH*sdfUpscale))
+	d := &font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: b.sdfFaces[b.faceFor(char)],
+		Dot:  fixed.P(pad*sdfUpscale, (pad+b.lineHeight.Ceil())*sdfUpscale),
+	}
+	d.DrawString(string(char))
+
+	field := signedDistanceField(mask)
+
+	// Sample the distance field at the center of every pixel of the cell
+	x0 := int(x) - pad
+	y0 := int(y) - pad
+	maxDist := float64(SDFSpread * sdfUpscale)
+	for y := 0; y < cellH; y++ {
+		for x := 0; x < cellW; x++ {
+			dist := field[(y*sdfUpscale+sdfUpscale/2)*cellW*sdfUpscale+x*sdfUpscale+sdfUpscale/2]
+			value := math.Max(0, math.Min(1, 0.5+dist/(2*maxDist)))
+			b.img.Pix[b.img.PixOffset(x0+x, y0+y)+3] = uint8(value * 255)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package common
+
+import "unicode"
+
+// bidiClass is the simplified bidirectional class of a character.
+type bidiClass uint8
+
+const (
+	// bidiL are strong left-to-right characters, such as Latin letters.
+	bidiL bidiClass = iota
+	// bidiR are strong right-to-left characters, such as Hebrew and Arabic letters.
+	bidiR
+	// bidiEN are numbers, which are always written left-to-right but do not change the direction around them.
+	bidiEN
+	// bidiN are neutral characters, such as spaces and punctuation, which take the direction of their surroundings.
+	bidiN
+)
+
+// isRightToLeft reports whether the character belongs to a right-to-left script.
+func isRightToLeft(r rune) bool {
+	switch {
+	case r >= 0x0590 && r <= 0x08FF: // Hebrew, Arabic, Syriac, Thaana, NKo, Samaritan, Mandaic, Arabic Extended
+		return true
+	case r >= 0xFB1D && r <= 0xFDFF: // Hebrew and Arabic presentation forms A
+		return true
+	case r >= 0xFE70 && r <= 0xFEFF: // Arabic presentation forms B
+		return true
+	}
+	return false
+}
+
+func classify(r rune) bidiClass {
+	switch {
+	case unicode.IsDigit(r):
+		return bidiEN
+	case isRightToLeft(r) && !unicode.Is(unicode.Mn, r):
+		return bidiR
+	case unicode.IsLetter(r):
+		return bidiL
+	}
+	return bidiN
+}
+
+// visualOrder returns the indices of the characters of a single line in the order they are displayed from left to
+// right. It implements a simplified version of the Unicode Bidirectional Algorithm without explicit embeddings:
+// right-to-left runs are reversed, numbers keep their left-to-right order and neutral characters take the direction
+// of the characters around them. If rtl is set, the base direction of the line is right-to-left.
+func visualOrder(chars []rune, rtl bool) []int {
+	order := make([]int, len(chars))
+	for i := range order {
+		order[i] = i
+	}
+
+	classes := make([]bidiClass, len(chars))
+	hasRTL := rtl
+	for i, char := range chars {
+		classes[i] = classify(char)
+		if classes[i] == bidiR {
+			hasRTL = true
+		}
+	}
+	if !hasRTL {
+		return order
+	}
+
+	base := 0
+	if rtl {
+		base = 1
+	}
+
+	// Numbers following right-to-left text, or in a right-to-left line, are part of the right-to-left run
+	prevStrong := bidiL
+	if rtl {
+		prevStrong = bidiR
+	}
+	for i, class := range classes {
+		switch class {
+		case bidiL, bidiR:
+			prevStrong = class
+		case bidiEN:
+			if prevStrong == bidiR {
+				classes[i] = bidiEN
+			} else {
+				classes[i] = bidiL
+			}
+		}
+	}
+
+	// strongAt returns the direction of the character at i for resolving neutrals, where numbers count as
+	// right-to-left.
+	strongAt := func(i int) (bidiClass, bool) {
+		switch classes[i] {
+		case bidiL:
+			return bidiL, true
+		case bidiR, bidiEN:
+			return bidiR, true
+		}
+		return bidiN, false
+	}
+
+	levels := make([]int, len(chars))
+	for i, class := range classes {
+		switch class {
+		case bidiL:
+			levels[i] = base * 2
+		case bidiR:
+			levels[i] = 1
+		case bidiEN:
+			levels[i] = 2
+		case bidiN:
+			// The start and end of the line count as the base direction
+			before, after := bidiL, bidiL
+			if rtl {
+				before, after = bidiR, bidiR
+			}
+			for j := i - 1; j >= 0; j-- {
+				if c, ok := strongAt(j); ok {
+					before = c
+					break
+				}
+			}
+			for j := i + 1; j < len(classes); j++ {
+				if c, ok := strongAt(j); ok {
+					after = c
+					break
+				}
+			}
+			levels[i] = base
+			if before == after {
+				if before == bidiR {
+					levels[i] = 1
+				} else {
+					levels[i] = base * 2
+				}
+			}
+		}
+	}
+
+	// Reverse every run at or above each level, starting with the highest one
+	maxLevel := 0
+	for _, level := range levels {
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+	for level := maxLevel; level >= 1; level-- {
+		for i := 0; i < len(order); {
+			if levels[order[i]] < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(order) && levels[order[j]] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = j
+		}
+	}
+
+	return order
+}
+
+// arabicForms maps Arabic letters to their isolated presentation form. Letters that join on both sides have their
+// final, initial and medial forms directly after it; letters that only join to the previous letter only have their
+// final form after it.
+var arabicForms = map[rune]struct {
+	isolated rune
+	dual     bool
+}{
+	0x0621: {0xFE80, false}, 0x0622: {0xFE81, false}, 0x0623: {0xFE83, false}, 0x0624: {0xFE85, false},
+	0x0625: {0xFE87, false}, 0x0626: {0xFE89, true}, 0x0627: {0xFE8D, false}, 0x0628: {0xFE8F, true},
+	0x0629: {0xFE93, false}, 0x062A: {0xFE95, true}, 0x062B: {0xFE99, true}, 0x062C: {0xFE9D, true},
+	0x062D: {0xFEA1, true}, 0x062E: {0xFEA5, true}, 0x062F: {0xFEA9, false}, 0x0630: {0xFEAB, false},
+	0x0631: {0xFEAD, false}, 0x0632: {0xFEAF, false}, 0x0633: {0xFEB1, true}, 0x0634: {0xFEB5, true},
+	0x0635: {0xFEB9, true}, 0x0636: {0xFEBD, true}, 0x0637: {0xFEC1, true}, 0x0638: {0xFEC5, true},
+	0x0639: {0xFEC9, true}, 0x063A: {0xFECD, true}, 0x0641: {0xFED1, true}, 0x0642: {0xFED5, true},
+	0x0643: {0xFED9, true}, 0x0644: {0xFEDD, true}, 0x0645: {0xFEE1, true}, 0x0646: {0xFEE5, true},
+	0x0647: {0xFEE9, true}, 0x0648: {0xFEED, false}, 0x0649: {0xFEEF, false}, 0x064A: {0xFEF1, true},
+}
+
+// lamAlef maps the alef that follows a lam to the isolated form of their ligature. The final form follows it.
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640
+)
+
+// isArabicTransparent reports whether the character is a mark that does not affect the joining of the letters
+// around it.
+func isArabicTransparent(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+// joinsToNext reports whether the character connects to the letter after it.
+func joinsToNext(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	form, ok := arabicForms[r]
+	return ok && form.dual
+}
+
+// joinsToPrevious reports whether the character connects to the letter before it.
+func joinsToPrevious(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	form, ok := arabicForms[r]
+	return ok && form.isolated != 0xFE80
+}
+
+// shapeArabic replaces Arabic letters with the presentation form matching their position within a word, and
+// combines lam and alef into a single ligature. Other characters are returned unchanged.
+func shapeArabic(chars []rune) []rune {
+	hasArabic := false
+	for _, char := range chars {
+		if _, ok := arabicForms[char]; ok {
+			hasArabic = true
+			break
+		}
+	}
+	if !hasArabic {
+		return chars
+	}
+
+	// neighbour returns the closest character in the given direction that is not transparent.
+	neighbour := func(i, dir int) rune {
+		for j := i + dir; j >= 0 && j < len(chars); j += dir {
+			if !isArabicTransparent(chars[j]) {
+				return chars[j]
+			}
+		}
+		return 0
+	}
+
+	shaped := make([]rune, 0, len(chars))
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		form, ok := arabicForms[char]
+		if !ok {
+			shaped = append(shaped, char)
+			continue
+		}
+
+		prev := joinsToNext(neighbour(i, -1))
+		if char == arabicLam {
+			if lig, ok := lamAlef[neighbour(i, 1)]; ok {
+				// Keep the transparent marks between the lam and the alef
+				for i++; isArabicTransparent(chars[i]); i++ {
+					shaped = append(shaped, chars[i])
+				}
+				if prev {
+					lig++
+				}
+				shaped = append(shaped, lig)
+				continue
+			}
+		}
+
+		next := joinsToPrevious(neighbour(i, 1))
+		switch {
+		case form.dual && prev && next:
+			shaped = append(shaped, form.isolated+3)
+		case form.dual && next:
+			shaped = append(shaped, form.isolated+2)
+		case prev && form.isolated != 0xFE80:
+			shaped = append(shaped, form.isolated+1)
+		default:
+			shaped = append(shaped, form.isolated)
+		}
+	}
+	return shaped
+}
@@ -22,6 +22,11 @@ func (f FontResource) URL() string {
 	return f.url
 }
 
+// Category returns engo.CategoryFont.
+func (f FontResource) Category() string {
+	return engo.CategoryFont
+}
+
 // fontLoader is responsible for managing `.ttf` files within `engo.Files`
 type fontLoader struct {
 	fonts map[string]FontResource
@@ -29,18 +34,29 @@ type fontLoader struct {
 
 // Load processes the data stream and parses it as a freetype font
 func (i *fontLoader) Load(url string, data io.Reader) error {
-	ttfBytes, err := ioutil.ReadAll(data)
+	finish, err := i.Decode(url, data)
 	if err != nil {
 		return err
 	}
+	return finish()
+}
+
+// Decode parses the font, the returned function stores it in the cache
+func (i *fontLoader) Decode(url string, data io.Reader) (func() error, error) {
+	ttfBytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
 
 	ttf, err := freetype.ParseFont(ttfBytes)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	i.fonts[url] = FontResource{Font: ttf, url: url}
-	return nil
+	return func() error {
+		i.fonts[url] = FontResource{Font: ttf, url: url}
+		return nil
+	}, nil
 }
 
 // Load removes the preloaded font from the cache
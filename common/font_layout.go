@@ -0,0 +1,414 @@
+package common
+
+import (
+	"unicode"
+
+	"github.com/EngoEngine/engo"
+)
+
+// WrapMode determines how a Text is broken into lines when it does not fit within its MaxWidth.
+type WrapMode uint8
+
+const (
+	// WrapNone only breaks lines at explicit newlines.
+	WrapNone WrapMode = iota
+	// WrapWord breaks lines between words. Words that are wider than the box by themselves are broken
+	// between characters.
+	WrapWord
+	// WrapCharacter breaks lines between any two characters.
+	WrapCharacter
+)
+
+// TextAlign is the horizontal alignment of the lines of a Text within its box.
+type TextAlign uint8
+
+const (
+	// AlignLeft aligns every line to the left edge of the box.
+	AlignLeft TextAlign = iota
+	// AlignCenter centers every line within the box.
+	AlignCenter
+	// AlignRight aligns every line to the right edge of the box.
+	AlignRight
+	// AlignJustify stretches the spaces of every wrapped line so it fills the width of the box. The last line of
+	// every paragraph is aligned to the left.
+	AlignJustify
+)
+
+// VerticalAlign is the vertical alignment of a Text within its box.
+type VerticalAlign uint8
+
+const (
+	// AlignTop places the first line at the top of the box.
+	AlignTop VerticalAlign = iota
+	// AlignMiddle centers the lines vertically within the box.
+	AlignMiddle
+	// AlignBottom places the last line at the bottom of the box.
+	AlignBottom
+)
+
+// textGlyph is a single character of a Text, placed at its final location relative to the top-left of the Text.
+type textGlyph struct {
+	char rune
+	x, y float32
+}
+
+// textLine is a single line of a Text before it is positioned.
+type textLine struct {
+	chars []rune
+	// wrapped is true when the line was ended by wrapping, rather than by a newline or the end of the text.
+	wrapped bool
+}
+
+// textLayout is the result of laying out a Text using the metrics of a FontAtlas.
+type textLayout struct {
+	glyphs []textGlyph
+	// width and height are the dimensions of the box the glyphs were laid out in.
+	width, height float32
+	// bounds is the smallest box containing every glyph.
+	bounds engo.AABB
+}
+
+// atlasHasRune reports whether the rune can be drawn using the atlas.
+func atlasHasRune(atlas FontAtlas, char rune) bool {
+	return char >= 32 && atlas.hasGlyph(char)
+}
+
+// kerning returns the adjustment to the distance between the two characters.
+func (a FontAtlas) kerning(prev, char rune) float32 {
+	return a.Kerning[[2]rune{prev, char}]
+}
+
+// layoutKey identifies the layout of a Text. The Font is part of it, since the Text only points to it.
+type layoutKey struct {
+	text Text
+	font Font
+}
+
+// layoutEntry is a cached layout, along with the shaped characters the FontAtlas has to contain to draw it.
+type layoutEntry struct {
+	chars  []rune
+	layout textLayout
+}
+
+// maxCachedLayouts is the amount of layouts that are kept, before they are all forgotten.
+const maxCachedLayouts = 256
+
+// cached returns the FontAtlas and the layout of the Text, making sure the atlas contains all the characters of the
+// Text. The Text is only shaped and laid out again when it or its Font changed. The typewriter effect doesn't change
+// the layout, so revealing the Text doesn't either.
+func (t Text) cached() (FontAtlas, textLayout) {
+	key := layoutKey{text: t, font: *t.Font}
+	key.text.Typewriter, key.text.Revealed, key.text.Effects = false, 0, TextEffects{}
+	if entry, ok := layoutCache[key]; ok {
+		atlas, ok := atlasCache[*t.Font]
+		if !ok {
+			atlas = cachedAtlas(t.Font, entry.chars)
+		}
+		return atlas, entry.layout.reveal(t)
+	}
+
+	chars := shapeArabic([]rune(t.Text))
+	entry := layoutEntry{chars: append(chars[:len(chars):len(chars)], []rune(t.Ellipsis)...)}
+	atlas := cachedAtlas(t.Font, entry.chars)
+	entry.layout = layoutText(key.text, atlas)
+	if len(layoutCache) >= maxCachedLayouts {
+		layoutCache = make(map[layoutKey]layoutEntry)
+	}
+	layoutCache[key] = entry
+	return atlas, entry.layout.reveal(t)
+}
+
+// layout returns the layout of the Text.
+func (t Text) layout() textLayout {
+	_, layout := t.cached()
+	return layout
+}
+
+// reveal returns the layout with only the glyphs revealed by the typewriter effect of the Text.
+func (l textLayout) reveal(t Text) textLayout {
+	if t.Typewriter && t.Revealed < len(l.glyphs) {
+		if t.Revealed < 0 {
+			l.glyphs = l.glyphs[:0]
+		} else {
+			l.glyphs = l.glyphs[:t.Revealed]
+		}
+	}
+	return l
+}
+
+// layoutText positions every character of the Text, taking into account wrapping, truncation and alignment. The
+// characters are measured like `Font.TextDimensions` does, or with the atlas for fonts without outlines.
+func layoutText(t Text, atlas FontAtlas) textLayout {
+	var lineHeight float32
+	if atlasHasRune(atlas, 'X') {
+		_, _, _, lineHeight = atlas.Glyph('X')
+	}
+	letterSpace := float32(t.Font.Size) * t.LetterSpacing
+	linePitch := lineHeight + t.LineSpacing*lineHeight
+
+	advance := func(char rune) float32 {
+		if t.Font.TTF != nil {
+			return t.Font.advance(char) + letterSpace
+		}
+		_, _, width, _ := atlas.Glyph(char)
+		return width + letterSpace
+	}
+	measure := func(chars []rune) float32 {
+		var w float32
+		for i, char := range chars {
+			w += advance(char)
+			if i > 0 {
+				w += atlas.kerning(chars[i-1], char)
+			}
+		}
+		return w
+	}
+
+	lines := t.wrapLines(atlas, advance)
+
+	// Drop the lines that do not fit in the box
+	truncated := false
+	if t.MaxHeight > 0 && linePitch > 0 {
+		maxLines := int(t.MaxHeight / linePitch)
+		if maxLines < 1 {
+			maxLines = 1
+		}
+		if len(lines) > maxLines {
+			lines = lines[:maxLines]
+			truncated = true
+		}
+	}
+
+	if t.Ellipsis != "" {
+		var ellipsis []rune
+		for _, char := range t.Ellipsis {
+			if atlasHasRune(atlas, char) {
+				ellipsis = append(ellipsis, char)
+			}
+		}
+		ellipsisWidth := measure(ellipsis)
+
+		for i := range lines {
+			last := truncated && i == len(lines)-1
+			overflows := t.MaxWidth > 0 && measure(lines[i].chars) > t.MaxWidth
+			if !last && !overflows {
+				continue
+			}
+
+			chars := lines[i].chars
+			width := measure(chars)
+			for len(chars) > 0 && t.MaxWidth > 0 && width+ellipsisWidth > t.MaxWidth {
+				last := len(chars) - 1
+				width -= advance(chars[last])
+				if last > 0 {
+					width -= atlas.kerning(chars[last-1], chars[last])
+				}
+				chars = chars[:last]
+			}
+			for len(chars) > 0 && unicode.IsSpace(chars[len(chars)-1]) {
+				chars = chars[:len(chars)-1]
+			}
+			lines[i].chars = append(chars[:len(chars):len(chars)], ellipsis...)
+			lines[i].wrapped = false
+		}
+	}
+
+	layout := textLayout{
+		width:  t.MaxWidth,
+		height: t.MaxHeight,
+	}
+	if layout.width <= 0 {
+		for _, line := range lines {
+			if w := measure(line.chars); w > layout.width {
+				layout.width = w
+			}
+		}
+	}
+	contentHeight := float32(len(lines)) * linePitch
+	if layout.height <= 0 {
+		layout.height = contentHeight
+	}
+
+	var currentY float32
+	switch t.VerticalAlign {
+	case AlignMiddle:
+		currentY = (layout.height - contentHeight) / 2
+	case AlignBottom:
+		currentY = layout.height - contentHeight
+	}
+
+	first := true
+	for _, line := range lines {
+		// Characters are positioned in the order they are displayed, but are kept in their logical order so the
+		// typewriter effect reveals them in the order they are read
+		order := visualOrder(line.chars, t.RightToLeft)
+		visual := make([]rune, len(order))
+		for i, index := range order {
+			visual[i] = line.chars[index]
+		}
+		lineWidth := measure(visual)
+
+		var currentX, spaceExtra float32
+		switch t.Align {
+		case AlignCenter:
+			currentX = (layout.width - lineWidth) / 2
+		case AlignRight:
+			currentX = layout.width - lineWidth
+		case AlignJustify:
+			if !line.wrapped {
+				break
+			}
+			spaces := 0
+			for _, char := range line.chars {
+				if char == ' ' {
+					spaces++
+				}
+			}
+			if spaces > 0 {
+				spaceExtra = (layout.width - lineWidth) / float32(spaces)
+				lineWidth = layout.width
+			}
+		}
+		if t.RightToLeft {
+			// Mirror the alignment, so lines start at the right side
+			currentX = layout.width - currentX - lineWidth
+		}
+
+		xs := make([]float32, len(line.chars))
+		for i, index := range order {
+			if i > 0 {
+				currentX += atlas.kerning(visual[i-1], visual[i])
+			}
+			xs[index] = currentX
+			currentX += advance(visual[i])
+			if visual[i] == ' ' {
+				currentX += spaceExtra
+			}
+		}
+
+		for i, char := range line.chars {
+			x, w := xs[i], advance(char)
+			layout.glyphs = append(layout.glyphs, textGlyph{char: char, x: x, y: currentY})
+
+			if first {
+				layout.bounds = engo.AABB{
+					Min: engo.Point{X: x, Y: currentY},
+					Max: engo.Point{X: x + w, Y: currentY + linePitch},
+				}
+				first = false
+			} else {
+				layout.bounds.Min.X = min32(layout.bounds.Min.X, x)
+				layout.bounds.Min.Y = min32(layout.bounds.Min.Y, currentY)
+				layout.bounds.Max.X = max32(layout.bounds.Max.X, x+w)
+				layout.bounds.Max.Y = max32(layout.bounds.Max.Y, currentY+linePitch)
+			}
+		}
+		currentY += linePitch
+	}
+
+	return layout.reveal(t)
+}
+
+// wrapLines splits the Text into lines at newlines and, depending on the WrapMode, wherever a line would be wider
+// than MaxWidth. Characters that are not in the atlas are removed.
+func (t Text) wrapLines(atlas FontAtlas, advance func(rune) float32) []textLine {
+	if t.Text == "" {
+		return nil
+	}
+
+	var lines []textLine
+	var line []rune
+	var lineWidth float32
+
+	push := func(wrapped bool) {
+		if wrapped {
+			for len(line) > 0 && unicode.IsSpace(line[len(line)-1]) {
+				lineWidth -= advance(line[len(line)-1])
+				line = line[:len(line)-1]
+			}
+		}
+		lines = append(lines, textLine{chars: line, wrapped: wrapped})
+		line = nil
+		lineWidth = 0
+	}
+	// appendChars adds the characters to the current line, breaking between characters if needed.
+	appendChars := func(chars []rune) {
+		for _, char := range chars {
+			w := advance(char)
+			if len(line) > 0 {
+				w += atlas.kerning(line[len(line)-1], char)
+			}
+			if t.Wrap != WrapNone && t.MaxWidth > 0 && len(line) > 0 && lineWidth+w > t.MaxWidth {
+				push(true)
+				if unicode.IsSpace(char) {
+					continue
+				}
+			}
+			line = append(line, char)
+			lineWidth += w
+		}
+	}
+
+	var word []rune
+	var wordWidth float32
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		if t.MaxWidth > 0 && len(line) > 0 && lineWidth+wordWidth > t.MaxWidth {
+			push(true)
+		}
+		appendChars(word)
+		word = word[:0]
+		wordWidth = 0
+	}
+
+	for _, char := range shapeArabic([]rune(t.Text)) {
+		switch {
+		case char == '\n':
+			flushWord()
+			push(false)
+			continue
+		case !atlasHasRune(atlas, char):
+			continue
+		}
+
+		if t.Wrap != WrapWord {
+			appendChars([]rune{char})
+			continue
+		}
+
+		if unicode.IsSpace(char) {
+			flushWord()
+			if len(line) == 0 && len(lines) > 0 && lines[len(lines)-1].wrapped {
+				// Don't start a wrapped line with a space
+				continue
+			}
+			appendChars([]rune{char})
+			continue
+		}
+		if len(word) > 0 {
+			wordWidth += atlas.kerning(word[len(word)-1], char)
+		}
+		word = append(word, char)
+		wordWidth += advance(char)
+	}
+	flushWord()
+	push(false)
+
+	return lines
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
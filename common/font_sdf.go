@@ -0,0 +1,114 @@
+package common
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/EngoEngine/engo"
+)
+
+const (
+	// SDFSpread is the distance in pixels, at the `Size` of the `Font`, that a signed distance field atlas stores
+	// around the edges of each glyph. Outlines, glows and shadow offsets of `TextEffects` are limited to this distance.
+	SDFSpread = 8
+	// sdfUpscale is how much larger than the `Size` of the `Font` the glyphs are rasterized before the distance
+	// field is calculated. This happens on the CPU, as the characters are added to the atlas.
+	sdfUpscale = 4
+)
+
+// TextEffects are the effects drawn around a Text whose Font uses a signed distance field atlas. All distances are in
+// pixels at the `Size` of the `Font`, and cannot be larger than `SDFSpread`. Effects without a color are not drawn.
+type TextEffects struct {
+	// OutlineWidth is the width of the outline around every glyph.
+	OutlineWidth float32
+	// OutlineColor is the color of the outline.
+	OutlineColor color.Color
+	// ShadowOffset is the offset of the drop shadow relative to the glyphs.
+	ShadowOffset engo.Point
+	// ShadowColor is the color of the drop shadow.
+	ShadowColor color.Color
+	// GlowWidth is the distance the glow extends beyond the glyphs. The glow fades out over that distance.
+	GlowWidth float32
+	// GlowColor is the color of the glow.
+	GlowColor color.Color
+}
+
+// signedDistanceField calculates the distance in pixels from every pixel of the mask to the nearest edge, where
+// pixels are considered to be inside if their alpha value is at least half. Distances are positive inside and
+// negative outside, and are measured from the boundary between pixels.
+func signedDistanceField(mask *image.Alpha) []float64 {
+	w, h := mask.Rect.Dx(), mask.Rect.Dy()
+	inside := make([]bool, w*h)
+	for i := range inside {
+		inside[i] = mask.Pix[i] >= 128
+	}
+
+	toInside := distanceTransform(w, h, inside, true)
+	toOutside := distanceTransform(w, h, inside, false)
+
+	field := make([]float64, w*h)
+	for i := range field {
+		if inside[i] {
+			field[i] = toOutside[i] - 0.5
+		} else {
+			field[i] = 0.5 - toInside[i]
+		}
+	}
+	return field
+}
+
+// distanceTransform calculates, for every pixel, the distance to the nearest pixel for which `inside` equals
+// `target`. It uses the two-pass eight-point sequential Euclidean distance transform (8SSEDT).
+func distanceTransform(w, h int, inside []bool, target bool) []float64 {
+	const far = 1 << 14
+
+	dx := make([]int, w*h)
+	dy := make([]int, w*h)
+	for i := range inside {
+		if inside[i] != target {
+			dx[i], dy[i] = far, far
+		}
+	}
+
+	compare := func(x, y, ox, oy int) {
+		nx, ny := x+ox, y+oy
+		if nx < 0 || ny < 0 || nx >= w || ny >= h {
+			return
+		}
+		i, n := y*w+x, ny*w+nx
+		cx, cy := dx[n]+ox, dy[n]+oy
+		if cx*cx+cy*cy < dx[i]*dx[i]+dy[i]*dy[i] {
+			dx[i], dy[i] = cx, cy
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			compare(x, y, -1, 0)
+			compare(x, y, 0, -1)
+			compare(x, y, -1, -1)
+			compare(x, y, 1, -1)
+		}
+		for x := w - 1; x >= 0; x-- {
+			compare(x, y, 1, 0)
+		}
+	}
+	for y := h - 1; y >= 0; y-- {
+		for x := w - 1; x >= 0; x-- {
+			compare(x, y, 1, 0)
+			compare(x, y, 0, 1)
+			compare(x, y, -1, 1)
+			compare(x, y, 1, 1)
+		}
+		for x := 0; x < w; x++ {
+			compare(x, y, -1, 0)
+		}
+	}
+
+	dist := make([]float64, w*h)
+	for i := range dist {
+		dist[i] = math.Sqrt(float64(dx[i]*dx[i] + dy[i]*dy[i]))
+	}
+	return dist
+}
@@ -0,0 +1,273 @@
+package common
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// testAtlas returns a FontAtlas where every character is 10 pixels wide and 20 pixels high.
+func testAtlas() FontAtlas {
+	atlas := FontAtlas{
+		XLocation: make([]float32, 0x800),
+		YLocation: make([]float32, 0x800),
+		Width:     make([]float32, 0x800),
+		Height:    make([]float32, 0x800),
+	}
+	for i := range atlas.Width {
+		atlas.Width[i] = 10
+		atlas.Height[i] = 20
+	}
+	return atlas
+}
+
+func glyphString(layout textLayout) string {
+	var s []rune
+	for _, g := range layout.glyphs {
+		s = append(s, g.char)
+	}
+	return string(s)
+}
+
+func lineStarts(layout textLayout) []engo.Point {
+	var starts []engo.Point
+	lastY := float32(-1)
+	for _, g := range layout.glyphs {
+		if g.y != lastY {
+			starts = append(starts, engo.Point{X: g.x, Y: g.y})
+			lastY = g.y
+		}
+	}
+	return starts
+}
+
+func TestTextLayoutNoWrap(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "ab\ncde"}
+	layout := layoutText(txt, testAtlas())
+
+	assert.Equal(t, "abcde", glyphString(layout))
+	assert.Equal(t, float32(30), layout.width)
+	assert.Equal(t, float32(40), layout.height)
+	assert.Equal(t, []engo.Point{{X: 0, Y: 0}, {X: 0, Y: 20}}, lineStarts(layout))
+	assert.Equal(t, engo.AABB{Max: engo.Point{X: 30, Y: 40}}, layout.bounds)
+}
+
+func TestTextLayoutWordWrap(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "the quick brown fox", MaxWidth: 100, Wrap: WrapWord}
+	layout := layoutText(txt, testAtlas())
+
+	assert.Equal(t, "the quickbrown fox", glyphString(layout), "the space at the wrap should be dropped")
+	assert.Equal(t, []engo.Point{{X: 0, Y: 0}, {X: 0, Y: 20}}, lineStarts(layout))
+
+	txt.Text = "abcdefghijklmno"
+	layout = layoutText(txt, testAtlas())
+	assert.Equal(t, []engo.Point{{X: 0, Y: 0}, {X: 0, Y: 20}}, lineStarts(layout), "long words should be broken up")
+}
+
+func TestTextLayoutCharacterWrap(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "abcde fgh", MaxWidth: 40, Wrap: WrapCharacter}
+	layout := layoutText(txt, testAtlas())
+
+	assert.Equal(t, "abcde fgh", glyphString(layout))
+	assert.Equal(t, []engo.Point{{X: 0, Y: 0}, {X: 0, Y: 20}, {X: 0, Y: 40}}, lineStarts(layout))
+}
+
+func TestTextLayoutAlign(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "ab", MaxWidth: 100, MaxHeight: 100}
+
+	txt.Align = AlignCenter
+	txt.VerticalAlign = AlignMiddle
+	layout := layoutText(txt, testAtlas())
+	assert.Equal(t, []engo.Point{{X: 40, Y: 40}}, lineStarts(layout))
+
+	txt.Align = AlignRight
+	txt.VerticalAlign = AlignBottom
+	layout = layoutText(txt, testAtlas())
+	assert.Equal(t, []engo.Point{{X: 80, Y: 80}}, lineStarts(layout))
+	assert.Equal(t, engo.AABB{Min: engo.Point{X: 80, Y: 80}, Max: engo.Point{X: 100, Y: 100}}, layout.bounds)
+}
+
+func TestTextLayoutJustify(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "a b c dd", MaxWidth: 60, Wrap: WrapWord, Align: AlignJustify}
+	layout := layoutText(txt, testAtlas())
+
+	// The first line "a b c" is 50 wide, so both spaces get 5 extra pixels
+	assert.Equal(t, "a b cdd", glyphString(layout))
+	assert.Equal(t, float32(50), layout.glyphs[4].x)
+	// The last line is not justified
+	assert.Equal(t, float32(0), layout.glyphs[5].x)
+	assert.Equal(t, float32(10), layout.glyphs[6].x)
+}
+
+func TestTextLayoutEllipsis(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "abcdefgh", MaxWidth: 50, Ellipsis: "..."}
+	layout := layoutText(txt, testAtlas())
+	assert.Equal(t, "ab...", glyphString(layout))
+
+	txt = Text{Font: &Font{Size: 10}, Text: "one two three", MaxWidth: 80, MaxHeight: 30, Wrap: WrapWord, Ellipsis: "."}
+	layout = layoutText(txt, testAtlas())
+	assert.Equal(t, "one two.", glyphString(layout))
+}
+
+func TestTextLayoutTypewriter(t *testing.T) {
+	txt := Text{Font: &Font{Size: 10}, Text: "hello world", MaxWidth: 60, Wrap: WrapWord, Typewriter: true, Revealed: 3}
+	layout := layoutText(txt, testAtlas())
+	assert.Equal(t, "hel", glyphString(layout))
+	assert.Equal(t, engo.AABB{Max: engo.Point{X: 50, Y: 40}}, layout.bounds, "bounds should include hidden glyphs")
+
+	txt.Revealed = 100
+	layout = layoutText(txt, testAtlas())
+	assert.Equal(t, "helloworld", glyphString(layout))
+}
+
+func TestTextLayoutRightToLeft(t *testing.T) {
+	// Latin text keeps its order, but starts at the right side of the box
+	txt := Text{Font: &Font{Size: 10}, Text: "abc", MaxWidth: 100, RightToLeft: true}
+	layout := layoutText(txt, testAtlas())
+	assert.Equal(t, float32(70), layout.glyphs[0].x)
+	assert.Equal(t, float32(90), layout.glyphs[2].x)
+
+	// Hebrew runs are always reversed
+	txt = Text{Font: &Font{Size: 10}, Text: "ab אב"}
+	layout = layoutText(txt, testAtlas())
+	assert.Equal(t, float32(40), layout.glyphs[3].x)
+	assert.Equal(t, float32(30), layout.glyphs[4].x)
+}
+
+func TestVisualOrder(t *testing.T) {
+	// alef bet gimel
+	assert.Equal(t, []int{2, 1, 0}, visualOrder([]rune("אבג"), false))
+	// In a right-to-left line, Hebrew comes first from the right, and Latin keeps its order
+	assert.Equal(t, []int{2, 3, 1, 0}, visualOrder([]rune("א ab"), true))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, visualOrder([]rune("ab cd"), true))
+	// Numbers within Hebrew stay left-to-right, while the Hebrew words around them are swapped
+	assert.Equal(t, []int{5, 4, 2, 3, 1, 0, 6}, visualOrder([]rune("א 12 ב "), false))
+	// Without right-to-left characters, nothing changes
+	assert.Equal(t, []int{0, 1, 2}, visualOrder([]rune("a.b"), false))
+}
+
+func TestShapeArabic(t *testing.T) {
+	// beh beh beh: initial, medial, final
+	assert.Equal(t, []rune{0xFE91, 0xFE92, 0xFE90}, shapeArabic([]rune("ببب")))
+	// alef only joins to the previous letter, so the beh after it is isolated
+	assert.Equal(t, []rune{0xFE91, 0xFE8E, 0xFE8F}, shapeArabic([]rune("باب")))
+	// lam alef becomes a ligature
+	assert.Equal(t, []rune{0xFEFB}, shapeArabic([]rune("لا")))
+	assert.Equal(t, []rune{0xFE91, 0xFEFC}, shapeArabic([]rune("بلا")))
+	// Marks don't interrupt joining
+	assert.Equal(t, []rune{0xFE91, 0x064E, 0xFE90}, shapeArabic([]rune("بَب")))
+	assert.Equal(t, []rune("abc"), shapeArabic([]rune("abc")))
+}
+
+type fontTestScene struct{}
+
+func (*fontTestScene) Preload()           {}
+func (*fontTestScene) Setup(engo.Updater) {}
+func (*fontTestScene) Type() string       { return "fontTestScene" }
+
+// loadRoboto loads the font of the text demo as "Roboto-Regular.ttf".
+func loadRoboto(t *testing.T) {
+	data, err := ioutil.ReadFile("../demos/text/assets/Roboto-Regular.ttf")
+	if err != nil {
+		t.Fatalf("Unable to read font. Error was: %v", err)
+	}
+	if err := engo.Files.LoadReaderData("Roboto-Regular.ttf", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Unable to load font. Error was: %v", err)
+	}
+}
+
+func TestFontAtlasFallbackAndGrowth(t *testing.T) {
+	engo.Run(engo.RunOptions{HeadlessMode: true, NoRun: true, AssetsRoot: "testdata"}, &fontTestScene{})
+	loadRoboto(t)
+	primary, err := truetype.Parse(goregular.TTF)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fallback := &Font{URL: "Roboto-Regular.ttf"}
+	if !assert.NoError(t, fallback.CreatePreloaded()) {
+		return
+	}
+	fnt := &Font{TTF: primary, Size: 16, Fallback: fallback}
+
+	const schwa = 'Ə' // Not in the Go font, but in Roboto
+	builder := newAtlasBuilder(fnt)
+	assert.Equal(t, 0, builder.faceFor('a'))
+	assert.Equal(t, 1, builder.faceFor(schwa))
+
+	atlas := cachedAtlas(fnt, []rune("abc"))
+	assert.Len(t, atlas.Width, UnicodeCap)
+	height := atlas.TotalHeight
+
+	atlas = cachedAtlas(fnt, []rune{'a', schwa, '😀'})
+	assert.Len(t, atlas.Width, UnicodeCap, "characters added later should not grow the slices")
+	_, _, width, _ := atlas.Glyph(schwa)
+	assert.NotZero(t, width)
+	assert.True(t, atlas.hasGlyph('😀'), "characters missing from all fonts should still be added")
+	assert.True(t, atlas.TotalHeight >= height)
+	cached := atlasCache[*fnt]
+	assert.True(t, cached.hasGlyph(schwa), "the grown atlas should be cached")
+
+	// The image has room for more characters, so it's not reallocated when they are added
+	img := atlas.builder.img
+	atlas = cachedAtlas(fnt, []rune{'Ω'})
+	assert.True(t, atlas.hasGlyph('Ω'))
+	assert.True(t, img == atlas.builder.img, "the image should not grow for a single character")
+
+	delete(atlasCache, *fnt)
+}
+
+func TestTextLayoutCache(t *testing.T) {
+	engo.Run(engo.RunOptions{HeadlessMode: true, NoRun: true}, &fontTestScene{})
+	ttf, err := truetype.Parse(goregular.TTF)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fnt := &Font{TTF: ttf, Size: 16}
+	defer delete(atlasCache, *fnt)
+	layoutCache = make(map[layoutKey]layoutEntry)
+
+	txt := Text{Font: fnt, Text: "hello world", Typewriter: true, Revealed: 3}
+	width, _, _ := fnt.TextDimensions(txt.Text)
+	assert.InDelta(t, float32(width), txt.Width(), 1, "the Text should be measured like TextDimensions")
+
+	layout := txt.layout()
+	assert.Equal(t, "hel", glyphString(layout))
+	cached := len(layoutCache)
+	txt.Revealed = 5
+	layout = txt.layout()
+	assert.Equal(t, "hello", glyphString(layout))
+	assert.Equal(t, cached, len(layoutCache), "revealing the Text should not lay it out again")
+
+	txt.Text = "hello"
+	txt.layout()
+	assert.Equal(t, cached+1, len(layoutCache), "changing the Text should lay it out again")
+
+	delete(atlasCache, *fnt)
+	atlas, _ := txt.cached()
+	assert.True(t, atlas.hasGlyph('h'), "the atlas should be generated again with the characters of the cached layout")
+}
+
+func TestSignedDistanceField(t *testing.T) {
+	// A 4x4 square in the middle of a 12x12 mask
+	mask := image.NewAlpha(image.Rect(0, 0, 12, 12))
+	for y := 4; y < 8; y++ {
+		for x := 4; x < 8; x++ {
+			mask.SetAlpha(x, y, color.Alpha{A: 255})
+		}
+	}
+	field := signedDistanceField(mask)
+
+	assert.InDelta(t, 0.5, field[5*12+4], 1e-9, "pixels on the inside edge should be half a pixel inside")
+	assert.InDelta(t, -0.5, field[5*12+3], 1e-9, "pixels on the outside edge should be half a pixel outside")
+	assert.InDelta(t, 1.5, field[5*12+5], 1e-9)
+	assert.InDelta(t, -3.5, field[5*12+0], 1e-9)
+	assert.InDelta(t, -(math.Sqrt(18) - 0.5), field[1*12+1], 1e-9, "corners should use the euclidean distance")
+}
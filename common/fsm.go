@@ -0,0 +1,325 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+// State is a state of a StateMachine. States can have substates, which are
+// the states whose Parent they are. While a substate is active, all of its
+// ancestors are active too.
+type State struct {
+	// Name is the unique name of the state within its StateMachine
+	Name string
+	// Parent is the name of the state this is a substate of, or "" for top
+	// level states
+	Parent string
+	// Initial is the name of the substate that is entered when the state is
+	// entered. States with substates have to have one.
+	Initial string
+	// Enter is called when the state becomes active
+	Enter func(m *StateMachine)
+	// Exit is called when the state stops being active
+	Exit func(m *StateMachine)
+	// Update is called every update while the state is active
+	Update func(m *StateMachine, dt float32)
+	// Transitions are the transitions out of the state. They are also taken
+	// while one of the state's substates is active, before the ones of the
+	// substate.
+	Transitions []Transition
+
+	parent *State
+}
+
+// Transition is a transition from a State to another one.
+type Transition struct {
+	// To is the name of the state the transition leads to
+	To string
+	// Message is the type of the Mailbox messages triggering the transition.
+	// Transitions without one are checked every update.
+	Message string
+	// Condition decides whether the transition is taken, with the message
+	// triggering it, or nil for transitions checked every update. The
+	// transition is always taken if it is nil.
+	Condition func(m *StateMachine, msg engo.Message) bool
+}
+
+// StateMachine is a hierarchical finite state machine. Its states are
+// changed by transitions, which are triggered by messages or conditions, or by
+// calling Transition.
+type StateMachine struct {
+	// Blackboard holds the data shared by the states
+	Blackboard Blackboard
+
+	states   map[string]*State
+	current  *State
+	messages []engo.Message
+}
+
+// NewStateMachine creates a StateMachine with the states. Their parents have
+// to be part of the states, and their initial states have to be their
+// substates.
+func NewStateMachine(states ...*State) (*StateMachine, error) {
+	m := &StateMachine{Blackboard: make(Blackboard), states: make(map[string]*State)}
+	for _, s := range states {
+		if _, ok := m.states[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate state %q", s.Name)
+		}
+		m.states[s.Name] = s
+	}
+	for _, s := range states {
+		s.parent = nil
+		if s.Parent == "" {
+			continue
+		}
+		p, ok := m.states[s.Parent]
+		if !ok {
+			return nil, fmt.Errorf("state %q: unknown parent %q", s.Name, s.Parent)
+		}
+		for a := p; a != nil; a = a.parent {
+			if a == s {
+				return nil, fmt.Errorf("state %q is its own ancestor", s.Name)
+			}
+		}
+		s.parent = p
+	}
+	for _, s := range states {
+		if s.Initial != "" {
+			initial, ok := m.states[s.Initial]
+			if !ok {
+				return nil, fmt.Errorf("state %q: unknown initial state %q", s.Name, s.Initial)
+			}
+			if initial.parent != s {
+				return nil, fmt.Errorf("state %q: initial state %q is not one of its substates", s.Name, s.Initial)
+			}
+		}
+		for _, t := range s.Transitions {
+			if m.states[t.To] == nil {
+				return nil, fmt.Errorf("state %q: transition to unknown state %q", s.Name, t.To)
+			}
+		}
+	}
+	return m, nil
+}
+
+// State returns the state with the name, or nil if there is none.
+func (m *StateMachine) State(name string) *State {
+	return m.states[name]
+}
+
+// Current returns the name of the innermost active state, or "" if the
+// StateMachine has not been started yet.
+func (m *StateMachine) Current() string {
+	if m.current == nil {
+		return ""
+	}
+	return m.current.Name
+}
+
+// In returns whether the state with the name is active, which it also is while
+// one of its substates is.
+func (m *StateMachine) In(name string) bool {
+	for s := m.current; s != nil; s = s.parent {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition exits the active states up to the closest ancestor they share
+// with the state with the name, and enters the states from there down to it,
+// and its initial substates.
+func (m *StateMachine) Transition(name string) error {
+	to, ok := m.states[name]
+	if !ok {
+		return fmt.Errorf("unknown state %q", name)
+	}
+
+	// the ancestors of the target, from the outermost one
+	var path []*State
+	for s := to; s != nil; s = s.parent {
+		path = append([]*State{s}, path...)
+	}
+	common := 0
+	for common < len(path) && m.In(path[common].Name) {
+		common++
+	}
+	// a transition to the current state or one of its ancestors re-enters it
+	if common == len(path) {
+		common--
+	}
+
+	for s := m.current; s != nil && (common == 0 || s != path[common-1]); s = s.parent {
+		if s.Exit != nil {
+			s.Exit(m)
+		}
+		m.current = s.parent
+	}
+	for _, s := range path[common:] {
+		m.enter(s)
+	}
+	for m.current.Initial != "" {
+		m.enter(m.states[m.current.Initial])
+	}
+	return nil
+}
+
+func (m *StateMachine) enter(s *State) {
+	m.current = s
+	if s.Enter != nil {
+		s.Enter(m)
+	}
+}
+
+// Handle queues the message, so it can trigger transitions at the next Update.
+func (m *StateMachine) Handle(msg engo.Message) {
+	m.messages = append(m.messages, msg)
+}
+
+// Update takes the transitions triggered by the queued messages, and the first
+// one of the ones without message whose condition is met. Then it calls the
+// Update of the active states, from the outermost one.
+func (m *StateMachine) Update(dt float32) {
+	messages := m.messages
+	m.messages = nil
+	if m.current == nil {
+		return
+	}
+	for _, msg := range messages {
+		if t, ok := m.transition(msg); ok {
+			m.Transition(t.To)
+		}
+	}
+	if t, ok := m.transition(nil); ok {
+		m.Transition(t.To)
+	}
+
+	var active []*State
+	for s := m.current; s != nil; s = s.parent {
+		active = append([]*State{s}, active...)
+	}
+	for _, s := range active {
+		if s.Update != nil {
+			s.Update(m, dt)
+		}
+	}
+}
+
+// transition returns the first transition of the active states, from the
+// outermost one, which is triggered by the message and whose condition is met.
+// A nil message checks the transitions without message.
+func (m *StateMachine) transition(msg engo.Message) (Transition, bool) {
+	var active []*State
+	for s := m.current; s != nil; s = s.parent {
+		active = append([]*State{s}, active...)
+	}
+	for _, s := range active {
+		for _, t := range s.Transitions {
+			if msg == nil && t.Message != "" || msg != nil && t.Message != msg.Type() {
+				continue
+			}
+			if t.Condition == nil || t.Condition(m, msg) {
+				return t, true
+			}
+		}
+	}
+	return Transition{}, false
+}
+
+// messageTypes returns the types of the messages triggering transitions.
+func (m *StateMachine) messageTypes() []string {
+	var ret []string
+	for _, s := range m.states {
+		for _, t := range s.Transitions {
+			if t.Message != "" {
+				ret = append(ret, t.Message)
+			}
+		}
+	}
+	return ret
+}
+
+// StateMachineComponent holds the StateMachine of an entity.
+type StateMachineComponent struct {
+	Machine *StateMachine
+	// Initial is the state the StateMachine is started in when it is added to
+	// the StateMachineSystem, if it has not been started yet.
+	Initial string
+}
+
+type stateMachineEntity struct {
+	*ecs.BasicEntity
+	*StateMachineComponent
+}
+
+// StateMachineSystem updates the StateMachines of its entities, and passes
+// them the Mailbox messages which trigger their transitions.
+type StateMachineSystem struct {
+	entities  []stateMachineEntity
+	listening map[string]bool
+}
+
+// Add adds an entity to the StateMachineSystem, and starts its StateMachine in
+// its Initial state. To be added, the entity has to have a basic and state
+// machine component.
+func (s *StateMachineSystem) Add(basic *ecs.BasicEntity, machine *StateMachineComponent) {
+	s.entities = append(s.entities, stateMachineEntity{basic, machine})
+	if machine.Machine == nil {
+		return
+	}
+	if machine.Machine.current == nil && machine.Initial != "" {
+		if err := machine.Machine.Transition(machine.Initial); err != nil {
+			warning("unable to start state machine: %v", err)
+		}
+	}
+
+	// the system listens once to every message type, and queues the messages
+	// in all machines, which ignore the ones they have no transition for
+	if engo.Mailbox == nil {
+		return
+	}
+	if s.listening == nil {
+		s.listening = make(map[string]bool)
+	}
+	for _, typ := range machine.Machine.messageTypes() {
+		if s.listening[typ] {
+			continue
+		}
+		s.listening[typ] = true
+		engo.Mailbox.Listen(typ, func(msg engo.Message) {
+			for _, e := range s.entities {
+				if e.Machine != nil {
+					e.Machine.Handle(msg)
+				}
+			}
+		})
+	}
+}
+
+// AddByInterface Provides a simple way to add an entity to the system that satisfies StateMachineable. Any entity containing, BasicEntity and StateMachineComponent anonymously, automatically does this.
+func (s *StateMachineSystem) AddByInterface(i ecs.Identifier) {
+	o, _ := i.(StateMachineable)
+	s.Add(o.GetBasicEntity(), o.GetStateMachineComponent())
+}
+
+// Remove removes an entity from the StateMachineSystem.
+func (s *StateMachineSystem) Remove(basic ecs.BasicEntity) {
+	for i, e := range s.entities {
+		if e.BasicEntity.ID() == basic.ID() {
+			s.entities = append(s.entities[:i], s.entities[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update updates the StateMachines of the entities.
+func (s *StateMachineSystem) Update(dt float32) {
+	for _, e := range s.entities {
+		if e.Machine != nil {
+			e.Machine.Update(dt)
+		}
+	}
+}
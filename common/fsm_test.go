@@ -0,0 +1,117 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+type testDamageMessage struct {
+	damage int
+}
+
+func (testDamageMessage) Type() string { return "testDamageMessage" }
+
+func TestStateMachine(t *testing.T) {
+	var log []string
+	hooks := func(s *State) *State {
+		s.Enter = func(*StateMachine) { log = append(log, "enter "+s.Name) }
+		s.Exit = func(*StateMachine) { log = append(log, "exit "+s.Name) }
+		return s
+	}
+	moving := func(m *StateMachine, _ engo.Message) bool {
+		v, _ := m.Blackboard.Bool("moving")
+		return v
+	}
+	updates := 0
+	m, err := NewStateMachine(
+		hooks(&State{Name: "alive", Initial: "idle", Transitions: []Transition{
+			{To: "dead", Message: "testDamageMessage", Condition: func(m *StateMachine, msg engo.Message) bool {
+				hp, _ := m.Blackboard.Int("hp")
+				hp -= msg.(testDamageMessage).damage
+				m.Blackboard.Set("hp", hp)
+				return hp <= 0
+			}},
+		}}),
+		hooks(&State{Name: "idle", Parent: "alive", Transitions: []Transition{{To: "walking", Condition: moving}}}),
+		hooks(&State{Name: "walking", Parent: "alive", Update: func(*StateMachine, float32) { updates++ }}),
+		hooks(&State{Name: "dead"}),
+	)
+	if err != nil {
+		t.Fatalf("Unable to create state machine. Error was: %v", err)
+	}
+	m.Blackboard.Set("hp", 10)
+
+	if err := m.Transition("alive"); err != nil {
+		t.Fatalf("Unable to start state machine. Error was: %v", err)
+	}
+	if m.Current() != "idle" || !m.In("alive") || m.In("walking") {
+		t.Errorf("Current state was %q, expected the initial substate", m.Current())
+	}
+	if exp := []string{"enter alive", "enter idle"}; !reflect.DeepEqual(log, exp) {
+		t.Errorf("States were entered as %v, expected %v", log, exp)
+	}
+
+	log = nil
+	m.Update(1)
+	if m.Current() != "idle" {
+		t.Error("Transition was taken although its condition was not met")
+	}
+	m.Blackboard.Set("moving", true)
+	m.Update(1)
+	if m.Current() != "walking" || updates != 1 {
+		t.Errorf("Current state was %q, expected the transition to walking", m.Current())
+	}
+	if exp := []string{"exit idle", "enter walking"}; !reflect.DeepEqual(log, exp) {
+		t.Errorf("States were changed as %v, expected %v", log, exp)
+	}
+
+	// the transition to an ancestor re-enters it
+	log = nil
+	m.Transition("alive")
+	if exp := []string{"exit walking", "exit alive", "enter alive", "enter idle"}; !reflect.DeepEqual(log, exp) {
+		t.Errorf("States were changed as %v, expected %v", log, exp)
+	}
+
+	// messages are passed by the system
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	sys := &StateMachineSystem{}
+	basic := ecs.NewBasic()
+	sys.Add(&basic, &StateMachineComponent{Machine: m})
+	engo.Mailbox.Dispatch(testDamageMessage{damage: 4})
+	m.Blackboard.Set("moving", false)
+	sys.Update(1)
+	if m.Current() != "idle" {
+		t.Errorf("Current state was %q, expected the damage to not kill", m.Current())
+	}
+	log = nil
+	engo.Mailbox.Dispatch(testDamageMessage{damage: 8})
+	sys.Update(1)
+	if m.Current() != "dead" || m.In("alive") {
+		t.Errorf("Current state was %q, expected the message to trigger the transition to dead", m.Current())
+	}
+	if exp := []string{"exit idle", "exit alive", "enter dead"}; !reflect.DeepEqual(log, exp) {
+		t.Errorf("States were changed as %v, expected %v", log, exp)
+	}
+
+	other, _ := NewStateMachine(&State{Name: "a"}, &State{Name: "b"})
+	sys.Add(&basic, &StateMachineComponent{Machine: other, Initial: "b"})
+	if other.Current() != "b" {
+		t.Errorf("State machine was started in %q, expected the initial state", other.Current())
+	}
+
+	if _, err := NewStateMachine(&State{Name: "a", Parent: "b"}); err == nil {
+		t.Error("State machine was created with an unknown parent")
+	}
+	if _, err := NewStateMachine(&State{Name: "a", Transitions: []Transition{{To: "b"}}}); err == nil {
+		t.Error("State machine was created with a transition to an unknown state")
+	}
+	if _, err := NewStateMachine(&State{Name: "a", Initial: "b"}, &State{Name: "b", Initial: "a"}); err == nil {
+		t.Error("State machine was created with initial states that are not substates")
+	}
+	if err := m.Transition("unknown"); err == nil {
+		t.Error("Transition to an unknown state did not return an error")
+	}
+}
@@ -64,6 +64,36 @@ func (c *CollisionComponent) GetCollisionComponent() *CollisionComponent {
 	return c
 }
 
+// GetLocalizedComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *LocalizedComponent) GetLocalizedComponent() *LocalizedComponent {
+	return c
+}
+
+// GetSteeringComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *SteeringComponent) GetSteeringComponent() *SteeringComponent {
+	return c
+}
+
+// GetStateMachineComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *StateMachineComponent) GetStateMachineComponent() *StateMachineComponent {
+	return c
+}
+
+// GetBehaviorTreeComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *BehaviorTreeComponent) GetBehaviorTreeComponent() *BehaviorTreeComponent {
+	return c
+}
+
+// GetLightComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *LightComponent) GetLightComponent() *LightComponent {
+	return c
+}
+
+// GetOccluderComponent Provides container classes ability to fulfil the interface and be accessed more simply by systems, eg in AddByInterface Methods
+func (c *OccluderComponent) GetOccluderComponent() *OccluderComponent {
+	return c
+}
+
 // Faces
 
 // BasicFace is the means of accessing the ecs.BasicEntity class , it also has the ID method, to simplify, finding an item within a system
@@ -77,6 +107,11 @@ type AnimationFace interface {
 	GetAnimationComponent() *AnimationComponent
 }
 
+// LocalizedFace allows typesafe access to an Anonymous child LocalizedComponent
+type LocalizedFace interface {
+	GetLocalizedComponent() *LocalizedComponent
+}
+
 // MouseFace allows typesafe access to an Anonymous child MouseComponent
 type MouseFace interface {
 	GetMouseComponent() *MouseComponent
@@ -102,6 +137,31 @@ type CollisionFace interface {
 	GetCollisionComponent() *CollisionComponent
 }
 
+// SteeringFace allows typesafe access to an anonymous SteeringComponent
+type SteeringFace interface {
+	GetSteeringComponent() *SteeringComponent
+}
+
+// StateMachineFace allows typesafe access to an anonymous StateMachineComponent
+type StateMachineFace interface {
+	GetStateMachineComponent() *StateMachineComponent
+}
+
+// BehaviorTreeFace allows typesafe access to an anonymous BehaviorTreeComponent
+type BehaviorTreeFace interface {
+	GetBehaviorTreeComponent() *BehaviorTreeComponent
+}
+
+// LightFace allows typesafe access to an anonymous LightComponent
+type LightFace interface {
+	GetLightComponent() *LightComponent
+}
+
+// OccluderFace allows typesafe access to an anonymous OccluderComponent
+type OccluderFace interface {
+	GetOccluderComponent() *OccluderComponent
+}
+
 // Combined for systems
 
 // Animationable is the required interface for AnimationSystem.AddByInterface method
@@ -111,6 +171,13 @@ type Animationable interface {
 	RenderFace
 }
 
+// Localizable is the required interface for the LocalizationSystem.AddByInterface method
+type Localizable interface {
+	BasicFace
+	LocalizedFace
+	RenderFace
+}
+
 // Mouseable is the required interface for the MouseSystem AddByInterface method
 type Mouseable interface {
 	BasicFace
@@ -139,6 +206,39 @@ type Collisionable interface {
 	SpaceFace
 }
 
+// Steerable is the required interface for the SteeringSystem.AddByInterface method
+type Steerable interface {
+	BasicFace
+	SteeringFace
+	SpaceFace
+}
+
+// StateMachineable is the required interface for the StateMachineSystem.AddByInterface method
+type StateMachineable interface {
+	BasicFace
+	StateMachineFace
+}
+
+// BehaviorTreeable is the required interface for the BehaviorTreeSystem.AddByInterface method
+type BehaviorTreeable interface {
+	BasicFace
+	BehaviorTreeFace
+}
+
+// Lightable is the required interface for the LightingSystem.AddByInterface method to add a light
+type Lightable interface {
+	BasicFace
+	LightFace
+	SpaceFace
+}
+
+// Occludable is the required interface for the LightingSystem.AddByInterface method to add an occluder
+type Occludable interface {
+	BasicFace
+	OccluderFace
+	SpaceFace
+}
+
 // Not-Ables
 
 // NotAnimationComponent is used to flag an entity as not in the AnimationSystem
@@ -215,3 +315,93 @@ func (n *NotCollisionComponent) GetNotCollisionComponent() *NotCollisionComponen
 type NotCollisionable interface {
 	GetNotCollisionComponent() *NotCollisionComponent
 }
+
+// NotLocalizedComponent is used to flag an entity as not in the LocalizationSystem
+// even if it has the proper components
+type NotLocalizedComponent struct{}
+
+// GetNotLocalizedComponent implements the NotLocalizable interface
+func (n *NotLocalizedComponent) GetNotLocalizedComponent() *NotLocalizedComponent {
+	return n
+}
+
+// NotLocalizable is an interface used to flag an entity as not in the
+// LocalizationSystem even if it has the proper components
+type NotLocalizable interface {
+	GetNotLocalizedComponent() *NotLocalizedComponent
+}
+
+// NotSteeringComponent is used to flag an entity as not in the SteeringSystem
+// even if it has the proper components
+type NotSteeringComponent struct{}
+
+// GetNotSteeringComponent implements the NotSteerable interface
+func (n *NotSteeringComponent) GetNotSteeringComponent() *NotSteeringComponent {
+	return n
+}
+
+// NotSteerable is an interface used to flag an entity as not in the
+// SteeringSystem even if it has the proper components
+type NotSteerable interface {
+	GetNotSteeringComponent() *NotSteeringComponent
+}
+
+// NotStateMachineComponent is used to flag an entity as not in the
+// StateMachineSystem even if it has the proper components
+type NotStateMachineComponent struct{}
+
+// GetNotStateMachineComponent implements the NotStateMachineable interface
+func (n *NotStateMachineComponent) GetNotStateMachineComponent() *NotStateMachineComponent {
+	return n
+}
+
+// NotStateMachineable is an interface used to flag an entity as not in the
+// StateMachineSystem even if it has the proper components
+type NotStateMachineable interface {
+	GetNotStateMachineComponent() *NotStateMachineComponent
+}
+
+// NotBehaviorTreeComponent is used to flag an entity as not in the
+// BehaviorTreeSystem even if it has the proper components
+type NotBehaviorTreeComponent struct{}
+
+// GetNotBehaviorTreeComponent implements the NotBehaviorTreeable interface
+func (n *NotBehaviorTreeComponent) GetNotBehaviorTreeComponent() *NotBehaviorTreeComponent {
+	return n
+}
+
+// NotBehaviorTreeable is an interface used to flag an entity as not in the
+// BehaviorTreeSystem even if it has the proper components
+type NotBehaviorTreeable interface {
+	GetNotBehaviorTreeComponent() *NotBehaviorTreeComponent
+}
+
+// NotLightComponent is used to flag an entity as not a light in the
+// LightingSystem even if it has the proper components
+type NotLightComponent struct{}
+
+// GetNotLightComponent implements the NotLightable interface
+func (n *NotLightComponent) GetNotLightComponent() *NotLightComponent {
+	return n
+}
+
+// NotLightable is an interface used to flag an entity as not a light in the
+// LightingSystem even if it has the proper components
+type NotLightable interface {
+	GetNotLightComponent() *NotLightComponent
+}
+
+// NotOccluderComponent is used to flag an entity as not an occluder in the
+// LightingSystem even if it has the proper components
+type NotOccluderComponent struct{}
+
+// GetNotOccluderComponent implements the NotOccludable interface
+func (n *NotOccluderComponent) GetNotOccluderComponent() *NotOccluderComponent {
+	return n
+}
+
+// NotOccludable is an interface used to flag an entity as not an occluder in
+// the LightingSystem even if it has the proper components
+type NotOccludable interface {
+	GetNotOccluderComponent() *NotOccluderComponent
+}
@@ -15,6 +15,11 @@ type EveryComp struct {
 	SpaceComponent
 	CollisionComponent
 	AudioComponent
+	SteeringComponent
+	StateMachineComponent
+	BehaviorTreeComponent
+	LightComponent
+	OccluderComponent
 }
 
 type TestInterfaceScene struct {
@@ -54,6 +59,26 @@ func (s *TestInterfaceScene) Setup(u engo.Updater) {
 	var notaud *NotAudioable
 	w.AddSystemInterface(&audsys, aud, notaud)
 
+	ssys := SteeringSystem{}
+	var st *Steerable
+	var notst *NotSteerable
+	w.AddSystemInterface(&ssys, st, notst)
+
+	fsmsys := StateMachineSystem{}
+	var fsm *StateMachineable
+	var notfsm *NotStateMachineable
+	w.AddSystemInterface(&fsmsys, fsm, notfsm)
+
+	btsys := BehaviorTreeSystem{}
+	var bt *BehaviorTreeable
+	var notbt *NotBehaviorTreeable
+	w.AddSystemInterface(&btsys, bt, notbt)
+
+	lsys := LightingSystem{}
+	var l *Lightable
+	var o *Occludable
+	w.AddSystemInterface(&lsys, []interface{}{l, o}, nil)
+
 	e := &EveryComp{BasicEntity: ecs.NewBasic()}
 	w.AddEntity(e)
 
@@ -116,6 +141,54 @@ func (s *TestInterfaceScene) Setup(u engo.Updater) {
 		s.reason = "did not remove entry from audio system"
 		return
 	}
+
+	if len(ssys.agents) != 1 {
+		s.failed = true
+		s.reason = "did not add entity to steering system"
+		return
+	}
+	ssys.Remove(e.BasicEntity)
+	if len(ssys.agents) != 0 {
+		s.failed = true
+		s.reason = "did not remove entry from steering system"
+		return
+	}
+
+	if len(fsmsys.entities) != 1 {
+		s.failed = true
+		s.reason = "did not add entity to state machine system"
+		return
+	}
+	fsmsys.Remove(e.BasicEntity)
+	if len(fsmsys.entities) != 0 {
+		s.failed = true
+		s.reason = "did not remove entry from state machine system"
+		return
+	}
+
+	if len(btsys.entities) != 1 {
+		s.failed = true
+		s.reason = "did not add entity to behavior tree system"
+		return
+	}
+	btsys.Remove(e.BasicEntity)
+	if len(btsys.entities) != 0 {
+		s.failed = true
+		s.reason = "did not remove entry from behavior tree system"
+		return
+	}
+
+	if len(lsys.lights) != 1 || len(lsys.occluders) != 1 {
+		s.failed = true
+		s.reason = "did not add entity to lighting system"
+		return
+	}
+	lsys.Remove(e.BasicEntity)
+	if len(lsys.lights) != 0 || len(lsys.occluders) != 0 {
+		s.failed = true
+		s.reason = "did not remove entry from lighting system"
+		return
+	}
 }
 
 // TestEveryInterface Creates an Everything component and tries to add and then remove it from each system to each system using AddByInterface.
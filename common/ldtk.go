@@ -0,0 +1,286 @@
+package common
+
+import (
+	"image/color"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// LDtkProject is a parsed LDtk project containing all of its worlds and levels
+type LDtkProject struct {
+	// Worlds contains the worlds of the project. Projects without multiple
+	// worlds have a single world containing all levels.
+	Worlds []*LDtkWorld
+	// TileWidth is the default grid size of the project's layers
+	TileWidth int
+}
+
+// LDtkWorld is a world of an LDtk project
+type LDtkWorld struct {
+	// Identifier is the unique name of the world
+	Identifier string
+	// IID is the unique instance ID of the world
+	IID string
+	// Layout is how the levels are placed in the world, which is Free,
+	// GridVania, LinearHorizontal or LinearVertical
+	Layout string
+	// Levels contains the levels of the world
+	Levels []*LDtkLevel
+}
+
+// LDtkLevel is a level of an LDtk project. Its tile layers, auto-layers and
+// the auto tiles of its IntGrid layers are unpacked into Level, so they can be
+// rendered like TMX levels.
+type LDtkLevel struct {
+	// Identifier is the unique name of the level
+	Identifier string
+	// IID is the unique instance ID of the level
+	IID string
+	// WorldX and WorldY are the position of the level in its world in pixels
+	WorldX, WorldY int
+	// WorldDepth is the depth of the level in its world, for levels that are
+	// above each other
+	WorldDepth int
+	// Width and Height are the size of the level in pixels
+	Width, Height int
+	// BgColor is the background color of the level
+	BgColor color.NRGBA
+	// Fields are the custom fields of the level
+	Fields Properties
+	// Level contains the tiles of the level. Its TileLayers are ordered from
+	// the bottom to the top layer, like the ones of TMX levels.
+	Level *Level
+	// IntGrids contains the IntGrid layers of the level
+	IntGrids []*IntGrid
+	// Entities contains the entity instances of all entity layers of the level
+	Entities []*LDtkEntity
+	// Neighbours are the levels next to the level
+	Neighbours []LDtkNeighbour
+
+	project *LDtkProject
+}
+
+// LDtkNeighbour is a level next to an LDtk level
+type LDtkNeighbour struct {
+	// IID is the instance ID of the neighbouring level
+	IID string
+	// Dir is the direction of the neighbour, which is "n", "s", "e", "w" or a
+	// combination like "ne" for the corners, "<" and ">" for levels below and
+	// above in depth, "o" for overlapping levels in the same depth.
+	Dir string
+}
+
+// LDtkEntity is an entity instance of an LDtk level
+type LDtkEntity struct {
+	// Identifier is the name of the entity's definition
+	Identifier string
+	// IID is the unique instance ID of the entity
+	IID string
+	// Layer is the identifier of the entity layer containing the entity
+	Layer string
+	// Position is the position of the entity's pivot in the level in pixels
+	Position engo.Point
+	// Pivot is the relative position of the pivot within the entity, 0,0 being
+	// the top-left and 1,1 the bottom-right corner
+	Pivot engo.Point
+	// Width and Height are the size of the entity in pixels
+	Width, Height float32
+	// GridX and GridY are the position of the entity's pivot in cells
+	GridX, GridY int
+	// Tags are the tags of the entity's definition
+	Tags []string
+	// Fields are the custom fields of the entity. Their types are int, float,
+	// bool, string, color, file, enum (with the enum as the PropertyType),
+	// point (with the cell as "x,y"), entity (with the IID of the referenced
+	// entity as value), tile (with "x,y,w,h" in the tileset as value and the
+	// uid of the tileset as the PropertyType) and array (with the elements as Members,
+	// and their type as the PropertyType).
+	Fields Properties
+	// Tile is the tile representing the entity, or nil if it has none. It is
+	// placed at the top-left corner of the entity.
+	Tile *Tile
+}
+
+// IntGrid is an IntGrid layer of an LDtk level, which has an int value for
+// every cell.
+type IntGrid struct {
+	// Name is the identifier of the layer
+	Name string
+	// Width and Height are the size of the layer in cells
+	Width, Height int
+	// GridSize is the size of a cell in pixels
+	GridSize int
+	// OffSetX and OffSetY are the offset of the layer in the level in pixels
+	OffSetX, OffSetY float32
+	// Values contains the values of the cells, row by row. A value of 0 means
+	// the cell is empty.
+	Values []int
+	names  map[int]string
+}
+
+// Value returns the value of the cell at x, y (in cells), or 0 if it is
+// outside of the layer.
+func (g *IntGrid) Value(x, y int) int {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height || y*g.Width+x >= len(g.Values) {
+		return 0
+	}
+	return g.Values[y*g.Width+x]
+}
+
+// ValueAt returns the value of the cell at the point (in pixels in the level).
+func (g *IntGrid) ValueAt(pt engo.Point) int {
+	if g.GridSize == 0 {
+		return 0
+	}
+	x := int(math.Floor((pt.X - g.OffSetX) / float32(g.GridSize)))
+	y := int(math.Floor((pt.Y - g.OffSetY) / float32(g.GridSize)))
+	return g.Value(x, y)
+}
+
+// ValueName returns the identifier of the value, or "" if it has none.
+func (g *IntGrid) ValueName(value int) string {
+	return g.names[value]
+}
+
+// Is returns whether the cell at x, y (in cells) has the value with the
+// identifier.
+func (g *IntGrid) Is(x, y int, name string) bool {
+	v := g.Value(x, y)
+	return v != 0 && g.names[v] == name
+}
+
+// Bounds returns the area of the entity in the level in pixels.
+func (e *LDtkEntity) Bounds() engo.AABB {
+	min := engo.Point{
+		X: e.Position.X - e.Pivot.X*e.Width,
+		Y: e.Position.Y - e.Pivot.Y*e.Height,
+	}
+	return engo.AABB{Min: min, Max: engo.Point{X: min.X + e.Width, Y: min.Y + e.Height}}
+}
+
+// World returns the world with the identifier, or nil if there is none.
+func (p *LDtkProject) World(identifier string) *LDtkWorld {
+	for _, w := range p.Worlds {
+		if w.Identifier == identifier {
+			return w
+		}
+	}
+	return nil
+}
+
+// Level returns the level with the identifier from any of the worlds, or nil
+// if there is none.
+func (p *LDtkProject) Level(identifier string) *LDtkLevel {
+	for _, w := range p.Worlds {
+		if l := w.Level(identifier); l != nil {
+			return l
+		}
+	}
+	return nil
+}
+
+// LevelByIID returns the level with the instance ID, or nil if there is none.
+func (p *LDtkProject) LevelByIID(iid string) *LDtkLevel {
+	for _, w := range p.Worlds {
+		for _, l := range w.Levels {
+			if l.IID == iid {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// Entity returns the entity with the instance ID from any level, or nil if
+// there is none.
+func (p *LDtkProject) Entity(iid string) *LDtkEntity {
+	for _, w := range p.Worlds {
+		for _, l := range w.Levels {
+			for _, e := range l.Entities {
+				if e.IID == iid {
+					return e
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Level returns the level with the identifier, or nil if there is none.
+func (w *LDtkWorld) Level(identifier string) *LDtkLevel {
+	for _, l := range w.Levels {
+		if l.Identifier == identifier {
+			return l
+		}
+	}
+	return nil
+}
+
+// LevelAt returns the level at the depth containing the point (in pixels in
+// the world), or nil if there is none.
+func (w *LDtkWorld) LevelAt(pt engo.Point, depth int) *LDtkLevel {
+	for _, l := range w.Levels {
+		b := l.Bounds()
+		if l.WorldDepth == depth && pt.X >= b.Min.X && pt.X < b.Max.X && pt.Y >= b.Min.Y && pt.Y < b.Max.Y {
+			return l
+		}
+	}
+	return nil
+}
+
+// Bounds returns the area of the level in its world in pixels.
+func (l *LDtkLevel) Bounds() engo.AABB {
+	return engo.AABB{
+		Min: engo.Point{X: float32(l.WorldX), Y: float32(l.WorldY)},
+		Max: engo.Point{X: float32(l.WorldX + l.Width), Y: float32(l.WorldY + l.Height)},
+	}
+}
+
+// IntGrid returns the IntGrid layer with the name, or nil if there is none.
+func (l *LDtkLevel) IntGrid(name string) *IntGrid {
+	for _, g := range l.IntGrids {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// EntitiesOf returns the entities with the identifier.
+func (l *LDtkLevel) EntitiesOf(identifier string) []*LDtkEntity {
+	var ret []*LDtkEntity
+	for _, e := range l.Entities {
+		if e.Identifier == identifier {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// NeighbourLevels returns the neighbouring levels in the direction, or all
+// of them if dir is empty.
+func (l *LDtkLevel) NeighbourLevels(dir string) []*LDtkLevel {
+	var ret []*LDtkLevel
+	for _, n := range l.Neighbours {
+		if dir != "" && n.Dir != dir {
+			continue
+		}
+		if nl := l.project.LevelByIID(n.IID); nl != nil {
+			ret = append(ret, nl)
+		}
+	}
+	return ret
+}
+
+// Entity returns the LDtk entity the entity field with the given name refers
+// to. It returns false if there is no such field, or the project has no
+// entity with the IID it refers to.
+func (p Properties) Entity(name string, project *LDtkProject) (*LDtkEntity, bool) {
+	prop, ok := p.Get(name)
+	if !ok || prop.Value == "" {
+		return nil, false
+	}
+	e := project.Entity(prop.Value)
+	return e, e != nil
+}
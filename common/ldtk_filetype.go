@@ -0,0 +1,83 @@
+package common
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/EngoEngine/engo"
+)
+
+// LDtkResource contains a project created from an LDtk file
+type LDtkResource struct {
+	// Project holds the reference to the parsed LDtk project
+	Project *LDtkProject
+	url     string
+}
+
+// URL retrieves the url to the .ldtk file
+func (r LDtkResource) URL() string {
+	return r.url
+}
+
+// Category returns engo.CategoryLevel.
+func (r LDtkResource) Category() string {
+	return engo.CategoryLevel
+}
+
+// ldtkLoader is responsible for managing '.ldtk' files within 'engo.Files'.
+// You can generate an LDtk file with the LDtk level editor.
+type ldtkLoader struct {
+	projects map[string]LDtkResource
+	root     string
+}
+
+func (l *ldtkLoader) SetRoot(root string) {
+	l.root = root
+}
+
+// Load will load the ldtk file and the images of its tilesets
+func (l *ldtkLoader) Load(url string, data io.Reader) error {
+	p, err := createProjectFromLDtk(data, url, l.root)
+	if err != nil {
+		return err
+	}
+
+	l.projects[url] = LDtkResource{Project: p, url: url}
+	return nil
+}
+
+// Reload loads the modified ldtk file into the LDtkProject that is already
+// used for it
+func (l *ldtkLoader) Reload(url string, data io.Reader) error {
+	p, err := createProjectFromLDtk(data, url, l.root)
+	if err != nil {
+		return err
+	}
+
+	if res, ok := l.projects[url]; ok {
+		*res.Project = *p
+		return nil
+	}
+	l.projects[url] = LDtkResource{Project: p, url: url}
+	return nil
+}
+
+// Unload removes the preloaded project from the cache
+func (l *ldtkLoader) Unload(url string) error {
+	delete(l.projects, url)
+	return nil
+}
+
+// Resource retrieves and returns the preloaded project of type 'LDtkResource'
+func (l *ldtkLoader) Resource(url string) (engo.Resource, error) {
+	res, ok := l.projects[url]
+	if !ok {
+		return nil, fmt.Errorf("resource not loaded by `FileLoader`: %q", url)
+	}
+
+	return res, nil
+}
+
+func init() {
+	engo.Files.Register(".ldtk", &ldtkLoader{projects: make(map[string]LDtkResource)})
+}
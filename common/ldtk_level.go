@@ -0,0 +1,504 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/EngoEngine/engo"
+	"github.com/Noofbiz/tmx"
+)
+
+// The types of LDtk layers, besides auto-layers
+const (
+	ldtkIntGrid  = "IntGrid"
+	ldtkEntities = "Entities"
+	ldtkTiles    = "Tiles"
+)
+
+type ldtkJSON struct {
+	DefaultGridSize int         `json:"defaultGridSize"`
+	WorldLayout     *string     `json:"worldLayout"`
+	Defs            ldtkDefs    `json:"defs"`
+	Levels          []ldtkLevel `json:"levels"`
+	Worlds          []ldtkWorld `json:"worlds"`
+}
+
+type ldtkDefs struct {
+	Tilesets []ldtkTileset  `json:"tilesets"`
+	Layers   []ldtkLayerDef `json:"layers"`
+}
+
+type ldtkTileset struct {
+	UID          int     `json:"uid"`
+	Identifier   string  `json:"identifier"`
+	RelPath      *string `json:"relPath"`
+	PxWid        int     `json:"pxWid"`
+	PxHei        int     `json:"pxHei"`
+	TileGridSize int     `json:"tileGridSize"`
+	Spacing      int     `json:"spacing"`
+	Padding      int     `json:"padding"`
+}
+
+type ldtkLayerDef struct {
+	UID           int `json:"uid"`
+	IntGridValues []struct {
+		Value      int     `json:"value"`
+		Identifier *string `json:"identifier"`
+	} `json:"intGridValues"`
+}
+
+type ldtkWorld struct {
+	Identifier  string      `json:"identifier"`
+	IID         string      `json:"iid"`
+	WorldLayout *string     `json:"worldLayout"`
+	Levels      []ldtkLevel `json:"levels"`
+}
+
+type ldtkLevel struct {
+	Identifier      string      `json:"identifier"`
+	IID             string      `json:"iid"`
+	WorldX          int         `json:"worldX"`
+	WorldY          int         `json:"worldY"`
+	WorldDepth      int         `json:"worldDepth"`
+	PxWid           int         `json:"pxWid"`
+	PxHei           int         `json:"pxHei"`
+	BgColor         string      `json:"__bgColor"`
+	ExternalRelPath *string     `json:"externalRelPath"`
+	FieldInstances  []ldtkField `json:"fieldInstances"`
+	LayerInstances  []ldtkLayer `json:"layerInstances"`
+	Neighbours      []struct {
+		LevelIID string `json:"levelIid"`
+		Dir      string `json:"dir"`
+	} `json:"__neighbours"`
+}
+
+type ldtkLayer struct {
+	Identifier      string       `json:"__identifier"`
+	Type            string       `json:"__type"`
+	CWid            int          `json:"__cWid"`
+	CHei            int          `json:"__cHei"`
+	GridSize        int          `json:"__gridSize"`
+	Opacity         float32      `json:"__opacity"`
+	PxTotalOffsetX  float32      `json:"__pxTotalOffsetX"`
+	PxTotalOffsetY  float32      `json:"__pxTotalOffsetY"`
+	TilesetDefUID   *int         `json:"__tilesetDefUid"`
+	LayerDefUID     int          `json:"layerDefUid"`
+	Visible         bool         `json:"visible"`
+	IntGridCsv      []int        `json:"intGridCsv"`
+	AutoLayerTiles  []ldtkTile   `json:"autoLayerTiles"`
+	GridTiles       []ldtkTile   `json:"gridTiles"`
+	EntityInstances []ldtkEntity `json:"entityInstances"`
+}
+
+type ldtkTile struct {
+	Px [2]int `json:"px"`
+	F  int    `json:"f"`
+	T  int    `json:"t"`
+}
+
+type ldtkTileRect struct {
+	TilesetUID int `json:"tilesetUid"`
+	X          int `json:"x"`
+	Y          int `json:"y"`
+	W          int `json:"w"`
+	H          int `json:"h"`
+}
+
+type ldtkEntity struct {
+	Identifier     string        `json:"__identifier"`
+	Grid           [2]int        `json:"__grid"`
+	Pivot          [2]float32    `json:"__pivot"`
+	Tags           []string      `json:"__tags"`
+	Tile           *ldtkTileRect `json:"__tile"`
+	IID            string        `json:"iid"`
+	Width          float32       `json:"width"`
+	Height         float32       `json:"height"`
+	Px             [2]float32    `json:"px"`
+	FieldInstances []ldtkField   `json:"fieldInstances"`
+}
+
+type ldtkField struct {
+	Identifier string          `json:"__identifier"`
+	Type       string          `json:"__type"`
+	Value      json.RawMessage `json:"__value"`
+}
+
+// ldtkBuilder holds the tiles of the tilesets while creating the levels of an
+// LDtk project.
+type ldtkBuilder struct {
+	root, url   string
+	tilesets    map[int]ldtkTileset
+	firstGIDs   map[int]uint32
+	resourceMap map[uint32]Texture
+	intGrids    map[int]map[int]string
+	project     *LDtkProject
+}
+
+// createProjectFromLDtk unmarshalls and unpacks LDtk data into an LDtkProject
+func createProjectFromLDtk(r io.Reader, url string, root string) (*LDtkProject, error) {
+	var data ldtkJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+	b := &ldtkBuilder{
+		root:        root,
+		url:         url,
+		tilesets:    make(map[int]ldtkTileset),
+		firstGIDs:   make(map[int]uint32),
+		resourceMap: make(map[uint32]Texture),
+		intGrids:    make(map[int]map[int]string),
+		project:     &LDtkProject{TileWidth: data.DefaultGridSize},
+	}
+	if err := b.loadTilesets(data.Defs.Tilesets); err != nil {
+		return nil, err
+	}
+	for _, def := range data.Defs.Layers {
+		names := make(map[int]string)
+		for _, v := range def.IntGridValues {
+			if v.Identifier != nil {
+				names[v.Value] = *v.Identifier
+			}
+		}
+		b.intGrids[def.UID] = names
+	}
+
+	worlds := data.Worlds
+	if len(worlds) == 0 {
+		worlds = []ldtkWorld{{Identifier: "World", WorldLayout: data.WorldLayout, Levels: data.Levels}}
+	}
+	for _, w := range worlds {
+		world := &LDtkWorld{Identifier: w.Identifier, IID: w.IID}
+		if w.WorldLayout != nil {
+			world.Layout = *w.WorldLayout
+		}
+		for _, l := range w.Levels {
+			level, err := b.level(l)
+			if err != nil {
+				return nil, fmt.Errorf("level %q: %v", l.Identifier, err)
+			}
+			world.Levels = append(world.Levels, level)
+		}
+		b.project.Worlds = append(b.project.Worlds, world)
+	}
+	return b.project, nil
+}
+
+// loadTilesets loads the images of the tilesets, and gives their tiles gids
+// like the ones of TMX levels.
+func (b *ldtkBuilder) loadTilesets(tilesets []ldtkTileset) error {
+	gid := uint32(1)
+	for _, ts := range tilesets {
+		b.tilesets[ts.UID] = ts
+		if ts.RelPath == nil || ts.TileGridSize == 0 {
+			continue
+		}
+		tr, err := loadedTextureResource(path.Join(path.Dir(b.url), *ts.RelPath))
+		if err != nil {
+			return err
+		}
+		b.firstGIDs[ts.UID] = gid
+		step := ts.TileGridSize + ts.Spacing
+		cols := (ts.PxWid - 2*ts.Padding + ts.Spacing) / step
+		rows := (ts.PxHei - 2*ts.Padding + ts.Spacing) / step
+		var regions []SpriteRegion
+		for y := 0; y < rows; y++ {
+			for x := 0; x < cols; x++ {
+				regions = append(regions, SpriteRegion{
+					Position: engo.Point{X: float32(ts.Padding + x*step), Y: float32(ts.Padding + y*step)},
+					Width:    ts.TileGridSize,
+					Height:   ts.TileGridSize,
+				})
+			}
+		}
+		for i, tex := range NewAsymmetricSpritesheetFromTexture(tr, regions).Cells() {
+			b.resourceMap[gid+uint32(i)] = tex
+		}
+		gid += uint32(len(regions))
+	}
+	return nil
+}
+
+func (b *ldtkBuilder) level(l ldtkLevel) (*LDtkLevel, error) {
+	dir := path.Dir(b.url)
+	if l.LayerInstances == nil && l.ExternalRelPath != nil {
+		f, err := ioutil.ReadFile(filepath.Join(b.root, dir, *l.ExternalRelPath))
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(f, &l); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := &LDtkLevel{
+		Identifier: l.Identifier,
+		IID:        l.IID,
+		WorldX:     l.WorldX,
+		WorldY:     l.WorldY,
+		WorldDepth: l.WorldDepth,
+		Width:      l.PxWid,
+		Height:     l.PxHei,
+		project:    b.project,
+	}
+	var err error
+	if ret.BgColor, err = parseTMXColor(l.BgColor); err != nil {
+		return nil, err
+	}
+	if ret.Fields, err = ldtkFields(l.FieldInstances, dir); err != nil {
+		return nil, err
+	}
+	for _, n := range l.Neighbours {
+		ret.Neighbours = append(ret.Neighbours, LDtkNeighbour{IID: n.LevelIID, Dir: n.Dir})
+	}
+
+	grid := b.project.TileWidth
+	if grid == 0 {
+		grid = 16
+	}
+	ret.Level = &Level{
+		Orientation: orth,
+		RenderOrder: "right-down",
+		width:       (l.PxWid + grid - 1) / grid,
+		height:      (l.PxHei + grid - 1) / grid,
+		TileWidth:   grid,
+		TileHeight:  grid,
+		Properties:  ret.Fields,
+		resourceMap: b.resourceMap,
+		pointMap:    make(map[mapPoint]*Tile),
+		framesMap:   make(map[uint32][]uint32),
+	}
+
+	// the layer instances start with the top layer
+	for i := len(l.LayerInstances) - 1; i >= 0; i-- {
+		layer := l.LayerInstances[i]
+		switch layer.Type {
+		case ldtkEntities:
+			for _, e := range layer.EntityInstances {
+				entity, err := b.entity(layer, e)
+				if err != nil {
+					return nil, fmt.Errorf("entity %q: %v", e.IID, err)
+				}
+				ret.Entities = append(ret.Entities, entity)
+			}
+			continue
+		case ldtkIntGrid:
+			ret.IntGrids = append(ret.IntGrids, &IntGrid{
+				Name:     layer.Identifier,
+				Width:    layer.CWid,
+				Height:   layer.CHei,
+				GridSize: layer.GridSize,
+				OffSetX:  layer.PxTotalOffsetX,
+				OffSetY:  layer.PxTotalOffsetY,
+				Values:   layer.IntGridCsv,
+				names:    b.intGrids[layer.LayerDefUID],
+			})
+		}
+		if layer.TilesetDefUID != nil {
+			ret.Level.TileLayers = append(ret.Level.TileLayers, b.tileLayer(ret.Level, layer))
+		}
+	}
+	return ret, nil
+}
+
+// tileLayer unpacks the tiles of a tile layer, auto-layer or IntGrid layer
+// into a TileLayer.
+func (b *ldtkBuilder) tileLayer(level *Level, layer ldtkLayer) *TileLayer {
+	tl := &TileLayer{
+		Name:    layer.Identifier,
+		Width:   layer.CWid,
+		Height:  layer.CHei,
+		Opacity: layer.Opacity,
+		Visible: layer.Visible,
+		OffSetX: layer.PxTotalOffsetX,
+		OffSetY: layer.PxTotalOffsetY,
+		tiles:   make(map[mapPoint]*Tile),
+	}
+	tiles := layer.AutoLayerTiles
+	if layer.Type == ldtkTiles {
+		tiles = layer.GridTiles
+	}
+	firstGID := b.firstGIDs[*layer.TilesetDefUID]
+	grid := layer.GridSize
+	if grid == 0 {
+		grid = level.TileWidth
+	}
+
+	areas := make(map[mapPoint]*TileChunk)
+	for _, t := range tiles {
+		tile := level.tileFromGID(firstGID+uint32(t.T), engo.Point{X: float32(t.Px[0]), Y: float32(t.Px[1])})
+		if t.F&1 != 0 {
+			tile.flip |= tmx.HorizontalFlipFlag
+		}
+		if t.F&2 != 0 {
+			tile.flip |= tmx.VerticalFlipFlag
+		}
+		pt := mapPoint{X: t.Px[0] / grid, Y: t.Px[1] / grid}
+		tl.Tiles = append(tl.Tiles, tile)
+		tl.tiles[pt] = tile
+		level.pointMap[pt] = tile
+		tl.Chunks = addToArea(areas, tl.Chunks, pt, tl.Width, tl.Height, tile)
+	}
+	for _, chunk := range tl.Chunks {
+		chunk.Bounds = level.chunkBounds(chunk.Tiles)
+	}
+	return tl
+}
+
+func (b *ldtkBuilder) entity(layer ldtkLayer, e ldtkEntity) (*LDtkEntity, error) {
+	fields, err := ldtkFields(e.FieldInstances, path.Dir(b.url))
+	if err != nil {
+		return nil, err
+	}
+	ret := &LDtkEntity{
+		Identifier: e.Identifier,
+		IID:        e.IID,
+		Layer:      layer.Identifier,
+		Position: engo.Point{
+			X: e.Px[0] + layer.PxTotalOffsetX,
+			Y: e.Px[1] + layer.PxTotalOffsetY,
+		},
+		Pivot:  engo.Point{X: e.Pivot[0], Y: e.Pivot[1]},
+		Width:  e.Width,
+		Height: e.Height,
+		GridX:  e.Grid[0],
+		GridY:  e.Grid[1],
+		Tags:   e.Tags,
+		Fields: fields,
+	}
+	if e.Tile != nil {
+		ts, ok := b.tilesets[e.Tile.TilesetUID]
+		if !ok || ts.RelPath == nil {
+			return nil, fmt.Errorf("unknown tileset %d", e.Tile.TilesetUID)
+		}
+		tr, err := loadedTextureResource(path.Join(path.Dir(b.url), *ts.RelPath))
+		if err != nil {
+			return nil, err
+		}
+		tex := NewAsymmetricSpritesheetFromTexture(tr, []SpriteRegion{{
+			Position: engo.Point{X: float32(e.Tile.X), Y: float32(e.Tile.Y)},
+			Width:    e.Tile.W,
+			Height:   e.Tile.H,
+		}}).Cell(0)
+		ret.Tile = &Tile{Point: ret.Bounds().Min, Image: &tex}
+	}
+	return ret, nil
+}
+
+// ldtkFields converts the field instances of levels and entities to
+// properties. dir is the directory of the LDtk file.
+func ldtkFields(fields []ldtkField, dir string) (Properties, error) {
+	ret := make(Properties, 0, len(fields))
+	for _, f := range fields {
+		prop, err := ldtkProperty(f.Identifier, f.Type, f.Value, dir)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.Identifier, err)
+		}
+		ret = append(ret, prop)
+	}
+	return ret, nil
+}
+
+func ldtkProperty(name, typ string, value json.RawMessage, dir string) (Property, error) {
+	prop := Property{Name: name, dir: dir}
+	if strings.HasPrefix(typ, "Array<") && strings.HasSuffix(typ, ">") {
+		elem := typ[len("Array<") : len(typ)-1]
+		var values []json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return prop, err
+		}
+		prop.Type = "array"
+		for i, v := range values {
+			member, err := ldtkProperty(strconv.Itoa(i), elem, v, dir)
+			if err != nil {
+				return prop, err
+			}
+			prop.PropertyType = member.Type
+			prop.Members = append(prop.Members, member)
+		}
+		return prop, nil
+	}
+
+	prop.Type = ldtkPropertyType(typ)
+	if prop.Type == "enum" {
+		prop.PropertyType = typ[strings.Index(typ, ".")+1:]
+	}
+	if string(value) == "null" || len(value) == 0 {
+		return prop, nil
+	}
+	var err error
+	switch typ {
+	case "Int", "Float", "Bool":
+		prop.Value = string(value)
+	case "Point":
+		var p struct {
+			Cx int `json:"cx"`
+			Cy int `json:"cy"`
+		}
+		err = json.Unmarshal(value, &p)
+		prop.Value = strconv.Itoa(p.Cx) + "," + strconv.Itoa(p.Cy)
+	case "EntityRef":
+		var ref struct {
+			EntityIID string `json:"entityIid"`
+		}
+		err = json.Unmarshal(value, &ref)
+		prop.Value = ref.EntityIID
+	case "Tile":
+		var rect ldtkTileRect
+		err = json.Unmarshal(value, &rect)
+		prop.PropertyType = strconv.Itoa(rect.TilesetUID)
+		prop.Value = fmt.Sprintf("%d,%d,%d,%d", rect.X, rect.Y, rect.W, rect.H)
+	default:
+		err = json.Unmarshal(value, &prop.Value)
+	}
+	return prop, err
+}
+
+// ldtkPropertyType returns the type of the property for an LDtk field type.
+func ldtkPropertyType(typ string) string {
+	switch {
+	case typ == "Int", typ == "Float", typ == "Bool":
+		return strings.ToLower(typ)
+	case typ == "Color":
+		return "color"
+	case typ == "FilePath":
+		return "file"
+	case typ == "Point":
+		return "point"
+	case typ == "EntityRef":
+		return "entity"
+	case typ == "Tile":
+		return "tile"
+	case strings.HasPrefix(typ, "LocalEnum."), strings.HasPrefix(typ, "ExternEnum."):
+		return "enum"
+	}
+	return "string"
+}
+
+// loadedTextureResource returns the texture of the image with the url, which
+// is loaded if it is not yet.
+func loadedTextureResource(url string) (*TextureResource, error) {
+	res, err := engo.Files.Resource(url)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), "resource not loaded") {
+			return nil, err
+		}
+		if err = engo.Files.Load(url); err != nil {
+			return nil, err
+		}
+		if res, err = engo.Files.Resource(url); err != nil {
+			return nil, err
+		}
+	}
+	tr, ok := res.(TextureResource)
+	if !ok {
+		return nil, errors.New("resource is not a texture: " + url)
+	}
+	return &tr, nil
+}
@@ -0,0 +1,210 @@
+package common
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/Noofbiz/tmx"
+)
+
+var testLDtk = `{
+ "jsonVersion": "1.5.3",
+ "defaultGridSize": 16,
+ "worldLayout": null,
+ "defs": {
+  "tilesets": [
+   {"uid": 1, "identifier": "Test", "relPath": "test.png", "pxWid": 457, "pxHei": 305, "tileGridSize": 16, "spacing": 1, "padding": 0},
+   {"uid": 2, "identifier": "Internal_Icons", "relPath": null, "pxWid": 512, "pxHei": 512, "tileGridSize": 16, "spacing": 0, "padding": 0}
+  ],
+  "layers": [
+   {"uid": 10, "identifier": "Collisions", "intGridValues": [{"value": 1, "identifier": "wall"}, {"value": 2, "identifier": "water"}]}
+  ]
+ },
+ "levels": [],
+ "worlds": [
+  {"identifier": "Overworld", "iid": "w0", "worldLayout": "GridVania", "levels": [
+   {
+    "identifier": "Level_0", "iid": "l0", "worldX": 0, "worldY": 0, "worldDepth": 0, "pxWid": 64, "pxHei": 32,
+    "__bgColor": "#40465B",
+    "fieldInstances": [{"__identifier": "difficulty", "__type": "Int", "__value": 3}],
+    "__neighbours": [{"levelIid": "l1", "dir": "e"}],
+    "layerInstances": [
+     {
+      "__identifier": "Entities", "__type": "Entities", "__cWid": 4, "__cHei": 2, "__gridSize": 16, "__opacity": 1,
+      "__pxTotalOffsetX": 0, "__pxTotalOffsetY": 0, "__tilesetDefUid": null, "layerDefUid": 11, "visible": true,
+      "intGridCsv": [], "autoLayerTiles": [], "gridTiles": [],
+      "entityInstances": [
+       {
+        "__identifier": "Player", "__grid": [1, 1], "__pivot": [0.5, 1], "__tags": ["actor"],
+        "__tile": {"tilesetUid": 1, "x": 17, "y": 0, "w": 16, "h": 16},
+        "iid": "e1", "width": 16, "height": 16, "px": [24, 32],
+        "fieldInstances": [
+         {"__identifier": "hp", "__type": "Int", "__value": 10},
+         {"__identifier": "speed", "__type": "Float", "__value": 1.5},
+         {"__identifier": "alive", "__type": "Bool", "__value": true},
+         {"__identifier": "name", "__type": "String", "__value": "Hero"},
+         {"__identifier": "tint", "__type": "Color", "__value": "#FF0000"},
+         {"__identifier": "sprite", "__type": "FilePath", "__value": "gfx/hero.png"},
+         {"__identifier": "target", "__type": "EntityRef", "__value": {"entityIid": "e2", "layerIid": "x", "levelIid": "l0", "worldIid": "w0"}},
+         {"__identifier": "spawn", "__type": "Point", "__value": {"cx": 2, "cy": 1}},
+         {"__identifier": "kind", "__type": "LocalEnum.Kind", "__value": "Warrior"},
+         {"__identifier": "path", "__type": "Array<Point>", "__value": [{"cx": 0, "cy": 0}, {"cx": 1, "cy": 0}]},
+         {"__identifier": "missing", "__type": "Int", "__value": null}
+        ]
+       },
+       {
+        "__identifier": "Chest", "__grid": [3, 0], "__pivot": [0, 0], "__tags": [], "__tile": null,
+        "iid": "e2", "width": 16, "height": 16, "px": [48, 0], "fieldInstances": []
+       }
+      ]
+     },
+     {
+      "__identifier": "Decor", "__type": "Tiles", "__cWid": 4, "__cHei": 2, "__gridSize": 16, "__opacity": 0.5,
+      "__pxTotalOffsetX": 0, "__pxTotalOffsetY": 0, "__tilesetDefUid": 1, "layerDefUid": 12, "visible": true,
+      "intGridCsv": [], "autoLayerTiles": [], "entityInstances": [],
+      "gridTiles": [{"px": [16, 0], "src": [17, 0], "f": 1, "t": 1, "d": [1], "a": 1}]
+     },
+     {
+      "__identifier": "Collisions", "__type": "IntGrid", "__cWid": 4, "__cHei": 2, "__gridSize": 16, "__opacity": 1,
+      "__pxTotalOffsetX": 0, "__pxTotalOffsetY": 0, "__tilesetDefUid": 1, "layerDefUid": 10, "visible": true,
+      "intGridCsv": [1, 1, 1, 1, 0, 0, 2, 0], "gridTiles": [], "entityInstances": [],
+      "autoLayerTiles": [{"px": [0, 0], "src": [0, 0], "f": 0, "t": 0}, {"px": [16, 16], "src": [17, 17], "f": 0, "t": 27}]
+     }
+    ]
+   },
+   {
+    "identifier": "Level_1", "iid": "l1", "worldX": 64, "worldY": 0, "worldDepth": 0, "pxWid": 64, "pxHei": 32,
+    "__bgColor": "#40465B", "fieldInstances": [], "__neighbours": [{"levelIid": "l0", "dir": "w"}], "layerInstances": []
+   }
+  ]},
+  {"identifier": "Dungeon", "iid": "w1", "worldLayout": "Free", "levels": [
+   {
+    "identifier": "Cellar", "iid": "l2", "worldX": 0, "worldY": 0, "worldDepth": 1, "pxWid": 32, "pxHei": 32,
+    "__bgColor": "#000000", "fieldInstances": [], "__neighbours": [], "layerInstances": []
+   }
+  ]}
+ ]
+}`
+
+func TestLDtkProject(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	loadEditTestPNG(t)
+	if err := engo.Files.LoadReaderData("test.ldtk", bytes.NewBufferString(testLDtk)); err != nil {
+		t.Fatalf("Unable to load the LDtk file. Error was: %v", err)
+	}
+	res, err := engo.Files.Resource("test.ldtk")
+	if err != nil {
+		t.Fatalf("Unable to get the LDtk resource. Error was: %v", err)
+	}
+	project := res.(LDtkResource).Project
+
+	if len(project.Worlds) != 2 || project.Worlds[0].Layout != "GridVania" {
+		t.Fatalf("Project had %d worlds, expected the 2 worlds", len(project.Worlds))
+	}
+	if l := project.Level("Cellar"); l == nil || project.World("Dungeon").Level("Cellar") != l || l.WorldDepth != 1 {
+		t.Error("Level of the second world was not returned")
+	}
+	l := project.Level("Level_0")
+	if l == nil {
+		t.Fatal("Level was not returned by its identifier")
+	}
+	if v, ok := l.Fields.Int("difficulty"); !ok || v != 3 {
+		t.Errorf("Level field was %v, expected 3", v)
+	}
+	if l.BgColor != (color.NRGBA{R: 0x40, G: 0x46, B: 0x5B, A: 255}) {
+		t.Errorf("Background color was %v", l.BgColor)
+	}
+
+	// neighbours
+	if n := l.NeighbourLevels("e"); len(n) != 1 || n[0] != project.Level("Level_1") {
+		t.Error("Neighbour to the east was not returned")
+	}
+	if n := l.NeighbourLevels("w"); len(n) != 0 {
+		t.Error("Neighbour was returned for a direction without neighbours")
+	}
+	if project.Worlds[0].LevelAt(engo.Point{X: 70, Y: 10}, 0) != project.Level("Level_1") {
+		t.Error("Level was not returned by its position in the world")
+	}
+
+	// tiles
+	layers := l.Level.TileLayers
+	if len(layers) != 2 || layers[0].Name != "Collisions" || layers[1].Name != "Decor" {
+		t.Fatal("Tile layers were not unpacked from the bottom to the top layer")
+	}
+	if len(layers[0].Tiles) != 2 || layers[0].GID(1, 1) != 28 || len(layers[0].Chunks) != 1 {
+		t.Error("Auto tiles of the IntGrid layer were not unpacked")
+	}
+	tile := layers[1].Tiles[0]
+	if tile.GID() != 2 || tile.flip != tmx.HorizontalFlipFlag || tile.Point != (engo.Point{X: 16, Y: 0}) || tile.Width() != 16 {
+		t.Errorf("Tile was not unpacked correctly: gid %d at %v", tile.GID(), tile.Point)
+	}
+	if layers[1].Opacity != 0.5 {
+		t.Errorf("Layer opacity was %v, expected 0.5", layers[1].Opacity)
+	}
+	if l.Level.GetTile(engo.Point{X: 20, Y: 4}) != tile {
+		t.Error("Tile was not returned by GetTile")
+	}
+
+	// IntGrid
+	g := l.IntGrid("Collisions")
+	if g == nil {
+		t.Fatal("IntGrid layer was not returned by its name")
+	}
+	if g.Value(2, 1) != 2 || !g.Is(2, 1, "water") || g.ValueAt(engo.Point{X: 5, Y: 5}) != 1 || g.Value(9, 9) != 0 {
+		t.Error("IntGrid values were not returned correctly")
+	}
+	if g.ValueName(1) != "wall" || g.Is(0, 1, "wall") {
+		t.Error("IntGrid value identifiers were not returned correctly")
+	}
+
+	// entities
+	if len(l.Entities) != 2 || len(l.EntitiesOf("Player")) != 1 {
+		t.Fatalf("Level had %d entities, expected 2", len(l.Entities))
+	}
+	player := l.EntitiesOf("Player")[0]
+	exp := engo.AABB{Min: engo.Point{X: 16, Y: 16}, Max: engo.Point{X: 32, Y: 32}}
+	if player.Bounds() != exp || player.GridX != 1 || player.Layer != "Entities" || len(player.Tags) != 1 {
+		t.Errorf("Entity bounds were %v, expected %v", player.Bounds(), exp)
+	}
+	if player.Tile == nil || player.Tile.Point != exp.Min || player.Tile.Width() != 16 {
+		t.Error("Tile of the entity was not created")
+	}
+	f := player.Fields
+	if v, ok := f.Int("hp"); !ok || v != 10 {
+		t.Errorf("Int field was %v", v)
+	}
+	if v, ok := f.Float("speed"); !ok || v != 1.5 {
+		t.Errorf("Float field was %v", v)
+	}
+	if v, ok := f.Bool("alive"); !ok || !v {
+		t.Errorf("Bool field was %v", v)
+	}
+	if v, ok := f.Value("name"); !ok || v != "Hero" {
+		t.Errorf("String field was %q", v)
+	}
+	if v, ok := f.Color("tint"); !ok || v != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("Color field was %v", v)
+	}
+	if v, ok := f.File("sprite"); !ok || v != "gfx/hero.png" {
+		t.Errorf("FilePath field was %q", v)
+	}
+	if e, ok := f.Entity("target", project); !ok || e != l.EntitiesOf("Chest")[0] {
+		t.Error("EntityRef field did not return the referenced entity")
+	}
+	if v, ok := f.Point("spawn"); !ok || v != (engo.Point{X: 2, Y: 1}) {
+		t.Errorf("Point field was %v", v)
+	}
+	if p, _ := f.Get("kind"); p.Type != "enum" || p.PropertyType != "Kind" || p.Value != "Warrior" {
+		t.Errorf("Enum field was %+v", p)
+	}
+	if members, ok := f.Class("path"); !ok || len(members) != 2 {
+		t.Error("Array field did not have the elements as members")
+	} else if v, ok := members.Point("1"); !ok || v != (engo.Point{X: 1}) {
+		t.Errorf("Element of the array field was %v", v)
+	}
+	if _, ok := f.Int("missing"); ok {
+		t.Error("Field without a value returned an int")
+	}
+}
@@ -7,8 +7,10 @@ import (
 )
 
 const (
-	orth = "orthogonal"
-	iso  = "isometric"
+	orth      = "orthogonal"
+	iso       = "isometric"
+	hex       = "hexagonal"
+	staggered = "staggered"
 )
 
 // Level is a parsed TMX level containing all layers and default Tiled attributes
@@ -17,8 +19,17 @@ type Level struct {
 	Orientation string
 	// RenderOrder is the in Tiled specified TileMap render order, like right-down, right-up, etc.
 	RenderOrder string
-	width       int
-	height      int
+	// StaggerAxis is the axis, "x" or "y", which is staggered in hexagonal and staggered levels
+	StaggerAxis string
+	// StaggerIndex is whether the "even" or "odd" indexes along the StaggerAxis are shifted
+	StaggerIndex string
+	// HexSideLength is the length in pixels of the tile's edge along the StaggerAxis of hexagonal levels
+	HexSideLength int
+	// Infinite is whether the tile layers of the level are stored in chunks, which can be placed anywhere
+	Infinite bool
+	x, y     int
+	width    int
+	height   int
 	// TileWidth defines the width of each tile in the level
 	TileWidth int
 	// TileHeight defines the height of each tile in the level
@@ -32,16 +43,29 @@ type Level struct {
 	// ObjectLayers contains all ObjectLayer of the level
 	ObjectLayers []*ObjectLayer
 	// Properties are custom properties of the level
-	Properties  []Property
-	resourceMap map[uint32]Texture
-	pointMap    map[mapPoint]*Tile
-	framesMap   map[uint32][]uint32
+	Properties Properties
+	// WangSets are the Wang sets and terrains of the tilesets, which are used
+	// for autotiling by SetTile
+	WangSets       []*WangSet
+	source         []byte
+	resourceMap    map[uint32]Texture
+	tileProperties map[uint32]Properties
+	tileClasses    map[uint32]string
+	pointMap       map[mapPoint]*Tile
+	framesMap      map[uint32][]uint32
 }
 
 // Property is any custom property. The Type corresponds to the type (int,
 // float, etc) stored in the Value as a string
 type Property struct {
 	Name, Type, Value string
+	// PropertyType is the name of the custom type of class and enum properties
+	PropertyType string
+	// Members are the members of a class property which are set in the map.
+	// Members with their default value are only stored in the Tiled project.
+	Members Properties
+	// dir is the directory of the TMX file, which files are relative to
+	dir string
 }
 
 // TileLayer contains a list of its tiles plus all default Tiled attributes
@@ -54,6 +78,9 @@ type TileLayer struct {
 	Height int
 	// Tiles contains the list of tiles
 	Tiles []*Tile
+	// Chunks contains the tiles grouped by area. These are the chunks of
+	// infinite levels, or areas of levelChunkSize by levelChunkSize tiles.
+	Chunks []*TileChunk
 	// Opacity is the opacity of the layer from [0,1]
 	Opacity float32
 	// Visible is if the layer is visible
@@ -67,7 +94,24 @@ type TileLayer struct {
 	// YOffset is the y-offset of the tile layer
 	OffSetY float32
 	// Properties are the custom properties of the layer
-	Properties []Property
+	Properties Properties
+	tiles      map[mapPoint]*Tile
+}
+
+// TileChunk is a rectangular area of the tiles of a TileLayer
+type TileChunk struct {
+	// X is the x coordinate of the chunk in tiles
+	X int
+	// Y is the y coordinate of the chunk in tiles
+	Y int
+	// Width is the width of the chunk in tiles
+	Width int
+	// Height is the height of the chunk in tiles
+	Height int
+	// Tiles contains the list of tiles in the chunk
+	Tiles []*Tile
+	// Bounds is the area covered by the tiles (in space / render coordinates)
+	Bounds engo.AABB
 }
 
 // ImageLayer contains a list of its images plus all default Tiled attributes
@@ -87,7 +131,7 @@ type ImageLayer struct {
 	// YOffset is the y-offset of the layer
 	OffSetY float32
 	// Properties are the custom properties of the layer
-	Properties []Property
+	Properties Properties
 }
 
 // ObjectLayer contains a list of its standard objects as well as a list of all its polyline objects
@@ -105,7 +149,7 @@ type ObjectLayer struct {
 	// Visible is if the layer is visible
 	Visible bool
 	// Properties are the custom properties of the layer
-	Properties []Property
+	Properties Properties
 	// Objects contains the list of (regular) Object objects
 	Objects []*Object
 	// DrawOrder is whether the objects are drawn according to the order of
@@ -122,6 +166,8 @@ type Object struct {
 	Name string
 	// Type contains the string type which was given in Tiled
 	Type string
+	// Class is the class of the object, which Tiled 1.9 and later use instead of the Type
+	Class string
 	// X holds the X float64 coordinate of the object in the map
 	X float32
 	// X holds the X float64 coordinate of the object in the map
@@ -130,8 +176,10 @@ type Object struct {
 	Width float32
 	// Height is the height of the object in pixels
 	Height float32
+	// Rotation is the rotation of the object in degrees, clockwise around its position
+	Rotation float32
 	// Properties are the custom properties of the object
-	Properties []Property
+	Properties Properties
 	// Tiles are the tiles, if any, associated with the object
 	Tiles []*Tile
 	// Lines are the lines, if any, associated with the object
@@ -173,28 +221,53 @@ type TMXText struct {
 	CharData   string
 }
 
-// Bounds returns the level boundaries as an engo.AABB object
+// Bounds returns the level boundaries as an engo.AABB object. For infinite
+// levels these are the boundaries of all chunks.
 func (l *Level) Bounds() engo.AABB {
+	x, y := float32(l.x), float32(l.y)
 	switch l.Orientation {
 	case orth:
 		return engo.AABB{
-			Min: l.screenPoint(engo.Point{X: 0, Y: 0}),
-			Max: l.screenPoint(engo.Point{X: float32(l.width), Y: float32(l.height)}),
+			Min: l.screenPoint(engo.Point{X: x, Y: y}),
+			Max: l.screenPoint(engo.Point{X: x + float32(l.width), Y: y + float32(l.height)}),
 		}
 	case iso:
-		xMin := l.screenPoint(engo.Point{X: 0, Y: float32(l.height)}).X + float32(l.TileWidth)/2
-		xMax := l.screenPoint(engo.Point{X: float32(l.width), Y: 0}).X + float32(l.TileWidth)/2
-		yMin := l.screenPoint(engo.Point{X: 0, Y: 0}).Y
-		yMax := l.screenPoint(engo.Point{X: float32(l.width), Y: float32(l.height)}).Y + float32(l.TileHeight)/2
+		xMin := l.screenPoint(engo.Point{X: x, Y: y + float32(l.height)}).X + float32(l.TileWidth)/2
+		xMax := l.screenPoint(engo.Point{X: x + float32(l.width), Y: y}).X + float32(l.TileWidth)/2
+		yMin := l.screenPoint(engo.Point{X: x, Y: y}).Y
+		yMax := l.screenPoint(engo.Point{X: x + float32(l.width), Y: y + float32(l.height)}).Y + float32(l.TileHeight)/2
 		return engo.AABB{
 			Min: engo.Point{X: xMin, Y: yMin},
 			Max: engo.Point{X: xMax, Y: yMax},
 		}
+	case hex, staggered:
+		// both parities of the staggered axis are in the level once it is more
+		// than a tile wide, so the origin only moves the bounds
+		p := l.staggerParams()
+		var min, max engo.Point
+		if p.staggerX {
+			min = engo.Point{X: x * p.columnWidth, Y: y * (p.tileHeight + p.sideLengthY)}
+			max.X = float32(l.width)*p.columnWidth + p.sideOffsetX
+			max.Y = float32(l.height) * (p.tileHeight + p.sideLengthY)
+			if l.width > 1 {
+				max.Y += p.rowHeight
+			}
+		} else {
+			min = engo.Point{X: x * (p.tileWidth + p.sideLengthX), Y: y * p.rowHeight}
+			max.X = float32(l.width) * (p.tileWidth + p.sideLengthX)
+			max.Y = float32(l.height)*p.rowHeight + p.sideOffsetY
+			if l.height > 1 {
+				max.X += p.columnWidth
+			}
+		}
+		max.Add(min)
+		return engo.AABB{Min: min, Max: max}
 	}
 	return engo.AABB{}
 }
 
-// mapPoint returns the map point of the passed in screen point
+// mapPoint returns the map point of the passed in screen point. For hexagonal
+// and staggered levels this is the map point of the tile containing it.
 func (l *Level) mapPoint(screenPt engo.Point) engo.Point {
 	switch l.Orientation {
 	case orth:
@@ -205,6 +278,10 @@ func (l *Level) mapPoint(screenPt engo.Point) engo.Point {
 			X: (screenPt.X / float32(l.TileWidth)) + (screenPt.Y / float32(l.TileHeight)),
 			Y: (screenPt.Y / float32(l.TileHeight)) - (screenPt.X / float32(l.TileWidth)),
 		}
+	case hex:
+		return l.staggerParams().hexMapPoint(screenPt)
+	case staggered:
+		return l.staggerParams().staggeredMapPoint(screenPt)
 	}
 	return engo.Point{X: 0, Y: 0}
 }
@@ -220,19 +297,197 @@ func (l *Level) screenPoint(mapPt engo.Point) engo.Point {
 			X: (mapPt.X - mapPt.Y) * float32(l.TileWidth) / 2,
 			Y: (mapPt.X + mapPt.Y) * float32(l.TileHeight) / 2,
 		}
+	case hex, staggered:
+		p := l.staggerParams()
+		if p.staggerX {
+			pt := engo.Point{X: mapPt.X * p.columnWidth, Y: mapPt.Y * (p.tileHeight + p.sideLengthY)}
+			if p.shifted(int(math.Floor(mapPt.X))) {
+				pt.Y += p.rowHeight
+			}
+			return pt
+		}
+		pt := engo.Point{X: mapPt.X * (p.tileWidth + p.sideLengthX), Y: mapPt.Y * p.rowHeight}
+		if p.shifted(int(math.Floor(mapPt.Y))) {
+			pt.X += p.columnWidth
+		}
+		return pt
 	}
 	return engo.Point{X: 0, Y: 0}
 }
 
+// staggerParams holds the sizes used to lay out the tiles of hexagonal and
+// staggered levels. A staggered level is laid out as a hexagonal one without
+// sides.
+type staggerParams struct {
+	staggerX, staggerEven    bool
+	tileWidth, tileHeight    float32
+	sideLengthX, sideLengthY float32
+	sideOffsetX, sideOffsetY float32
+	columnWidth, rowHeight   float32
+}
+
+func (l *Level) staggerParams() staggerParams {
+	p := staggerParams{
+		staggerX:    l.StaggerAxis == "x",
+		staggerEven: l.StaggerIndex == "even",
+		tileWidth:   float32(l.TileWidth),
+		tileHeight:  float32(l.TileHeight),
+	}
+	if l.Orientation == hex {
+		if p.staggerX {
+			p.sideLengthX = float32(l.HexSideLength)
+		} else {
+			p.sideLengthY = float32(l.HexSideLength)
+		}
+	}
+	p.sideOffsetX = (p.tileWidth - p.sideLengthX) / 2
+	p.sideOffsetY = (p.tileHeight - p.sideLengthY) / 2
+	p.columnWidth = p.sideOffsetX + p.sideLengthX
+	p.rowHeight = p.sideOffsetY + p.sideLengthY
+	return p
+}
+
+// shifted returns whether the column or row i along the stagger axis is shifted
+func (p staggerParams) shifted(i int) bool {
+	return (i&1 == 1) != p.staggerEven
+}
+
+// hexMapPoint returns the map point of the hexagonal tile containing the screen
+// point, which is the one with the nearest center.
+func (p staggerParams) hexMapPoint(screenPt engo.Point) engo.Point {
+	if p.staggerX {
+		if p.staggerEven {
+			screenPt.X -= p.tileWidth
+		} else {
+			screenPt.X -= p.sideOffsetX
+		}
+	} else {
+		if p.staggerEven {
+			screenPt.Y -= p.tileHeight
+		} else {
+			screenPt.Y -= p.sideOffsetY
+		}
+	}
+
+	// start with the grid-aligned tile, which covers two columns or rows
+	ref := engo.Point{
+		X: math.Floor(screenPt.X / (p.columnWidth * 2)),
+		Y: math.Floor(screenPt.Y / (p.rowHeight * 2)),
+	}
+	rel := engo.Point{
+		X: screenPt.X - ref.X*p.columnWidth*2,
+		Y: screenPt.Y - ref.Y*p.rowHeight*2,
+	}
+
+	var centers, offsets [4]engo.Point
+	if p.staggerX {
+		ref.X *= 2
+		if p.staggerEven {
+			ref.X++
+		}
+		left := p.sideLengthX / 2
+		centerX := left + p.columnWidth
+		centerY := p.tileHeight / 2
+		centers = [4]engo.Point{
+			{X: left, Y: centerY},
+			{X: centerX, Y: centerY - p.rowHeight},
+			{X: centerX, Y: centerY + p.rowHeight},
+			{X: centerX + p.columnWidth, Y: centerY},
+		}
+		offsets = [4]engo.Point{{X: 0, Y: 0}, {X: 1, Y: -1}, {X: 1, Y: 0}, {X: 2, Y: 0}}
+	} else {
+		ref.Y *= 2
+		if p.staggerEven {
+			ref.Y++
+		}
+		top := p.sideLengthY / 2
+		centerX := p.tileWidth / 2
+		centerY := top + p.rowHeight
+		centers = [4]engo.Point{
+			{X: centerX, Y: top},
+			{X: centerX - p.columnWidth, Y: centerY},
+			{X: centerX + p.columnWidth, Y: centerY},
+			{X: centerX, Y: centerY + p.rowHeight},
+		}
+		offsets = [4]engo.Point{{X: 0, Y: 0}, {X: -1, Y: 1}, {X: 0, Y: 1}, {X: 0, Y: 2}}
+	}
+
+	nearest := 0
+	minDist := rel.PointDistanceSquared(centers[0])
+	for i := 1; i < len(centers); i++ {
+		if d := rel.PointDistanceSquared(centers[i]); d < minDist {
+			nearest, minDist = i, d
+		}
+	}
+	ref.Add(offsets[nearest])
+	return ref
+}
+
+// staggeredMapPoint returns the map point of the staggered tile containing the
+// screen point.
+func (p staggerParams) staggeredMapPoint(screenPt engo.Point) engo.Point {
+	if p.staggerEven {
+		if p.staggerX {
+			screenPt.X -= p.sideOffsetX
+		} else {
+			screenPt.Y -= p.sideOffsetY
+		}
+	}
+
+	// start with the grid-aligned tile, whose diamond touches the sides of its square
+	x := int(math.Floor(screenPt.X / p.tileWidth))
+	y := int(math.Floor(screenPt.Y / p.tileHeight))
+	relX := screenPt.X - float32(x)*p.tileWidth
+	relY := screenPt.Y - float32(y)*p.tileHeight
+	if p.staggerX {
+		x *= 2
+		if p.staggerEven {
+			x++
+		}
+	} else {
+		y *= 2
+		if p.staggerEven {
+			y++
+		}
+	}
+
+	// the corners of the square belong to the neighbouring tiles
+	yPos := relX * p.tileHeight / p.tileWidth
+	switch {
+	case p.sideOffsetY-yPos > relY:
+		x, y = p.diagonal(x, y, -1, -1)
+	case yPos-p.sideOffsetY > relY:
+		x, y = p.diagonal(x, y, 1, -1)
+	case p.sideOffsetY+yPos < relY:
+		x, y = p.diagonal(x, y, -1, 1)
+	case p.sideOffsetY*3-yPos < relY:
+		x, y = p.diagonal(x, y, 1, 1)
+	}
+	return engo.Point{X: float32(x), Y: float32(y)}
+}
+
+// diagonal returns the staggered tile next to x, y in the direction dx, dy,
+// each either -1 or 1.
+func (p staggerParams) diagonal(x, y, dx, dy int) (int, int) {
+	if p.staggerX {
+		if p.shifted(x) {
+			return x + dx, y + (dy+1)/2
+		}
+		return x + dx, y + (dy-1)/2
+	}
+	if p.shifted(y) {
+		return x + (dx+1)/2, y + dy
+	}
+	return x + (dx-1)/2, y + dy
+}
+
 type mapPoint struct {
 	X, Y int
 }
 
 // GetTile returns a *Tile at the given point (in space / render coordinates).
 func (l *Level) GetTile(pt engo.Point) *Tile {
-	mp := l.mapPoint(pt)
-	x := int(math.Floor(mp.X))
-	y := int(math.Floor(mp.Y))
+	x, y := l.TileCoordinates(pt)
 	t, ok := l.pointMap[mapPoint{X: x, Y: y}]
 	if !ok {
 		return nil
@@ -240,6 +495,121 @@ func (l *Level) GetTile(pt engo.Point) *Tile {
 	return t
 }
 
+// TileCenter returns the center of the tile at x, y (in tiles), in space /
+// render coordinates.
+func (l *Level) TileCenter(x, y int) engo.Point {
+	switch l.Orientation {
+	case hex, staggered:
+		pt := l.screenPoint(engo.Point{X: float32(x), Y: float32(y)})
+		pt.Add(engo.Point{X: float32(l.TileWidth) / 2, Y: float32(l.TileHeight) / 2})
+		return pt
+	}
+	return l.screenPoint(engo.Point{X: float32(x) + 0.5, Y: float32(y) + 0.5})
+}
+
+// TileCoordinates returns the position in tiles of the tile containing the
+// point (in space / render coordinates).
+func (l *Level) TileCoordinates(pt engo.Point) (int, int) {
+	mp := l.mapPoint(pt)
+	return int(math.Floor(mp.X)), int(math.Floor(mp.Y))
+}
+
+// Origin returns the position in tiles of the top-left tile of the level. It
+// is only not 0, 0 for infinite levels.
+func (l *Level) Origin() (int, int) {
+	return l.x, l.y
+}
+
+// ObjectLines returns the outlines of the objects of the level's object layers
+// in space / render coordinates, which are the lines of polygons and
+// polylines, and the rectangles of the objects which are neither tiles,
+// ellipses nor text. If names are given, only the object layers with one of
+// the names are used.
+func (l *Level) ObjectLines(names ...string) []engo.Line {
+	var ret []engo.Line
+	for _, layer := range l.ObjectLayers {
+		if len(names) > 0 && !containsName(names, layer.Name) {
+			continue
+		}
+		for _, o := range layer.Objects {
+			if len(o.Lines) > 0 {
+				for _, tl := range o.Lines {
+					var pts []engo.Point
+					for _, line := range tl.Lines {
+						if len(pts) == 0 {
+							pts = append(pts, line.P1)
+						}
+						pts = append(pts, line.P2)
+					}
+					ret = append(ret, l.outlineLines(o, pts, tl.Type == "Polygon")...)
+				}
+				continue
+			}
+			if len(o.Ellipses) > 0 || len(o.Text) > 0 || isTileObject(o) || o.Width == 0 || o.Height == 0 {
+				continue
+			}
+			pts := []engo.Point{
+				{X: o.X, Y: o.Y},
+				{X: o.X + o.Width, Y: o.Y},
+				{X: o.X + o.Width, Y: o.Y + o.Height},
+				{X: o.X, Y: o.Y + o.Height},
+			}
+			ret = append(ret, l.outlineLines(o, pts, true)...)
+		}
+	}
+	return ret
+}
+
+// outlineLines rotates the points of the object around its position, converts
+// them to space / render coordinates, and returns the lines between them.
+func (l *Level) outlineLines(o *Object, pts []engo.Point, closed bool) []engo.Line {
+	sin, cos := math.Sincos(o.Rotation * math.Pi / 180)
+	for i, p := range pts {
+		dx, dy := p.X-o.X, p.Y-o.Y
+		pts[i] = l.objectPoint(engo.Point{X: o.X + dx*cos - dy*sin, Y: o.Y + dx*sin + dy*cos})
+	}
+	var ret []engo.Line
+	for i := 1; i < len(pts); i++ {
+		ret = append(ret, engo.Line{P1: pts[i-1], P2: pts[i]})
+	}
+	if closed && len(pts) > 2 {
+		ret = append(ret, engo.Line{P1: pts[len(pts)-1], P2: pts[0]})
+	}
+	return ret
+}
+
+// objectPoint converts the position of an object to space / render
+// coordinates. Objects of isometric levels are placed in pixels along the axes
+// of the tiles, using the height of the tiles for both axes.
+func (l *Level) objectPoint(pt engo.Point) engo.Point {
+	if l.Orientation != iso {
+		return pt
+	}
+	x, y := pt.X/float32(l.TileHeight), pt.Y/float32(l.TileHeight)
+	return engo.Point{
+		X: (x - y) * float32(l.TileWidth) / 2,
+		Y: (x + y) * float32(l.TileHeight) / 2,
+	}
+}
+
+func isTileObject(o *Object) bool {
+	for _, t := range o.Tiles {
+		if t != nil && t.GID() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Width returns the integer width of the level
 func (l *Level) Width() int {
 	return l.width
@@ -281,4 +651,7 @@ type Tile struct {
 	Image     *Texture
 	Drawables []Drawable
 	Animation *Animation
+	// Properties are the custom properties of the tile in its tileset
+	Properties Properties
+	gid, flip  uint32
 }
@@ -0,0 +1,333 @@
+package common
+
+import (
+	"image/color"
+	"log"
+	"sort"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+	"github.com/EngoEngine/gl"
+)
+
+const (
+	// LightingSystemPriority is the priority of the LightingSystem. It renders the light map before the RenderSystem
+	// draws it.
+	LightingSystemPriority = -990
+
+	// visibilityEpsilon is the angle in radians the rays are cast at next to the end of a line, to see past it.
+	visibilityEpsilon = 0.0001
+)
+
+// LightComponent makes an entity a light source at the center of its SpaceComponent. Lights are point lights,
+// unless their Angle makes them spot lights.
+type LightComponent struct {
+	// Color is the color of the light, white if it's nil. Its alpha scales the intensity of the light.
+	Color color.Color
+	// Radius is the distance the light reaches
+	Radius float32
+	// Falloff is the exponent of the attenuation from the center of the light to its radius. The light fades
+	// linearly if it's 1 or 0, and faster with higher values.
+	Falloff float32
+	// Angle is the width in degrees of the cone lit by a spot light. The light shines in all directions if it's 0 or
+	// 360 and more.
+	Angle float32
+	// Direction is the direction in degrees, clockwise from the x axis, a spot light shines in. The rotation of the
+	// SpaceComponent is added to it.
+	Direction float32
+	// Disabled turns the light off
+	Disabled bool
+
+	center  engo.Point
+	polygon []engo.Point
+}
+
+// Spot returns whether the light is a spot light.
+func (l *LightComponent) Spot() bool {
+	return l.Angle > 0 && l.Angle < 360
+}
+
+// Polygon returns the visibility polygon of the light as of the last update, which is the area its light reaches
+// within the square around its radius. The center of the light is the first point of the polygon of spot lights.
+func (l *LightComponent) Polygon() []engo.Point {
+	if !l.Spot() || len(l.polygon) == 0 {
+		return l.polygon
+	}
+	return append([]engo.Point{l.center}, l.polygon...)
+}
+
+// OccluderComponent makes an entity cast shadows. The outline of the hitboxes of its SpaceComponent blocks the
+// light, or its rectangle if it has none.
+type OccluderComponent struct {
+	// Disabled stops the entity from casting shadows
+	Disabled bool
+}
+
+type lightEntity struct {
+	*ecs.BasicEntity
+	*LightComponent
+	*SpaceComponent
+}
+
+type occluderEntity struct {
+	*ecs.BasicEntity
+	*OccluderComponent
+	*SpaceComponent
+}
+
+// LightingSystem lights the scene. It computes which area each light reaches with the occluders casting hard shadows,
+// renders the lights into a light map and draws it over the scene with the RenderSystem, darkening what is not lit.
+//
+// Entities with a LightComponent are added as lights, and ones with an OccluderComponent as occluders. An entity can
+// be both, its own outline doesn't block its light. To add both with AddByInterface, use
+//
+//	w.AddSystemInterface(&common.LightingSystem{}, []interface{}{new(common.Lightable), new(common.Occludable)}, nil)
+type LightingSystem struct {
+	// Ambient is the color of the light reaching everything, black if it's nil
+	Ambient color.Color
+	// Lines block the light in addition to the occluders, like the outlines of TMX objects returned by
+	// Level.ObjectLines
+	Lines []engo.Line
+	// ZIndex is the z-index the light map is drawn at. The entities with higher ones are not darkened. The light map
+	// is drawn on top of everything if it's 0, including the HUD: it has to be set below the z-index of the HUD
+	// entities to keep them from being darkened.
+	ZIndex float32
+
+	lights    []lightEntity
+	occluders []occluderEntity
+
+	camera      *CameraSystem
+	program     *gl.Program
+	buffer      *gl.Buffer
+	uniforms    lightUniforms
+	framebuffer *Framebuffer
+	texture     *RenderTexture
+	lightMap    lightMapEntity
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (*LightingSystem) Priority() int { return LightingSystemPriority }
+
+// New adds the light map to the RenderSystem of the world, which has to be added before the LightingSystem.
+func (s *LightingSystem) New(w *ecs.World) {
+	var rs *RenderSystem
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *RenderSystem:
+			rs = sys
+		case *CameraSystem:
+			s.camera = sys
+		}
+	}
+	if rs == nil || s.camera == nil {
+		log.Println("ERROR: RenderSystem and CameraSystem not found - have you added them BEFORE the LightingSystem?")
+		return
+	}
+	if engo.Headless() {
+		return
+	}
+
+	var err error
+	if s.program, err = LoadShader(lightVertexShader, lightFragmentShader); err != nil {
+		log.Println("ERROR: unable to load the light shader:", err)
+		return
+	}
+	s.uniforms = lightUniforms{
+		matrixProjView: engo.Gl.GetUniformLocation(s.program, "matrixProjView"),
+		center:         engo.Gl.GetUniformLocation(s.program, "uf_Center"),
+		radius:         engo.Gl.GetUniformLocation(s.program, "uf_Radius"),
+		falloff:        engo.Gl.GetUniformLocation(s.program, "uf_Falloff"),
+		color:          engo.Gl.GetUniformLocation(s.program, "uf_Color"),
+		position:       engo.Gl.GetAttribLocation(s.program, "in_Position"),
+	}
+	s.buffer = engo.Gl.CreateBuffer()
+	s.framebuffer = CreateFramebuffer()
+
+	zIndex := s.ZIndex
+	if zIndex == 0 {
+		zIndex = math.MaxFloat32
+	}
+	s.lightMap = lightMapEntity{
+		BasicEntity: ecs.NewBasic(),
+		RenderComponent: RenderComponent{
+			Drawable:    &lightMap{},
+			Hidden:      true,
+			StartZIndex: zIndex,
+			shader:      LightMapShader,
+		},
+	}
+	rs.Add(&s.lightMap.BasicEntity, &s.lightMap.RenderComponent, &s.lightMap.SpaceComponent)
+}
+
+// Add adds a light to the LightingSystem. To be added, the entity has to have a basic, light and space component.
+func (s *LightingSystem) Add(basic *ecs.BasicEntity, light *LightComponent, space *SpaceComponent) {
+	s.lights = append(s.lights, lightEntity{basic, light, space})
+}
+
+// AddOccluder adds an occluder to the LightingSystem. To be added, the entity has to have a basic, occluder and space
+// component.
+func (s *LightingSystem) AddOccluder(basic *ecs.BasicEntity, occluder *OccluderComponent, space *SpaceComponent) {
+	s.occluders = append(s.occluders, occluderEntity{basic, occluder, space})
+}
+
+// AddByInterface Provides a simple way to add an entity to the system that satisfies Lightable or Occludable. Any entity containing, BasicEntity, LightComponent or OccluderComponent and SpaceComponent anonymously, automatically does this.
+func (s *LightingSystem) AddByInterface(i ecs.Identifier) {
+	if o, ok := i.(Lightable); ok {
+		if _, not := i.(NotLightable); !not {
+			s.Add(o.GetBasicEntity(), o.GetLightComponent(), o.GetSpaceComponent())
+		}
+	}
+	if o, ok := i.(Occludable); ok {
+		if _, not := i.(NotOccludable); !not {
+			s.AddOccluder(o.GetBasicEntity(), o.GetOccluderComponent(), o.GetSpaceComponent())
+		}
+	}
+}
+
+// Remove removes an entity from the LightingSystem, both as a light and as an occluder.
+func (s *LightingSystem) Remove(basic ecs.BasicEntity) {
+	for i, e := range s.lights {
+		if e.BasicEntity.ID() == basic.ID() {
+			s.lights = append(s.lights[:i], s.lights[i+1:]...)
+			break
+		}
+	}
+	for i, e := range s.occluders {
+		if e.BasicEntity.ID() == basic.ID() {
+			s.occluders = append(s.occluders[:i], s.occluders[i+1:]...)
+			break
+		}
+	}
+}
+
+// Update computes the visibility polygons of the lights and renders the light map.
+func (s *LightingSystem) Update(dt float32) {
+	lines := append([]engo.Line(nil), s.Lines...)
+	owners := make(map[uint64][2]int)
+	for _, o := range s.occluders {
+		if o.Disabled {
+			continue
+		}
+		start := len(lines)
+		lines = append(lines, o.Outline()...)
+		owners[o.ID()] = [2]int{start, len(lines)}
+	}
+
+	for _, l := range s.lights {
+		if l.Disabled || l.Radius <= 0 {
+			l.polygon = nil
+			continue
+		}
+		blocking := lines
+		// the outline of the light's own entity doesn't block its light
+		if r, ok := owners[l.ID()]; ok {
+			blocking = append(append([]engo.Line(nil), lines[:r[0]]...), lines[r[1]:]...)
+		}
+		l.center = l.Center()
+		l.polygon = VisibilityPolygon(l.center, l.Radius, l.Direction+l.Rotation, l.Angle, blocking)
+	}
+
+	if s.program != nil {
+		s.render()
+	}
+}
+
+// Lit returns whether any light reached the point at the last update.
+func (s *LightingSystem) Lit(pt engo.Point) bool {
+	for _, l := range s.lights {
+		if len(l.polygon) == 0 || l.center.PointDistanceSquared(pt) >= l.Radius*l.Radius {
+			continue
+		}
+		if polygonContains(l.Polygon(), pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibilityPolygon returns the outline of the area which can be seen from the origin without looking through any of
+// the lines, clipped by the square around the origin whose sides are twice the radius away from each other. The
+// points are sorted by their angle around the origin, clockwise. If width is between 0 and 360, only the part within
+// the cone of that width in degrees around the direction is returned. It starts and ends at the edges of the cone,
+// and doesn't contain the origin.
+func VisibilityPolygon(origin engo.Point, radius, direction, width float32, lines []engo.Line) []engo.Point {
+	min := engo.Point{X: origin.X - radius, Y: origin.Y - radius}
+	max := engo.Point{X: origin.X + radius, Y: origin.Y + radius}
+	corners := []engo.Point{min, {X: max.X, Y: min.Y}, max, {X: min.X, Y: max.Y}}
+	boundaries := []engo.Line{
+		{P1: corners[0], P2: corners[1]},
+		{P1: corners[1], P2: corners[2]},
+		{P1: corners[2], P2: corners[3]},
+		{P1: corners[3], P2: corners[0]},
+	}
+
+	// the angles of the rays are relative to the start of the cone, or the x axis for point lights
+	var start, span float32 = 0, 2 * math.Pi
+	spot := width > 0 && width < 360
+	if spot {
+		span = width * math.Pi / 180
+		start = direction*math.Pi/180 - span/2
+	}
+	var angles []float32
+	if spot {
+		angles = append(angles, 0, span)
+	}
+	addAngle := func(a float32) {
+		a -= start
+		for a < 0 {
+			a += 2 * math.Pi
+		}
+		for a >= 2*math.Pi {
+			a -= 2 * math.Pi
+		}
+		if !spot || a < span {
+			angles = append(angles, a)
+		}
+	}
+	for _, c := range corners {
+		addAngle(math.Atan2(c.Y-origin.Y, c.X-origin.X))
+	}
+	for _, l := range lines {
+		if math.Max(l.P1.X, l.P2.X) < min.X || math.Min(l.P1.X, l.P2.X) > max.X ||
+			math.Max(l.P1.Y, l.P2.Y) < min.Y || math.Min(l.P1.Y, l.P2.Y) > max.Y {
+			continue
+		}
+		boundaries = append(boundaries, l)
+		for _, p := range [2]engo.Point{l.P1, l.P2} {
+			a := math.Atan2(p.Y-origin.Y, p.X-origin.X)
+			addAngle(a - visibilityEpsilon)
+			addAngle(a)
+			addAngle(a + visibilityEpsilon)
+		}
+	}
+	sort.Slice(angles, func(i, j int) bool {
+		return angles[i] < angles[j]
+	})
+
+	// the rays reach beyond the corners of the square
+	var ret []engo.Point
+	for i, a := range angles {
+		if i > 0 && a-angles[i-1] < visibilityEpsilon/10 {
+			continue
+		}
+		sin, cos := math.Sincos(start + a)
+		ray := engo.Line{P1: origin, P2: engo.Point{X: origin.X + 2*radius*cos, Y: origin.Y + 2*radius*sin}}
+		if t := engo.LineTrace(ray, boundaries); t.Fraction <= 1 {
+			ret = append(ret, t.EndPosition)
+		}
+	}
+	return ret
+}
+
+// polygonContains returns whether the point is inside the polygon, using the even-odd rule.
+func polygonContains(polygon []engo.Point, pt engo.Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+func TestVisibilityPolygon(t *testing.T) {
+	origin := engo.Point{}
+	polygon := VisibilityPolygon(origin, 100, 0, 0, nil)
+	if len(polygon) != 4 {
+		t.Fatalf("Polygon without lines had %d points, expected the 4 corners of the square", len(polygon))
+	}
+	for _, p := range polygon {
+		if math.Abs(math.Abs(p.X)-100) > 1e-3 || math.Abs(math.Abs(p.Y)-100) > 1e-3 {
+			t.Errorf("Point %v was not a corner of the square", p)
+		}
+	}
+
+	wall := []engo.Line{{P1: engo.Point{X: 10, Y: -10}, P2: engo.Point{X: 10, Y: 10}}}
+	polygon = VisibilityPolygon(origin, 100, 0, 0, wall)
+	tests := []struct {
+		pt      engo.Point
+		visible bool
+	}{
+		{engo.Point{X: 5, Y: 0}, true},
+		{engo.Point{X: 50, Y: 0}, false},
+		{engo.Point{X: 50, Y: 9}, false},
+		{engo.Point{X: 50, Y: 60}, true},
+		{engo.Point{X: -50, Y: 0}, true},
+		{engo.Point{X: 0, Y: 150}, false},
+	}
+	for _, test := range tests {
+		if polygonContains(polygon, test.pt) != test.visible {
+			t.Errorf("Visibility of %v was %v, expected %v", test.pt, !test.visible, test.visible)
+		}
+	}
+
+	// the wall is outside of the square
+	far := []engo.Line{{P1: engo.Point{X: 200, Y: -10}, P2: engo.Point{X: 200, Y: 10}}}
+	if polygon = VisibilityPolygon(origin, 100, 0, 0, far); len(polygon) != 4 {
+		t.Errorf("Polygon with a line outside of the radius had %d points, expected 4", len(polygon))
+	}
+}
+
+func TestVisibilityPolygonSpot(t *testing.T) {
+	origin := engo.Point{X: 10, Y: 10}
+	polygon := VisibilityPolygon(origin, 100, 90, 90, nil)
+	if len(polygon) < 2 {
+		t.Fatalf("Spot polygon had %d points, expected at least the edges of the cone", len(polygon))
+	}
+	angle := func(p engo.Point) float32 {
+		return math.Atan2(p.Y-origin.Y, p.X-origin.X) * 180 / math.Pi
+	}
+	if a := angle(polygon[0]); math.Abs(a-45) > 1e-2 {
+		t.Errorf("Spot polygon started at %v degrees, expected 45", a)
+	}
+	if a := angle(polygon[len(polygon)-1]); math.Abs(a-135) > 1e-2 {
+		t.Errorf("Spot polygon ended at %v degrees, expected 135", a)
+	}
+	for _, p := range polygon {
+		if a := angle(p); a < 45-1e-2 || a > 135+1e-2 {
+			t.Errorf("Point %v at %v degrees was outside of the cone", p, a)
+		}
+	}
+}
+
+func TestLightingSystem(t *testing.T) {
+	s := &LightingSystem{}
+
+	light := ecs.NewBasic()
+	lc := &LightComponent{Radius: 100}
+	s.Add(&light, lc, &SpaceComponent{Position: engo.Point{X: -5, Y: -5}, Width: 10, Height: 10})
+	// the light's own occluder doesn't block it
+	s.AddOccluder(&light, &OccluderComponent{}, &SpaceComponent{Position: engo.Point{X: -5, Y: -5}, Width: 10, Height: 10})
+
+	box := ecs.NewBasic()
+	boxSpace := &SpaceComponent{Position: engo.Point{X: 20, Y: -10}, Width: 10, Height: 20}
+	s.AddOccluder(&box, &OccluderComponent{}, boxSpace)
+	s.Lines = []engo.Line{{P1: engo.Point{X: -20, Y: -10}, P2: engo.Point{X: -20, Y: 10}}}
+	s.Update(0)
+
+	tests := []struct {
+		pt  engo.Point
+		lit bool
+	}{
+		{engo.Point{X: 10, Y: 0}, true},
+		{engo.Point{X: 50, Y: 0}, false},
+		{engo.Point{X: -50, Y: 0}, false},
+		{engo.Point{X: 0, Y: 50}, true},
+		{engo.Point{X: 50, Y: 35}, true},
+		{engo.Point{X: 0, Y: 99}, true},
+		{engo.Point{X: 90, Y: 90}, false},
+	}
+	for _, test := range tests {
+		if s.Lit(test.pt) != test.lit {
+			t.Errorf("Point %v was lit: %v, expected %v", test.pt, !test.lit, test.lit)
+		}
+	}
+
+	// the occluder is rotated around its top left corner
+	boxSpace.Rotation = 45
+	s.Update(0)
+	if s.Lit(engo.Point{X: 50, Y: 35}) || !s.Lit(engo.Point{X: 50, Y: -35}) {
+		t.Error("Rotated occluder did not cast the expected shadow")
+	}
+
+	s.Remove(box)
+	s.Update(0)
+	if !s.Lit(engo.Point{X: 50, Y: 0}) {
+		t.Error("Removed occluder still cast a shadow")
+	}
+
+	lc.Disabled = true
+	s.Update(0)
+	if s.Lit(engo.Point{X: 10, Y: 0}) {
+		t.Error("Disabled light lit a point")
+	}
+}
@@ -0,0 +1,326 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+// Translation is a localized string. Strings without plural forms only contain PluralOther.
+type Translation map[PluralCategory]string
+
+// StringTable contains the translations of a locale, by key.
+type StringTable struct {
+	Locale  string
+	Strings map[string]Translation
+}
+
+// LocaleChangedMessage is dispatched through `engo.Mailbox` whenever the locale of the `Localization` is changed.
+type LocaleChangedMessage struct {
+	Old, New string
+}
+
+// Type returns the type of the message, "LocaleChangedMessage"
+func (LocaleChangedMessage) Type() string { return "LocaleChangedMessage" }
+
+// Localizer translates keys into the current locale, using the string tables that have been added to it. String
+// tables loaded through `engo.Files` are added to `Localization`.
+type Localizer struct {
+	mu sync.RWMutex
+
+	locale        string
+	defaultLocale string
+
+	// tables are in the order they have been added, later tables override the keys of earlier ones
+	tables []localizerTable
+}
+
+type localizerTable struct {
+	StringTable
+	url string
+}
+
+// Localization is the Localizer used by the string table loaders and the LocalizationSystem.
+var Localization = NewLocalizer("en")
+
+// NewLocalizer creates a Localizer that uses the given locale both as current and as default locale.
+func NewLocalizer(locale string) *Localizer {
+	return &Localizer{locale: locale, defaultLocale: locale}
+}
+
+// Locale returns the current locale.
+func (l *Localizer) Locale() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.locale
+}
+
+// SetLocale changes the current locale, such as "fr" or "pt-BR", and dispatches a LocaleChangedMessage so that
+// localized texts can be updated.
+func (l *Localizer) SetLocale(locale string) {
+	l.mu.Lock()
+	old := l.locale
+	l.locale = locale
+	l.mu.Unlock()
+
+	if old != locale && engo.Mailbox != nil {
+		engo.Mailbox.Dispatch(LocaleChangedMessage{Old: old, New: locale})
+	}
+}
+
+// DefaultLocale returns the locale that is used for keys that are missing from the current locale.
+func (l *Localizer) DefaultLocale() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.defaultLocale
+}
+
+// SetDefaultLocale changes the locale that is used for keys that are missing from the current locale.
+func (l *Localizer) SetDefaultLocale(locale string) {
+	l.mu.Lock()
+	l.defaultLocale = locale
+	l.mu.Unlock()
+}
+
+// Locales returns the locales of all string tables, in the order they have first been added.
+func (l *Localizer) Locales() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var locales []string
+	seen := make(map[string]bool)
+	for _, t := range l.tables {
+		if n := normalizeLocale(t.Locale); !seen[n] {
+			seen[n] = true
+			locales = append(locales, t.Locale)
+		}
+	}
+	return locales
+}
+
+// Add adds string tables to the Localizer. Keys that already exist for the same locale are overridden.
+func (l *Localizer) Add(tables ...StringTable) {
+	l.add("", tables)
+}
+
+func (l *Localizer) add(url string, tables []StringTable) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range tables {
+		l.tables = append(l.tables, localizerTable{t, url})
+	}
+}
+
+// remove removes all string tables that have been loaded from the url.
+func (l *Localizer) remove(url string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tables := l.tables[:0]
+	for _, t := range l.tables {
+		if t.url != url {
+			tables = append(tables, t)
+		}
+	}
+	l.tables = tables
+}
+
+// Clear removes all string tables.
+func (l *Localizer) Clear() {
+	l.mu.Lock()
+	l.tables = nil
+	l.mu.Unlock()
+}
+
+// lookup finds the translation of the key, searching the current locale, the less specific locales it falls back
+// to, and finally the default locale. The form function selects the string to use from a translation and the locale
+// it was found in; translations without that form are skipped.
+func (l *Localizer) lookup(key string, form func(tr Translation, locale string) (string, bool)) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, locale := range append(localeChain(l.locale), localeChain(l.defaultLocale)...) {
+		for i := len(l.tables) - 1; i >= 0; i-- {
+			if normalizeLocale(l.tables[i].Locale) != locale {
+				continue
+			}
+			if tr, ok := l.tables[i].Strings[key]; ok {
+				if s, ok := form(tr, locale); ok {
+					return s, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// Has reports whether the key has a translation in the current or default locale.
+func (l *Localizer) Has(key string) bool {
+	_, ok := l.lookup(key, func(tr Translation, _ string) (string, bool) {
+		return "", len(tr) > 0
+	})
+	return ok
+}
+
+// Translate returns the translation of the key in the current locale, with the `{placeholders}` replaced by the
+// values of args. If the key has no translation, the key itself is returned.
+func (l *Localizer) Translate(key string, args map[string]interface{}) string {
+	s, ok := l.lookup(key, func(tr Translation, _ string) (string, bool) {
+		if s, ok := tr[PluralOther]; ok {
+			return s, true
+		}
+		s, ok := tr[PluralOne]
+		return s, ok
+	})
+	if !ok {
+		s = key
+	}
+	return Interpolate(s, args)
+}
+
+// TranslatePlural returns the plural form of the translation of the key for n, using the plural rule of the locale
+// the translation was found in. The `{count}` placeholder is replaced by n, unless args contains another value for
+// it.
+func (l *Localizer) TranslatePlural(key string, n int, args map[string]interface{}) string {
+	withCount := map[string]interface{}{"count": n}
+	for k, v := range args {
+		withCount[k] = v
+	}
+
+	s, ok := l.lookup(key, func(tr Translation, locale string) (string, bool) {
+		if s, ok := tr[PluralRuleFor(locale).Select(n)]; ok {
+			return s, true
+		}
+		s, ok := tr[PluralOther]
+		return s, ok
+	})
+	if !ok {
+		s = key
+	}
+	return Interpolate(s, withCount)
+}
+
+// Interpolate replaces the `{name}` placeholders in s by the values of args. Placeholders without a value are kept
+// as they are. Use `{{` and `}}` to write literal braces.
+func Interpolate(s string, args map[string]interface{}) string {
+	if !strings.ContainsAny(s, "{}") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"), strings.HasPrefix(s[i:], "}}"):
+			b.WriteByte(s[i])
+			i++
+		case s[i] == '{':
+			end := strings.IndexAny(s[i+1:], "{}")
+			if end < 0 || s[i+1+end] != '}' {
+				b.WriteByte(s[i])
+				continue
+			}
+			name := s[i+1 : i+1+end]
+			if v, ok := args[strings.TrimSpace(name)]; ok {
+				b.WriteString(fmt.Sprint(v))
+			} else {
+				b.WriteString(s[i : i+2+end])
+			}
+			i += 1 + end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// LocalizedComponent binds the `Text` of a RenderComponent to a key of the `Localization`.
+type LocalizedComponent struct {
+	// Key is the key of the translation.
+	Key string
+	// Args are the values of the `{placeholders}` of the translation.
+	Args map[string]interface{}
+	// Plural selects the plural form of the translation for Count.
+	Plural bool
+	// Count is the number used to select the plural form, it is also available as the `{count}` placeholder.
+	Count int
+}
+
+// String returns the translation in the current locale of the `Localization`.
+func (c *LocalizedComponent) String() string {
+	if c.Plural {
+		return Localization.TranslatePlural(c.Key, c.Count, c.Args)
+	}
+	return Localization.Translate(c.Key, c.Args)
+}
+
+// LocalizationSystem keeps the texts of LocalizedComponents up to date. The texts are set when the entities are added
+// and whenever the locale of the `Localization` changes. Call Refresh after changing the Key or Args of a component.
+type LocalizationSystem struct {
+	entities map[uint64]localizedEntity
+	changed  bool
+}
+
+type localizedEntity struct {
+	*LocalizedComponent
+	*RenderComponent
+}
+
+// New listens for LocaleChangedMessages.
+func (l *LocalizationSystem) New(w *ecs.World) {
+	engo.Mailbox.Listen("LocaleChangedMessage", func(engo.Message) {
+		l.changed = true
+	})
+}
+
+// Add starts tracking the given entity, and sets its text.
+func (l *LocalizationSystem) Add(basic *ecs.BasicEntity, loc *LocalizedComponent, render *RenderComponent) {
+	if l.entities == nil {
+		l.entities = make(map[uint64]localizedEntity)
+	}
+	e := localizedEntity{loc, render}
+	l.entities[basic.ID()] = e
+	e.update()
+}
+
+// AddByInterface allows an Entity to be added directly using the Localizable interface, which every entity
+// containing the BasicEntity, LocalizedComponent and RenderComponent anonymously automatically satisfies.
+func (l *LocalizationSystem) AddByInterface(i ecs.Identifier) {
+	o, _ := i.(Localizable)
+	l.Add(o.GetBasicEntity(), o.GetLocalizedComponent(), o.GetRenderComponent())
+}
+
+// Remove stops tracking the given entity.
+func (l *LocalizationSystem) Remove(basic ecs.BasicEntity) {
+	if l.entities != nil {
+		delete(l.entities, basic.ID())
+	}
+}
+
+// Refresh sets the text of the given entity again, for example after changing the Args of its LocalizedComponent.
+func (l *LocalizationSystem) Refresh(basic ecs.BasicEntity) {
+	if e, ok := l.entities[basic.ID()]; ok {
+		e.update()
+	}
+}
+
+// Update sets the texts of all tracked entities if the locale has changed.
+func (l *LocalizationSystem) Update(dt float32) {
+	if !l.changed {
+		return
+	}
+	l.changed = false
+	for _, e := range l.entities {
+		e.update()
+	}
+}
+
+// update sets the text of the entity to the current translation. If the Drawable of the RenderComponent is not a
+// Text, nothing happens.
+func (e localizedEntity) update() {
+	txt, ok := e.RenderComponent.Drawable.(Text)
+	if !ok {
+		return
+	}
+	txt.Text = e.LocalizedComponent.String()
+	e.RenderComponent.Drawable = txt
+}
@@ -0,0 +1,311 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EngoEngine/engo"
+)
+
+// StringTableResource contains the string tables of a `.po`, `.strings.json` or `.strings.csv` file. Loading the file
+// through `engo.Files` adds the tables to `Localization`, unloading it removes them again.
+type StringTableResource struct {
+	Tables []StringTable
+	url    string
+}
+
+// URL returns the file path for the StringTableResource.
+func (r StringTableResource) URL() string {
+	return r.url
+}
+
+// stringTableLoader is responsible for managing string table files within `engo.Files`
+type stringTableLoader struct {
+	tables map[string]StringTableResource
+	parse  func(data []byte) ([]StringTable, error)
+}
+
+// Load parses the string tables and adds them to `Localization`
+func (l *stringTableLoader) Load(url string, data io.Reader) error {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	tables, err := l.parse(b)
+	if err != nil {
+		return fmt.Errorf("unable to parse string table %q: %v", url, err)
+	}
+
+	if _, ok := l.tables[url]; ok {
+		Localization.remove(url)
+	}
+	l.tables[url] = StringTableResource{Tables: tables, url: url}
+	Localization.add(url, tables)
+	return nil
+}
+
+// Unload removes the string tables from the cache and from `Localization`
+func (l *stringTableLoader) Unload(url string) error {
+	delete(l.tables, url)
+	Localization.remove(url)
+	return nil
+}
+
+// Resource retrieves the preloaded string tables, passed as a `StringTableResource`
+func (l *stringTableLoader) Resource(url string) (engo.Resource, error) {
+	res, ok := l.tables[url]
+	if !ok {
+		return nil, fmt.Errorf("resource not loaded by `FileLoader`: %q", url)
+	}
+
+	return res, nil
+}
+
+// poEntry is an entry of a gettext file being parsed.
+type poEntry struct {
+	id, plural string
+	strs       []string
+	fuzzy      bool
+}
+
+// ParsePO parses a gettext `.po` file. The locale is read from the `Language` header, and the `msgstr[n]` entries of
+// plural strings are assigned to the categories of the plural rule of that locale. Message contexts are ignored, and
+// fuzzy and untranslated entries are skipped.
+func ParsePO(data []byte) ([]StringTable, error) {
+	table := StringTable{Strings: make(map[string]Translation)}
+
+	entry := &poEntry{}
+	flush := func() {
+		e := entry
+		entry = &poEntry{}
+		if e.strs == nil {
+			return
+		}
+		if e.id == "" {
+			// The header is the translation of the empty string
+			for _, line := range strings.Split(e.strs[0], "\n") {
+				if i := strings.IndexByte(line, ':'); i > 0 && strings.TrimSpace(line[:i]) == "Language" {
+					table.Locale = strings.TrimSpace(line[i+1:])
+				}
+			}
+			return
+		}
+		if e.fuzzy {
+			return
+		}
+
+		tr := make(Translation)
+		if e.plural == "" {
+			if e.strs[0] != "" {
+				tr[PluralOther] = e.strs[0]
+			}
+		} else {
+			categories := PluralRuleFor(table.Locale).Categories
+			for n, s := range e.strs {
+				if n < len(categories) && s != "" {
+					tr[categories[n]] = s
+				}
+			}
+		}
+		if len(tr) > 0 {
+			table.Strings[e.id] = tr
+		}
+	}
+
+	// field is the string continuation lines are appended to
+	var field *string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, `"`) {
+			if field == nil {
+				return nil, fmt.Errorf("line %d: string without keyword", lineNum)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			*field += s
+			continue
+		}
+
+		// Comments and keywords other than msgstr start a new entry once the current one has been translated
+		if entry.strs != nil && !strings.HasPrefix(line, "msgstr") {
+			flush()
+		}
+		field = nil
+
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: keyword without string", lineNum)
+		}
+		keyword := line[:i]
+		s, err := strconv.Unquote(strings.TrimSpace(line[i:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			field = new(string)
+		case keyword == "msgid":
+			entry.id = s
+			field = &entry.id
+		case keyword == "msgid_plural":
+			entry.plural = s
+			field = &entry.plural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n := 0
+			if keyword != "msgstr" {
+				n, err = strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("line %d: invalid keyword %q", lineNum, keyword)
+				}
+			}
+			for len(entry.strs) <= n {
+				entry.strs = append(entry.strs, "")
+			}
+			entry.strs[n] = s
+			field = &entry.strs[n]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNum, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if table.Locale == "" {
+		return nil, fmt.Errorf("missing Language header")
+	}
+	return []StringTable{table}, nil
+}
+
+// ParseStringTablesJSON parses a `.strings.json` file, which contains an object with a table for every locale. The
+// values of a table are either strings, or objects that map plural categories to strings:
+//
+//	{
+//	  "en": {"greeting": "Hello {name}!", "apples": {"one": "{count} apple", "other": "{count} apples"}},
+//	  "fr": {"greeting": "Bonjour {name} !", "apples": {"one": "{count} pomme", "other": "{count} pommes"}}
+//	}
+func ParseStringTablesJSON(data []byte) ([]StringTable, error) {
+	var locales map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &locales); err != nil {
+		return nil, err
+	}
+
+	var tables []StringTable
+	for locale, values := range locales {
+		table := StringTable{Locale: locale, Strings: make(map[string]Translation, len(values))}
+		for key, value := range values {
+			var s string
+			if err := json.Unmarshal(value, &s); err == nil {
+				table.Strings[key] = Translation{PluralOther: s}
+				continue
+			}
+
+			var forms map[string]string
+			if err := json.Unmarshal(value, &forms); err != nil {
+				return nil, fmt.Errorf("%s: %q is neither a string nor an object of plural forms", locale, key)
+			}
+			tr := make(Translation, len(forms))
+			for name, s := range forms {
+				c, err := ParsePluralCategory(name)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %q: %v", locale, key, err)
+				}
+				tr[c] = s
+			}
+			table.Strings[key] = tr
+		}
+		tables = append(tables, table)
+	}
+	sortStringTables(tables)
+	return tables, nil
+}
+
+// ParseStringTablesCSV parses a `.strings.csv` file. The header contains "key" followed by the locales, and every
+// other row contains a key followed by its translations. The plural forms of a key are written as separate rows,
+// with the plural category after a '#':
+//
+//	key,en,fr
+//	greeting,Hello {name}!,Bonjour {name} !
+//	apples#one,{count} apple,{count} pomme
+//	apples#other,{count} apples,{count} pommes
+//
+// Empty cells are treated as missing translations.
+func ParseStringTablesCSV(data []byte) ([]StringTable, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) < 2 {
+		return nil, fmt.Errorf("missing header with locales")
+	}
+
+	tables := make([]StringTable, len(rows[0])-1)
+	for i, locale := range rows[0][1:] {
+		tables[i] = StringTable{Locale: strings.TrimSpace(locale), Strings: make(map[string]Translation)}
+	}
+
+	for n, row := range rows[1:] {
+		if len(row) == 0 || row[0] == "" {
+			continue
+		}
+		key, category := row[0], PluralOther
+		if i := strings.LastIndexByte(key, '#'); i >= 0 {
+			c, err := ParsePluralCategory(key[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %v", n+2, err)
+			}
+			key, category = key[:i], c
+		}
+		for i, s := range row[1:] {
+			if i >= len(tables) || s == "" {
+				continue
+			}
+			tr, ok := tables[i].Strings[key]
+			if !ok {
+				tr = make(Translation)
+				tables[i].Strings[key] = tr
+			}
+			tr[category] = s
+		}
+	}
+	return tables, nil
+}
+
+// sortStringTables sorts the tables by locale, so tables parsed from maps are always added in the same order.
+func sortStringTables(tables []StringTable) {
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Locale < tables[j].Locale
+	})
+}
+
+func init() {
+	engo.Files.Register(".po", &stringTableLoader{tables: make(map[string]StringTableResource), parse: ParsePO})
+	engo.Files.Register(".strings.json", &stringTableLoader{tables: make(map[string]StringTableResource), parse: ParseStringTablesJSON})
+	engo.Files.Register(".strings.csv", &stringTableLoader{tables: make(map[string]StringTableResource), parse: ParseStringTablesCSV})
+}
@@ -0,0 +1,212 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PluralCategory is one of the plural forms defined by the Unicode CLDR. Every language uses a subset of them.
+type PluralCategory uint8
+
+const (
+	// PluralOther is the general plural form, and the only form of languages without plurals. Strings without
+	// plural forms are stored as PluralOther.
+	PluralOther PluralCategory = iota
+	// PluralZero is used by some languages for 0, such as Arabic.
+	PluralZero
+	// PluralOne is used for singular, such as 1 in English.
+	PluralOne
+	// PluralTwo is used for dual, such as 2 in Arabic.
+	PluralTwo
+	// PluralFew is used for paucal forms, such as 2 to 4 in Russian.
+	PluralFew
+	// PluralMany is used for large numbers in some languages, such as 5 in Russian.
+	PluralMany
+)
+
+var pluralCategoryNames = [...]string{
+	PluralOther: "other",
+	PluralZero:  "zero",
+	PluralOne:   "one",
+	PluralTwo:   "two",
+	PluralFew:   "few",
+	PluralMany:  "many",
+}
+
+// String returns the CLDR name of the category, such as "one" or "few".
+func (c PluralCategory) String() string {
+	if int(c) < len(pluralCategoryNames) {
+		return pluralCategoryNames[c]
+	}
+	return fmt.Sprintf("PluralCategory(%d)", uint8(c))
+}
+
+// ParsePluralCategory returns the category with the given CLDR name.
+func ParsePluralCategory(name string) (PluralCategory, error) {
+	for c, n := range pluralCategoryNames {
+		if n == name {
+			return PluralCategory(c), nil
+		}
+	}
+	return PluralOther, fmt.Errorf("unknown plural category %q", name)
+}
+
+// PluralRule selects the plural form of a language for a number.
+type PluralRule struct {
+	// Categories are the forms used by the language, in the order of the `msgstr[n]` entries of gettext files.
+	Categories []PluralCategory
+	// Select returns the form to use for n.
+	Select func(n int) PluralCategory
+}
+
+var (
+	pluralRulesMutex sync.RWMutex
+	pluralRules      = map[string]PluralRule{}
+)
+
+// RegisterPluralRule sets the plural rule of a language, such as "fr", or of a specific locale, such as "pt-PT".
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralRulesMutex.Lock()
+	pluralRules[normalizeLocale(lang)] = rule
+	pluralRulesMutex.Unlock()
+}
+
+// PluralRuleFor returns the plural rule of the locale, falling back to the rule of its language. Languages without
+// a registered rule use the English one.
+func PluralRuleFor(locale string) PluralRule {
+	pluralRulesMutex.RLock()
+	defer pluralRulesMutex.RUnlock()
+	for _, l := range localeChain(locale) {
+		if rule, ok := pluralRules[l]; ok {
+			return rule
+		}
+	}
+	return pluralRules["en"]
+}
+
+func init() {
+	oneOther := PluralRule{
+		Categories: []PluralCategory{PluralOne, PluralOther},
+		Select: func(n int) PluralCategory {
+			if n == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	for _, lang := range []string{"en", "de", "nl", "sv", "da", "nb", "no", "fi", "et", "el", "hu", "it", "es", "tr", "bg"} {
+		pluralRules[lang] = oneOther
+	}
+
+	zeroOneOther := PluralRule{
+		Categories: []PluralCategory{PluralOne, PluralOther},
+		Select: func(n int) PluralCategory {
+			if n == 0 || n == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	for _, lang := range []string{"fr", "pt", "hi"} {
+		pluralRules[lang] = zeroOneOther
+	}
+
+	pluralRules["pt-pt"] = oneOther
+
+	// paucal returns whether n ends in 2, 3 or 4, but not in 12, 13 or 14
+	paucal := func(n int) bool {
+		return n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14)
+	}
+
+	eastSlavic := PluralRule{
+		Categories: []PluralCategory{PluralOne, PluralFew, PluralMany},
+		Select: func(n int) PluralCategory {
+			switch {
+			case n%10 == 1 && n%100 != 11:
+				return PluralOne
+			case paucal(n):
+				return PluralFew
+			}
+			return PluralMany
+		},
+	}
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = eastSlavic
+	}
+
+	pluralRules["pl"] = PluralRule{
+		Categories: []PluralCategory{PluralOne, PluralFew, PluralMany},
+		Select: func(n int) PluralCategory {
+			switch {
+			case n == 1:
+				return PluralOne
+			case paucal(n):
+				return PluralFew
+			}
+			return PluralMany
+		},
+	}
+
+	westSlavic := PluralRule{
+		Categories: []PluralCategory{PluralOne, PluralFew, PluralOther},
+		Select: func(n int) PluralCategory {
+			switch {
+			case n == 1:
+				return PluralOne
+			case n >= 2 && n <= 4:
+				return PluralFew
+			}
+			return PluralOther
+		},
+	}
+	pluralRules["cs"] = westSlavic
+	pluralRules["sk"] = westSlavic
+
+	none := PluralRule{
+		Categories: []PluralCategory{PluralOther},
+		Select:     func(int) PluralCategory { return PluralOther },
+	}
+	for _, lang := range []string{"ja", "zh", "ko", "vi", "th", "id"} {
+		pluralRules[lang] = none
+	}
+
+	pluralRules["ar"] = PluralRule{
+		Categories: []PluralCategory{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther},
+		Select: func(n int) PluralCategory {
+			switch {
+			case n == 0:
+				return PluralZero
+			case n == 1:
+				return PluralOne
+			case n == 2:
+				return PluralTwo
+			case n%100 >= 3 && n%100 <= 10:
+				return PluralFew
+			case n%100 >= 11:
+				return PluralMany
+			}
+			return PluralOther
+		},
+	}
+}
+
+// normalizeLocale lower-cases the locale and uses '-' as separator, so "pt_BR" and "pt-BR" are the same locale.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// localeChain returns the normalized locale followed by the locales it falls back to, from the most to the least
+// specific. For example "zh-Hant-TW" returns "zh-hant-tw", "zh-hant" and "zh".
+func localeChain(locale string) []string {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		return nil
+	}
+	chain := []string{locale}
+	for i := strings.LastIndexByte(locale, '-'); i > 0; i = strings.LastIndexByte(locale, '-') {
+		locale = locale[:i]
+		chain = append(chain, locale)
+	}
+	return chain
+}
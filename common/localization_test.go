@@ -0,0 +1,215 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	args := map[string]interface{}{"name": "Ada", "count": 3}
+	assert.Equal(t, "Hello Ada, you have 3 messages", Interpolate("Hello {name}, you have {count} messages", args))
+	assert.Equal(t, "Hello { name }", Interpolate("Hello {{ name }}", args))
+	assert.Equal(t, "Hello {missing}", Interpolate("Hello {missing}", args))
+	assert.Equal(t, "{name}", Interpolate("{{name}", args), "the escaped brace does not start a placeholder")
+	assert.Equal(t, "Ada}", Interpolate("{name}}}", args))
+	assert.Equal(t, "open { brace", Interpolate("open { brace", args))
+}
+
+func TestPluralRules(t *testing.T) {
+	cases := []struct {
+		locale   string
+		n        int
+		expected PluralCategory
+	}{
+		{"en", 0, PluralOther},
+		{"en", 1, PluralOne},
+		{"en-US", 2, PluralOther},
+		{"fr", 0, PluralOne},
+		{"fr_CA", 1, PluralOne},
+		{"fr", 2, PluralOther},
+		{"pt-PT", 0, PluralOther},
+		{"pt-BR", 0, PluralOne},
+		{"ru", 1, PluralOne},
+		{"ru", 11, PluralMany},
+		{"ru", 22, PluralFew},
+		{"ru", 12, PluralMany},
+		{"ru", 25, PluralMany},
+		{"pl", 21, PluralMany},
+		{"pl", 24, PluralFew},
+		{"cs", 3, PluralFew},
+		{"cs", 5, PluralOther},
+		{"ja", 1, PluralOther},
+		{"ar", 0, PluralZero},
+		{"ar", 2, PluralTwo},
+		{"ar", 103, PluralFew},
+		{"ar", 111, PluralMany},
+		{"ar", 100, PluralOther},
+		{"xx", 1, PluralOne},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, PluralRuleFor(c.locale).Select(c.n), "%s %d", c.locale, c.n)
+	}
+
+	defer func() {
+		pluralRulesMutex.Lock()
+		delete(pluralRules, "xx")
+		pluralRulesMutex.Unlock()
+	}()
+	RegisterPluralRule("xx", PluralRule{
+		Categories: []PluralCategory{PluralOther},
+		Select:     func(int) PluralCategory { return PluralOther },
+	})
+	assert.Equal(t, PluralOther, PluralRuleFor("xx").Select(1))
+}
+
+func TestLocalizerFallback(t *testing.T) {
+	l := NewLocalizer("en")
+	l.Add(
+		StringTable{Locale: "en", Strings: map[string]Translation{
+			"greeting": {PluralOther: "Hello {name}!"},
+			"quit":     {PluralOther: "Quit"},
+			"apples":   {PluralOne: "{count} apple", PluralOther: "{count} apples"},
+		}},
+		StringTable{Locale: "fr", Strings: map[string]Translation{
+			"greeting": {PluralOther: "Bonjour {name} !"},
+			"apples":   {PluralOne: "{count} pomme", PluralOther: "{count} pommes"},
+		}},
+		StringTable{Locale: "fr-CA", Strings: map[string]Translation{
+			"greeting": {PluralOther: "Allô {name} !"},
+		}},
+	)
+	args := map[string]interface{}{"name": "Ada"}
+
+	assert.Equal(t, "Hello Ada!", l.Translate("greeting", args))
+	assert.Equal(t, "1 apple", l.TranslatePlural("apples", 1, nil))
+	assert.Equal(t, "0 apples", l.TranslatePlural("apples", 0, nil))
+
+	l.SetLocale("fr_CA")
+	assert.Equal(t, "Allô Ada !", l.Translate("greeting", args))
+	assert.Equal(t, "0 pomme", l.TranslatePlural("apples", 0, nil), "the plural rule of French is used")
+	assert.Equal(t, "Quit", l.Translate("quit", nil), "missing keys fall back to the default locale")
+	assert.Equal(t, "missing.key", l.Translate("missing.key", nil))
+	assert.False(t, l.Has("missing.key"))
+	assert.Equal(t, []string{"en", "fr", "fr-CA"}, l.Locales())
+
+	l.Add(StringTable{Locale: "fr", Strings: map[string]Translation{"quit": {PluralOther: "Quitter"}}})
+	assert.Equal(t, "Quitter", l.Translate("quit", nil), "later tables override earlier ones")
+}
+
+func TestParsePO(t *testing.T) {
+	tables, err := ParsePO([]byte(`msgid ""
+msgstr "Language: ru\n"
+
+msgid "apples"
+msgid_plural "apples"
+msgstr[0] "{count} яблоко"
+msgstr[1] "{count} яблока"
+msgstr[2] "{count} яблок"
+`))
+	if !assert.NoError(t, err) || !assert.Len(t, tables, 1) {
+		return
+	}
+	assert.Equal(t, "ru", tables[0].Locale)
+	assert.Equal(t, Translation{PluralOne: "{count} яблоко", PluralFew: "{count} яблока", PluralMany: "{count} яблок"}, tables[0].Strings["apples"])
+
+	_, err = ParsePO([]byte("msgid \"a\"\nmsgstr \"b\"\n"))
+	assert.Error(t, err, "the Language header is required")
+	_, err = ParsePO([]byte("msgid \"a\"\nmsgstr b\n"))
+	assert.Error(t, err)
+}
+
+func TestParseStringTablesCSV(t *testing.T) {
+	_, err := ParseStringTablesCSV([]byte("key\n"))
+	assert.Error(t, err)
+	_, err = ParseStringTablesCSV([]byte("key,en\nlives#lots,many lives\n"))
+	assert.Error(t, err)
+}
+
+type localizationTestScene struct{}
+
+func (*localizationTestScene) Preload()           {}
+func (*localizationTestScene) Setup(engo.Updater) {}
+func (*localizationTestScene) Type() string       { return "localizationTestScene" }
+
+func TestStringTableLoaders(t *testing.T) {
+	engo.Run(engo.RunOptions{HeadlessMode: true, NoRun: true, AssetsRoot: "testdata"}, &localizationTestScene{})
+	defer func() {
+		Localization.Clear()
+		Localization.SetLocale("en")
+	}()
+
+	if !assert.NoError(t, engo.Files.Load("fr.po", "ui.strings.json", "ui.strings.csv")) {
+		return
+	}
+
+	res, err := engo.Files.Resource("fr.po")
+	assert.NoError(t, err)
+	fr := res.(StringTableResource).Tables[0]
+	assert.Equal(t, "fr", fr.Locale)
+	assert.Equal(t, Translation{PluralOther: "Première ligne\nDeuxième ligne"}, fr.Strings["multiline"])
+	assert.NotContains(t, fr.Strings, "quit", "fuzzy entries are skipped")
+	assert.NotContains(t, fr.Strings, "untranslated")
+
+	res, err = engo.Files.Resource("ui.strings.json")
+	assert.NoError(t, err)
+	assert.Len(t, res.(StringTableResource).Tables, 2)
+
+	assert.Equal(t, "Quit game", Localization.Translate("quit", nil), "the CSV file was loaded last")
+	assert.Equal(t, "Hello, world", Localization.Translate("title", nil))
+
+	Localization.SetLocale("fr")
+	assert.Equal(t, "Bonjour Ada !", Localization.Translate("greeting", map[string]interface{}{"name": "Ada"}))
+	assert.Equal(t, "1 pomme", Localization.TranslatePlural("apples", 1, nil))
+
+	Localization.SetLocale("ru")
+	assert.Equal(t, "3 яблока", Localization.TranslatePlural("apples", 3, nil))
+
+	Localization.SetLocale("de")
+	assert.Equal(t, "1 Leben", Localization.TranslatePlural("lives", 1, nil))
+	assert.Equal(t, "2 lives", Localization.TranslatePlural("lives", 2, nil), "empty cells fall back to the default locale")
+
+	assert.NoError(t, engo.Files.Unload("ui.strings.csv"))
+	assert.Equal(t, "Quit", Localization.Translate("quit", nil))
+}
+
+type localizedTestEntity struct {
+	ecs.BasicEntity
+	LocalizedComponent
+	RenderComponent
+}
+
+func TestLocalizationSystem(t *testing.T) {
+	engo.Run(engo.RunOptions{HeadlessMode: true, NoRun: true}, &localizationTestScene{})
+	defer func() {
+		Localization.Clear()
+		Localization.SetLocale("en")
+	}()
+	Localization.Add(
+		StringTable{Locale: "en", Strings: map[string]Translation{"coins": {PluralOne: "{count} coin", PluralOther: "{count} coins"}}},
+		StringTable{Locale: "de", Strings: map[string]Translation{"coins": {PluralOne: "{count} Münze", PluralOther: "{count} Münzen"}}},
+	)
+
+	w := &ecs.World{}
+	sys := &LocalizationSystem{}
+	var l *Localizable
+	var notl *NotLocalizable
+	w.AddSystemInterface(sys, l, notl)
+
+	e := &localizedTestEntity{BasicEntity: ecs.NewBasic()}
+	e.LocalizedComponent = LocalizedComponent{Key: "coins", Plural: true, Count: 1}
+	e.RenderComponent.Drawable = Text{Font: &Font{}}
+	w.AddEntity(e)
+	assert.Equal(t, "1 coin", e.Drawable.(Text).Text)
+
+	Localization.SetLocale("de")
+	assert.Equal(t, "1 coin", e.Drawable.(Text).Text, "texts are updated during Update")
+	w.Update(1)
+	assert.Equal(t, "1 Münze", e.Drawable.(Text).Text)
+
+	e.Count = 5
+	sys.Refresh(e.BasicEntity)
+	assert.Equal(t, "5 Münzen", e.Drawable.(Text).Text)
+}
@@ -110,6 +110,9 @@ func (r *RenderComponent) ensureShader() {
 			r.shader = LegacyShader
 		case Text:
 			r.shader = TextShader
+			if r.Drawable.(Text).Font.SDF {
+				r.shader = SDFTextShader
+			}
 		case Blendmap:
 			r.shader = BlendmapShader
 		default:
@@ -237,10 +240,21 @@ func (rs *RenderSystem) New(w *ecs.World) {
 	engo.Mailbox.Listen("renderChangeMessage", func(engo.Message) {
 		rs.sortingNeeded = true
 	})
+
+	engo.Mailbox.Listen("ResourceReloadedMessage", func(msg engo.Message) {
+		m, ok := msg.(engo.ResourceReloadedMessage)
+		if !ok || m.Err != nil {
+			return
+		}
+		rs.reloadFonts(m.URL)
+	})
 }
 
 var cameraInitMutex sync.Mutex
 
+// cameraWorld is the World whose camera the shaders currently use.
+var cameraWorld *ecs.World
+
 func addCameraSystemOnce(w *ecs.World) {
 	cameraInitMutex.Lock()
 	defer cameraInitMutex.Unlock()
@@ -281,6 +295,9 @@ func (rs *RenderSystem) Add(basic *ecs.BasicEntity, render *RenderComponent, spa
 			render.shader = LegacyHUDShader
 		case Text:
 			render.shader = TextHUDShader
+			if render.Drawable.(Text).Font.SDF {
+				render.shader = SDFTextHUDShader
+			}
 		default:
 			render.shader = HUDShader
 		}
@@ -331,6 +348,12 @@ func (rs *RenderSystem) Remove(basic ecs.BasicEntity) {
 
 // Update draws the entities in the RenderSystem to the OpenGL Surface.
 func (rs *RenderSystem) Update(dt float32) {
+	rs.Render()
+}
+
+// Render draws the entities in the RenderSystem to the OpenGL Surface. It is called by Update, and by engo when the
+// Scene is drawn underneath other Scenes of the stack without being updated.
+func (rs *RenderSystem) Render() {
 	if engo.Headless() {
 		return
 	}
@@ -340,12 +363,23 @@ func (rs *RenderSystem) Update(dt float32) {
 		rs.sortingNeeded = false
 	}
 
-	if rs.newCamera {
+	// The shaders are shared by all Scenes, so they need the camera of this one if another Scene was drawn before
+	if rs.newCamera || cameraWorld != rs.world {
 		newCamera(rs.world)
+		cameraWorld = rs.world
 		rs.newCamera = false
 	}
 
-	engo.Gl.Clear(engo.Gl.COLOR_BUFFER_BIT)
+	// Only the first Scene drawn in a frame clears the screen, the others are drawn on top of it
+	layer := engo.CurrentSceneLayer()
+	if layer.Index == 0 {
+		engo.Gl.Clear(engo.Gl.COLOR_BUFFER_BIT)
+	}
+
+	defer endTransition(layer)
+	if !beginTransition(layer) {
+		return
+	}
 
 	preparedCullingShaders := make(map[CullingShader]struct{})
 	var cullingShader CullingShader // current culling shader
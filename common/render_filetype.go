@@ -39,16 +39,71 @@ func (t TextureResource) URL() string {
 	return t.url
 }
 
+// Size returns the amount of bytes the texture uses on the GPU.
+func (t TextureResource) Size() int64 {
+	return int64(t.Width) * int64(t.Height) * 4
+}
+
+// Category returns engo.CategoryTexture.
+func (t TextureResource) Category() string {
+	return engo.CategoryTexture
+}
+
 type imageLoader struct {
 	images map[string]TextureResource
 }
 
 func (i *imageLoader) Load(url string, data io.Reader) error {
-	var res TextureResource
+	finish, err := i.Decode(url, data)
+	if err != nil {
+		return err
+	}
+	return finish()
+}
+
+// Decode decodes the image, the returned function uploads it to the GPU
+func (i *imageLoader) Decode(url string, data io.Reader) (func() error, error) {
+	newm, err := decodeImage(url, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		res := NewTextureResource(&ImageObject{newm})
+		res.url = url
+		i.images[url] = res
+		return nil
+	}, nil
+}
+
+// Reload uploads the modified image to the texture that is already used for it, so that the Textures created from it
+// show the new image.
+func (i *imageLoader) Reload(url string, data io.Reader) error {
+	newm, err := decodeImage(url, data)
+	if err != nil {
+		return err
+	}
+
+	res, ok := i.images[url]
+	if !ok || res.Texture == nil {
+		res = NewTextureResource(&ImageObject{newm})
+		res.url = url
+		i.images[url] = res
+		return nil
+	}
+	engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, res.Texture)
+	engo.Gl.TexImage2D(engo.Gl.TEXTURE_2D, 0, engo.Gl.RGBA, engo.Gl.RGBA, engo.Gl.UNSIGNED_BYTE, newm)
+	res.Width, res.Height = float32(newm.Rect.Dx()), float32(newm.Rect.Dy())
+	i.images[url] = res
+	return nil
+}
+
+// decodeImage decodes a raster or svg image
+func decodeImage(url string, data io.Reader) (*image.NRGBA, error) {
 	if getExt(url) == ".svg" {
 		icon, err := oksvg.ReadIconStream(data, oksvg.WarnErrorMode)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		w, h := int(icon.ViewBox.W), int(icon.ViewBox.H)
 		img := image.NewRGBA(image.Rect(0, 0, w, h))
@@ -58,21 +113,17 @@ func (i *imageLoader) Load(url string, data io.Reader) error {
 		b := img.Bounds()
 		newm := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
 		draw.Draw(newm, newm.Bounds(), img, b.Min, draw.Src)
-		res = NewTextureResource(&ImageObject{newm})
-	} else {
-		img, _, err := image.Decode(data)
-		if err != nil {
-			return err
-		}
-		b := img.Bounds()
-		newm := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-		draw.Draw(newm, newm.Bounds(), img, b.Min, draw.Src)
-		res = NewTextureResource(&ImageObject{newm})
+		return newm, nil
 	}
-	res.url = url
-	i.images[url] = res
 
-	return nil
+	img, _, err := image.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	newm := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(newm, newm.Bounds(), img, b.Min, draw.Src)
+	return newm, nil
 }
 
 func (i *imageLoader) Unload(url string) error {
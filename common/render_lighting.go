@@ -0,0 +1,214 @@
+package common
+
+import (
+	"image/color"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/gl"
+)
+
+const (
+	lightVertexShader = `
+	attribute vec2 in_Position;
+
+	uniform mat3 matrixProjView;
+
+	varying vec2 var_Position;
+
+	void main() {
+	  var_Position = in_Position;
+
+	  vec3 matr = matrixProjView * vec3(in_Position, 1.0);
+	  gl_Position = vec4(matr.xy, 0, matr.z);
+	}
+`
+
+	lightFragmentShader = `
+	#ifdef GL_ES
+	#define LOWP lowp
+	precision mediump float;
+	#else
+	#define LOWP
+	#endif
+
+	varying vec2 var_Position;
+
+	uniform vec2 uf_Center;
+	uniform float uf_Radius;
+	uniform float uf_Falloff;
+	uniform vec4 uf_Color;
+
+	void main (void) {
+	  float attenuation = pow(clamp(1.0 - distance(var_Position, uf_Center) / uf_Radius, 0.0, 1.0), uf_Falloff);
+	  gl_FragColor = vec4(uf_Color.rgb * uf_Color.a * attenuation, 1.0);
+	}
+`
+)
+
+// lightUniforms are the locations of the uniforms and the attribute of the light shader.
+type lightUniforms struct {
+	matrixProjView, center, radius, falloff, color *gl.UniformLocation
+	position                                       int
+}
+
+// ambientQuad covers the whole light map, in the coordinates of the framebuffer.
+var ambientQuad = []float32{-1, -1, 1, -1, 1, 1, -1, 1}
+
+type lightMapShader struct {
+	*basicShader
+}
+
+func (s *lightMapShader) Pre() {
+	s.basicShader.Pre()
+	engo.Gl.BlendFunc(engo.Gl.DST_COLOR, engo.Gl.ZERO)
+}
+
+// lightMap is the Drawable of the light map. Its texture is upside down, as the framebuffer is drawn with the y axis
+// pointing up.
+type lightMap struct {
+	tex *RenderTexture
+}
+
+// Texture returns the OpenGL ID of the Texture.
+func (m *lightMap) Texture() *gl.Texture {
+	if m.tex == nil {
+		return nil
+	}
+	return m.tex.Texture()
+}
+
+// Width returns the width of the texture.
+func (m *lightMap) Width() float32 {
+	if m.tex == nil {
+		return 0
+	}
+	return m.tex.Width()
+}
+
+// Height returns the height of the texture.
+func (m *lightMap) Height() float32 {
+	if m.tex == nil {
+		return 0
+	}
+	return m.tex.Height()
+}
+
+// View returns the viewport properties of the Texture, flipped vertically.
+func (m *lightMap) View() (float32, float32, float32, float32) {
+	return 0, 1, 1, 0
+}
+
+// Close does nothing, the texture is owned by the LightingSystem.
+func (m *lightMap) Close() {}
+
+type lightMapEntity struct {
+	ecs.BasicEntity
+	RenderComponent
+	SpaceComponent
+}
+
+// render renders the lights into the light map, and scales the light map to cover the screen.
+func (s *LightingSystem) render() {
+	w, h := int(engo.CanvasWidth()), int(engo.CanvasHeight())
+	if w <= 0 || h <= 0 {
+		return
+	}
+	if s.texture == nil || int(s.texture.Width()) != w || int(s.texture.Height()) != h {
+		if s.texture != nil {
+			s.texture.Close()
+		}
+		s.texture = CreateRenderTexture(w, h, false)
+		s.lightMap.Drawable.(*lightMap).tex = s.texture
+	}
+
+	s.framebuffer.Open(w, h)
+	s.texture.Bind()
+
+	u := s.uniforms
+	engo.Gl.UseProgram(s.program)
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, s.buffer)
+	engo.Gl.EnableVertexAttribArray(u.position)
+
+	// The light map is filled with the ambient light by drawing it over all of it, rather than by clearing it, so
+	// that the clear color of the screen stays the same
+	ambient := s.Ambient
+	if ambient == nil {
+		ambient = color.Black
+	}
+	r, g, b, _ := ambient.RGBA()
+	engo.Gl.Disable(engo.Gl.BLEND)
+	engo.Gl.UniformMatrix3fv(u.matrixProjView, false, engo.IdentityMatrix().Val[:])
+	engo.Gl.Uniform4f(u.color, float32(r)/0xffff, float32(g)/0xffff, float32(b)/0xffff, 1)
+	engo.Gl.Uniform2f(u.center, 0, 0)
+	engo.Gl.Uniform1f(u.radius, 2)
+	engo.Gl.Uniform1f(u.falloff, 0)
+	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ambientQuad, engo.Gl.DYNAMIC_DRAW)
+	engo.Gl.VertexAttribPointer(u.position, 2, engo.Gl.FLOAT, false, 0, 0)
+	engo.Gl.DrawArrays(engo.Gl.TRIANGLE_FAN, 0, len(ambientQuad)/2)
+
+	engo.Gl.Enable(engo.Gl.BLEND)
+	engo.Gl.BlendFunc(engo.Gl.ONE, engo.Gl.ONE)
+	engo.Gl.UniformMatrix3fv(u.matrixProjView, false, s.projView().Val[:])
+
+	for _, l := range s.lights {
+		if len(l.polygon) == 0 {
+			continue
+		}
+		// the polygon is drawn as a fan around the center of the light, which is closed for point lights
+		vertices := []float32{l.center.X, l.center.Y}
+		for _, p := range l.polygon {
+			vertices = append(vertices, p.X, p.Y)
+		}
+		if !l.Spot() {
+			vertices = append(vertices, l.polygon[0].X, l.polygon[0].Y)
+		}
+		engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, vertices, engo.Gl.DYNAMIC_DRAW)
+		engo.Gl.VertexAttribPointer(u.position, 2, engo.Gl.FLOAT, false, 0, 0)
+
+		c := l.Color
+		if c == nil {
+			c = color.White
+		}
+		r, g, b, a := c.RGBA()
+		engo.Gl.Uniform4f(u.color, float32(r)/0xffff, float32(g)/0xffff, float32(b)/0xffff, float32(a)/0xffff)
+		engo.Gl.Uniform2f(u.center, l.center.X, l.center.Y)
+		engo.Gl.Uniform1f(u.radius, l.Radius)
+		if l.Falloff > 0 {
+			engo.Gl.Uniform1f(u.falloff, l.Falloff)
+		} else {
+			engo.Gl.Uniform1f(u.falloff, 1)
+		}
+		engo.Gl.DrawArrays(engo.Gl.TRIANGLE_FAN, 0, len(vertices)/2)
+	}
+
+	engo.Gl.DisableVertexAttribArray(u.position)
+	engo.Gl.BindBuffer(engo.Gl.ARRAY_BUFFER, nil)
+	engo.Gl.Disable(engo.Gl.BLEND)
+	s.framebuffer.Close()
+
+	// the light map covers the screen like the HUD, in the same way as the fade of transitions
+	sw, sh := engo.GameWidth(), engo.GameHeight()
+	if !engo.ScaleOnResize() {
+		sw, sh = engo.CanvasWidth()/engo.CanvasScale(), engo.CanvasHeight()/engo.CanvasScale()
+	}
+	scale := engo.GetGlobalScale()
+	s.lightMap.Scale = engo.Point{X: sw / (scale.X * float32(w)), Y: sh / (scale.Y * float32(h))}
+	s.lightMap.SpaceComponent = SpaceComponent{Width: sw / scale.X, Height: sh / scale.Y}
+	s.lightMap.Hidden = false
+}
+
+// projView returns the matrix converting space coordinates to the ones of the framebuffer, which are the same as the
+// ones of the screen.
+func (s *LightingSystem) projView() *engo.Matrix {
+	m := engo.IdentityMatrix()
+	if engo.ScaleOnResize() {
+		m.Scale(1/(engo.GameWidth()/2), 1/(-engo.GameHeight()/2))
+	} else {
+		m.Scale(1/(engo.CanvasWidth()/(2*engo.CanvasScale())), 1/(-engo.CanvasHeight()/(2*engo.CanvasScale())))
+	}
+	m.Scale(1/s.camera.Z(), 1/s.camera.Z())
+	m.Translate(-s.camera.X(), -s.camera.Y()).Rotate(s.camera.Angle())
+	m.Scale(engo.GetGlobalScale().X, engo.GetGlobalScale().Y)
+	return m
+}
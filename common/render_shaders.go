@@ -29,10 +29,18 @@ var (
 	TextShader = &textShader{cameraEnabled: true}
 	// TextHUDShader is the shader used to draw fonts from a FontAtlas on the HUD.
 	TextHUDShader = &textShader{cameraEnabled: false}
+	// SDFTextShader is the shader used to draw fonts from a signed distance field FontAtlas.
+	SDFTextShader = &textShader{cameraEnabled: true, distanceField: true}
+	// SDFTextHUDShader is the shader used to draw fonts from a signed distance field FontAtlas on the HUD.
+	SDFTextHUDShader = &textShader{cameraEnabled: false, distanceField: true}
 	// BlendmapShader is a shader used to create blendmaps
 	BlendmapShader = &blendmapShader{cameraEnabled: true}
+	// LightMapShader is the shader used to draw the light map of the LightingSystem. It multiplies the colors drawn
+	// underneath it with the ones of the light map.
+	LightMapShader = &lightMapShader{basicShader: &basicShader{cameraEnabled: false}}
 	shadersSet     bool
 	atlasCache     = make(map[Font]FontAtlas)
+	layoutCache    = make(map[layoutKey]layoutEntry)
 	shaders        = []Shader{
 		DefaultShader,
 		HUDShader,
@@ -40,7 +48,10 @@ var (
 		LegacyHUDShader,
 		TextShader,
 		TextHUDShader,
+		SDFTextShader,
+		SDFTextHUDShader,
 		BlendmapShader,
+		LightMapShader,
 	}
 )
 
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"image/color"
+
 	"github.com/EngoEngine/ecs"
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/math"
@@ -25,16 +27,23 @@ type textShader struct {
 	viewMatrix       []float32
 	modelMatrix      []float32
 
+	smoothing    *gl.UniformLocation
+	outlineWidth *gl.UniformLocation
+	outlineColor *gl.UniformLocation
+	shadowOffset *gl.UniformLocation
+	shadowColor  *gl.UniformLocation
+	glowWidth    *gl.UniformLocation
+	glowColor    *gl.UniformLocation
+
 	camera        *CameraSystem
 	cameraEnabled bool
+	distanceField bool
 
 	lastBuffer  *gl.Buffer
 	lastTexture *gl.Texture
 }
 
-func (l *textShader) Setup(w *ecs.World) error {
-	var err error
-	l.program, err = LoadShader(`
+const textVertexShader = `
 attribute vec2 in_Position;
 attribute vec2 in_TexCoords;
 attribute vec4 in_Color;
@@ -53,7 +62,9 @@ void main() {
   vec3 matr = matrixProjection * matrixView * matrixModel * vec3(in_Position, 1.0);
   gl_Position = vec4(matr.xy, 0, matr.z);
 }
-`, `
+`
+
+const textFragmentShader = `
 #ifdef GL_ES
 #define LOWP lowp
 precision mediump float;
@@ -68,7 +79,67 @@ uniform sampler2D uf_Texture;
 
 void main (void) {
   gl_FragColor = var_Color * texture2D(uf_Texture, var_TexCoords);
-}`)
+}`
+
+// textDistanceFieldFragmentShader draws text from a signed distance field atlas, where the alpha channel
+// contains the distance to the edge of the glyph. All widths are in the same units as that distance.
+const textDistanceFieldFragmentShader = `
+#ifdef GL_ES
+#define LOWP lowp
+precision mediump float;
+#else
+#define LOWP
+#endif
+
+varying vec4 var_Color;
+varying vec2 var_TexCoords;
+
+uniform sampler2D uf_Texture;
+uniform float uf_Smoothing;
+uniform float uf_OutlineWidth;
+uniform vec4 uf_OutlineColor;
+uniform vec2 uf_ShadowOffset;
+uniform vec4 uf_ShadowColor;
+uniform float uf_GlowWidth;
+uniform vec4 uf_GlowColor;
+
+// over composites src over dst, both with straight alpha.
+vec4 over(vec4 src, vec4 dst) {
+  float a = src.a + dst.a * (1.0 - src.a);
+  if (a <= 0.0) {
+    return vec4(0.0);
+  }
+  return vec4((src.rgb * src.a + dst.rgb * dst.a * (1.0 - src.a)) / a, a);
+}
+
+void main (void) {
+  vec4 texel = texture2D(uf_Texture, var_TexCoords);
+  float dist = texel.a;
+
+  vec4 fill = var_Color * vec4(texel.rgb, 1.0);
+  fill.a *= smoothstep(0.5 - uf_Smoothing, 0.5 + uf_Smoothing, dist);
+
+  float outlineEdge = 0.5 - uf_OutlineWidth;
+  vec4 outline = uf_OutlineColor;
+  outline.a *= smoothstep(outlineEdge - uf_Smoothing, outlineEdge + uf_Smoothing, dist);
+
+  float shadowDist = texture2D(uf_Texture, var_TexCoords - uf_ShadowOffset).a;
+  vec4 shadow = uf_ShadowColor;
+  shadow.a *= smoothstep(outlineEdge - uf_Smoothing, outlineEdge + uf_Smoothing, shadowDist);
+
+  vec4 glow = uf_GlowColor;
+  glow.a *= smoothstep(0.5 - uf_GlowWidth, 0.5, dist);
+
+  gl_FragColor = over(fill, over(outline, over(shadow, glow)));
+}`
+
+func (l *textShader) Setup(w *ecs.World) error {
+	var err error
+	if l.distanceField {
+		l.program, err = LoadShader(textVertexShader, textDistanceFieldFragmentShader)
+	} else {
+		l.program, err = LoadShader(textVertexShader, textFragmentShader)
+	}
 
 	if err != nil {
 		return err
@@ -98,6 +169,16 @@ void main (void) {
 	l.matrixView = engo.Gl.GetUniformLocation(l.program, "matrixView")
 	l.matrixModel = engo.Gl.GetUniformLocation(l.program, "matrixModel")
 
+	if l.distanceField {
+		l.smoothing = engo.Gl.GetUniformLocation(l.program, "uf_Smoothing")
+		l.outlineWidth = engo.Gl.GetUniformLocation(l.program, "uf_OutlineWidth")
+		l.outlineColor = engo.Gl.GetUniformLocation(l.program, "uf_OutlineColor")
+		l.shadowOffset = engo.Gl.GetUniformLocation(l.program, "uf_ShadowOffset")
+		l.shadowColor = engo.Gl.GetUniformLocation(l.program, "uf_ShadowColor")
+		l.glowWidth = engo.Gl.GetUniformLocation(l.program, "uf_GlowWidth")
+		l.glowColor = engo.Gl.GetUniformLocation(l.program, "uf_GlowColor")
+	}
+
 	l.projectionMatrix = make([]float32, 9)
 	l.projectionMatrix[8] = 1
 
@@ -156,14 +237,16 @@ func (l *textShader) updateBuffer(ren *RenderComponent, space *SpaceComponent) {
 		return
 	}
 
-	if len(ren.BufferContent) < 20*len(txt.Text) {
-		ren.BufferContent = make([]float32, 20*len(txt.Text)) // TODO: update this to actual value?
+	atlas, layout := txt.cached()
+
+	if len(ren.BufferContent) < 20*len(layout.glyphs) {
+		ren.BufferContent = make([]float32, 20*len(layout.glyphs))
 	}
 	// Reset buffer so artifacts don't occur when txt.Text changes
 	for i := 0; i < len(ren.BufferContent); i++ {
 		ren.BufferContent[i] = 0
 	}
-	if changed := l.generateBufferContent(ren, space, ren.BufferContent); !changed {
+	if changed := l.generateBufferContent(ren, txt, atlas, layout, ren.BufferContent); !changed {
 		return
 	}
 
@@ -174,76 +257,55 @@ func (l *textShader) updateBuffer(ren *RenderComponent, space *SpaceComponent) {
 	engo.Gl.BufferData(engo.Gl.ARRAY_BUFFER, ren.BufferContent, engo.Gl.STATIC_DRAW)
 }
 
-func (l *textShader) generateBufferContent(ren *RenderComponent, space *SpaceComponent, buffer []float32) bool {
+func (l *textShader) generateBufferContent(ren *RenderComponent, txt Text, atlas FontAtlas, layout textLayout, buffer []float32) bool {
 	var changed bool
 
 	tint := colorToFloat32(ren.Color)
-	txt, ok := ren.Drawable.(Text)
-	if !ok {
-		unsupportedType(ren.Drawable)
-		return false
-	}
 
-	atlas, ok := atlasCache[*txt.Font]
-	if !ok {
-		// Generate texture first
-		atlas = txt.Font.generateFontAtlas(UnicodeCap)
-		atlasCache[*txt.Font] = atlas
-	}
+	letterSpace := float32(txt.Font.Size) * txt.LetterSpacing
+	_, _, _, xHeight := atlas.Glyph('X')
+	lineSpace := txt.LineSpacing * xHeight
 
-	var currentX float32
-	var currentY float32
+	pad := atlas.Padding
 
-	var modifier float32 = 1
-	if txt.RightToLeft {
-		modifier = -1
-	}
-
-	letterSpace := float32(txt.Font.Size) * txt.LetterSpacing
-	lineSpace := txt.LineSpacing * atlas.Height['X']
-
-	for index, char := range txt.Text {
-		// TODO: this might not work for all characters
-		switch {
-		case char == '\n':
-			currentX = 0
-			currentY += atlas.Height['X'] + lineSpace
-			continue
-		case char < 32: // all system stuff should be ignored
-			continue
-		}
+	for index, glyph := range layout.glyphs {
+		x, y, width, height := atlas.Glyph(glyph.char)
+		x1, y1 := glyph.x-pad, glyph.y-pad
+		x2 := glyph.x + width + letterSpace + pad
+		y2 := glyph.y + height + lineSpace + pad
+		u1, v1 := (x-pad)/atlas.TotalWidth, (y-pad)/atlas.TotalHeight
+		u2 := (x + width + pad) / atlas.TotalWidth
+		v2 := (y + height + pad) / atlas.TotalHeight
 
 		offset := 20 * index
 
 		// These five are at 0, 0:
-		setBufferValue(buffer, 0+offset, currentX, &changed)
-		setBufferValue(buffer, 1+offset, currentY, &changed)
-		setBufferValue(buffer, 2+offset, atlas.XLocation[char]/atlas.TotalWidth, &changed)
-		setBufferValue(buffer, 3+offset, atlas.YLocation[char]/atlas.TotalHeight, &changed)
+		setBufferValue(buffer, 0+offset, x1, &changed)
+		setBufferValue(buffer, 1+offset, y1, &changed)
+		setBufferValue(buffer, 2+offset, u1, &changed)
+		setBufferValue(buffer, 3+offset, v1, &changed)
 		setBufferValue(buffer, 4+offset, tint, &changed)
 
 		// These five are at 1, 0:
-		setBufferValue(buffer, 5+offset, currentX+atlas.Width[char]+letterSpace, &changed)
-		setBufferValue(buffer, 6+offset, currentY, &changed)
-		setBufferValue(buffer, 7+offset, (atlas.XLocation[char]+atlas.Width[char])/atlas.TotalWidth, &changed)
-		setBufferValue(buffer, 8+offset, atlas.YLocation[char]/atlas.TotalHeight, &changed)
+		setBufferValue(buffer, 5+offset, x2, &changed)
+		setBufferValue(buffer, 6+offset, y1, &changed)
+		setBufferValue(buffer, 7+offset, u2, &changed)
+		setBufferValue(buffer, 8+offset, v1, &changed)
 		setBufferValue(buffer, 9+offset, tint, &changed)
 
 		// These five are at 1, 1:
-		setBufferValue(buffer, 10+offset, currentX+atlas.Width[char]+letterSpace, &changed)
-		setBufferValue(buffer, 11+offset, currentY+atlas.Height[char]+lineSpace, &changed)
-		setBufferValue(buffer, 12+offset, (atlas.XLocation[char]+atlas.Width[char])/atlas.TotalWidth, &changed)
-		setBufferValue(buffer, 13+offset, (atlas.YLocation[char]+atlas.Height[char])/atlas.TotalHeight, &changed)
+		setBufferValue(buffer, 10+offset, x2, &changed)
+		setBufferValue(buffer, 11+offset, y2, &changed)
+		setBufferValue(buffer, 12+offset, u2, &changed)
+		setBufferValue(buffer, 13+offset, v2, &changed)
 		setBufferValue(buffer, 14+offset, tint, &changed)
 
 		// These five are at 0, 1:
-		setBufferValue(buffer, 15+offset, currentX, &changed)
-		setBufferValue(buffer, 16+offset, currentY+atlas.Height[char]+lineSpace, &changed)
-		setBufferValue(buffer, 17+offset, atlas.XLocation[char]/atlas.TotalWidth, &changed)
-		setBufferValue(buffer, 18+offset, (atlas.YLocation[char]+atlas.Height[char])/atlas.TotalHeight, &changed)
+		setBufferValue(buffer, 15+offset, x1, &changed)
+		setBufferValue(buffer, 16+offset, y2, &changed)
+		setBufferValue(buffer, 17+offset, u1, &changed)
+		setBufferValue(buffer, 18+offset, v2, &changed)
 		setBufferValue(buffer, 19+offset, tint, &changed)
-
-		currentX += modifier * (atlas.Width[char] + letterSpace)
 	}
 
 	return changed
@@ -253,6 +315,7 @@ func (l *textShader) Draw(ren *RenderComponent, space *SpaceComponent) {
 	txt, ok := ren.Drawable.(Text)
 	if !ok {
 		unsupportedType(ren.Drawable)
+		return
 	}
 
 	if l.lastBuffer != ren.Buffer || ren.Buffer == nil {
@@ -266,13 +329,7 @@ func (l *textShader) Draw(ren *RenderComponent, space *SpaceComponent) {
 		l.lastBuffer = ren.Buffer
 	}
 
-	atlas, ok := atlasCache[*txt.Font]
-	if !ok {
-		// Generate texture first
-		atlas = txt.Font.generateFontAtlas(UnicodeCap)
-		atlasCache[*txt.Font] = atlas
-	}
-
+	atlas, layout := txt.cached()
 	if atlas.Texture != l.lastTexture {
 		engo.Gl.BindTexture(engo.Gl.TEXTURE_2D, atlas.Texture)
 		l.lastTexture = atlas.Texture
@@ -300,7 +357,50 @@ func (l *textShader) Draw(ren *RenderComponent, space *SpaceComponent) {
 
 	engo.Gl.UniformMatrix3fv(l.matrixModel, false, l.modelMatrix)
 
-	engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*len(txt.Text), engo.Gl.UNSIGNED_SHORT, 0)
+	if l.distanceField {
+		l.setEffects(ren, txt, atlas)
+	}
+
+	engo.Gl.DrawElements(engo.Gl.TRIANGLES, 6*len(layout.glyphs), engo.Gl.UNSIGNED_SHORT, 0)
+}
+
+// setEffects sets the uniforms used to draw the signed distance field and the TextEffects of the Text.
+func (l *textShader) setEffects(ren *RenderComponent, txt Text, atlas FontAtlas) {
+	// One pixel on the screen is this many pixels in the atlas
+	scale := ren.Scale.X * engo.GetGlobalScale().X
+	if l.cameraEnabled && l.camera != nil && l.camera.z != 0 {
+		scale /= l.camera.z
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+
+	// Distances in the atlas are mapped so that `SDFSpread` pixels equals 0.5
+	toDist := func(pixels float32) float32 {
+		return math.Min(pixels, SDFSpread) / (2 * SDFSpread)
+	}
+	setColor := func(location *gl.UniformLocation, c color.Color) {
+		if c == nil {
+			engo.Gl.Uniform4f(location, 0, 0, 0, 0)
+			return
+		}
+		r, g, b, a := c.RGBA()
+		if a == 0 {
+			engo.Gl.Uniform4f(location, 0, 0, 0, 0)
+			return
+		}
+		// Convert from premultiplied to straight alpha
+		engo.Gl.Uniform4f(location, float32(r)/float32(a), float32(g)/float32(a), float32(b)/float32(a), float32(a)/0xffff)
+	}
+
+	fx := txt.Effects
+	engo.Gl.Uniform1f(l.smoothing, toDist(0.5/scale))
+	engo.Gl.Uniform1f(l.outlineWidth, toDist(fx.OutlineWidth))
+	setColor(l.outlineColor, fx.OutlineColor)
+	engo.Gl.Uniform2f(l.shadowOffset, math.Min(fx.ShadowOffset.X, SDFSpread)/atlas.TotalWidth, math.Min(fx.ShadowOffset.Y, SDFSpread)/atlas.TotalHeight)
+	setColor(l.shadowColor, fx.ShadowColor)
+	engo.Gl.Uniform1f(l.glowWidth, toDist(fx.GlowWidth))
+	setColor(l.glowColor, fx.GlowColor)
 }
 
 func (l *textShader) Post() {
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"image/color"
+
+	"github.com/EngoEngine/engo"
+)
+
+// transitionViewport is the viewport that was active before a slide moved it.
+var transitionViewport [4]int32
+
+// transitionDirection returns the unit vector of the direction in OpenGL window coordinates, where y points up.
+func transitionDirection(d engo.TransitionDirection) (int, int) {
+	switch d {
+	case engo.TransitionRight:
+		return 1, 0
+	case engo.TransitionUp:
+		return 0, 1
+	case engo.TransitionDown:
+		return 0, -1
+	}
+	return -1, 0
+}
+
+// transitionShown returns how much of a Scene is visible during a slide or a wipe, from 0 to 1.
+func transitionShown(layer engo.SceneLayer) float32 {
+	if layer.Leaving {
+		return 1 - layer.Progress
+	}
+	return layer.Progress
+}
+
+// beginTransition applies the effect of the transition to the draw calls of the layer. It returns false if the
+// layer should not be drawn at all.
+func beginTransition(layer engo.SceneLayer) bool {
+	if !layer.Entering && !layer.Leaving {
+		return true
+	}
+
+	switch layer.Transition.Effect {
+	case engo.TransitionFade:
+		// The screen is covered by the fade color halfway, that's when the Scenes are switched
+		if layer.Entering {
+			return layer.Progress >= 0.5
+		}
+		return layer.Progress < 0.5
+	case engo.TransitionSlide:
+		transitionViewport = engo.Gl.GetViewport()
+		x, y, w, h := int(transitionViewport[0]), int(transitionViewport[1]), int(transitionViewport[2]), int(transitionViewport[3])
+		dx, dy := transitionDirection(layer.Transition.Direction)
+		// Entering Scenes come from the opposite side, leaving Scenes move towards the direction
+		offset := 1 - transitionShown(layer)
+		if layer.Entering {
+			offset = -offset
+		}
+		engo.Gl.Viewport(x+int(float32(dx*w)*offset), y+int(float32(dy*h)*offset), w, h)
+	case engo.TransitionWipe:
+		vp := engo.Gl.GetViewport()
+		x, y, w, h := int(vp[0]), int(vp[1]), int(vp[2]), int(vp[3])
+		shown := transitionShown(layer)
+		dx, dy := transitionDirection(layer.Transition.Direction)
+		// The edge moves towards the direction, revealing entering Scenes behind it and hiding leaving ones
+		front := layer.Entering
+		if dx > 0 || dy > 0 {
+			front = !front
+		}
+		sw, sh := w, h
+		if dx != 0 {
+			sw = int(float32(w) * shown)
+			if front {
+				x += w - sw
+			}
+		} else {
+			sh = int(float32(h) * shown)
+			if front {
+				y += h - sh
+			}
+		}
+		engo.Gl.Enable(engo.Gl.SCISSOR_TEST)
+		engo.Gl.Scissor(x, y, sw, sh)
+	}
+	return true
+}
+
+// endTransition restores the state changed by beginTransition. The fade color is drawn over the last layer.
+func endTransition(layer engo.SceneLayer) {
+	if layer.Entering || layer.Leaving {
+		switch layer.Transition.Effect {
+		case engo.TransitionSlide:
+			vp := transitionViewport
+			engo.Gl.Viewport(int(vp[0]), int(vp[1]), int(vp[2]), int(vp[3]))
+		case engo.TransitionWipe:
+			engo.Gl.Disable(engo.Gl.SCISSOR_TEST)
+		}
+	}
+
+	if layer.Transition.Effect == engo.TransitionFade && layer.Index == layer.Count-1 {
+		alpha := 2 * layer.Progress
+		if alpha > 1 {
+			alpha = 2 - alpha
+		}
+		drawFade(layer.Transition.Color, alpha)
+	}
+}
+
+// drawFade covers the screen with the color at the given opacity.
+func drawFade(c color.Color, alpha float32) {
+	if c == nil {
+		c = color.Black
+	}
+	r, g, b, _ := c.RGBA()
+
+	w, h := engo.GameWidth(), engo.GameHeight()
+	if !engo.ScaleOnResize() {
+		w, h = engo.CanvasWidth()/engo.CanvasScale(), engo.CanvasHeight()/engo.CanvasScale()
+	}
+	ren := &RenderComponent{
+		Drawable: Rectangle{},
+		Color:    color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(alpha * 255)},
+		Scale:    engo.Point{X: 1, Y: 1},
+	}
+	space := &SpaceComponent{Width: w / engo.GetGlobalScale().X, Height: h / engo.GetGlobalScale().Y}
+
+	LegacyHUDShader.Pre()
+	LegacyHUDShader.Draw(ren, space)
+	LegacyHUDShader.Post()
+	engo.Gl.DeleteBuffer(ren.Buffer)
+}
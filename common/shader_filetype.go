@@ -0,0 +1,83 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/gl"
+)
+
+// ShaderSourceResource contains the GLSL source of a `.vert` or `.frag` file.
+type ShaderSourceResource struct {
+	Source string
+	url    string
+}
+
+// URL returns the file path of the ShaderSourceResource.
+func (s ShaderSourceResource) URL() string {
+	return s.url
+}
+
+// shaderSourceLoader is responsible for managing `.vert` and `.frag` files within `engo.Files`
+type shaderSourceLoader struct {
+	sources map[string]ShaderSourceResource
+}
+
+// Load reads the shader source
+func (s *shaderSourceLoader) Load(url string, data io.Reader) error {
+	src, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.sources[url] = ShaderSourceResource{Source: string(src), url: url}
+	return nil
+}
+
+// Unload removes the shader source from the cache
+func (s *shaderSourceLoader) Unload(url string) error {
+	delete(s.sources, url)
+	return nil
+}
+
+// Resource retrieves the shader source, passed as a `ShaderSourceResource`
+func (s *shaderSourceLoader) Resource(url string) (engo.Resource, error) {
+	src, ok := s.sources[url]
+	if !ok {
+		return nil, fmt.Errorf("resource not loaded by `FileLoader`: %q", url)
+	}
+	return src, nil
+}
+
+// LoadedShader compiles the preloaded vertex and fragment shader sources into a program, see `LoadShader`. Shaders
+// that listen for `engo.ResourceReloadedMessage` can call it again to use the modified sources while hot reloading.
+func LoadedShader(vertURL, fragURL string) (*gl.Program, error) {
+	vert, err := loadedShaderSource(vertURL)
+	if err != nil {
+		return nil, err
+	}
+	frag, err := loadedShaderSource(fragURL)
+	if err != nil {
+		return nil, err
+	}
+	return LoadShader(vert, frag)
+}
+
+func loadedShaderSource(url string) (string, error) {
+	res, err := engo.Files.Resource(url)
+	if err != nil {
+		return "", err
+	}
+	src, ok := res.(ShaderSourceResource)
+	if !ok {
+		return "", fmt.Errorf("resource not of type `ShaderSourceResource`: %s", url)
+	}
+	return src.Source, nil
+}
+
+func init() {
+	loader := &shaderSourceLoader{sources: make(map[string]ShaderSourceResource)}
+	engo.Files.Register(".vert", loader)
+	engo.Files.Register(".frag", loader)
+}
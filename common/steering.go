@@ -0,0 +1,267 @@
+package common
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// SteeringComponent moves an entity using steering behaviors, like seeking a
+// point, following a path or flocking with other entities. The steering forces
+// of the behaviors are multiplied with their weights and added, so they can be
+// combined.
+type SteeringComponent struct {
+	// Velocity is the velocity of the entity in units per second
+	Velocity engo.Point
+	// MaxSpeed is the maximum speed of the entity in units per second
+	MaxSpeed float32
+	// MaxForce is the maximum change of the velocity in units per second
+	// squared. The velocity changes without limit if it is 0.
+	MaxForce float32
+	// MaxTurnRate is the maximum change of the direction of the velocity in
+	// degrees per second. The direction changes without limit if it is 0.
+	MaxTurnRate float32
+	// Radius is the radius of the entity, used to avoid obstacles. It is half
+	// of the larger side of the entity's SpaceComponent if 0.
+	Radius float32
+	// Rotate is whether the Rotation of the entity's SpaceComponent is set to
+	// the direction of its velocity, with 0 being to the right.
+	Rotate bool
+	// Behaviors are the behaviors steering the entity
+	Behaviors []WeightedBehavior
+}
+
+// WeightedBehavior is a steering behavior with the weight its steering force
+// is multiplied with.
+type WeightedBehavior struct {
+	Behavior SteeringBehavior
+	Weight   float32
+}
+
+// SteeringBehavior is a behavior which steers an entity of the SteeringSystem.
+type SteeringBehavior interface {
+	// Steer returns the steering force, which is the change of velocity in
+	// units per second squared the behavior wants for the agent.
+	Steer(agent *SteeringAgent, dt float32) engo.Point
+}
+
+// SteeringAgent is an entity of the SteeringSystem, which is passed to the
+// behaviors steering it.
+type SteeringAgent struct {
+	*ecs.BasicEntity
+	*SteeringComponent
+	*SpaceComponent
+
+	system *SteeringSystem
+	force  engo.Point
+}
+
+// Position returns the center of the agent.
+func (a *SteeringAgent) Position() engo.Point {
+	return a.SpaceComponent.Center()
+}
+
+// Size returns the radius of the agent.
+func (a *SteeringAgent) Size() float32 {
+	if a.Radius > 0 {
+		return a.Radius
+	}
+	return math.Max(a.Width, a.Height) / 2
+}
+
+// Neighbours returns the other agents of the SteeringSystem whose centers are
+// within the radius of the agent's center.
+func (a *SteeringAgent) Neighbours(radius float32) []*SteeringAgent {
+	var ret []*SteeringAgent
+	pos := a.Position()
+	for _, other := range a.system.agents {
+		if other == a {
+			continue
+		}
+		if pos.PointDistanceSquared(other.Position()) <= radius*radius {
+			ret = append(ret, other)
+		}
+	}
+	return ret
+}
+
+// Obstacles returns the SpaceComponents of the obstacles of the SteeringSystem
+// which overlap the area, and belong to one of the groups. All obstacles
+// overlapping the area are returned if groups is 0. The agent itself is never
+// returned.
+func (a *SteeringAgent) Obstacles(area engo.AABB, groups CollisionGroup) []*SpaceComponent {
+	if a.system.tree == nil {
+		return nil
+	}
+	var ret []*SpaceComponent
+	found := a.system.tree.Retrieve(area, func(aabb engo.AABBer) bool {
+		o := aabb.(*steeringObstacle)
+		return o.BasicEntity.ID() != a.BasicEntity.ID() && (groups == 0 || o.Group&groups != 0)
+	})
+	for _, o := range found {
+		ret = append(ret, o.(*steeringObstacle).SpaceComponent)
+	}
+	return ret
+}
+
+// Lines returns the Lines of the SteeringSystem which have an end in the area
+// or cross it.
+func (a *SteeringAgent) Lines(area engo.AABB) []engo.Line {
+	var ret []engo.Line
+	for _, l := range a.system.Lines {
+		b := engo.AABB{
+			Min: engo.Point{X: math.Min(l.P1.X, l.P2.X), Y: math.Min(l.P1.Y, l.P2.Y)},
+			Max: engo.Point{X: math.Max(l.P1.X, l.P2.X), Y: math.Max(l.P1.Y, l.P2.Y)},
+		}
+		if b.Max.X >= area.Min.X && b.Min.X <= area.Max.X && b.Max.Y >= area.Min.Y && b.Min.Y <= area.Max.Y {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+type steeringObstacle struct {
+	*ecs.BasicEntity
+	*CollisionComponent
+	*SpaceComponent
+}
+
+// SteeringSystem moves the entities with a SteeringComponent according to
+// their steering behaviors, limited by their maximum speed, force and turn
+// rate. Entities added as obstacles are avoided by ObstacleAvoidance.
+type SteeringSystem struct {
+	// Lines are walls which are avoided by ObstacleAvoidance, like the
+	// collision lines of a level
+	Lines []engo.Line
+
+	agents    []*SteeringAgent
+	obstacles []*steeringObstacle
+	tree      *engo.Quadtree
+}
+
+// Add adds an entity to the SteeringSystem. To be added, the entity has to have
+// a basic, steering, and space component.
+func (s *SteeringSystem) Add(basic *ecs.BasicEntity, steering *SteeringComponent, space *SpaceComponent) {
+	s.agents = append(s.agents, &SteeringAgent{BasicEntity: basic, SteeringComponent: steering, SpaceComponent: space, system: s})
+}
+
+// AddByInterface Provides a simple way to add an entity to the system that satisfies Steerable. Any entity containing, BasicEntity,SteeringComponent, and SpaceComponent anonymously, automatically does this.
+func (s *SteeringSystem) AddByInterface(i ecs.Identifier) {
+	o, _ := i.(Steerable)
+	s.Add(o.GetBasicEntity(), o.GetSteeringComponent(), o.GetSpaceComponent())
+}
+
+// AddObstacle adds an entity to be avoided by the entities of the
+// SteeringSystem. The Group of its collision component decides which
+// ObstacleAvoidance behaviors avoid it.
+func (s *SteeringSystem) AddObstacle(basic *ecs.BasicEntity, collision *CollisionComponent, space *SpaceComponent) {
+	s.obstacles = append(s.obstacles, &steeringObstacle{basic, collision, space})
+}
+
+// Remove removes an entity from the SteeringSystem, both as a steered entity
+// and as an obstacle.
+func (s *SteeringSystem) Remove(basic ecs.BasicEntity) {
+	for i, a := range s.agents {
+		if a.BasicEntity.ID() == basic.ID() {
+			s.agents = append(s.agents[:i], s.agents[i+1:]...)
+			break
+		}
+	}
+	for i, o := range s.obstacles {
+		if o.BasicEntity.ID() == basic.ID() {
+			s.obstacles = append(s.obstacles[:i], s.obstacles[i+1:]...)
+			break
+		}
+	}
+}
+
+// Update steers and moves the entities. The steering forces of all entities
+// are computed before any of them moves.
+func (s *SteeringSystem) Update(dt float32) {
+	s.buildTree()
+	for _, a := range s.agents {
+		a.force = engo.Point{}
+		for _, b := range a.Behaviors {
+			f := b.Behavior.Steer(a, dt)
+			f.MultiplyScalar(b.Weight)
+			a.force.Add(f)
+		}
+	}
+	for _, a := range s.agents {
+		a.move(dt)
+	}
+}
+
+// buildTree inserts the obstacles into a quadtree, so they can be found
+// quickly.
+func (s *SteeringSystem) buildTree() {
+	if len(s.obstacles) == 0 {
+		s.tree = nil
+		return
+	}
+	bounds := s.obstacles[0].AABB()
+	for _, o := range s.obstacles[1:] {
+		b := o.AABB()
+		bounds.Min.X = math.Min(bounds.Min.X, b.Min.X)
+		bounds.Min.Y = math.Min(bounds.Min.Y, b.Min.Y)
+		bounds.Max.X = math.Max(bounds.Max.X, b.Max.X)
+		bounds.Max.Y = math.Max(bounds.Max.Y, b.Max.Y)
+	}
+	s.tree = engo.NewQuadtree(bounds, false, 8)
+	for _, o := range s.obstacles {
+		s.tree.Insert(o)
+	}
+}
+
+// move applies the steering force to the velocity of the agent, and moves it.
+func (a *SteeringAgent) move(dt float32) {
+	force := truncate(a.force, a.MaxForce)
+	v := a.Velocity
+	v.Add(*force.MultiplyScalar(dt))
+	v = truncate(v, a.MaxSpeed)
+	if a.MaxTurnRate > 0 {
+		v = limitTurn(a.Velocity, v, a.MaxTurnRate*dt)
+	}
+	a.Velocity = v
+	if v.X == 0 && v.Y == 0 {
+		return
+	}
+
+	center := a.Position()
+	if a.Rotate {
+		a.SpaceComponent.Rotation = math.Atan2(v.Y, v.X) * 180 / math.Pi
+	}
+	center.Add(*v.MultiplyScalar(dt))
+	a.SpaceComponent.SetCenter(center)
+}
+
+// truncate returns the vector shortened to the length max, if it is longer
+// and max is greater than 0.
+func truncate(v engo.Point, max float32) engo.Point {
+	if max <= 0 {
+		return v
+	}
+	if l := math.Sqrt(v.X*v.X + v.Y*v.Y); l > max {
+		v.MultiplyScalar(max / l)
+	}
+	return v
+}
+
+// limitTurn returns the new velocity rotated towards the old one, so their
+// directions differ by at most max degrees.
+func limitTurn(old, new engo.Point, max float32) engo.Point {
+	if (old.X == 0 && old.Y == 0) || (new.X == 0 && new.Y == 0) {
+		return new
+	}
+	angle := math.Atan2(old.X*new.Y-old.Y*new.X, old.X*new.X+old.Y*new.Y) * 180 / math.Pi
+	if math.Abs(angle) <= max {
+		return new
+	}
+	if angle < 0 {
+		max = -max
+	}
+	dir, _ := old.Normalize()
+	_, speed := new.Normalize()
+	sin, cos := math.Sincos(max * math.Pi / 180)
+	return engo.Point{X: (dir.X*cos - dir.Y*sin) * speed, Y: (dir.X*sin + dir.Y*cos) * speed}
+}
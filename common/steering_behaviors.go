@@ -0,0 +1,358 @@
+package common
+
+import (
+	"math/rand"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// Seek steers towards the target at full speed.
+type Seek struct {
+	Target engo.Point
+}
+
+// Steer implements the SteeringBehavior interface
+func (s *Seek) Steer(a *SteeringAgent, dt float32) engo.Point {
+	return seek(a, s.Target)
+}
+
+func seek(a *SteeringAgent, target engo.Point) engo.Point {
+	desired := target
+	desired.Subtract(a.Position())
+	desired, _ = desired.Normalize()
+	desired.MultiplyScalar(a.MaxSpeed)
+	desired.Subtract(a.Velocity)
+	return desired
+}
+
+// Flee steers away from the target at full speed.
+type Flee struct {
+	Target engo.Point
+	// Radius is the distance to the target within which the agent flees. It
+	// always flees if it is 0.
+	Radius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (f *Flee) Steer(a *SteeringAgent, dt float32) engo.Point {
+	return flee(a, f.Target, f.Radius)
+}
+
+func flee(a *SteeringAgent, target engo.Point, radius float32) engo.Point {
+	pos := a.Position()
+	if radius > 0 && pos.PointDistanceSquared(target) > radius*radius {
+		return engo.Point{}
+	}
+	desired := pos
+	desired.Subtract(target)
+	desired, _ = desired.Normalize()
+	desired.MultiplyScalar(a.MaxSpeed)
+	desired.Subtract(a.Velocity)
+	return desired
+}
+
+// Arrive steers towards the target, and slows down when getting close to it,
+// so the agent stops at the target.
+type Arrive struct {
+	Target engo.Point
+	// SlowRadius is the distance to the target at which the agent starts
+	// slowing down
+	SlowRadius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (r *Arrive) Steer(a *SteeringAgent, dt float32) engo.Point {
+	return arrive(a, r.Target, r.SlowRadius)
+}
+
+func arrive(a *SteeringAgent, target engo.Point, slowRadius float32) engo.Point {
+	desired := target
+	desired.Subtract(a.Position())
+	desired, dist := desired.Normalize()
+	speed := a.MaxSpeed
+	if dist < slowRadius {
+		speed *= dist / slowRadius
+	}
+	desired.MultiplyScalar(speed)
+	desired.Subtract(a.Velocity)
+	return desired
+}
+
+// Pursue steers towards the position a moving target will be at when the agent
+// reaches it.
+type Pursue struct {
+	Target *SpaceComponent
+	// Velocity is the velocity of the target, like the one of its
+	// SteeringComponent. The current position of the target is used if it is
+	// nil.
+	Velocity *engo.Point
+	// MaxPrediction is the maximum time in seconds the position of the target
+	// is predicted ahead. It is not limited if 0.
+	MaxPrediction float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (p *Pursue) Steer(a *SteeringAgent, dt float32) engo.Point {
+	return seek(a, predict(a, p.Target, p.Velocity, p.MaxPrediction))
+}
+
+// Evade steers away from the position a moving target will be at when it
+// reaches the agent.
+type Evade struct {
+	Target *SpaceComponent
+	// Velocity is the velocity of the target, like the one of its
+	// SteeringComponent. The current position of the target is used if it is
+	// nil.
+	Velocity *engo.Point
+	// MaxPrediction is the maximum time in seconds the position of the target
+	// is predicted ahead. It is not limited if 0.
+	MaxPrediction float32
+	// Radius is the distance to the target within which the agent evades it.
+	// It always evades if it is 0.
+	Radius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (e *Evade) Steer(a *SteeringAgent, dt float32) engo.Point {
+	pos := a.Position()
+	if e.Radius > 0 && pos.PointDistanceSquared(e.Target.Center()) > e.Radius*e.Radius {
+		return engo.Point{}
+	}
+	return flee(a, predict(a, e.Target, e.Velocity, e.MaxPrediction), 0)
+}
+
+// predict returns the position of the target after the time it takes the
+// agent to cover the distance to it.
+func predict(a *SteeringAgent, target *SpaceComponent, velocity *engo.Point, max float32) engo.Point {
+	pos := target.Center()
+	if velocity == nil || a.MaxSpeed <= 0 {
+		return pos
+	}
+	from := a.Position()
+	t := from.PointDistance(pos) / a.MaxSpeed
+	if max > 0 {
+		t = math.Min(t, max)
+	}
+	v := *velocity
+	pos.Add(*v.MultiplyScalar(t))
+	return pos
+}
+
+// Wander steers in a randomly changing direction. It moves a target on a
+// circle in front of the agent a bit each frame, and steers towards it. Every
+// agent needs its own Wander.
+type Wander struct {
+	// Distance is the distance of the center of the circle in front of the
+	// agent
+	Distance float32
+	// Radius is the radius of the circle. The larger it is compared to the
+	// Distance, the sharper the agent turns.
+	Radius float32
+	// Jitter is the maximum change of the position of the target on the circle
+	// in radians per second
+	Jitter float32
+
+	angle float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (w *Wander) Steer(a *SteeringAgent, dt float32) engo.Point {
+	w.angle += (rand.Float32()*2 - 1) * w.Jitter * dt
+	heading, speed := a.Velocity.Normalize()
+	if speed == 0 {
+		heading = engo.Point{X: 1}
+		if a.SpaceComponent.Rotation != 0 {
+			heading.Y, heading.X = math.Sincos(a.SpaceComponent.Rotation * math.Pi / 180)
+		}
+	}
+	target := a.Position()
+	target.Add(*heading.MultiplyScalar(w.Distance))
+	sin, cos := math.Sincos(w.angle)
+	target.Add(engo.Point{X: cos * w.Radius, Y: sin * w.Radius})
+	return seek(a, target)
+}
+
+// ObstacleAvoidance steers away from the obstacles and Lines of the
+// SteeringSystem in front of the agent. It traces three lines along the
+// velocity of the agent, from its center and its sides, and steers away from
+// the nearest obstacle they hit. The closer the obstacle, the stronger it
+// steers.
+type ObstacleAvoidance struct {
+	// Distance is how far ahead of the agent obstacles are avoided. It is the
+	// distance the agent covers in a second if 0.
+	Distance float32
+	// Groups are the collision groups of the obstacles to avoid. All obstacles
+	// are avoided if it is 0.
+	Groups CollisionGroup
+}
+
+// Steer implements the SteeringBehavior interface
+func (o *ObstacleAvoidance) Steer(a *SteeringAgent, dt float32) engo.Point {
+	dir, speed := a.Velocity.Normalize()
+	if speed == 0 {
+		return engo.Point{}
+	}
+	length := o.Distance
+	if length == 0 {
+		length = speed
+	}
+	r := a.Size()
+	pos := a.Position()
+	ahead := dir
+	ahead.MultiplyScalar(length)
+	side := engo.Point{X: -dir.Y * r, Y: dir.X * r}
+
+	area := engo.AABB{
+		Min: engo.Point{X: math.Min(pos.X, pos.X+ahead.X) - r, Y: math.Min(pos.Y, pos.Y+ahead.Y) - r},
+		Max: engo.Point{X: math.Max(pos.X, pos.X+ahead.X) + r, Y: math.Max(pos.Y, pos.Y+ahead.Y) + r},
+	}
+	lines := a.Lines(area)
+	for _, sc := range a.Obstacles(area, o.Groups) {
+		b := sc.AABB()
+		corners := []engo.Point{b.Min, {X: b.Max.X, Y: b.Min.Y}, b.Max, {X: b.Min.X, Y: b.Max.Y}}
+		for i, c := range corners {
+			lines = append(lines, engo.Line{P1: c, P2: corners[(i+1)%4]})
+		}
+	}
+	if len(lines) == 0 {
+		return engo.Point{}
+	}
+
+	nearest := engo.Trace{Fraction: 1}
+	for _, offset := range []float32{0, 1, -1} {
+		start := pos
+		start.Add(engo.Point{X: side.X * offset, Y: side.Y * offset})
+		end := start
+		end.Add(ahead)
+		if t := engo.LineTrace(engo.Line{P1: start, P2: end}, lines); t.Fraction < nearest.Fraction {
+			nearest = t
+		}
+	}
+	if nearest.Fraction >= 1 {
+		return engo.Point{}
+	}
+	normal := nearest.Line.Normal()
+	if normal.X*dir.X+normal.Y*dir.Y > 0 {
+		normal.MultiplyScalar(-1)
+	}
+	normal.MultiplyScalar(math.Max(a.MaxSpeed, speed) * (1 - nearest.Fraction))
+	return normal
+}
+
+// FollowPath steers along the points of a path, like the smoothed paths of the
+// pathfinding package, and arrives at its last point. Every agent needs its
+// own FollowPath.
+type FollowPath struct {
+	Path []engo.Point
+	// Radius is the distance to a point at which it counts as reached, and the
+	// agent steers towards the next one
+	Radius float32
+	// SlowRadius is the distance to the last point at which the agent starts
+	// slowing down
+	SlowRadius float32
+	// Loop is whether the agent goes back to the first point after reaching
+	// the last one
+	Loop bool
+
+	current int
+}
+
+// SetPath replaces the path, and starts following it from its first point.
+func (f *FollowPath) SetPath(path []engo.Point) {
+	f.Path = path
+	f.current = 0
+}
+
+// Current returns the index of the point the agent is steering towards.
+func (f *FollowPath) Current() int {
+	return f.current
+}
+
+// Steer implements the SteeringBehavior interface
+func (f *FollowPath) Steer(a *SteeringAgent, dt float32) engo.Point {
+	if len(f.Path) == 0 {
+		return engo.Point{}
+	}
+	if f.current >= len(f.Path) {
+		f.current = len(f.Path) - 1
+	}
+	pos := a.Position()
+	for i := 0; i < len(f.Path) && pos.PointDistanceSquared(f.Path[f.current]) <= f.Radius*f.Radius; i++ {
+		if f.current == len(f.Path)-1 {
+			if !f.Loop {
+				break
+			}
+			f.current = -1
+		}
+		f.current++
+	}
+	if !f.Loop && f.current == len(f.Path)-1 {
+		return arrive(a, f.Path[f.current], f.SlowRadius)
+	}
+	return seek(a, f.Path[f.current])
+}
+
+// Separation steers away from the neighbouring agents, the stronger the closer
+// they are. Together with Alignment and Cohesion it makes agents flock.
+type Separation struct {
+	// Radius is the distance within which agents are neighbours
+	Radius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (s *Separation) Steer(a *SteeringAgent, dt float32) engo.Point {
+	var force engo.Point
+	pos := a.Position()
+	for _, n := range a.Neighbours(s.Radius) {
+		away := pos
+		away.Subtract(n.Position())
+		away, dist := away.Normalize()
+		if dist == 0 {
+			continue
+		}
+		force.Add(*away.MultiplyScalar(a.MaxSpeed * (1 - dist/s.Radius)))
+	}
+	return force
+}
+
+// Alignment steers towards the average velocity of the neighbouring agents.
+type Alignment struct {
+	// Radius is the distance within which agents are neighbours
+	Radius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (l *Alignment) Steer(a *SteeringAgent, dt float32) engo.Point {
+	neighbours := a.Neighbours(l.Radius)
+	if len(neighbours) == 0 {
+		return engo.Point{}
+	}
+	var v engo.Point
+	for _, n := range neighbours {
+		v.Add(n.Velocity)
+	}
+	v.MultiplyScalar(1 / float32(len(neighbours)))
+	v.Subtract(a.Velocity)
+	return v
+}
+
+// Cohesion steers towards the center of the neighbouring agents.
+type Cohesion struct {
+	// Radius is the distance within which agents are neighbours
+	Radius float32
+}
+
+// Steer implements the SteeringBehavior interface
+func (c *Cohesion) Steer(a *SteeringAgent, dt float32) engo.Point {
+	neighbours := a.Neighbours(c.Radius)
+	if len(neighbours) == 0 {
+		return engo.Point{}
+	}
+	var center engo.Point
+	for _, n := range neighbours {
+		center.Add(n.Position())
+	}
+	center.MultiplyScalar(1 / float32(len(neighbours)))
+	return seek(a, center)
+}
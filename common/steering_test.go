@@ -0,0 +1,197 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// addSteeringAgent adds an agent of 10 by 10 centered at x, y to the system.
+func addSteeringAgent(s *SteeringSystem, x, y float32, behaviors ...SteeringBehavior) *SteeringAgent {
+	basic := ecs.NewBasic()
+	space := &SpaceComponent{Position: engo.Point{X: x - 5, Y: y - 5}, Width: 10, Height: 10}
+	steering := &SteeringComponent{MaxSpeed: 10}
+	for _, b := range behaviors {
+		steering.Behaviors = append(steering.Behaviors, WeightedBehavior{Behavior: b, Weight: 1})
+	}
+	s.Add(&basic, steering, space)
+	return s.agents[len(s.agents)-1]
+}
+
+func speed(v engo.Point) float32 {
+	_, l := v.Normalize()
+	return l
+}
+
+func TestSteeringArrive(t *testing.T) {
+	s := &SteeringSystem{}
+	a := addSteeringAgent(s, 0, 0, &Arrive{Target: engo.Point{X: 50, Y: 0}, SlowRadius: 20})
+	for i := 0; i < 1200; i++ {
+		s.Update(1.0 / 60)
+		if speed(a.Velocity) > a.MaxSpeed+1e-4 {
+			t.Fatalf("Speed was %v, which is more than the max speed", speed(a.Velocity))
+		}
+	}
+	if pos := a.Position(); math.Abs(pos.X-50) > 0.5 || math.Abs(pos.Y) > 0.5 {
+		t.Errorf("Agent was at %v, expected it to arrive at 50, 0", pos)
+	}
+	if speed(a.Velocity) > 0.5 {
+		t.Errorf("Agent still moved with %v after arriving", a.Velocity)
+	}
+}
+
+func TestSteeringWeights(t *testing.T) {
+	s := &SteeringSystem{}
+	target := engo.Point{X: 20, Y: 20}
+	a := addSteeringAgent(s, 0, 0, &Seek{Target: target}, &Flee{Target: target})
+	s.Update(1)
+	if a.Velocity != (engo.Point{}) {
+		t.Errorf("Velocity was %v, expected seeking and fleeing to cancel out", a.Velocity)
+	}
+
+	a.Behaviors[1].Weight = 0
+	a.MaxForce = 5
+	s.Update(1)
+	if speed(a.Velocity) > 5+1e-4 || a.Velocity.X <= 0 || a.Velocity.Y <= 0 {
+		t.Errorf("Velocity was %v, expected it to be limited by the max force", a.Velocity)
+	}
+	if a.Behaviors[1].Weight = 1; (&Flee{Target: target, Radius: 5}).Steer(a, 1) != (engo.Point{}) {
+		t.Error("Agent fled from a target outside of the radius")
+	}
+}
+
+func TestSteeringTurnRate(t *testing.T) {
+	s := &SteeringSystem{}
+	a := addSteeringAgent(s, 0, 0, &Seek{Target: engo.Point{X: 0, Y: 100}})
+	a.Velocity = engo.Point{X: 10}
+	a.MaxTurnRate = 90
+	a.Rotate = true
+	s.Update(0.5)
+	angle := math.Atan2(a.Velocity.Y, a.Velocity.X) * 180 / math.Pi
+	if math.Abs(angle-45) > 1e-3 {
+		t.Errorf("Velocity turned by %v degrees, expected 45", angle)
+	}
+	if math.Abs(a.SpaceComponent.Rotation-45) > 1e-3 {
+		t.Errorf("Rotation was %v, expected the direction of the velocity", a.SpaceComponent.Rotation)
+	}
+}
+
+func TestSteeringPursueEvade(t *testing.T) {
+	s := &SteeringSystem{}
+	target := &SpaceComponent{Position: engo.Point{X: 95, Y: -5}, Width: 10, Height: 10}
+	velocity := engo.Point{X: 0, Y: 10}
+	a := addSteeringAgent(s, 0, 0)
+
+	f := (&Pursue{Target: target, Velocity: &velocity}).Steer(a, 1)
+	if f.X <= 0 || f.Y <= 0 {
+		t.Errorf("Pursuing steered to %v, expected it to steer ahead of the target", f)
+	}
+	f = (&Pursue{Target: target}).Steer(a, 1)
+	if f.X <= 0 || f.Y != 0 {
+		t.Errorf("Pursuing without a velocity steered to %v, expected the target's position", f)
+	}
+	f = (&Evade{Target: target, Velocity: &velocity}).Steer(a, 1)
+	if f.X >= 0 || f.Y >= 0 {
+		t.Errorf("Evading steered to %v, expected it to steer away", f)
+	}
+	if f = (&Evade{Target: target, Radius: 50}).Steer(a, 1); f != (engo.Point{}) {
+		t.Error("Agent evaded a target outside of the radius")
+	}
+}
+
+func TestSteeringWander(t *testing.T) {
+	s := &SteeringSystem{}
+	a := addSteeringAgent(s, 0, 0, &Wander{Distance: 10, Radius: 5, Jitter: 1})
+	for i := 0; i < 60; i++ {
+		s.Update(1.0 / 60)
+	}
+	if speed(a.Velocity) == 0 || a.Position() == (engo.Point{}) {
+		t.Error("Wandering agent did not move")
+	}
+}
+
+func TestSteeringObstacleAvoidance(t *testing.T) {
+	s := &SteeringSystem{}
+	avoid := &ObstacleAvoidance{Distance: 50, Groups: 1}
+	a := addSteeringAgent(s, 0, 0, avoid)
+	a.Velocity = engo.Point{X: 10}
+
+	obstacle := ecs.NewBasic()
+	s.AddObstacle(&obstacle, &CollisionComponent{Group: 2}, &SpaceComponent{Position: engo.Point{X: 20, Y: -2}, Width: 10, Height: 20})
+	s.Update(0)
+	if f := avoid.Steer(a, 0); f != (engo.Point{}) {
+		t.Errorf("Obstacle of another group was avoided with %v", f)
+	}
+
+	avoid.Groups = 0
+	s.Update(0)
+	f := avoid.Steer(a, 0)
+	if f.X >= 0 {
+		t.Errorf("Avoidance steered to %v, expected it to steer away from the obstacle", f)
+	}
+	s.Remove(obstacle)
+	s.Update(0)
+	if f := avoid.Steer(a, 0); f != (engo.Point{}) {
+		t.Errorf("Removed obstacle was avoided with %v", f)
+	}
+
+	// the wall is hit by the trace from the agent's side
+	s.Lines = []engo.Line{{P1: engo.Point{X: 30, Y: 4}, P2: engo.Point{X: 10, Y: 24}}}
+	f = avoid.Steer(a, 0)
+	if f.X >= 0 || f.Y >= 0 {
+		t.Errorf("Avoidance steered to %v, expected it to steer away from the wall", f)
+	}
+	a.Velocity = engo.Point{X: -10}
+	if f := avoid.Steer(a, 0); f != (engo.Point{}) {
+		t.Errorf("Wall behind the agent was avoided with %v", f)
+	}
+}
+
+func TestSteeringFollowPath(t *testing.T) {
+	s := &SteeringSystem{}
+	path := &FollowPath{Radius: 2, SlowRadius: 10}
+	path.SetPath([]engo.Point{{X: 20, Y: 0}, {X: 20, Y: 20}, {X: 0, Y: 20}})
+	a := addSteeringAgent(s, 0, 0, path)
+	for i := 0; i < 1200; i++ {
+		s.Update(1.0 / 60)
+	}
+	if path.Current() != 2 {
+		t.Errorf("Agent steered towards point %d, expected the last one", path.Current())
+	}
+	if pos := a.Position(); pos.PointDistance(engo.Point{X: 0, Y: 20}) > 0.5 {
+		t.Errorf("Agent was at %v, expected it to arrive at the end of the path", pos)
+	}
+
+	path.Loop = true
+	s.Update(1.0 / 60)
+	if path.Current() != 0 {
+		t.Errorf("Agent steered towards point %d, expected it to loop to the first one", path.Current())
+	}
+}
+
+func TestSteeringFlocking(t *testing.T) {
+	s := &SteeringSystem{}
+	a := addSteeringAgent(s, 0, 0)
+	b := addSteeringAgent(s, 10, 0)
+	far := addSteeringAgent(s, 100, 0)
+	b.Velocity = engo.Point{X: 0, Y: 10}
+	far.Velocity = engo.Point{X: 0, Y: -10}
+
+	if n := a.Neighbours(20); len(n) != 1 || n[0] != b {
+		t.Fatalf("Agent had %d neighbours, expected 1", len(n))
+	}
+	if f := (&Separation{Radius: 20}).Steer(a, 1); f.X >= 0 || f.Y != 0 {
+		t.Errorf("Separation steered to %v, expected it to steer away from the neighbour", f)
+	}
+	if f := (&Cohesion{Radius: 20}).Steer(a, 1); f.X <= 0 || f.Y != 0 {
+		t.Errorf("Cohesion steered to %v, expected it to steer towards the neighbour", f)
+	}
+	if f := (&Alignment{Radius: 20}).Steer(a, 1); f != (engo.Point{X: 0, Y: 10}) {
+		t.Errorf("Alignment steered to %v, expected the velocity of the neighbour", f)
+	}
+	if f := (&Alignment{Radius: 5}).Steer(a, 1); f != (engo.Point{}) {
+		t.Errorf("Alignment without neighbours steered to %v", f)
+	}
+}
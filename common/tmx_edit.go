@@ -0,0 +1,158 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/EngoEngine/engo"
+)
+
+// TileChangedMessage is dispatched when a tile of a level is changed by
+// SetTile or ClearTile, including the tiles changed by autotiling.
+type TileChangedMessage struct {
+	Level *Level
+	Layer *TileLayer
+	Chunk *TileChunk
+	// X and Y are the position of the tile in tiles
+	X, Y int
+	Tile *Tile
+}
+
+// Type implements the engo.Message interface
+func (TileChangedMessage) Type() string {
+	return "TileChangedMessage"
+}
+
+// GID returns the global tile ID of the tile, without the flipping flags. It
+// is 0 for empty tiles.
+func (t *Tile) GID() uint32 {
+	return t.gid
+}
+
+// GID returns the global tile ID of the tile at x, y (in tiles), without the
+// flipping flags. It is 0 for empty tiles.
+func (tl *TileLayer) GID(x, y int) uint32 {
+	if t, ok := tl.tiles[mapPoint{X: x, Y: y}]; ok {
+		return t.gid
+	}
+	return 0
+}
+
+// Tile returns the tile at x, y (in tiles), or nil if the layer has no tile
+// there.
+func (tl *TileLayer) Tile(x, y int) *Tile {
+	return tl.tiles[mapPoint{X: x, Y: y}]
+}
+
+// SetTile changes the tile at x, y (in tiles) of the layer to the tile with the
+// gid. The Tile is changed in place, so entities drawing its Image show the new
+// tile right away, and a TileChangedMessage is dispatched for everything else,
+// like animations. If the tile is part of one of the level's WangSets, the
+// tiles around it, which are part of the same set, are changed so their
+// corners and edges match the ones of the tile. Autotiling is only done for
+// orthogonal and isometric levels.
+//
+// Infinite levels get new chunks when tiles are set outside of the existing
+// ones, for other levels this returns an error.
+func (l *Level) SetTile(layer *TileLayer, x, y int, gid uint32) error {
+	if layer == nil {
+		return errors.New("no tile layer")
+	}
+	if _, ok := l.resourceMap[gid]; gid != 0 && !ok {
+		return fmt.Errorf("unknown tile %d", gid)
+	}
+	if err := l.setTile(layer, x, y, gid); err != nil {
+		return err
+	}
+	if gid != 0 {
+		l.autotile(layer, x, y, gid)
+	}
+	return nil
+}
+
+// ClearTile removes the tile at x, y (in tiles) of the layer.
+func (l *Level) ClearTile(layer *TileLayer, x, y int) error {
+	return l.SetTile(layer, x, y, 0)
+}
+
+// Paint sets the tile at x, y (in tiles) of the layer to the tile of the
+// WangSet whose corners and/or edges all have the color, and changes the tiles
+// around it to match.
+func (l *Level) Paint(layer *TileLayer, x, y int, set *WangSet, color int) error {
+	gid, ok := set.TileFor(color)
+	if !ok {
+		return fmt.Errorf("wang set %q has no tile with only color %d", set.Name, color)
+	}
+	return l.SetTile(layer, x, y, gid)
+}
+
+func (l *Level) setTile(layer *TileLayer, x, y int, gid uint32) error {
+	if layer.tiles == nil {
+		layer.tiles = make(map[mapPoint]*Tile)
+	}
+	pt := mapPoint{X: x, Y: y}
+	t, ok := layer.tiles[pt]
+	if !ok && !l.Infinite {
+		return fmt.Errorf("tile %d,%d is outside of the layer", x, y)
+	}
+	var chunk *TileChunk
+	if ok {
+		chunk = layer.chunk(x, y)
+		n := l.tileFromGID(gid, t.Point)
+		*t.Image = *n.Image
+		t.Drawables, t.Animation, t.Properties = n.Drawables, n.Animation, n.Properties
+		t.gid, t.flip = gid, 0
+	} else {
+		t = l.tileFromGID(gid, l.screenPoint(engo.Point{X: float32(x), Y: float32(y)}))
+		if chunk = layer.chunk(x, y); chunk == nil {
+			chunk = &TileChunk{
+				X:      floorDiv(x, levelChunkSize) * levelChunkSize,
+				Y:      floorDiv(y, levelChunkSize) * levelChunkSize,
+				Width:  levelChunkSize,
+				Height: levelChunkSize,
+			}
+			layer.Chunks = append(layer.Chunks, chunk)
+		}
+		chunk.Tiles = append(chunk.Tiles, t)
+		layer.Tiles = append(layer.Tiles, t)
+		layer.tiles[pt] = t
+		if l.pointMap == nil {
+			l.pointMap = make(map[mapPoint]*Tile)
+		}
+		l.pointMap[pt] = t
+		l.fitChunks()
+	}
+	if chunk != nil {
+		chunk.Bounds = l.chunkBounds(chunk.Tiles)
+	}
+
+	if engo.Mailbox != nil {
+		engo.Mailbox.Dispatch(TileChangedMessage{
+			Level: l,
+			Layer: layer,
+			Chunk: chunk,
+			X:     x,
+			Y:     y,
+			Tile:  t,
+		})
+	}
+	return nil
+}
+
+// chunk returns the chunk containing the tile at x, y (in tiles).
+func (tl *TileLayer) chunk(x, y int) *TileChunk {
+	for _, c := range tl.Chunks {
+		if x >= c.X && x < c.X+c.Width && y >= c.Y && y < c.Y+c.Height {
+			return c
+		}
+	}
+	return nil
+}
+
+// floorDiv returns a / b rounded towards negative infinity.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
@@ -0,0 +1,236 @@
+package common
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+var editTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.5" tiledversion="1.5.0" orientation="orthogonal" renderorder="right-up" width="4" height="4" tilewidth="16" tileheight="16" infinite="0" nextlayerid="2" nextobjectid="1">
+ <tileset firstgid="1" name="test" tilewidth="16" tileheight="16" spacing="1" tilecount="468" columns="26">
+  <image source="test.png" width="457" height="305"/>
+  <terraintypes>
+   <terrain name="grass" tile="0"/>
+   <terrain name="dirt" tile="1"/>
+  </terraintypes>
+  <tile id="10" terrain="0,0,,1"/>
+  <wangsets>
+   <wangset name="ground" tile="-1">
+    <wangtile tileid="0" wangid="0,1,0,1,0,1,0,1"/>
+    <wangtile tileid="1" wangid="0,2,0,2,0,2,0,2"/>
+    <wangtile tileid="2" wangid="0,1,0,1,0,2,0,2"/>
+    <wangtile tileid="3" wangid="0,2,0,2,0,1,0,1"/>
+    <wangtile tileid="4" wangid="0,1,0,2,0,1,0,1"/>
+   </wangset>
+  </wangsets>
+ </tileset>
+ <layer id="1" name="Ground" width="4" height="4">
+  <!-- the ground -->
+  <data encoding="csv">
+1,1,1,1,
+1,1,1,1,
+1,1,1,1,
+1,1,1,2147483649
+</data>
+ </layer>
+</map>
+`
+
+func loadEditTestPNG(t *testing.T) {
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+}
+
+func TestTMXSetTile(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true, Width: 160, Height: 160}, &tmxTestScene{})
+	loadEditTestPNG(t)
+	level, err := createLevelFromTmx(bytes.NewBufferString(editTMX), "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+	layer := level.TileLayers[0]
+
+	if len(level.WangSets) != 2 {
+		t.Fatalf("Level had %d wang sets, expected the wang set and the terrains", len(level.WangSets))
+	}
+	set := level.WangSets[0]
+	if set.Name != "ground" || set.Type != WangCorner || set.Colors != 2 {
+		t.Errorf("Wang set was %q, %s with %d colors, expected ground, corner with 2 colors", set.Name, set.Type, set.Colors)
+	}
+	if id, ok := level.WangSets[1].wangID(11); !ok || id != (wangID{0, 1, 0, 2, 0, 0, 0, 1}) {
+		t.Errorf("Terrain of the tile was %v, expected the top corners to be 1 and the bottom-right 2", id)
+	}
+
+	CameraBounds = engo.AABB{Min: engo.Point{X: -5000, Y: -5000}, Max: engo.Point{X: 5000, Y: 5000}}
+	defer func() { CameraBounds = engo.AABB{} }()
+	w := &ecs.World{}
+	render := &RenderSystem{}
+	w.AddSystem(render)
+	streamer := &ChunkStreamer{Level: level, Margin: 32}
+	w.AddSystem(streamer)
+	streamer.camera.moveToX(32)
+	streamer.camera.moveToY(32)
+	streamer.Update(0)
+	if len(render.ids) != 16 {
+		t.Fatalf("RenderSystem had %d entities, expected the 16 tiles", len(render.ids))
+	}
+
+	var changed []TileChangedMessage
+	engo.Mailbox.Listen("TileChangedMessage", func(msg engo.Message) {
+		changed = append(changed, msg.(TileChangedMessage))
+	})
+	tile := level.GetTile(engo.Point{X: 24, Y: 24})
+	if err = level.Paint(layer, 1, 1, set, 2); err != nil {
+		t.Fatalf("Paint returned an error: %v", err)
+	}
+	if tile.GID() != 2 || layer.GID(1, 1) != 2 {
+		t.Errorf("Painted tile was %d, expected 2", tile.GID())
+	}
+	// the right, left and top-left neighbors get dirt where they touch the
+	// painted tile, for the neighbor above there is no matching tile
+	for _, exp := range []struct {
+		x, y int
+		gid  uint32
+	}{{2, 1, 3}, {0, 1, 4}, {0, 0, 5}, {1, 0, 1}, {3, 1, 1}} {
+		if gid := layer.GID(exp.x, exp.y); gid != exp.gid {
+			t.Errorf("Tile %d,%d was %d after autotiling, expected %d", exp.x, exp.y, gid, exp.gid)
+		}
+	}
+	if len(changed) != 4 || changed[0].Tile != tile || changed[0].X != 1 || changed[0].Chunk != layer.Chunks[0] {
+		t.Errorf("TileChangedMessage was dispatched %d times, expected 4 times starting with the painted tile", len(changed))
+	}
+
+	if err = level.ClearTile(layer, 3, 3); err != nil {
+		t.Fatalf("ClearTile returned an error: %v", err)
+	}
+	if len(render.ids) != 15 {
+		t.Errorf("RenderSystem had %d entities after clearing a tile, expected 15", len(render.ids))
+	}
+	if err = level.SetTile(layer, 3, 3, 1); err != nil {
+		t.Fatalf("SetTile returned an error: %v", err)
+	}
+	if len(render.ids) != 16 {
+		t.Errorf("RenderSystem had %d entities after setting a cleared tile, expected 16", len(render.ids))
+	}
+
+	if err = level.SetTile(layer, 4, 0, 1); err == nil {
+		t.Error("SetTile outside of a finite level did not return an error")
+	}
+	if err = level.SetTile(layer, 0, 0, 9999); err == nil {
+		t.Error("SetTile with an unknown tile did not return an error")
+	}
+}
+
+func TestTMXWriteTMX(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	loadEditTestPNG(t)
+	level, err := createLevelFromTmx(bytes.NewBufferString(editTMX), "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+	layer := level.TileLayers[0]
+	level.SetTile(layer, 0, 3, 7)
+	level.ClearTile(layer, 2, 0)
+
+	buf := &bytes.Buffer{}
+	if err = level.WriteTMX(buf); err != nil {
+		t.Fatalf("WriteTMX returned an error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<!-- the ground -->") || !strings.Contains(buf.String(), `<wangtile tileid="4"`) {
+		t.Error("WriteTMX did not keep the rest of the TMX")
+	}
+	saved, err := createLevelFromTmx(buf, "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level from the written TMX. Error was: %v", err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if exp, gid := layer.GID(x, y), saved.TileLayers[0].GID(x, y); gid != exp {
+				t.Errorf("Tile %d,%d was %d in the written TMX, expected %d", x, y, gid, exp)
+			}
+		}
+	}
+	if flip := saved.TileLayers[0].tiles[mapPoint{X: 3, Y: 0}].flip; flip == 0 {
+		t.Error("Flipping flags were not written")
+	}
+
+	if err = (&Level{}).WriteTMX(buf); err == nil {
+		t.Error("WriteTMX of a level without a TMX file did not return an error")
+	}
+}
+
+func TestTMXSetTileInfinite(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	loadEditTestPNG(t)
+	tmpl, err := template.New("test").Parse(testTMXtmpl)
+	if err != nil {
+		t.Fatal("Error parsing tmx template")
+	}
+	buf := bytes.NewBuffer([]byte{})
+	if err = tmpl.Execute(buf, tmxData{Orientation: "orthogonal", RenderOrder: "right-down", ChunkData: true}); err != nil {
+		t.Fatal("Error executing tmx template")
+	}
+	level, err := createLevelFromTmx(buf, "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+	layer := level.TileLayers[0]
+
+	if err = level.SetTile(layer, -40, 5, 3); err != nil {
+		t.Fatalf("SetTile outside of the chunks returned an error: %v", err)
+	}
+	chunk := layer.Chunks[len(layer.Chunks)-1]
+	if len(layer.Chunks) != 3 || chunk.X != -48 || chunk.Y != 0 || len(chunk.Tiles) != 1 {
+		t.Errorf("SetTile did not add a chunk at -48,0")
+	}
+	if level.Width() != 51 || level.Height() != 32 {
+		t.Errorf("Level size was %dx%d after adding a chunk, expected 51x32", level.Width(), level.Height())
+	}
+	if tile := level.GetTile(engo.Point{X: -40*16 + 1, Y: 5*16 + 1}); tile == nil || tile.GID() != 3 {
+		t.Error("New tile was not returned by GetTile")
+	}
+
+	out := &bytes.Buffer{}
+	if err = level.WriteTMX(out); err != nil {
+		t.Fatalf("WriteTMX returned an error: %v", err)
+	}
+	saved, err := createLevelFromTmx(out, "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level from the written TMX. Error was: %v", err)
+	}
+	if len(saved.TileLayers[0].Chunks) != 3 || saved.TileLayers[0].GID(-40, 5) != 3 || saved.TileLayers[0].GID(-41, 5) != 0 {
+		t.Error("Chunks were not written")
+	}
+	if saved.TileLayers[0].GID(-20, -10) != layer.GID(-20, -10) {
+		t.Error("Tiles of the loaded chunks were not written")
+	}
+}
+
+func TestParseWangID(t *testing.T) {
+	for s, exp := range map[string]wangID{
+		"0x10101010":      {0, 1, 0, 1, 0, 1, 0, 1},
+		"0x00000021":      {1, 2},
+		"0,1,2,3,4,5,6,7": {0, 1, 2, 3, 4, 5, 6, 7},
+	} {
+		if id, err := parseWangID(s); err != nil || id != exp {
+			t.Errorf("Wang id %q was parsed as %v, expected %v", s, id, exp)
+		}
+	}
+	for _, s := range []string{"0xZ", "1,2,3", "0,1,2,3,4,5,6,x"} {
+		if _, err := parseWangID(s); err == nil {
+			t.Errorf("Invalid wang id %q did not return an error", s)
+		}
+	}
+}
@@ -19,6 +19,11 @@ func (r TMXResource) URL() string {
 	return r.url
 }
 
+// Category returns engo.CategoryLevel.
+func (r TMXResource) Category() string {
+	return engo.CategoryLevel
+}
+
 // tmxLoader is responsible for managing '.tmx' files within 'engo.Files'.
 // You can generate a TMX file with the Tiled map editor.
 type tmxLoader struct {
@@ -41,6 +46,24 @@ func (t *tmxLoader) Load(url string, data io.Reader) error {
 	return nil
 }
 
+// Reload loads the modified tmx file into the Level that is already used for it. The layers, tiles and objects of
+// the Level are replaced, so the entities created from it, such as the ones drawing its tiles, and ChunkStreamers
+// keep the old ones: the Scene has to create them again when it receives the engo.ResourceReloadedMessage for the
+// url.
+func (t *tmxLoader) Reload(url string, data io.Reader) error {
+	lvl, err := createLevelFromTmx(data, url, t.root)
+	if err != nil {
+		return err
+	}
+
+	if res, ok := t.levels[url]; ok {
+		*res.Level = *lvl
+		return nil
+	}
+	t.levels[url] = TMXResource{Level: lvl, url: url}
+	return nil
+}
+
 // Unload removes the preloaded level from the cache
 func (t *tmxLoader) Unload(url string) error {
 	delete(t.levels, url)
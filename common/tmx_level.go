@@ -1,14 +1,17 @@
 package common
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
 	"github.com/Noofbiz/tmx"
 )
 
@@ -18,22 +21,46 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 		return nil, errors.New("createLevelFromTmx should be called with a real root")
 	}
 	tmx.TMXURL = filepath.Join(root, tmxURL)
-	tmxLevel, err := tmx.Parse(r)
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	level := &Level{}
+	tmxLevel, err := tmx.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	extra, err := parseTMXExtra(data)
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Dir(tmxURL)
+	level := &Level{source: data}
 	level.Orientation = orth
 	level.resourceMap = make(map[uint32]Texture)
 	level.pointMap = make(map[mapPoint]*Tile)
 	level.framesMap = make(map[uint32][]uint32)
+	level.tileProperties = make(map[uint32]Properties)
+	level.tileClasses = make(map[uint32]string)
 
 	// get a map of the gids to textures from the tilesets
-	for _, ts := range tmxLevel.Tilesets {
+	for tsIdx, ts := range tmxLevel.Tilesets {
 		for _, g := range ts.Grid {
 			level.Orientation = g.Orientation
 		}
+		var extraTiles []tmxExtraObject
+		if tsIdx < len(extra.Tilesets) {
+			extraTiles = extra.Tilesets[tsIdx].Tiles
+		}
 		for _, t := range ts.Tiles {
+			et := findExtraObject(extraTiles, t.ID)
+			if props := getProperties(t.Properties, et.Properties, dir); len(props) > 0 {
+				level.tileProperties[ts.FirstGID+t.ID] = props
+			}
+			if et.Class != "" {
+				level.tileClasses[ts.FirstGID+t.ID] = et.Class
+			} else if t.Type != "" {
+				level.tileClasses[ts.FirstGID+t.ID] = t.Type
+			}
 			for _, i := range t.Image {
 				if i.Source != "" {
 					tex, err := LoadedSprite(path.Join(path.Dir(tmxURL), i.Source))
@@ -57,6 +84,11 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 			}
 			level.framesMap[ts.FirstGID+t.ID] = frames
 		}
+		sets, err := wangSets(ts)
+		if err != nil {
+			return nil, err
+		}
+		level.WangSets = append(level.WangSets, sets...)
 		for _, i := range ts.Image {
 			if i.Source != "" {
 				_, err := LoadedSprite(path.Join(path.Dir(tmxURL), i.Source))
@@ -81,15 +113,18 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 
 	level.Orientation = tmxLevel.Orientation
 	level.RenderOrder = tmxLevel.RenderOrder
+	level.StaggerAxis = tmxLevel.StaggerAxis
+	level.StaggerIndex = tmxLevel.StaggerIndex
+	level.HexSideLength = tmxLevel.HexSideLength
 	level.TileWidth = tmxLevel.TileWidth
 	level.width = tmxLevel.Width
 	level.height = tmxLevel.Height
 	level.TileHeight = tmxLevel.TileHeight
 	level.NextObjectID = tmxLevel.NextObjectID
-	level.Properties = getProperties(tmxLevel.Properties)
+	level.Properties = getProperties(tmxLevel.Properties, extra.Properties, dir)
 
 	// tile layers
-	for _, l := range tmxLevel.Layers {
+	for i, l := range tmxLevel.Layers {
 		tl := &TileLayer{}
 		tl.Name = l.Name
 		tl.X = float32(l.X)
@@ -108,20 +143,29 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 		} else {
 			tl.Height = tmxLevel.Height
 		}
-		tl.Properties = getProperties(l.Properties)
-		tl.Tiles = level.unpackTiles(0, 0, tl.Width, tl.Height, l.Data)
+		if i < len(extra.Layers) {
+			tl.Properties = getProperties(l.Properties, extra.Layers[i].Properties, dir)
+		} else {
+			tl.Properties = getProperties(l.Properties, nil, dir)
+		}
+		level.unpackTiles(tl, 0, 0, tl.Width, tl.Height, l.Data)
 		level.TileLayers = append(level.TileLayers, tl)
 	}
+	level.fitChunks()
 
 	//image layers
-	for _, l := range tmxLevel.ImageLayers {
+	for i, l := range tmxLevel.ImageLayers {
 		il := &ImageLayer{}
 		il.Name = l.Name
 		il.Opacity = float32(l.Opacity)
 		il.Visible = l.Visible == 1
 		il.OffSetX = float32(l.OffsetX)
 		il.OffSetY = float32(l.OffsetY)
-		il.Properties = getProperties(l.Properties)
+		if i < len(extra.ImageLayers) {
+			il.Properties = getProperties(l.Properties, extra.ImageLayers[i].Properties, dir)
+		} else {
+			il.Properties = getProperties(l.Properties, nil, dir)
+		}
 		il.Images, err = level.imageTiles(tmxURL, l.Images, il.OffSetX, il.OffSetY)
 		if err != nil {
 			return nil, err
@@ -130,7 +174,7 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 	}
 
 	// Objects
-	for _, o := range tmxLevel.ObjectGroups {
+	for i, o := range tmxLevel.ObjectGroups {
 		ol := &ObjectLayer{}
 		ol.Color = o.Color
 		ol.Name = o.Name
@@ -139,17 +183,35 @@ func createLevelFromTmx(r io.Reader, tmxURL string, root string) (*Level, error)
 		ol.OffSetY = float32(o.OffsetY)
 		ol.Opacity = float32(o.Opacity)
 		ol.Visible = o.Visible == 1
-		ol.Properties = getProperties(o.Properties)
+		var extraObjects []tmxExtraObject
+		if i < len(extra.ObjectGroups) {
+			ol.Properties = getProperties(o.Properties, extra.ObjectGroups[i].Properties, dir)
+			extraObjects = extra.ObjectGroups[i].Objects
+		} else {
+			ol.Properties = getProperties(o.Properties, nil, dir)
+		}
 		for _, tmxobj := range o.Objects {
+			eo := findExtraObject(extraObjects, tmxobj.ID)
 			object := Object{}
 			object.ID = tmxobj.ID
 			object.Name = tmxobj.Name
 			object.Type = tmxobj.Type
+			object.Class = eo.Class
 			object.X = float32(tmxobj.X)
 			object.Y = float32(tmxobj.Y)
 			object.Width = float32(tmxobj.Width)
 			object.Height = float32(tmxobj.Height)
-			object.Properties = getProperties(tmxobj.Properties)
+			object.Rotation = float32(tmxobj.Rotation)
+			object.Properties = getProperties(tmxobj.Properties, eo.Properties, dir)
+
+			// tile objects inherit the class and properties of their tile
+			gid := tmxobj.GID &^ (tmx.HorizontalFlipFlag | tmx.VerticalFlipFlag | tmx.DiagonalFlipFlag)
+			if gid != 0 {
+				object.Properties = object.Properties.inherit(level.tileProperties[gid])
+				if object.Class == "" && object.Type == "" {
+					object.Class = level.tileClasses[gid]
+				}
+			}
 			object.Tiles = append(object.Tiles, level.tileFromGID(tmxobj.GID, engo.Point{
 				X: object.X,
 				Y: object.Y,
@@ -232,111 +294,169 @@ func pointStringToLines(str string, xOff, yOff float64) []*engo.Line {
 	return lines
 }
 
-func (l *Level) unpackTiles(x, y, w, h int, d []tmx.Data) []*Tile {
-	var ret []*Tile
-	const (
-		rd = "right-down"
-		ru = "right-up"
-		ld = "left-down"
-		lu = "left-up"
-	)
-
-	switch l.RenderOrder {
-	case ru:
-		y = h - 1
-	case ld:
-		x = w - 1
-	case lu:
-		x = w - 1
-		y = h - 1
-	}
+// levelChunkSize is the width and height in tiles of the chunks the tiles of
+// finite levels are grouped in.
+const levelChunkSize = 16
 
+// unpackTiles adds the tiles of the data to the tile layer, grouped in chunks.
+func (l *Level) unpackTiles(tl *TileLayer, x, y, w, h int, d []tmx.Data) {
+	var ret []*Tile
+	var chunks []*TileChunk
+	tl.tiles = make(map[mapPoint]*Tile)
+	areas := make(map[mapPoint]*TileChunk)
 	for _, data := range d {
-		for _, t := range data.Tiles {
+		order := l.tileOrder(x, y, w, h)
+		for i, t := range data.Tiles {
+			if i >= len(order) {
+				break
+			}
+			pt := order[i]
 			tile := l.tileFromGID(t.GID, l.screenPoint(engo.Point{
-				X: float32(x),
-				Y: float32(y),
+				X: float32(pt.X),
+				Y: float32(pt.Y),
 			}))
+			tile.flip = t.Flipping
 			ret = append(ret, tile)
-			l.pointMap[mapPoint{X: x, Y: y}] = tile
-			switch l.RenderOrder {
-			case rd:
-				x++
-				if x >= w {
-					x = 0
-					y++
-				}
-			case ru:
-				x++
-				if x >= w {
-					x = 0
-					y--
-				}
-			case ld:
-				x--
-				if x < 0 {
-					x = w
-					y++
-				}
-			case lu:
-				x--
-				if x < 0 {
-					x = w
-					y--
-				}
-			}
+			l.pointMap[pt] = tile
+			tl.tiles[pt] = tile
+			chunks = addToArea(areas, chunks, pt, w, h, tile)
 		}
 		for _, c := range data.Chunks {
-			x = c.X
-			y = c.Y
-			switch l.RenderOrder {
-			case ru:
-				y += c.Height - 1
-			case ld:
-				x += c.Width - 1
-			case lu:
-				x += c.Width - 1
-				y += c.Height - 1
-			}
-			for _, t := range c.Tiles {
+			l.Infinite = true
+			chunk := &TileChunk{X: c.X, Y: c.Y, Width: c.Width, Height: c.Height}
+			order := l.tileOrder(c.X, c.Y, c.Width, c.Height)
+			for i, t := range c.Tiles {
+				if i >= len(order) {
+					break
+				}
+				pt := order[i]
 				tile := l.tileFromGID(t.GID, l.screenPoint(engo.Point{
-					X: float32(x),
-					Y: float32(y),
+					X: float32(pt.X),
+					Y: float32(pt.Y),
 				}))
+				tile.flip = t.Flipping
 				ret = append(ret, tile)
-				l.pointMap[mapPoint{X: x, Y: y}] = tile
-				switch l.RenderOrder {
-				case rd:
-					x++
-					if x >= c.X+c.Width {
-						x = c.X
-						y++
-					}
-				case ru:
-					x++
-					if x >= c.X+c.Width {
-						x = c.X
-						y--
-					}
-				case ld:
-					x--
-					if x < c.X {
-						x = c.X + c.Width - 1
-						y++
-					}
-				case lu:
-					x--
-					if x < c.Width {
-						x = c.X + c.Width - 1
-						y--
-					}
-				}
+				chunk.Tiles = append(chunk.Tiles, tile)
+				l.pointMap[pt] = tile
+				tl.tiles[pt] = tile
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+	for _, chunk := range chunks {
+		chunk.Bounds = l.chunkBounds(chunk.Tiles)
+	}
+	tl.Tiles, tl.Chunks = ret, chunks
+}
+
+// addToArea adds the tile at pt to the chunk of the area of levelChunkSize by
+// levelChunkSize tiles containing it, which is created if there is none yet.
+// w and h are the size of the layer.
+func addToArea(areas map[mapPoint]*TileChunk, chunks []*TileChunk, pt mapPoint, w, h int, tile *Tile) []*TileChunk {
+	area := mapPoint{X: pt.X / levelChunkSize, Y: pt.Y / levelChunkSize}
+	chunk, ok := areas[area]
+	if !ok {
+		chunk = &TileChunk{
+			X:      area.X * levelChunkSize,
+			Y:      area.Y * levelChunkSize,
+			Width:  levelChunkSize,
+			Height: levelChunkSize,
+		}
+		if chunk.X+chunk.Width > w {
+			chunk.Width = w - chunk.X
+		}
+		if chunk.Y+chunk.Height > h {
+			chunk.Height = h - chunk.Y
+		}
+		areas[area] = chunk
+		chunks = append(chunks, chunk)
+	}
+	chunk.Tiles = append(chunk.Tiles, tile)
+	return chunks
+}
+
+// tileOrder returns the positions of the tiles of an area in the order they
+// are stored in the tile data, which follows the render order of the level.
+func (l *Level) tileOrder(x, y, w, h int) []mapPoint {
+	const (
+		ru = "right-up"
+		ld = "left-down"
+		lu = "left-up"
+	)
+	left := l.RenderOrder == ld || l.RenderOrder == lu
+	up := l.RenderOrder == ru || l.RenderOrder == lu
+
+	ret := make([]mapPoint, 0, w*h)
+	for row := 0; row < h; row++ {
+		ty := y + row
+		if up {
+			ty = y + h - 1 - row
+		}
+		for col := 0; col < w; col++ {
+			tx := x + col
+			if left {
+				tx = x + w - 1 - col
 			}
+			ret = append(ret, mapPoint{X: tx, Y: ty})
 		}
 	}
 	return ret
 }
 
+// chunkBounds returns the area covered by the tiles, which are at least a level
+// tile in size.
+func (l *Level) chunkBounds(tiles []*Tile) engo.AABB {
+	var bounds engo.AABB
+	for i, t := range tiles {
+		w, h := float32(l.TileWidth), float32(l.TileHeight)
+		if t.Image != nil {
+			w = math.Max(w, t.Width())
+			h = math.Max(h, t.Height())
+		}
+		if i == 0 {
+			bounds = engo.AABB{Min: t.Point, Max: t.Point}
+		}
+		bounds.Min.X = math.Min(bounds.Min.X, t.X)
+		bounds.Min.Y = math.Min(bounds.Min.Y, t.Y)
+		bounds.Max.X = math.Max(bounds.Max.X, t.X+w)
+		bounds.Max.Y = math.Max(bounds.Max.Y, t.Y+h)
+	}
+	return bounds
+}
+
+// fitChunks sets the size of infinite levels to the area covered by the chunks
+// of all tile layers.
+func (l *Level) fitChunks() {
+	if !l.Infinite {
+		return
+	}
+	minX, minY, maxX, maxY := 0, 0, l.width, l.height
+	first := true
+	for _, tl := range l.TileLayers {
+		for _, c := range tl.Chunks {
+			if first {
+				minX, minY, maxX, maxY = c.X, c.Y, c.X+c.Width, c.Y+c.Height
+				first = false
+				continue
+			}
+			if c.X < minX {
+				minX = c.X
+			}
+			if c.Y < minY {
+				minY = c.Y
+			}
+			if c.X+c.Width > maxX {
+				maxX = c.X + c.Width
+			}
+			if c.Y+c.Height > maxY {
+				maxY = c.Y + c.Height
+			}
+		}
+	}
+	l.x, l.y = minX, minY
+	l.width, l.height = maxX-minX, maxY-minY
+}
+
 func (l *Level) imageTiles(tmxURL string, imgs []tmx.Image, x, y float32) ([]*Tile, error) {
 	ret := make([]*Tile, 0)
 	for _, i := range imgs {
@@ -372,6 +492,8 @@ func (l *Level) tileFromGID(gid uint32, pt engo.Point) *Tile {
 	tex := l.resourceMap[gid]
 	ret.Image = &tex
 	ret.Point = pt
+	ret.gid = gid
+	ret.Properties = l.tileProperties[gid]
 
 	drawables, frames := []Drawable{}, []int{}
 	for i, id := range l.framesMap[gid] {
@@ -383,15 +505,3 @@ func (l *Level) tileFromGID(gid uint32, pt engo.Point) *Tile {
 
 	return ret
 }
-
-func getProperties(props []tmx.Property) []Property {
-	ret := make([]Property, 0)
-	for _, p := range props {
-		ret = append(ret, Property{
-			Name:  p.Name,
-			Type:  p.Type,
-			Value: p.Value,
-		})
-	}
-	return ret
-}
@@ -0,0 +1,288 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+// ObjectFactory creates the entities for a Tiled object and adds them to the
+// World of the spawn.
+type ObjectFactory func(spawn *ObjectSpawn) error
+
+// ObjectSpawn is passed to an ObjectFactory for every object it creates the
+// entities for.
+type ObjectSpawn struct {
+	// World is the World the entities are added to
+	World *ecs.World
+	// Level is the level containing the object
+	Level *Level
+	// Layer is the object layer containing the object
+	Layer *ObjectLayer
+	// ZIndex is the StartZIndex for the entities of the layer, which is above
+	// all tile layers of the level
+	ZIndex float32
+	// Object is the object to create the entities for
+	Object *Object
+	// Properties are the custom properties of the object, converted according
+	// to their type. These are int, float32, bool, color.NRGBA, the URL of a
+	// file (relative to the assets root), the referenced *Object, the members
+	// of a class as Properties, or string.
+	Properties map[string]interface{}
+}
+
+// ObjectRegistry holds the factories creating the entities of Tiled objects,
+// keyed by their class or type.
+type ObjectRegistry struct {
+	// Fonts maps the font families of text objects to the URLs of preloaded
+	// fonts.
+	Fonts map[string]string
+	// DefaultFont is the URL of the preloaded font used for text objects whose
+	// font family is not in Fonts.
+	DefaultFont string
+
+	mutex     sync.RWMutex
+	factories map[string]ObjectFactory
+}
+
+// ObjectFactories is the ObjectRegistry used by SpawnLevel.
+var ObjectFactories = &ObjectRegistry{}
+
+// Register sets the factory used for objects of the class or type typ.
+func (r *ObjectRegistry) Register(typ string, factory ObjectFactory) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.factories == nil {
+		r.factories = make(map[string]ObjectFactory)
+	}
+	r.factories[typ] = factory
+}
+
+// Unregister removes the factory used for objects of the class or type typ.
+func (r *ObjectRegistry) Unregister(typ string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.factories, typ)
+}
+
+// Factory returns the factory registered for objects of the class or type typ.
+func (r *ObjectRegistry) Factory(typ string) (ObjectFactory, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	f, ok := r.factories[typ]
+	return f, ok
+}
+
+// Spawn calls the registered factory of every object in the level's object
+// layers, looked up by the object's class and then its type. Text objects
+// without a factory are added to the World's RenderSystem as Text entities,
+// other objects without a factory are skipped.
+func (r *ObjectRegistry) Spawn(w *ecs.World, l *Level) error {
+	for i, layer := range l.ObjectLayers {
+		z := float32(len(l.TileLayers) + i)
+		for _, obj := range layer.Objects {
+			factory, ok := r.Factory(obj.Class)
+			if obj.Class == "" || !ok {
+				factory, ok = r.Factory(obj.Type)
+			}
+			if !ok {
+				if err := r.spawnText(w, layer, z, obj); err != nil {
+					return fmt.Errorf("object %d: %v", obj.ID, err)
+				}
+				continue
+			}
+
+			props, err := l.propertyValues(obj.Properties)
+			if err != nil {
+				return fmt.Errorf("object %d: %v", obj.ID, err)
+			}
+			spawn := &ObjectSpawn{
+				World:      w,
+				Level:      l,
+				Layer:      layer,
+				ZIndex:     z,
+				Object:     obj,
+				Properties: props,
+			}
+			if err = factory(spawn); err != nil {
+				return fmt.Errorf("object %d: %v", obj.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SpawnLevel spawns the objects of the level into the World using the
+// factories registered in ObjectFactories.
+func SpawnLevel(w *ecs.World, l *Level) error {
+	return ObjectFactories.Spawn(w, l)
+}
+
+type objectText struct {
+	ecs.BasicEntity
+	RenderComponent
+	SpaceComponent
+}
+
+func (r *ObjectRegistry) spawnText(w *ecs.World, layer *ObjectLayer, z float32, obj *Object) error {
+	if len(obj.Text) == 0 {
+		return nil
+	}
+	var render *RenderSystem
+	for _, system := range w.Systems() {
+		if sys, ok := system.(*RenderSystem); ok {
+			render = sys
+		}
+	}
+	if render == nil {
+		return errors.New("text objects need a RenderSystem")
+	}
+
+	for _, t := range obj.Text {
+		text, err := r.Text(t, obj)
+		if err != nil {
+			return err
+		}
+		e := &objectText{BasicEntity: ecs.NewBasic()}
+		e.RenderComponent = RenderComponent{
+			Drawable:    text,
+			Scale:       engo.Point{X: 1, Y: 1},
+			StartZIndex: z,
+		}
+		e.SpaceComponent = SpaceComponent{
+			Position: engo.Point{X: obj.X + layer.OffSetX, Y: obj.Y + layer.OffSetY},
+			Width:    obj.Width,
+			Height:   obj.Height,
+			Rotation: obj.Rotation,
+		}
+		render.Add(&e.BasicEntity, &e.RenderComponent, &e.SpaceComponent)
+	}
+	return nil
+}
+
+// Text returns the Text drawing the text of a Tiled object within the object's
+// box. The font is looked up by its family in Fonts, and has to be preloaded.
+func (r *ObjectRegistry) Text(t TMXText, obj *Object) (Text, error) {
+	url, ok := r.Fonts[t.FontFamily]
+	if !ok {
+		url = r.DefaultFont
+	}
+	if url == "" {
+		return Text{}, fmt.Errorf("no font for the font family %q", t.FontFamily)
+	}
+
+	size := float64(t.Size)
+	if size == 0 {
+		size = 16
+	}
+	fg := color.NRGBA{A: 255}
+	if t.Color != "" {
+		var err error
+		if fg, err = parseTMXColor(t.Color); err != nil {
+			return Text{}, err
+		}
+	}
+	fnt := &Font{URL: url, Size: size, FG: fg}
+	if err := fnt.CreatePreloaded(); err != nil {
+		return Text{}, err
+	}
+
+	text := Text{
+		Font:      fnt,
+		Text:      t.CharData,
+		MaxWidth:  obj.Width,
+		MaxHeight: obj.Height,
+	}
+	if t.WordWrap {
+		text.Wrap = WrapWord
+	}
+	switch t.Halign {
+	case "center":
+		text.Align = AlignCenter
+	case "right":
+		text.Align = AlignRight
+	case "justify":
+		text.Align = AlignJustify
+	}
+	switch t.Valign {
+	case "center":
+		text.VerticalAlign = AlignMiddle
+	case "bottom":
+		text.VerticalAlign = AlignBottom
+	}
+	return text, nil
+}
+
+// ObjectByID returns the object with the given ID from the level's object
+// layers, or nil if there is none.
+func (l *Level) ObjectByID(id uint32) *Object {
+	for _, layer := range l.ObjectLayers {
+		for _, obj := range layer.Objects {
+			if obj.ID == id {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+// propertyValues converts the values of the properties according to their type.
+func (l *Level) propertyValues(props Properties) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(props))
+	for _, p := range props {
+		var v interface{}
+		var ok bool
+		switch p.Type {
+		case "int":
+			v, ok = props.Int(p.Name)
+		case "float":
+			v, ok = props.Float(p.Name)
+		case "bool":
+			v, ok = props.Bool(p.Name)
+		case "color":
+			v, ok = props.Color(p.Name)
+		case "file":
+			v, ok = props.File(p.Name)
+		case "object":
+			if v, ok = props.Object(p.Name, l); !ok && p.Value == "0" {
+				v, ok = (*Object)(nil), true
+			}
+		case "class":
+			v, ok = p.Members, true
+		default:
+			v, ok = p.Value, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("property %q: invalid %s %q", p.Name, p.Type, p.Value)
+		}
+		values[p.Name] = v
+	}
+	return values, nil
+}
+
+// parseTMXColor parses a Tiled color, which is either #RRGGBB or #AARRGGBB. An
+// empty color is transparent.
+func parseTMXColor(s string) (color.NRGBA, error) {
+	if s == "" {
+		return color.NRGBA{}, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || (len(hex) != 6 && len(hex) != 8) {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	c := color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+	if len(hex) == 8 {
+		c.A = uint8(v >> 24)
+	}
+	return c, nil
+}
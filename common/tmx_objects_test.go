@@ -0,0 +1,186 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+func objectTestLevel() *Level {
+	target := &Object{ID: 2, Type: "door"}
+	enemy := &Object{ID: 1, Class: "enemy", Type: "ignored", X: 10, Y: 20, Properties: []Property{
+		{Name: "hp", Type: "int", Value: "3"},
+		{Name: "speed", Type: "float", Value: "1.5"},
+		{Name: "boss", Type: "bool", Value: "true"},
+		{Name: "tint", Type: "color", Value: "#80ff0000"},
+		{Name: "sprite", Type: "file", Value: "gfx/enemy.png", dir: "maps"},
+		{Name: "guards", Type: "object", Value: "2"},
+		{Name: "name", Value: "Bob"},
+	}}
+	return &Level{
+		TileLayers: []*TileLayer{{}},
+		ObjectLayers: []*ObjectLayer{
+			{Objects: []*Object{enemy}},
+			{Objects: []*Object{target}},
+		},
+	}
+}
+
+func TestObjectRegistrySpawn(t *testing.T) {
+	level := objectTestLevel()
+	r := &ObjectRegistry{}
+	var enemies, doors []*ObjectSpawn
+	r.Register("enemy", func(spawn *ObjectSpawn) error {
+		enemies = append(enemies, spawn)
+		return nil
+	})
+	r.Register("ignored", func(*ObjectSpawn) error {
+		t.Error("Factory of the type was used for an object with a class")
+		return nil
+	})
+	r.Register("door", func(spawn *ObjectSpawn) error {
+		doors = append(doors, spawn)
+		return nil
+	})
+
+	w := &ecs.World{}
+	if err := r.Spawn(w, level); err != nil {
+		t.Fatalf("Spawn returned an error: %v", err)
+	}
+	if len(enemies) != 1 || len(doors) != 1 {
+		t.Fatalf("Factories were called for %d enemies and %d doors, expected one each", len(enemies), len(doors))
+	}
+	spawn := enemies[0]
+	if spawn.World != w || spawn.Level != level || spawn.Layer != level.ObjectLayers[0] || spawn.Object.ID != 1 || spawn.ZIndex != 1 {
+		t.Errorf("Spawn was not passed the object and its level: %+v", spawn)
+	}
+	if doors[0].ZIndex != 2 {
+		t.Errorf("Spawn of the second object layer had the ZIndex %v, expected 2", doors[0].ZIndex)
+	}
+
+	exp := map[string]interface{}{
+		"hp":     3,
+		"speed":  float32(1.5),
+		"boss":   true,
+		"tint":   color.NRGBA{R: 255, A: 128},
+		"sprite": "maps/gfx/enemy.png",
+		"guards": level.ObjectLayers[1].Objects[0],
+		"name":   "Bob",
+	}
+	for name, v := range exp {
+		if spawn.Properties[name] != v {
+			t.Errorf("Property %q was %#v, expected %#v", name, spawn.Properties[name], v)
+		}
+	}
+
+	r.Unregister("door")
+	doors = nil
+	if err := r.Spawn(w, level); err != nil || len(doors) != 0 {
+		t.Error("Unregistered factory was still used")
+	}
+}
+
+func TestObjectRegistrySpawnErrors(t *testing.T) {
+	level := objectTestLevel()
+	r := &ObjectRegistry{}
+	r.Register("enemy", func(*ObjectSpawn) error { return errors.New("boom") })
+	if err := r.Spawn(&ecs.World{}, level); err == nil || !strings.Contains(err.Error(), "object 1") {
+		t.Errorf("Spawn returned %v, expected the error of the factory", err)
+	}
+
+	r.Register("enemy", func(*ObjectSpawn) error { return nil })
+	level.ObjectLayers[0].Objects[0].Properties = []Property{{Name: "guards", Type: "object", Value: "42"}}
+	if err := r.Spawn(&ecs.World{}, level); err == nil {
+		t.Error("Spawn did not return an error for a reference to a missing object")
+	}
+	level.ObjectLayers[0].Objects[0].Properties = []Property{{Name: "tint", Type: "color", Value: "red"}}
+	if err := r.Spawn(&ecs.World{}, level); err == nil {
+		t.Error("Spawn did not return an error for an invalid color")
+	}
+}
+
+func TestObjectRegistrySpawnText(t *testing.T) {
+	engo.Run(engo.RunOptions{HeadlessMode: true, NoRun: true, AssetsRoot: "testdata"}, &tmxTestScene{})
+	loadRoboto(t)
+
+	obj := &Object{ID: 1, X: 5, Y: 6, Width: 100, Height: 40, Text: []TMXText{{
+		FontFamily: "Roboto",
+		Size:       12,
+		Color:      "#00ff00",
+		Halign:     "center",
+		Valign:     "bottom",
+		WordWrap:   true,
+		CharData:   "Hello World",
+	}}}
+	level := &Level{ObjectLayers: []*ObjectLayer{{OffSetX: 1, Objects: []*Object{obj}}}}
+
+	w := &ecs.World{}
+	render := &RenderSystem{}
+	w.AddSystem(render)
+	r := &ObjectRegistry{}
+	if err := r.Spawn(w, level); err == nil {
+		t.Error("Spawn did not return an error for a text object without a font")
+	}
+
+	r.Fonts = map[string]string{"Roboto": "Roboto-Regular.ttf"}
+	if err := r.Spawn(w, level); err != nil {
+		t.Fatalf("Spawn returned an error: %v", err)
+	}
+	if len(render.entities) != 1 {
+		t.Fatalf("RenderSystem had %d entities, expected the text", len(render.entities))
+	}
+	e := render.entities[0]
+	text, ok := e.Drawable.(Text)
+	if !ok {
+		t.Fatalf("Drawable was a %T, expected a Text", e.Drawable)
+	}
+	if text.Text != "Hello World" || text.Font.Size != 12 || text.Font.FG != (color.NRGBA{G: 255, A: 255}) ||
+		text.MaxWidth != 100 || text.MaxHeight != 40 || text.Wrap != WrapWord || text.Align != AlignCenter || text.VerticalAlign != AlignBottom {
+		t.Errorf("Text was not created from the text object: %+v", text)
+	}
+	if e.Position != (engo.Point{X: 6, Y: 6}) {
+		t.Errorf("Text was at %v, expected the position of the object", e.Position)
+	}
+}
+
+func TestObjectRegistrySpawnTMX(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	level, err := createLevelFromTmx(bytes.NewBufferString(`<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.9" orientation="orthogonal" renderorder="right-down" width="1" height="1" tilewidth="16" tileheight="16" infinite="0" nextobjectid="4">
+ <objectgroup id="1" name="Objects">
+  <object id="1" class="enemy" x="1" y="2"/>
+  <object id="2" type="coin"/>
+  <object id="3" class="chest" type="coin"/>
+ </objectgroup>
+</map>`), "maps/objects.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+
+	spawned := make(map[uint32]string)
+	r := &ObjectRegistry{}
+	for _, typ := range []string{"enemy", "coin"} {
+		typ := typ
+		r.Register(typ, func(spawn *ObjectSpawn) error {
+			spawned[spawn.Object.ID] = typ
+			return nil
+		})
+	}
+	if err = r.Spawn(&ecs.World{}, level); err != nil {
+		t.Fatalf("Spawn returned an error: %v", err)
+	}
+	exp := map[uint32]string{1: "enemy", 2: "coin", 3: "coin"}
+	for id, typ := range exp {
+		if spawned[id] != typ {
+			t.Errorf("Object %d was spawned by the factory %q, expected %q", id, spawned[id], typ)
+		}
+	}
+	if obj := level.ObjectByID(3); obj == nil || obj.Class != "chest" || obj.Type != "coin" {
+		t.Errorf("Object 3 was %+v, expected the class and the type of the TMX", obj)
+	}
+}
@@ -0,0 +1,237 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/EngoEngine/engo"
+	"github.com/Noofbiz/tmx"
+)
+
+// Properties are the custom properties of a level, layer, object or tile.
+type Properties []Property
+
+// Get returns the property with the given name.
+func (p Properties) Get(name string) (Property, bool) {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+	return Property{}, false
+}
+
+// Value returns the value of the property with the given name.
+func (p Properties) Value(name string) (string, bool) {
+	prop, ok := p.Get(name)
+	return prop.Value, ok
+}
+
+// Int returns the value of the property with the given name as an int. It
+// returns false if there is no such property or its value is not an int.
+func (p Properties) Int(name string) (int, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(prop.Value)
+	return v, err == nil
+}
+
+// Float returns the value of the property with the given name as a float32.
+// It returns false if there is no such property or its value is not a number.
+func (p Properties) Float(name string) (float32, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(prop.Value, 32)
+	return float32(v), err == nil
+}
+
+// Bool returns the value of the property with the given name as a bool. It
+// returns false if there is no such property or its value is not a bool.
+func (p Properties) Bool(name string) (bool, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return false, false
+	}
+	v, err := strconv.ParseBool(prop.Value)
+	return v, err == nil
+}
+
+// Color returns the value of the property with the given name as a color. It
+// returns false if there is no such property or its value is not a color of
+// the form #RRGGBB or #AARRGGBB.
+func (p Properties) Color(name string) (color.NRGBA, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return color.NRGBA{}, false
+	}
+	c, err := parseTMXColor(prop.Value)
+	return c, err == nil
+}
+
+// Point returns the value of the property with the given name as a point. It
+// returns false if there is no such property or its value is not of the form
+// x,y.
+func (p Properties) Point(name string) (engo.Point, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return engo.Point{}, false
+	}
+	parts := strings.Split(prop.Value, ",")
+	if len(parts) != 2 {
+		return engo.Point{}, false
+	}
+	x, errX := strconv.ParseFloat(parts[0], 32)
+	y, errY := strconv.ParseFloat(parts[1], 32)
+	return engo.Point{X: float32(x), Y: float32(y)}, errX == nil && errY == nil
+}
+
+// File returns the URL of the file the property with the given name refers
+// to. Tiled stores files relative to the TMX file, the URL is relative to the
+// assets root like the URL of the TMX file, so it can be loaded with
+// engo.Files. It returns false if there is no such property.
+func (p Properties) File(name string) (string, bool) {
+	prop, ok := p.Get(name)
+	if !ok || prop.Value == "" {
+		return "", ok
+	}
+	return path.Join(prop.dir, prop.Value), true
+}
+
+// Object returns the object of the level the property with the given name
+// refers to. It returns false if there is no such property, or the level has
+// no object with the id it refers to.
+func (p Properties) Object(name string, l *Level) (*Object, bool) {
+	prop, ok := p.Get(name)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.ParseUint(prop.Value, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	obj := l.ObjectByID(uint32(id))
+	return obj, obj != nil
+}
+
+// Class returns the members of the class property with the given name. It
+// returns false if there is no such property.
+func (p Properties) Class(name string) (Properties, bool) {
+	prop, ok := p.Get(name)
+	return prop.Members, ok
+}
+
+// inherit returns the properties with the ones from base added, that have
+// names that are not in the properties.
+func (p Properties) inherit(base Properties) Properties {
+	if len(base) == 0 {
+		return p
+	}
+	ret := append(Properties{}, p...)
+	for _, prop := range base {
+		if _, ok := p.Get(prop.Name); !ok {
+			ret = append(ret, prop)
+		}
+	}
+	return ret
+}
+
+// tmxProperty is a property as stored in the TMX, including the members of
+// class properties, which the tmx package does not parse.
+type tmxProperty struct {
+	Name         string        `xml:"name,attr"`
+	Type         string        `xml:"type,attr"`
+	PropertyType string        `xml:"propertytype,attr"`
+	Value        *string       `xml:"value,attr"`
+	Text         string        `xml:",chardata"`
+	Members      []tmxProperty `xml:"properties>property"`
+}
+
+// tmxExtra holds what the tmx package does not parse from a TMX file. The
+// layers are in the same order as the ones of the tmx package.
+type tmxExtra struct {
+	Properties   []tmxProperty   `xml:"properties>property"`
+	Layers       []tmxExtraLayer `xml:"layer"`
+	ImageLayers  []tmxExtraLayer `xml:"imagelayer"`
+	ObjectGroups []struct {
+		tmxExtraLayer
+		Objects []tmxExtraObject `xml:"object"`
+	} `xml:"objectgroup"`
+	Tilesets []struct {
+		Tiles []tmxExtraObject `xml:"tile"`
+	} `xml:"tileset"`
+}
+
+type tmxExtraLayer struct {
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxExtraObject struct {
+	ID         uint32        `xml:"id,attr"`
+	Class      string        `xml:"class,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+func parseTMXExtra(data []byte) (tmxExtra, error) {
+	var extra tmxExtra
+	err := xml.NewDecoder(bytes.NewReader(data)).Decode(&extra)
+	return extra, err
+}
+
+// getProperties converts the properties parsed by the tmx package, or the
+// ones from the extra data if there are any, as these include the members of
+// class properties. dir is the directory of the TMX file.
+func getProperties(props []tmx.Property, extra []tmxProperty, dir string) Properties {
+	if len(extra) > 0 {
+		return extraProperties(extra, dir)
+	}
+	ret := make(Properties, 0)
+	for _, p := range props {
+		ret = append(ret, Property{
+			Name:  p.Name,
+			Type:  p.Type,
+			Value: p.Value,
+			dir:   dir,
+		})
+	}
+	return ret
+}
+
+func extraProperties(props []tmxProperty, dir string) Properties {
+	ret := make(Properties, 0, len(props))
+	for _, p := range props {
+		prop := Property{
+			Name:         p.Name,
+			Type:         p.Type,
+			PropertyType: p.PropertyType,
+			dir:          dir,
+		}
+		// multi-line strings are stored as the text of the property
+		if p.Value != nil {
+			prop.Value = *p.Value
+		} else if len(p.Members) == 0 {
+			prop.Value = p.Text
+		}
+		if len(p.Members) > 0 {
+			prop.Members = extraProperties(p.Members, dir)
+		}
+		ret = append(ret, prop)
+	}
+	return ret
+}
+
+func findExtraObject(objs []tmxExtraObject, id uint32) tmxExtraObject {
+	for _, o := range objs {
+		if o.ID == id {
+			return o
+		}
+	}
+	return tmxExtraObject{}
+}
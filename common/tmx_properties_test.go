@@ -0,0 +1,130 @@
+package common
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+)
+
+var propertiesTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.9" orientation="orthogonal" renderorder="right-down" width="1" height="1" tilewidth="16" tileheight="16" infinite="0" nextobjectid="4">
+ <properties>
+  <property name="title" value="Level 1"/>
+  <property name="ambient" type="color" value="#ff102030"/>
+ </properties>
+ <tileset firstgid="1" name="test" tilewidth="16" tileheight="16" tilecount="2" columns="2">
+  <image source="test.png" width="32" height="16"/>
+  <tile id="1" class="chest">
+   <properties>
+    <property name="gold" type="int" value="10"/>
+    <property name="locked" type="bool" value="true"/>
+   </properties>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="1" height="1">
+  <properties>
+   <property name="friction" type="float" value="0.5"/>
+  </properties>
+  <data encoding="csv">2</data>
+ </layer>
+ <objectgroup id="2" name="Objects">
+  <object id="1" name="spawn" class="enemy" x="8" y="8">
+   <properties>
+    <property name="sprite" type="file" value="../gfx/enemy.png"/>
+    <property name="target" type="object" value="2"/>
+    <property name="stats" type="class" propertytype="Stats">
+     <properties>
+      <property name="hp" type="int" value="3"/>
+      <property name="resist" type="class" propertytype="Resistances">
+       <properties>
+        <property name="fire" type="float" value="0.25"/>
+       </properties>
+      </property>
+     </properties>
+    </property>
+    <property name="lore">first line
+second line</property>
+   </properties>
+  </object>
+  <object id="2" name="chest" gid="2" x="32" y="32" width="16" height="16">
+   <properties>
+    <property name="gold" type="int" value="50"/>
+   </properties>
+  </object>
+ </objectgroup>
+</map>
+`
+
+func TestTMXProperties(t *testing.T) {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &tmxTestScene{})
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 32, 16))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("maps/test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+	level, err := createLevelFromTmx(bytes.NewBufferString(propertiesTMX), "maps/props.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+
+	if title, ok := level.Properties.Value("title"); !ok || title != "Level 1" {
+		t.Errorf("Level property was %q, expected %q", title, "Level 1")
+	}
+	if c, ok := level.Properties.Color("ambient"); !ok || c != (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}) {
+		t.Errorf("Color property was %v", c)
+	}
+	if f, ok := level.TileLayers[0].Properties.Float("friction"); !ok || f != 0.5 {
+		t.Errorf("Float property of the layer was %v", f)
+	}
+	if _, ok := level.Properties.Int("title"); ok {
+		t.Error("Int returned a string property")
+	}
+	if _, ok := level.Properties.Int("missing"); ok {
+		t.Error("Int returned a missing property")
+	}
+
+	tile := level.TileLayers[0].Tiles[0]
+	if gold, ok := tile.Properties.Int("gold"); !ok || gold != 10 {
+		t.Errorf("Tile property was %v, expected the property of its tileset tile", gold)
+	}
+
+	objects := level.ObjectLayers[0].Objects
+	props := objects[0].Properties
+	if file, ok := props.File("sprite"); !ok || file != "gfx/enemy.png" {
+		t.Errorf("File property was %q, expected it relative to the assets root", file)
+	}
+	if obj, ok := props.Object("target", level); !ok || obj != objects[1] {
+		t.Error("Object property did not return the referenced object")
+	}
+	stats, ok := props.Class("stats")
+	if p, _ := props.Get("stats"); !ok || p.PropertyType != "Stats" {
+		t.Errorf("Class property had the type %q, expected Stats", p.PropertyType)
+	}
+	if hp, ok := stats.Int("hp"); !ok || hp != 3 {
+		t.Errorf("Member of the class property was %v, expected 3", hp)
+	}
+	resist, _ := stats.Class("resist")
+	if fire, ok := resist.Float("fire"); !ok || fire != 0.25 {
+		t.Errorf("Member of the nested class property was %v, expected 0.25", fire)
+	}
+	if lore, _ := props.Value("lore"); lore != "first line\nsecond line" {
+		t.Errorf("Multi-line property was %q", lore)
+	}
+
+	chest := objects[1]
+	if chest.Class != "chest" {
+		t.Errorf("Tile object had the class %q, expected the class of its tile", chest.Class)
+	}
+	if gold, _ := chest.Properties.Int("gold"); gold != 50 {
+		t.Errorf("Tile object property was %v, expected its own value to override the tile's", gold)
+	}
+	if locked, ok := chest.Properties.Bool("locked"); !ok || !locked {
+		t.Error("Tile object did not inherit the properties of its tile")
+	}
+}
@@ -0,0 +1,262 @@
+package common
+
+import (
+	"log"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// ChunkStreamerPriority is the priority of the ChunkStreamer. It is lower than
+// the CameraSystem's, so the chunks are streamed after the camera moved, and
+// higher than the RenderSystem's, so they are drawn in the same frame.
+const ChunkStreamerPriority = -100
+
+// ChunkLoadedMessage is dispatched by the ChunkStreamer when the tiles of a
+// chunk are added to the RenderSystem.
+type ChunkLoadedMessage struct {
+	Layer *TileLayer
+	Chunk *TileChunk
+}
+
+// Type implements the engo.Message interface
+func (ChunkLoadedMessage) Type() string {
+	return "ChunkLoadedMessage"
+}
+
+// ChunkUnloadedMessage is dispatched by the ChunkStreamer when the tiles of a
+// chunk are removed from the RenderSystem.
+type ChunkUnloadedMessage struct {
+	Layer *TileLayer
+	Chunk *TileChunk
+}
+
+// Type implements the engo.Message interface
+func (ChunkUnloadedMessage) Type() string {
+	return "ChunkUnloadedMessage"
+}
+
+type chunkTile struct {
+	ecs.BasicEntity
+	AnimationComponent
+	RenderComponent
+	SpaceComponent
+}
+
+type loadedChunk struct {
+	layer *TileLayer
+	z     float32
+	tiles map[*Tile]*chunkTile
+}
+
+// ChunkStreamer is a System that only renders the chunks of a Level's tile
+// layers which are near the camera. The tiles of a chunk are added to the
+// RenderSystem (and the AnimationSystem, if there is one) when the chunk comes
+// into view, and removed again when the camera moves away from it, so huge
+// levels don't have all of their tiles in the RenderSystem at once. Tiles
+// changed with SetTile or ClearTile are updated in the loaded chunks. The
+// RenderSystem has to be added to the World before the ChunkStreamer, and
+// Close has to be called once the ChunkStreamer is not used anymore.
+type ChunkStreamer struct {
+	// Level is the level whose tile layers are streamed
+	Level *Level
+	// Margin is the distance in pixels around the visible area within which
+	// chunks are loaded. Chunks are unloaded once they are further away than
+	// twice the Margin, so they don't get reloaded while the camera moves back
+	// and forth along the edge of a chunk.
+	Margin float32
+
+	camera    *CameraSystem
+	render    *RenderSystem
+	animation *AnimationSystem
+	loaded    map[*TileChunk]loadedChunk
+	// mailbox is the one the handler of the TileChangedMessages was added to
+	mailbox  *engo.MessageManager
+	listener engo.MessageHandlerId
+}
+
+// New finds the systems the chunks are added to.
+func (s *ChunkStreamer) New(w *ecs.World) {
+	s.loaded = make(map[*TileChunk]loadedChunk)
+	for _, system := range w.Systems() {
+		switch sys := system.(type) {
+		case *CameraSystem:
+			s.camera = sys
+		case *RenderSystem:
+			s.render = sys
+		case *AnimationSystem:
+			s.animation = sys
+		}
+	}
+
+	if s.render == nil {
+		log.Println("ERROR: RenderSystem not found - have you added the `RenderSystem` before the `ChunkStreamer`?")
+	}
+	if s.camera == nil {
+		log.Println("ERROR: CameraSystem not found - have you added the `RenderSystem` before the `ChunkStreamer`?")
+	}
+
+	s.mailbox = engo.Mailbox
+	s.listener = s.mailbox.Listen("TileChangedMessage", func(msg engo.Message) {
+		m, ok := msg.(TileChangedMessage)
+		if !ok || m.Level != s.Level {
+			return
+		}
+		s.tileChanged(m.Chunk, m.Tile)
+	})
+}
+
+// Close removes the tiles of all loaded chunks, and stops updating them when
+// tiles are changed.
+func (s *ChunkStreamer) Close() {
+	if s.mailbox != nil {
+		s.mailbox.StopListen("TileChangedMessage", s.listener)
+		s.mailbox = nil
+	}
+	s.UnloadAll()
+}
+
+// Priority implements the ecs.Prioritizer interface.
+func (*ChunkStreamer) Priority() int { return ChunkStreamerPriority }
+
+// Remove does nothing, as the ChunkStreamer creates and removes the entities
+// of the tiles itself. This implements the ecs.System interface.
+func (*ChunkStreamer) Remove(ecs.BasicEntity) {}
+
+// Update loads the chunks near the camera and unloads the ones that are too
+// far away from it.
+func (s *ChunkStreamer) Update(float32) {
+	if s.Level == nil || s.render == nil || s.camera == nil {
+		return
+	}
+
+	view := s.View()
+	load := growAABB(view, s.Margin)
+	keep := growAABB(view, 2*s.Margin)
+	for chunk, lc := range s.loaded {
+		if !IsIntersecting(chunk.Bounds, keep) {
+			s.unload(chunk, lc)
+		}
+	}
+	for i, layer := range s.Level.TileLayers {
+		for _, chunk := range layer.Chunks {
+			if _, ok := s.loaded[chunk]; ok || !IsIntersecting(chunk.Bounds, load) {
+				continue
+			}
+			s.load(layer, float32(i), chunk)
+		}
+	}
+}
+
+// View returns the area that is visible through the camera, in space / render
+// coordinates. For a rotated camera it is the area visible at any angle.
+func (s *ChunkStreamer) View() engo.AABB {
+	if s.camera == nil {
+		return engo.AABB{}
+	}
+	halfW := engo.GameWidth() / 2 * s.camera.Z()
+	halfH := engo.GameHeight() / 2 * s.camera.Z()
+	if s.camera.Angle() != 0 {
+		halfW = math.Sqrt(halfW*halfW + halfH*halfH)
+		halfH = halfW
+	}
+	scale := engo.GetGlobalScale()
+	return engo.AABB{
+		Min: engo.Point{X: (s.camera.X() - halfW) / scale.X, Y: (s.camera.Y() - halfH) / scale.Y},
+		Max: engo.Point{X: (s.camera.X() + halfW) / scale.X, Y: (s.camera.Y() + halfH) / scale.Y},
+	}
+}
+
+// Loaded returns whether the tiles of the chunk are currently rendered.
+func (s *ChunkStreamer) Loaded(chunk *TileChunk) bool {
+	_, ok := s.loaded[chunk]
+	return ok
+}
+
+// UnloadAll removes the tiles of all loaded chunks. They are loaded again
+// during the next Update, so this can be used after changing the Level.
+func (s *ChunkStreamer) UnloadAll() {
+	for chunk, lc := range s.loaded {
+		s.unload(chunk, lc)
+	}
+}
+
+func (s *ChunkStreamer) load(layer *TileLayer, z float32, chunk *TileChunk) {
+	lc := loadedChunk{layer: layer, z: z, tiles: make(map[*Tile]*chunkTile)}
+	for _, t := range chunk.Tiles {
+		if t.gid == 0 {
+			continue
+		}
+		lc.tiles[t] = s.add(t, z)
+	}
+	s.loaded[chunk] = lc
+	engo.Mailbox.Dispatch(ChunkLoadedMessage{Layer: layer, Chunk: chunk})
+}
+
+func (s *ChunkStreamer) add(t *Tile, z float32) *chunkTile {
+	tile := &chunkTile{BasicEntity: ecs.NewBasic()}
+	tile.RenderComponent = RenderComponent{
+		Drawable:    t.Image,
+		Scale:       engo.Point{X: 1, Y: 1},
+		StartZIndex: z,
+	}
+	tile.SpaceComponent = SpaceComponent{Position: t.Point}
+	s.render.Add(&tile.BasicEntity, &tile.RenderComponent, &tile.SpaceComponent)
+	s.animate(t, tile)
+	return tile
+}
+
+func (s *ChunkStreamer) animate(t *Tile, tile *chunkTile) {
+	if len(t.Drawables) > 0 && s.animation != nil {
+		tile.AnimationComponent = NewAnimationComponent(t.Drawables, 0.5)
+		tile.AnimationComponent.AddDefaultAnimation(t.Animation)
+		s.animation.Add(&tile.BasicEntity, &tile.AnimationComponent, &tile.RenderComponent)
+	}
+}
+
+// tileChanged updates the entity of a tile in a loaded chunk after it was
+// changed by SetTile or ClearTile.
+func (s *ChunkStreamer) tileChanged(chunk *TileChunk, t *Tile) {
+	lc, ok := s.loaded[chunk]
+	if !ok {
+		return
+	}
+	tile, ok := lc.tiles[t]
+	switch {
+	case !ok && t.gid != 0:
+		lc.tiles[t] = s.add(t, lc.z)
+	case ok && t.gid == 0:
+		s.render.Remove(tile.BasicEntity)
+		if s.animation != nil {
+			s.animation.Remove(tile.BasicEntity)
+		}
+		delete(lc.tiles, t)
+	case ok:
+		tile.Drawable = t.Image
+		if s.animation != nil {
+			s.animation.Remove(tile.BasicEntity)
+		}
+		s.animate(t, tile)
+	}
+}
+
+func (s *ChunkStreamer) unload(chunk *TileChunk, lc loadedChunk) {
+	for _, tile := range lc.tiles {
+		s.render.Remove(tile.BasicEntity)
+		if s.animation != nil {
+			s.animation.Remove(tile.BasicEntity)
+		}
+	}
+	delete(s.loaded, chunk)
+	engo.Mailbox.Dispatch(ChunkUnloadedMessage{Layer: lc.layer, Chunk: chunk})
+}
+
+// growAABB returns the AABB extended by d on every side.
+func growAABB(a engo.AABB, d float32) engo.AABB {
+	a.Min.X -= d
+	a.Min.Y -= d
+	a.Max.X += d
+	a.Max.Y += d
+	return a
+}
@@ -0,0 +1,97 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+)
+
+func streamTestChunk(x, y float32) *TileChunk {
+	chunk := &TileChunk{Width: 2, Height: 1}
+	for i := float32(0); i < 2; i++ {
+		chunk.Tiles = append(chunk.Tiles, &Tile{Point: engo.Point{X: x + i*16, Y: y}, Image: &Texture{}, gid: 1})
+	}
+	chunk.Tiles = append(chunk.Tiles, &Tile{Point: engo.Point{X: x, Y: y}, Image: &Texture{}})
+	chunk.Bounds = engo.AABB{Min: engo.Point{X: x, Y: y}, Max: engo.Point{X: x + 32, Y: y + 16}}
+	return chunk
+}
+
+func TestChunkStreamer(t *testing.T) {
+	engo.Run(engo.RunOptions{
+		NoRun:        true,
+		HeadlessMode: true,
+		Width:        160,
+		Height:       160,
+	}, &tmxTestScene{})
+	CameraBounds = engo.AABB{Min: engo.Point{X: -5000, Y: -5000}, Max: engo.Point{X: 5000, Y: 5000}}
+	defer func() { CameraBounds = engo.AABB{} }()
+
+	near, far := streamTestChunk(0, 0), streamTestChunk(2000, 0)
+	level := &Level{TileLayers: []*TileLayer{{Chunks: []*TileChunk{near, far}}}}
+
+	var loaded, unloaded []*TileChunk
+	engo.Mailbox.Listen("ChunkLoadedMessage", func(msg engo.Message) {
+		loaded = append(loaded, msg.(ChunkLoadedMessage).Chunk)
+	})
+	engo.Mailbox.Listen("ChunkUnloadedMessage", func(msg engo.Message) {
+		unloaded = append(unloaded, msg.(ChunkUnloadedMessage).Chunk)
+	})
+
+	w := &ecs.World{}
+	render := &RenderSystem{}
+	w.AddSystem(render)
+	streamer := &ChunkStreamer{Level: level, Margin: 32}
+	w.AddSystem(streamer)
+
+	streamer.camera.moveToX(80)
+	streamer.camera.moveToY(80)
+	streamer.Update(0)
+	if !streamer.Loaded(near) || streamer.Loaded(far) {
+		t.Error("Only the chunk in view should have been loaded")
+	}
+	if len(render.ids) != 2 {
+		t.Errorf("RenderSystem had %d entities, expected the 2 tiles of the chunk", len(render.ids))
+	}
+	if len(loaded) != 1 || loaded[0] != near {
+		t.Errorf("ChunkLoadedMessage was dispatched for %v, expected the chunk in view", loaded)
+	}
+
+	// within twice the margin the chunk is kept
+	streamer.camera.moveToX(170)
+	streamer.Update(0)
+	if !streamer.Loaded(near) {
+		t.Error("Chunk within twice the margin was unloaded")
+	}
+
+	streamer.camera.moveToX(2100)
+	streamer.Update(0)
+	if streamer.Loaded(near) || !streamer.Loaded(far) {
+		t.Error("Chunks were not streamed after the camera moved")
+	}
+	if len(render.ids) != 2 {
+		t.Errorf("RenderSystem had %d entities, expected the 2 tiles of the chunk", len(render.ids))
+	}
+	if len(unloaded) != 1 || unloaded[0] != near {
+		t.Errorf("ChunkUnloadedMessage was dispatched for %v, expected the chunk out of view", unloaded)
+	}
+
+	streamer.UnloadAll()
+	if streamer.Loaded(far) || len(render.ids) != 0 {
+		t.Error("UnloadAll did not remove all tiles")
+	}
+
+	streamer.Update(0)
+	streamer.Close()
+	if streamer.Loaded(far) || len(render.ids) != 0 {
+		t.Error("Close did not remove all tiles")
+	}
+
+	// The tiles loaded after closing are not updated anymore
+	streamer.Update(0)
+	far.Tiles[0].gid = 0
+	engo.Mailbox.Dispatch(TileChangedMessage{Level: level, Chunk: far, Tile: far.Tiles[0]})
+	if len(render.ids) != 2 {
+		t.Error("Closed ChunkStreamer still handled a TileChangedMessage")
+	}
+}
@@ -11,11 +11,12 @@ import (
 	"text/template"
 
 	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
 )
 
 var testTMXtmpl = `
 <?xml version="1.0" encoding="UTF-8"?>
-<map version="1.0" tiledversion="1.1.5" orientation="{{ .Orientation }}" renderorder="{{ .RenderOrder }}" width="3" height="3" tilewidth="16" tileheight="16" infinite="0" nextobjectid="1">
+<map version="1.0" tiledversion="1.1.5" orientation="{{ .Orientation }}"{{ if .StaggerAxis }} staggeraxis="{{ .StaggerAxis }}" staggerindex="{{ .StaggerIndex }}" hexsidelength="{{ .HexSideLength }}"{{ end }} renderorder="{{ .RenderOrder }}" width="3" height="3" tilewidth="16" tileheight="16" infinite="0" nextobjectid="1">
  <tileset firstgid="1" name="test" tilewidth="16" tileheight="16" spacing="1" tilecount="468" columns="26">
   {{ if .Grid }}
   <grid orientation="isometric" width="1" height="1"/>
@@ -100,6 +101,8 @@ type tmxData struct {
 	Orientation, RenderOrder                                  string
 	BadExtensions, BadImageExtension, BadObjectImageExtension string
 	Grid, Tiles, InvalidImageTile, ObjectImageTest, ChunkData bool
+	StaggerAxis, StaggerIndex                                 string
+	HexSideLength                                             int
 }
 
 type tmxTestScene struct{}
@@ -721,3 +724,317 @@ func TestTMXLevelIsometric(t *testing.T) {
 		t.Errorf("Tile was not returned correctly\nWanted: %v\nGot: %v", expTile, tile.Point)
 	}
 }
+
+func TestTMXLevelInfinite(t *testing.T) {
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+	tmpl, err := template.New("test").Parse(testTMXtmpl)
+	if err != nil {
+		t.Fatal("Error parsing tmx template")
+	}
+	buf := bytes.NewBuffer([]byte{})
+	if err = tmpl.Execute(buf, tmxData{Orientation: "orthogonal", RenderOrder: "right-down", ChunkData: true}); err != nil {
+		t.Fatal("Error executing tmx template")
+	}
+	level, err := createLevelFromTmx(buf, "test.tmx", engo.Files.GetRoot())
+	if err != nil {
+		t.Fatalf("Unable to create level. Error was: %v", err)
+	}
+
+	if !level.Infinite {
+		t.Error("Level with chunks was not infinite")
+	}
+	chunks := level.TileLayers[0].Chunks
+	if len(chunks) != 2 {
+		t.Fatalf("Chunked layer had %d chunks, expected 2", len(chunks))
+	}
+	if chunks[0].X != -32 || chunks[0].Y != -16 || chunks[0].Width != 16 || chunks[0].Height != 16 || len(chunks[0].Tiles) != 256 {
+		t.Errorf("Chunk was not unpacked correctly. Got: %d,%d %dx%d with %d tiles", chunks[0].X, chunks[0].Y, chunks[0].Width, chunks[0].Height, len(chunks[0].Tiles))
+	}
+	exp := engo.AABB{Min: engo.Point{X: -512, Y: -256}, Max: engo.Point{X: -256, Y: 0}}
+	if chunks[0].Bounds != exp {
+		t.Errorf("Chunk bounds were not returned correctly\nWanted: %v\nGot: %v", exp, chunks[0].Bounds)
+	}
+	if chunks := level.TileLayers[1].Chunks; len(chunks) != 1 || chunks[0].Width != 3 || chunks[0].Height != 3 || len(chunks[0].Tiles) != 9 {
+		t.Error("Tiles of a finite layer were not grouped in a chunk")
+	}
+
+	exp = engo.AABB{Min: engo.Point{X: -512, Y: -256}, Max: engo.Point{X: 48, Y: 48}}
+	if bounds := level.Bounds(); bounds != exp {
+		t.Errorf("Bounds was not returned correctly\nWanted: %v\nGot: %v", exp, bounds)
+	}
+	if level.Width() != 35 || level.Height() != 19 {
+		t.Errorf("Level size was %dx%d, expected 35x19", level.Width(), level.Height())
+	}
+	tile := level.GetTile(engo.Point{X: -250, Y: -10})
+	expTile := engo.Point{X: -256, Y: -16}
+	if tile == nil || tile.Point != expTile {
+		t.Errorf("Tile in a chunk was not returned correctly\nWanted: %v\nGot: %v", expTile, tile)
+	}
+}
+
+func TestTMXLevelStaggered(t *testing.T) {
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+	tmpl, err := template.New("test").Parse(testTMXtmpl)
+	if err != nil {
+		t.Fatal("Error parsing tmx template")
+	}
+
+	tests := []struct {
+		data     tmxData
+		bounds   engo.AABB
+		pick     engo.Point
+		expTile  engo.Point
+		outsides []engo.Point
+	}{
+		{
+			data:     tmxData{Orientation: "hexagonal", StaggerAxis: "y", StaggerIndex: "odd", HexSideLength: 8},
+			bounds:   engo.AABB{Max: engo.Point{X: 56, Y: 40}},
+			pick:     engo.Point{X: 32, Y: 20},
+			expTile:  engo.Point{X: 24, Y: 12},
+			outsides: []engo.Point{{X: 1, Y: 1}, {X: 17, Y: 1}},
+		},
+		{
+			data:     tmxData{Orientation: "hexagonal", StaggerAxis: "x", StaggerIndex: "even", HexSideLength: 8},
+			bounds:   engo.AABB{Max: engo.Point{X: 40, Y: 56}},
+			pick:     engo.Point{X: 20, Y: 24},
+			expTile:  engo.Point{X: 12, Y: 16},
+			outsides: []engo.Point{{X: 1, Y: 1}},
+		},
+		{
+			data:     tmxData{Orientation: "staggered", StaggerAxis: "y", StaggerIndex: "odd"},
+			bounds:   engo.AABB{Max: engo.Point{X: 56, Y: 32}},
+			pick:     engo.Point{X: 32, Y: 16},
+			expTile:  engo.Point{X: 24, Y: 8},
+			outsides: []engo.Point{{X: 1, Y: 1}, {X: 55, Y: 31}},
+		},
+		{
+			data:     tmxData{Orientation: "staggered", StaggerAxis: "x", StaggerIndex: "even"},
+			bounds:   engo.AABB{Max: engo.Point{X: 32, Y: 56}},
+			pick:     engo.Point{X: 16, Y: 24},
+			expTile:  engo.Point{X: 8, Y: 16},
+			outsides: []engo.Point{{X: 1, Y: 1}},
+		},
+	}
+	for _, test := range tests {
+		test.data.RenderOrder = "right-down"
+		buf := bytes.NewBuffer([]byte{})
+		if err = tmpl.Execute(buf, test.data); err != nil {
+			t.Fatal("Error executing tmx template")
+		}
+		level, err := createLevelFromTmx(buf, "test.tmx", engo.Files.GetRoot())
+		if err != nil {
+			t.Fatalf("Unable to create %v level. Error was: %v", test.data.Orientation, err)
+		}
+		name := test.data.Orientation + " " + test.data.StaggerAxis + " " + test.data.StaggerIndex
+
+		if bounds := level.Bounds(); bounds != test.bounds {
+			t.Errorf("Bounds of the %v level was not returned correctly\nWanted: %v\nGot: %v", name, test.bounds, bounds)
+		}
+		tile := level.GetTile(test.pick)
+		if tile == nil {
+			t.Errorf("No tile of the %v level was returned at %v", name, test.pick)
+		} else if tile.Point != test.expTile {
+			t.Errorf("Tile of the %v level was not returned correctly\nWanted: %v\nGot: %v", name, test.expTile, tile.Point)
+		}
+		for _, pt := range test.outsides {
+			if tile := level.GetTile(pt); tile != nil {
+				t.Errorf("Tile of the %v level was returned at %v, which is outside of the tiles", name, pt)
+			}
+		}
+	}
+}
+
+func TestTMXReload(t *testing.T) {
+	engo.Run(engo.RunOptions{
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &tmxTestScene{})
+
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+
+	tmpl, err := template.New("test").Parse(testTMXtmpl)
+	if err != nil {
+		t.Fatal("Error parsing tmx template")
+	}
+	loader := &tmxLoader{levels: make(map[string]TMXResource), root: engo.Files.GetRoot()}
+	for i, orientation := range []string{"orthogonal", "isometric"} {
+		buf := bytes.NewBuffer([]byte{})
+		if err = tmpl.Execute(buf, tmxData{Orientation: orientation, RenderOrder: "right-down"}); err != nil {
+			t.Fatal("Error executing tmx template")
+		}
+		if i == 0 {
+			err = loader.Load("reload.tmx", buf)
+		} else {
+			err = loader.Reload("reload.tmx", buf)
+		}
+		if err != nil {
+			t.Fatalf("Unable to load tmx file for testing. Error was: %v", err)
+		}
+	}
+
+	res, _ := loader.Resource("reload.tmx")
+	lvl := res.(TMXResource).Level
+	if lvl.Orientation != "isometric" {
+		t.Errorf("Reloaded level has orientation %v, expected isometric", lvl.Orientation)
+	}
+
+	// The Level is updated in place
+	if err := loader.Reload("reload.tmx", bytes.NewBufferString(badTMX)); err == nil {
+		t.Error("Reloading a bad tmx file did not return an error")
+	}
+	res, _ = loader.Resource("reload.tmx")
+	if res.(TMXResource).Level != lvl || lvl.Orientation != "isometric" {
+		t.Error("Reloading a bad tmx file changed the level")
+	}
+}
+
+func TestTMXReloadMessage(t *testing.T) {
+	engo.Run(engo.RunOptions{
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &tmxTestScene{})
+
+	dir, err := ioutil.TempDir(".", "testing")
+	if err != nil {
+		t.Fatalf("failed to create temp directory for testing, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	engo.Files.SetRoot(dir)
+
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "test.png"), imgbuf.Bytes(), 0666); err != nil {
+		t.Fatalf("failed to create temp file for testing, error: %v", err)
+	}
+	tmpl, err := template.New("test").Parse(testTMXtmpl)
+	if err != nil {
+		t.Fatal("Error parsing tmx template")
+	}
+	write := func(orientation string) {
+		buf := bytes.NewBuffer([]byte{})
+		if err := tmpl.Execute(buf, tmxData{Orientation: orientation, RenderOrder: "right-down"}); err != nil {
+			t.Fatal("Error executing tmx template")
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "message.tmx"), buf.Bytes(), 0666); err != nil {
+			t.Fatalf("failed to create temp file for testing, error: %v", err)
+		}
+	}
+
+	write("orthogonal")
+	if err := engo.Files.Load("message.tmx"); err != nil {
+		t.Fatalf("Unable to load tmx file for testing. Error was: %v", err)
+	}
+	defer engo.Files.Unload("message.tmx")
+	res, _ := engo.Files.Resource("message.tmx")
+	lvl := res.(TMXResource).Level
+	tile := lvl.TileLayers[0].Tiles[0]
+
+	// the Scene is told to rebuild the entities created from the Level
+	var msgs []engo.ResourceReloadedMessage
+	engo.Mailbox.Listen("ResourceReloadedMessage", func(msg engo.Message) {
+		msgs = append(msgs, msg.(engo.ResourceReloadedMessage))
+	})
+	write("isometric")
+	if err := engo.Files.Reload("message.tmx"); err != nil {
+		t.Fatalf("Unable to reload tmx file. Error was: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].URL != "message.tmx" {
+		t.Fatalf("ResourceReloadedMessages were %+v, expected one for the level", msgs)
+	}
+	if msgs[0].Resource.(TMXResource).Level != lvl || lvl.Orientation != "isometric" {
+		t.Error("Reloaded level was not updated in place")
+	}
+	if lvl.TileLayers[0].Tiles[0] == tile {
+		t.Error("Reloaded level kept the old tiles, expected entities created from them to have to be rebuilt")
+	}
+}
+
+func TestImageReload(t *testing.T) {
+	engo.Run(engo.RunOptions{
+		NoRun:        true,
+		HeadlessMode: true,
+	}, &tmxTestScene{})
+
+	for i, size := range []int{4, 8} {
+		imgbuf := bytes.NewBuffer([]byte{})
+		if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+			t.Fatal("Unable to encode png from image")
+		}
+		var err error
+		if i == 0 {
+			err = imgLoader.Load("reload.png", imgbuf)
+		} else {
+			err = imgLoader.Reload("reload.png", imgbuf)
+		}
+		if err != nil {
+			t.Fatalf("Unable to load test png. Error was: %v", err)
+		}
+	}
+
+	res, err := imgLoader.Resource("reload.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tex := res.(TextureResource); tex.Width != 8 || tex.Height != 8 || tex.URL() != "reload.png" {
+		t.Errorf("Reloaded texture was %vx%v, expected 8x8", tex.Width, tex.Height)
+	}
+}
+
+func TestLevelObjectLines(t *testing.T) {
+	level := &Level{
+		Orientation: "orthogonal",
+		ObjectLayers: []*ObjectLayer{
+			{Name: "Walls", Objects: []*Object{
+				{X: 10, Y: 10, Width: 20, Height: 10},
+				{X: 0, Y: 0, Width: 10, Height: 10, Rotation: 90},
+				{X: 0, Y: 0, Width: 16, Height: 16, Tiles: []*Tile{{gid: 1}}},
+				{X: 0, Y: 0, Width: 16, Height: 16, Ellipses: []TMXCircle{{}}},
+				{Lines: []TMXLine{{Type: "Polyline", Lines: []*engo.Line{
+					{P1: engo.Point{X: 0, Y: 0}, P2: engo.Point{X: 5, Y: 0}},
+					{P1: engo.Point{X: 5, Y: 0}, P2: engo.Point{X: 5, Y: 5}},
+				}}}},
+			}},
+			{Name: "Other", Objects: []*Object{{Width: 10, Height: 10}}},
+		},
+	}
+
+	lines := level.ObjectLines("Walls")
+	if len(lines) != 10 {
+		t.Fatalf("Level had %d object lines, expected the 8 lines of the rectangles and the 2 of the polyline", len(lines))
+	}
+	if lines[0] != (engo.Line{P1: engo.Point{X: 10, Y: 10}, P2: engo.Point{X: 30, Y: 10}}) {
+		t.Errorf("First line of the rectangle was %v", lines[0])
+	}
+	if p := lines[4].P2; math.Abs(p.X) > 1e-3 || math.Abs(p.Y-10) > 1e-3 {
+		t.Errorf("Rotated rectangle had the corner %v, expected it to be rotated around its position", p)
+	}
+	if len(level.ObjectLines()) != 14 {
+		t.Error("Lines of all object layers were not returned without names")
+	}
+
+	level.Orientation = "isometric"
+	level.TileWidth, level.TileHeight = 32, 16
+	if lines = level.ObjectLines("Walls"); lines[0].P1 != (engo.Point{X: 0, Y: 10}) {
+		t.Errorf("Isometric object was at %v, expected it to be converted to space coordinates", lines[0].P1)
+	}
+}
@@ -0,0 +1,255 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Noofbiz/tmx"
+)
+
+// The types of Wang sets, depending on which parts of the tiles have a color.
+const (
+	WangCorner = "corner"
+	WangEdge   = "edge"
+	WangMixed  = "mixed"
+)
+
+// wangID holds the colors of the parts of a Wang tile, starting with the top
+// edge and going clockwise: top, top-right, right, bottom-right, bottom,
+// bottom-left, left and top-left. A color of 0 means the part has no color.
+type wangID [8]uint8
+
+// wangOffsets are the positions of the parts of a wangID within a tile, on a
+// grid with twice the resolution of the tile grid, so the parts that tiles
+// share end up at the same position.
+var wangOffsets = [8]mapPoint{
+	{X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2},
+	{X: 1, Y: 2}, {X: 0, Y: 2}, {X: 0, Y: 1}, {X: 0, Y: 0},
+}
+
+type wangTile struct {
+	gid uint32
+	id  wangID
+}
+
+// WangSet is a Wang set or terrain of a tileset. The tiles of a Wang set have a
+// color at their corners, edges, or both, and SetTile picks the tiles next to
+// a tile of the set so their colors match.
+type WangSet struct {
+	// Name is the name of the Wang set, or "terrains" for the terrains of a
+	// tileset
+	Name string
+	// Type is WangCorner, WangEdge or WangMixed
+	Type string
+	// Colors is the number of colors of the Wang set. The colors are numbered
+	// from 1.
+	Colors int
+	tiles  []wangTile
+}
+
+// Has returns whether the tile with the gid is part of the Wang set.
+func (s *WangSet) Has(gid uint32) bool {
+	_, ok := s.wangID(gid)
+	return ok
+}
+
+func (s *WangSet) wangID(gid uint32) (wangID, bool) {
+	for _, t := range s.tiles {
+		if t.gid == gid {
+			return t.id, true
+		}
+	}
+	return wangID{}, false
+}
+
+// used returns whether the part with the index has a color in the set's tiles.
+func (s *WangSet) used(i int) bool {
+	switch s.Type {
+	case WangCorner:
+		return i%2 == 1
+	case WangEdge:
+		return i%2 == 0
+	}
+	return true
+}
+
+// TileFor returns the gid of the tile whose corners and/or edges all have the
+// color. It returns false if the set has no such tile.
+func (s *WangSet) TileFor(color int) (uint32, bool) {
+	for _, t := range s.tiles {
+		match := true
+		for i, c := range t.id {
+			if s.used(i) && int(c) != color {
+				match = false
+				break
+			}
+		}
+		if match {
+			return t.gid, true
+		}
+	}
+	return 0, false
+}
+
+// match returns the gid of the tile with the colors of want.
+func (s *WangSet) match(want wangID) (uint32, bool) {
+	for _, t := range s.tiles {
+		if t.id == want {
+			return t.gid, true
+		}
+	}
+	return 0, false
+}
+
+// wangSet returns the Wang set the tile with the gid is part of.
+func (l *Level) wangSet(gid uint32) (*WangSet, wangID, bool) {
+	for _, s := range l.WangSets {
+		if id, ok := s.wangID(gid); ok {
+			return s, id, true
+		}
+	}
+	return nil, wangID{}, false
+}
+
+// autotile picks the tiles around the tile at x, y, which are part of the same
+// Wang set, so their corners and edges match the ones of the tile. Neighbors
+// are left as they are if the set has no tile for their new colors.
+func (l *Level) autotile(layer *TileLayer, x, y int, gid uint32) {
+	if l.Orientation != orth && l.Orientation != iso {
+		return
+	}
+	set, id, ok := l.wangSet(gid)
+	if !ok {
+		return
+	}
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			t := layer.tiles[mapPoint{X: x + dx, Y: y + dy}]
+			if t == nil || t.flip != 0 {
+				continue
+			}
+			want, ok := set.wangID(t.gid)
+			if !ok {
+				continue
+			}
+
+			// the parts the tiles share get the colors of the tile, the
+			// other parts keep theirs, as they are shared with other tiles
+			for j, off := range wangOffsets {
+				px, py := 2*dx+off.X, 2*dy+off.Y
+				for i, o := range wangOffsets {
+					if o.X == px && o.Y == py && id[i] != 0 {
+						want[j] = id[i]
+					}
+				}
+			}
+			if n, ok := set.match(want); ok && n != t.gid {
+				l.setTile(layer, x+dx, y+dy, n)
+			}
+		}
+	}
+}
+
+// wangSets returns the Wang sets of the tileset, including its terrains.
+func wangSets(ts tmx.Tileset) ([]*WangSet, error) {
+	var ret []*WangSet
+	for _, ws := range ts.WangSets {
+		set := &WangSet{Name: ws.Name}
+		for _, t := range ws.WangTiles {
+			id, err := parseWangID(t.WangID)
+			if err != nil {
+				return nil, fmt.Errorf("wang set %q: %v", ws.Name, err)
+			}
+			set.tiles = append(set.tiles, wangTile{gid: ts.FirstGID + t.TileID, id: id})
+		}
+		set.setType()
+		ret = append(ret, set)
+	}
+
+	if len(ts.TerrainTypes) == 0 {
+		return ret, nil
+	}
+	// terrains are corner sets, with the terrains given for the top-left,
+	// top-right, bottom-left and bottom-right corner
+	set := &WangSet{Name: "terrains", Type: WangCorner, Colors: len(ts.TerrainTypes)}
+	corners := [4]int{7, 1, 5, 3}
+	for _, t := range ts.Tiles {
+		if t.Terrain == "" {
+			continue
+		}
+		var id wangID
+		for i, v := range strings.Split(t.Terrain, ",") {
+			if v == "" || i >= len(corners) {
+				continue
+			}
+			terrain, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("tile %d: invalid terrain %q", t.ID, t.Terrain)
+			}
+			id[corners[i]] = uint8(terrain + 1)
+		}
+		set.tiles = append(set.tiles, wangTile{gid: ts.FirstGID + t.ID, id: id})
+	}
+	return append(ret, set), nil
+}
+
+// setType sets the type and the number of colors from the set's tiles.
+func (s *WangSet) setType() {
+	corners, edges := false, false
+	for _, t := range s.tiles {
+		for i, c := range t.id {
+			if c == 0 {
+				continue
+			}
+			if i%2 == 1 {
+				corners = true
+			} else {
+				edges = true
+			}
+			if int(c) > s.Colors {
+				s.Colors = int(c)
+			}
+		}
+	}
+	switch {
+	case corners && edges:
+		s.Type = WangMixed
+	case edges:
+		s.Type = WangEdge
+	default:
+		s.Type = WangCorner
+	}
+}
+
+// parseWangID parses the Wang ID of a tile, which is either a list of the
+// eight colors, or a hexadecimal number of the form 0xCECECECE with the top
+// edge in the lowest digit.
+func parseWangID(s string) (wangID, error) {
+	var id wangID
+	if strings.HasPrefix(s, "0x") {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return id, fmt.Errorf("invalid wang id %q", s)
+		}
+		for i := range id {
+			id[i] = uint8(v >> (4 * uint(i)) & 0xF)
+		}
+		return id, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != len(id) {
+		return id, fmt.Errorf("invalid wang id %q", s)
+	}
+	for i, p := range parts {
+		c, err := strconv.ParseUint(p, 10, 8)
+		if err != nil {
+			return id, fmt.Errorf("invalid wang id %q", s)
+		}
+		id[i] = uint8(c)
+	}
+	return id, nil
+}
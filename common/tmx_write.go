@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteTMX writes the level as TMX, with the tiles of the tile layers as they
+// are now, so levels changed with SetTile can be saved and opened in Tiled.
+// Everything else is written as it was in the TMX the level was loaded from,
+// the tile data is written as CSV.
+func (l *Level) WriteTMX(w io.Writer) error {
+	if l.source == nil {
+		return errors.New("level was not loaded from a TMX file")
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(l.source))
+	e := xml.NewEncoder(w)
+	depth, layer := 0, -1
+	started := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			// only the layers directly in the map are unpacked into TileLayers
+			if depth == 2 && t.Name.Local == "layer" {
+				layer++
+			}
+			if depth == 3 && t.Name.Local == "data" && layer >= 0 && layer < len(l.TileLayers) {
+				if err = d.Skip(); err != nil {
+					return err
+				}
+				depth--
+				if err = l.encodeTileData(e, l.TileLayers[layer]); err != nil {
+					return err
+				}
+				continue
+			}
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			// the encoder only allows the xml declaration at the start
+			if !started && len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		}
+		if err = e.EncodeToken(tok); err != nil {
+			return err
+		}
+		started = true
+	}
+	return e.Flush()
+}
+
+// encodeTileData writes the data element of the tile layer, with chunks for
+// infinite levels.
+func (l *Level) encodeTileData(e *xml.Encoder, tl *TileLayer) error {
+	data := xml.StartElement{
+		Name: xml.Name{Local: "data"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "encoding"}, Value: "csv"}},
+	}
+	if err := e.EncodeToken(data); err != nil {
+		return err
+	}
+	if !l.Infinite {
+		if err := e.EncodeToken(xml.CharData(l.tileCSV(tl, 0, 0, tl.Width, tl.Height))); err != nil {
+			return err
+		}
+		return e.EncodeToken(data.End())
+	}
+
+	for _, c := range tl.Chunks {
+		chunk := xml.StartElement{
+			Name: xml.Name{Local: "chunk"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "x"}, Value: strconv.Itoa(c.X)},
+				{Name: xml.Name{Local: "y"}, Value: strconv.Itoa(c.Y)},
+				{Name: xml.Name{Local: "width"}, Value: strconv.Itoa(c.Width)},
+				{Name: xml.Name{Local: "height"}, Value: strconv.Itoa(c.Height)},
+			},
+		}
+		if err := e.EncodeToken(chunk); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(xml.CharData(l.tileCSV(tl, c.X, c.Y, c.Width, c.Height))); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(chunk.End()); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(data.End())
+}
+
+// tileCSV returns the gids, including the flipping flags, of the tiles of an
+// area of the layer in CSV, one row of tiles per line.
+func (l *Level) tileCSV(tl *TileLayer, x, y, w, h int) string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for i, pt := range l.tileOrder(x, y, w, h) {
+		var gid uint32
+		if t, ok := tl.tiles[pt]; ok {
+			gid = t.gid | t.flip
+		}
+		sb.WriteString(strconv.FormatUint(uint64(gid), 10))
+		switch {
+		case i == w*h-1:
+			sb.WriteString("\n")
+		case (i+1)%w == 0:
+			sb.WriteString(",\n")
+		default:
+			sb.WriteString(",")
+		}
+	}
+	return sb.String()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/EngoEngine/ecs"
 )
@@ -108,6 +109,15 @@ type RunOptions struct {
 	// FPSLimit indicates the maximum number of frames per second
 	FPSLimit int
 
+	// FixedUpdateRate enables the fixed timestep: it is the number of times per second the `FixedUpdate` method of
+	// `FixedUpdater`s is called with the same delta, independently of the frame rate. This makes simulations such as
+	// physics deterministic. Leaving it at 0 disables the fixed timestep.
+	FixedUpdateRate int
+
+	// MaxFixedSteps is the maximum number of fixed updates per frame. When the game can't keep up with the fixed rate,
+	// the time exceeding it is dropped instead of making the frames ever longer. Defaults to `DefaultMaxFixedSteps`.
+	MaxFixedSteps int
+
 	// OverrideCloseAction indicates that (when true) engo will never close whenever the gamer wants to close the
 	// game - that will be your responsibility
 	OverrideCloseAction bool
@@ -139,6 +149,12 @@ type RunOptions struct {
 	// use any subfolder-structure within that `assets` directory.
 	AssetsRoot string
 
+	// HotReload loads the files loaded from `AssetsRoot` again whenever they are modified, and dispatches a
+	// `ResourceReloadedMessage`. This is meant to be used during development. HotReloadInterval is how often the files
+	// are checked when the operating system can't report modified files, it defaults to `DefaultPollInterval`.
+	HotReload         bool
+	HotReloadInterval time.Duration
+
 	// MobileWidth and MobileHeight are the width and height given from the Android/iOS OpenGL Surface used for Gomobile bind
 	MobileWidth, MobileHeight int
 
@@ -198,7 +214,12 @@ func Run(o RunOptions, defaultScene Scene) {
 		Input.RegisterAxis(DefaultMouseYAxis, NewAxisMouse(AxisMouseVert))
 	}
 
+	fixedAccumulator, fixedAlpha = 0, 1
+
 	Files.SetRoot(opts.AssetsRoot)
+	if opts.HotReload {
+		Files.Watch(opts.HotReloadInterval)
+	}
 	currentUpdater = opts.Update
 
 	// And run the game
@@ -251,6 +272,17 @@ func SetFPSLimit(limit int) error {
 	return nil
 }
 
+// SetFixedUpdateRate can be used to change the value in the given `RunOpts` after already having called `engo.Run`.
+// A rate of 0 disables the fixed timestep.
+func SetFixedUpdateRate(rate int) error {
+	if rate < 0 {
+		return fmt.Errorf("Fixed update rate out of bounds. Requires >= 0")
+	}
+	opts.FixedUpdateRate = rate
+	fixedAccumulator, fixedAlpha = 0, 1
+	return nil
+}
+
 // Headless indicates whether or not OpenGL-calls should be made
 func Headless() bool {
 	return opts.HeadlessMode
@@ -202,7 +202,7 @@ func CreateWindow(title string, width, height int, fullscreen bool, msaa int) {
 	})
 
 	Window.SetCharCallback(func(Window *glfw.Window, char rune) {
-		Mailbox.Dispatch(TextMessage{char})
+		Input.typed(char)
 	})
 
 	glfw.SetJoystickCallback(func(joy glfw.Joystick, event glfw.PeripheralEvent) {
@@ -210,10 +210,11 @@ func CreateWindow(title string, width, height int, fullscreen bool, msaa int) {
 		defer Input.gamepads.mutex.Unlock()
 		if event == glfw.Connected {
 			found := false
-			for _, gamepad := range Input.gamepads.gamepads {
+			for name, gamepad := range Input.gamepads.gamepads {
 				if gamepad.id == joy.GetGUID() {
 					gamepad.connected = true
 					found = true
+					Input.gamepads.connected(name, gamepad.id)
 				}
 			}
 			if !found {
@@ -224,13 +225,19 @@ func CreateWindow(title string, width, height int, fullscreen bool, msaa int) {
 							id:        joy.GetGUID(),
 							connected: true,
 						}
+						found = true
+						Input.gamepads.connected(name, joy.GetGUID())
 					}
 				}
 			}
+			if !found {
+				Input.gamepads.connected("", joy.GetGUID())
+			}
 		} else if event == glfw.Disconnected {
-			for _, gamepad := range Input.gamepads.gamepads {
-				if gamepad.id == joy.GetGUID() {
+			for name, gamepad := range Input.gamepads.gamepads {
+				if gamepad.joystick == joy && gamepad.connected {
 					gamepad.connected = false
+					Input.gamepads.disconnected(name, gamepad.id)
 				}
 			}
 		}
@@ -266,7 +273,7 @@ func RunIteration() {
 	}
 
 	// Then update the world and all Systems
-	currentUpdater.Update(Time.Delta())
+	updateScenes(Time.Delta())
 
 	// Lastly, forget keypresses and swap buffers
 	if !opts.HeadlessMode {
@@ -103,7 +103,7 @@ func CreateWindow(title string, width, height int, fullscreen bool, msaa int) {
 		}
 		char := event.Get("key").String()
 		if len(char) == 1 {
-			Mailbox.Dispatch(TextMessage{[]rune(char)[0]})
+			Input.typed([]rune(char)[0])
 		}
 
 		checkModifiers(event)
@@ -299,7 +299,7 @@ func RunIteration() {
 	Time.Tick()
 	Input.update()
 	jsPollKeys()
-	currentUpdater.Update(Time.Delta())
+	updateScenes(Time.Delta())
 	Input.Mouse.Action = Neutral
 	// TODO: this may not work, and sky-rocket the FPS
 	//  requestAnimationFrame(func(dt float32) {
@@ -182,7 +182,7 @@ func RunIteration() {
 	}
 
 	// Then update the world and all Systems
-	currentUpdater.Update(Time.Delta())
+	updateScenes(Time.Delta())
 }
 
 // SetCursor changes the cursor - not yet implemented
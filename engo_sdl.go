@@ -221,14 +221,14 @@ func RunIteration() {
 				n := bytes.IndexByte(e.Text[:], 0)
 				s := string(e.Text[:n])
 				if len(s) == 1 {
-					Mailbox.Dispatch(TextMessage{[]rune(s)[0]})
+					Input.typed([]rune(s)[0])
 				}
 			}
 		}
 	}
 
 	// Then update the world and all Systems
-	currentUpdater.Update(Time.Delta())
+	updateScenes(Time.Delta())
 
 	// Lastly, forget keypresses and swap buffers
 	if !opts.HeadlessMode {
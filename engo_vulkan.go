@@ -192,7 +192,7 @@ func CreateWindow(title string, width, height int, fullscreen bool, msaa int) {
 	})
 
 	Window.SetCharCallback(func(Window *glfw.Window, char rune) {
-		Mailbox.Dispatch(TextMessage{char})
+		Input.typed(char)
 	})
 
 	Window.SetCloseCallback(func(Window *glfw.Window) {
@@ -225,7 +225,7 @@ func RunIteration() {
 	}
 
 	// Then update the world and all Systems
-	currentUpdater.Update(Time.Delta())
+	updateScenes(Time.Delta())
 
 	// Lastly, forget keypresses and swap buffers
 	if !opts.HeadlessMode {
@@ -0,0 +1,88 @@
+package engo
+
+import "github.com/EngoEngine/ecs"
+
+// DefaultMaxFixedSteps is the maximum number of fixed updates per frame when `RunOptions.MaxFixedSteps` isn't set.
+const DefaultMaxFixedSteps = 5
+
+// FixedUpdater is an optional interface for Updaters and ecs Systems that simulate the game at a fixed rate. When
+// `RunOptions.FixedUpdateRate` is set, FixedUpdate is called zero or more times per frame with the fixed timestep,
+// before Update is called once with the delta of the frame. The simulation belongs in FixedUpdate, while Update can
+// draw its state, interpolating it with `InterpolationAlpha`.
+type FixedUpdater interface {
+	FixedUpdate(dt float32)
+}
+
+var (
+	// fixedAccumulator is the time that has not been simulated by fixed updates yet
+	fixedAccumulator float32
+	fixedAlpha       float32 = 1
+)
+
+// FixedTimestep returns the delta given to FixedUpdate, in seconds. It's 0 when the fixed timestep is disabled.
+func FixedTimestep() float32 {
+	if opts.FixedUpdateRate <= 0 {
+		return 0
+	}
+	return 1 / float32(opts.FixedUpdateRate)
+}
+
+// InterpolationAlpha returns how far the current frame is between the last fixed update and the next one, from 0 to
+// 1. Systems drawing a state simulated by FixedUpdate can interpolate between its previous and its current value,
+// `previous + (current - previous) * alpha`, so that the movement stays smooth when the frame rate differs from the
+// fixed rate. It's always 1 when the fixed timestep is disabled.
+func InterpolationAlpha() float32 {
+	return fixedAlpha
+}
+
+// fixedSteps returns the number of fixed updates to run for a frame of dt seconds.
+func fixedSteps(dt float32) int {
+	step := FixedTimestep()
+	if step == 0 {
+		fixedAlpha = 1
+		return 0
+	}
+
+	maxSteps := opts.MaxFixedSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxFixedSteps
+	}
+
+	fixedAccumulator += dt
+	steps := 0
+	for fixedAccumulator >= step && steps < maxSteps {
+		fixedAccumulator -= step
+		steps++
+	}
+	// Drop the time the game couldn't keep up with, to avoid the spiral of death
+	for fixedAccumulator >= step {
+		fixedAccumulator -= step
+	}
+	fixedAlpha = fixedAccumulator / step
+	return steps
+}
+
+// fixedUpdate calls FixedUpdate on the Updater, or on the Systems of an `*ecs.World`, steps times.
+func fixedUpdate(u Updater, steps int) {
+	if steps == 0 {
+		return
+	}
+	step := FixedTimestep()
+	if f, ok := u.(FixedUpdater); ok {
+		for i := 0; i < steps; i++ {
+			f.FixedUpdate(step)
+		}
+		return
+	}
+	w, ok := u.(*ecs.World)
+	if !ok {
+		return
+	}
+	for i := 0; i < steps; i++ {
+		for _, sys := range w.Systems() {
+			if f, ok := sys.(FixedUpdater); ok && !timelineStopped(sys) {
+				f.FixedUpdate(step)
+			}
+		}
+	}
+}
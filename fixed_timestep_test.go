@@ -0,0 +1,81 @@
+package engo
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/ecs"
+)
+
+// fixedSystem counts its fixed and regular updates.
+type fixedSystem struct {
+	fixed, updates int
+	alphas         []float32
+}
+
+func (s *fixedSystem) FixedUpdate(dt float32) {
+	if dt != 0.1 {
+		panic("FixedUpdate was not called with the fixed timestep")
+	}
+	s.fixed++
+}
+
+func (s *fixedSystem) Update(float32) {
+	s.updates++
+	s.alphas = append(s.alphas, InterpolationAlpha())
+}
+
+func (*fixedSystem) Remove(ecs.BasicEntity) {}
+
+type fixedScene struct {
+	sys *fixedSystem
+}
+
+func (*fixedScene) Preload() {}
+
+func (s *fixedScene) Setup(u Updater) {
+	s.sys = &fixedSystem{}
+	u.(*ecs.World).AddSystem(s.sys)
+}
+
+func (*fixedScene) Type() string { return "fixedScene" }
+
+func closeTo(a, b float32) bool {
+	return a-b < 1e-4 && b-a < 1e-4
+}
+
+func TestFixedTimestep(t *testing.T) {
+	scene := &fixedScene{}
+	Run(RunOptions{NoRun: true, HeadlessMode: true, FixedUpdateRate: 10, MaxFixedSteps: 4}, scene)
+	sys := scene.sys
+
+	if FixedTimestep() != 0.1 {
+		t.Errorf("FixedTimestep was %v, expected 0.1", FixedTimestep())
+	}
+
+	updateScenes(0.05)
+	if sys.fixed != 0 || sys.updates != 1 || !closeTo(sys.alphas[0], 0.5) {
+		t.Errorf("Short frame ran %d fixed updates with alpha %v, expected none with alpha 0.5", sys.fixed, sys.alphas[0])
+	}
+
+	updateScenes(0.2)
+	if sys.fixed != 2 || sys.updates != 2 || !closeTo(sys.alphas[1], 0.5) {
+		t.Errorf("Frame ran %d fixed updates with alpha %v, expected 2 with alpha 0.5", sys.fixed, sys.alphas[1])
+	}
+
+	// A long frame is limited to MaxFixedSteps, the rest of the time is dropped
+	updateScenes(1.02)
+	if sys.fixed != 6 || !closeTo(sys.alphas[2], 0.7) {
+		t.Errorf("Long frame ran %d fixed updates with alpha %v, expected 4 more with alpha 0.7", sys.fixed-2, sys.alphas[2])
+	}
+
+	if err := SetFixedUpdateRate(0); err != nil {
+		t.Fatal(err)
+	}
+	updateScenes(1)
+	if sys.fixed != 6 || sys.updates != 4 || InterpolationAlpha() != 1 {
+		t.Errorf("Fixed updates ran while the fixed timestep was disabled")
+	}
+	if err := SetFixedUpdateRate(-1); err == nil {
+		t.Error("Setting a negative fixed update rate did not return an error")
+	}
+}
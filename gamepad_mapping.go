@@ -0,0 +1,381 @@
+package engo
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// GamepadConnectedMessage is dispatched when a gamepad is plugged in.
+type GamepadConnectedMessage struct {
+	// Name is the name the Gamepad has been registered with, or empty if it's a new gamepad that can be registered.
+	Name string
+	// ID identifies the gamepad, such as its GUID.
+	ID string
+}
+
+// Type returns the type of the message, "GamepadConnectedMessage"
+func (GamepadConnectedMessage) Type() string { return "GamepadConnectedMessage" }
+
+// GamepadDisconnectedMessage is dispatched when a registered gamepad is unplugged.
+type GamepadDisconnectedMessage struct {
+	// Name is the name the Gamepad has been registered with.
+	Name string
+	// ID identifies the gamepad, such as its GUID.
+	ID string
+}
+
+// Type returns the type of the message, "GamepadDisconnectedMessage"
+func (GamepadDisconnectedMessage) Type() string { return "GamepadDisconnectedMessage" }
+
+// sdlControls are the names of the GamepadControls in the SDL_GameControllerDB format.
+var sdlControls = map[string]GamepadControl{
+	"a":             GamepadA,
+	"b":             GamepadB,
+	"x":             GamepadX,
+	"y":             GamepadY,
+	"back":          GamepadBack,
+	"start":         GamepadStart,
+	"guide":         GamepadGuide,
+	"dpup":          GamepadDpadUp,
+	"dpright":       GamepadDpadRight,
+	"dpdown":        GamepadDpadDown,
+	"dpleft":        GamepadDpadLeft,
+	"leftshoulder":  GamepadLeftBumper,
+	"rightshoulder": GamepadRightBumper,
+	"leftstick":     GamepadLeftThumb,
+	"rightstick":    GamepadRightThumb,
+	"leftx":         GamepadLeftX,
+	"lefty":         GamepadLeftY,
+	"rightx":        GamepadRightX,
+	"righty":        GamepadRightY,
+	"lefttrigger":   GamepadLeftTrigger,
+	"righttrigger":  GamepadRightTrigger,
+}
+
+// sdlPlatforms are the names of the platforms in the SDL_GameControllerDB format.
+var sdlPlatforms = map[string]string{
+	"windows": "Windows",
+	"darwin":  "Mac OS X",
+	"linux":   "Linux",
+	"android": "Android",
+	"ios":     "iOS",
+}
+
+type rawInputKind uint8
+
+const (
+	rawButton rawInputKind = iota
+	rawAxis
+	rawHat
+)
+
+// mappingElement maps a button, an axis or a hat of a joystick onto a control of a Gamepad.
+type mappingElement struct {
+	control GamepadControl
+	// half is 1 or -1 when the element only drives that half of an axis control
+	half float32
+
+	kind  rawInputKind
+	index int
+	// hatMask is the bit of the hat, scale and offset transform the axis to [-1, 1]
+	hatMask       int
+	scale, offset float32
+}
+
+// GamepadMapping maps the buttons, axes and hats of a joystick onto the Gamepad struct. It's parsed from a line of
+// the SDL_GameControllerDB format, see https://github.com/gabomdq/SDL_GameControllerDB.
+type GamepadMapping struct {
+	// GUID identifies the joystick model.
+	GUID string
+	Name string
+	// Platform is the platform the mapping is for, empty if it's for all platforms.
+	Platform string
+
+	elements []mappingElement
+}
+
+// ParseGamepadMapping parses a line of the SDL_GameControllerDB format, such as
+//
+//	030000004c050000c405000000010000,PS4 Controller,a:b1,b:b2,leftx:a0,lefty:a1,platform:Mac OS X,
+//
+// Buttons are given as "b" followed by their index, axes as "a" and hats as "h" followed by their index, a dot and the
+// bit of the direction. Axes can be prefixed by + or - to use half of them, or suffixed by ~ to invert them. Controls
+// can be prefixed by + or - to map the element onto half of an axis.
+func ParseGamepadMapping(line string) (GamepadMapping, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 2 || fields[0] == "" {
+		return GamepadMapping{}, fmt.Errorf("invalid gamepad mapping: %q", line)
+	}
+	m := GamepadMapping{GUID: strings.ToLower(fields[0]), Name: fields[1]}
+
+	for _, field := range fields[2:] {
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			return GamepadMapping{}, fmt.Errorf("invalid gamepad mapping element %q of %s", field, m.Name)
+		}
+		key, value := kv[0], kv[1]
+		if key == "platform" {
+			m.Platform = value
+			continue
+		}
+
+		var e mappingElement
+		switch {
+		case strings.HasPrefix(key, "+"):
+			e.half, key = 1, key[1:]
+		case strings.HasPrefix(key, "-"):
+			e.half, key = -1, key[1:]
+		}
+		control, ok := sdlControls[key]
+		if !ok {
+			// Such as the paddles, touchpads and the crc, which have no equivalent
+			continue
+		}
+		e.control = control
+		if err := e.parseInput(value); err != nil {
+			return GamepadMapping{}, fmt.Errorf("invalid gamepad mapping element %q of %s: %v", field, m.Name, err)
+		}
+		m.elements = append(m.elements, e)
+	}
+	return m, nil
+}
+
+// parseInput parses the button, axis or hat of the element.
+func (e *mappingElement) parseInput(value string) error {
+	min, max := float32(-1), float32(1)
+	switch {
+	case strings.HasPrefix(value, "+"):
+		min, value = 0, value[1:]
+	case strings.HasPrefix(value, "-"):
+		max, value = 0, value[1:]
+	}
+	invert := strings.HasSuffix(value, "~")
+	value = strings.TrimSuffix(value, "~")
+	if len(value) < 2 {
+		return fmt.Errorf("missing input")
+	}
+
+	var err error
+	switch value[0] {
+	case 'b':
+		e.kind = rawButton
+		e.index, err = strconv.Atoi(value[1:])
+	case 'a':
+		e.kind = rawAxis
+		e.index, err = strconv.Atoi(value[1:])
+		// Transforms the used range of the axis to [-1, 1]
+		e.scale, e.offset = 2/(max-min), -(max + min)
+		if invert {
+			e.scale, e.offset = -e.scale, -e.offset
+		}
+	case 'h':
+		e.kind = rawHat
+		parts := strings.SplitN(value[1:], ".", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("missing hat direction")
+		}
+		if e.index, err = strconv.Atoi(parts[0]); err == nil {
+			e.hatMask, err = strconv.Atoi(parts[1])
+		}
+	default:
+		return fmt.Errorf("unknown input %q", value)
+	}
+	return err
+}
+
+// VendorProduct returns the USB vendor and product ids encoded in the GUID.
+func (m GamepadMapping) VendorProduct() (vendor, product uint16, ok bool) {
+	b, err := hex.DecodeString(m.GUID)
+	if err != nil || len(b) != 16 {
+		return 0, 0, false
+	}
+	return uint16(b[4]) | uint16(b[5])<<8, uint16(b[8]) | uint16(b[9])<<8, true
+}
+
+// joystickState is the raw state of a joystick, before it's mapped onto a Gamepad.
+type joystickState struct {
+	buttons []bool
+	axes    []float32
+	// hats are bit masks of the directions pressed, 1 is up, 2 right, 4 down and 8 left
+	hats []int
+}
+
+// value returns the input of the element, from -1 to 1.
+func (e mappingElement) value(js joystickState) float32 {
+	switch e.kind {
+	case rawButton:
+		if e.index < len(js.buttons) && js.buttons[e.index] {
+			return 1
+		}
+	case rawAxis:
+		if e.index >= len(js.axes) {
+			return 0
+		}
+		v := js.axes[e.index]*e.scale + e.offset
+		if v > 1 {
+			return 1
+		}
+		if v < -1 {
+			return -1
+		}
+		return v
+	case rawHat:
+		if e.index < len(js.hats) && js.hats[e.index]&e.hatMask != 0 {
+			return 1
+		}
+	}
+	return -1
+}
+
+// apply sets the buttons and axes of the Gamepad from the state of the joystick.
+func (m GamepadMapping) apply(g *Gamepad, js joystickState) {
+	var buttons [GamepadLeftX]bool
+	var axes, halves [GamepadRightTrigger - GamepadLeftX + 1]float32
+	var halved [len(axes)]bool
+
+	for _, e := range m.elements {
+		v := e.value(js)
+		if !e.control.IsAxis() {
+			if v > 0 {
+				buttons[e.control] = true
+			}
+			continue
+		}
+		i := e.control - GamepadLeftX
+		if e.half != 0 {
+			// The halves of an axis are combined, each being 0 while it's released
+			halved[i] = true
+			halves[i] += e.half * (v + 1) / 2
+			continue
+		}
+		axes[i] = v
+	}
+	for i := range axes {
+		if halved[i] {
+			axes[i] = halves[i]
+		}
+	}
+
+	for i, b := range g.buttons() {
+		b.set(buttons[i])
+	}
+	for i, a := range g.axes() {
+		v := axes[i]
+		if v > 1 {
+			v = 1
+		} else if v < -1 {
+			v = -1
+		}
+		a.set(v)
+	}
+}
+
+// AddGamepadMappings reads mappings in the SDL_GameControllerDB format, such as the gamecontrollerdb.txt file, so that
+// gamepads which are not supported natively can be used. Lines for other platforms and comments are skipped.
+func (im *InputManager) AddGamepadMappings(r io.Reader) error {
+	return im.gamepads.AddMappings(r)
+}
+
+// AddMappings reads mappings in the SDL_GameControllerDB format. Lines for other platforms and comments are skipped.
+func (gm *GamepadManager) AddMappings(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		m, err := ParseGamepadMapping(text)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		gm.AddMapping(m)
+	}
+	return scanner.Err()
+}
+
+// AddMapping adds the mapping, replacing the one with the same GUID. Mappings for other platforms are ignored.
+func (gm *GamepadManager) AddMapping(m GamepadMapping) {
+	if m.Platform != "" && sdlPlatforms[runtime.GOOS] != "" && m.Platform != sdlPlatforms[runtime.GOOS] {
+		return
+	}
+	gm.mutex.Lock()
+	if gm.mappings == nil {
+		gm.mappings = make(map[string]GamepadMapping)
+	}
+	gm.mappings[m.GUID] = m
+	gm.mutex.Unlock()
+}
+
+// Mapping returns the mapping for the joystick with the given GUID.
+func (gm *GamepadManager) Mapping(guid string) (GamepadMapping, bool) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+	return gm.mapping(guid)
+}
+
+// mapping returns the mapping for the joystick with the given GUID, gm.mutex has to be held.
+func (gm *GamepadManager) mapping(guid string) (GamepadMapping, bool) {
+	m, ok := gm.mappings[strings.ToLower(guid)]
+	return m, ok
+}
+
+var (
+	// Chrome names gamepads such as "Wireless Controller (STANDARD GAMEPAD Vendor: 054c Product: 05c4)"
+	chromeGamepadID = regexp.MustCompile(`Vendor: ([0-9a-fA-F]{4}) Product: ([0-9a-fA-F]{4})`)
+	// Firefox names gamepads such as "054c-05c4-Wireless Controller"
+	firefoxGamepadID = regexp.MustCompile(`^([0-9a-fA-F]{1,4})-([0-9a-fA-F]{1,4})-`)
+)
+
+// mappingByID returns the mapping for a gamepad identified by the browser, using the vendor and product ids in its id.
+// gm.mutex has to be held.
+func (gm *GamepadManager) mappingByID(id string) (GamepadMapping, bool) {
+	match := chromeGamepadID.FindStringSubmatch(id)
+	if match == nil {
+		match = firefoxGamepadID.FindStringSubmatch(id)
+	}
+	if match == nil {
+		return GamepadMapping{}, false
+	}
+	vendor, _ := strconv.ParseUint(match[1], 16, 16)
+	product, _ := strconv.ParseUint(match[2], 16, 16)
+	for _, m := range gm.mappings {
+		if v, p, ok := m.VendorProduct(); ok && uint64(v) == vendor && uint64(p) == product {
+			return m, true
+		}
+	}
+	return GamepadMapping{}, false
+}
+
+// connected queues a GamepadConnectedMessage, gm.mutex has to be held.
+func (gm *GamepadManager) connected(name, id string) {
+	gm.events = append(gm.events, GamepadConnectedMessage{Name: name, ID: id})
+}
+
+// disconnected queues a GamepadDisconnectedMessage, gm.mutex has to be held.
+func (gm *GamepadManager) disconnected(name, id string) {
+	gm.events = append(gm.events, GamepadDisconnectedMessage{Name: name, ID: id})
+}
+
+// dispatchEvents dispatches the queued connection messages.
+func (gm *GamepadManager) dispatchEvents() {
+	gm.mutex.Lock()
+	events := gm.events
+	gm.events = nil
+	gm.mutex.Unlock()
+
+	if Mailbox == nil {
+		return
+	}
+	for _, msg := range events {
+		Mailbox.Dispatch(msg)
+	}
+}
@@ -0,0 +1,169 @@
+package engo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const mappingTestDB = `# Game Controller DB for SDL
+030000004c050000c405000000010000,PS4 Controller,a:b1,b:b2,back:b8,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b12,leftshoulder:b4,leftstick:b10,lefttrigger:a3,leftx:a0,lefty:a1,rightshoulder:b5,rightstick:b11,righttrigger:a4,rightx:a2,righty:a5~,start:b9,x:b0,y:b3,touchpad:b13,
+
+03000000790000000600000000000000,Generic USB Joystick,a:b2,b:b1,-leftx:b6,+leftx:b7,lefty:-a1,righttrigger:+a2,platform:Plan 9,
+`
+
+func TestParseGamepadMapping(t *testing.T) {
+	gm := NewGamepadManager()
+	if err := gm.AddMappings(strings.NewReader(mappingTestDB)); err != nil {
+		t.Fatalf("AddMappings returned an error: %v", err)
+	}
+	m, ok := gm.Mapping("030000004C050000C405000000010000")
+	if !ok {
+		t.Fatal("Mapping was not found by its GUID")
+	}
+	if m.Name != "PS4 Controller" {
+		t.Errorf("Mapping was named %q, expected %q", m.Name, "PS4 Controller")
+	}
+	if vendor, product, ok := m.VendorProduct(); !ok || vendor != 0x054c || product != 0x05c4 {
+		t.Errorf("VendorProduct returned %04x %04x, expected 054c 05c4", vendor, product)
+	}
+
+	g := &Gamepad{}
+	m.apply(g, joystickState{
+		buttons: []bool{false, true, false, false, false, true},
+		axes:    []float32{0.25, -1, 0, 1, -1, 0.5},
+		hats:    []int{1 | 2},
+	})
+	if !g.A.currentState || g.B.currentState || !g.RightBumper.currentState {
+		t.Error("Buttons were not mapped")
+	}
+	if !g.DpadUp.currentState || !g.DpadRight.currentState || g.DpadDown.currentState {
+		t.Error("Hat directions were not mapped")
+	}
+	if g.LeftX.Value() != 0.25 || g.LeftY.Value() != -1 || g.LeftTrigger.Value() != 1 || g.RightTrigger.Value() != -1 {
+		t.Errorf("Axes were mapped to %v %v %v %v", g.LeftX.Value(), g.LeftY.Value(), g.LeftTrigger.Value(), g.RightTrigger.Value())
+	}
+	if g.RightY.Value() != -0.5 {
+		t.Errorf("Inverted axis was mapped to %v, expected -0.5", g.RightY.Value())
+	}
+
+	m, ok = gm.Mapping("03000000790000000600000000000000")
+	if _, current := sdlPlatforms[runtime.GOOS]; current {
+		if ok {
+			t.Error("Mapping for another platform was added")
+		}
+		return
+	}
+	if !ok {
+		t.Fatal("Mapping was not found by its GUID")
+	}
+	m.apply(g, joystickState{
+		buttons: []bool{false, false, false, false, false, false, false, true},
+		axes:    []float32{0, -0.5, 0.5},
+	})
+	if g.LeftX.Value() != 1 {
+		t.Errorf("Half axis was mapped to %v, expected 1", g.LeftX.Value())
+	}
+	if g.LeftY.Value() != 0 {
+		t.Errorf("Negative half of an axis was mapped to %v, expected 0", g.LeftY.Value())
+	}
+	if g.RightTrigger.Value() != 0 {
+		t.Errorf("Positive half of an axis was mapped to %v, expected 0", g.RightTrigger.Value())
+	}
+}
+
+func TestParseGamepadMappingInvalid(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"030000004c050000c405000000010000",
+		"030000004c050000c405000000010000,Pad,a:z1",
+		"030000004c050000c405000000010000,Pad,dpup:h0",
+		"030000004c050000c405000000010000,Pad,a",
+	} {
+		if _, err := ParseGamepadMapping(line); err == nil {
+			t.Errorf("ParseGamepadMapping did not return an error for %q", line)
+		}
+	}
+}
+
+func TestGamepadMappingByID(t *testing.T) {
+	gm := NewGamepadManager()
+	gm.AddMappings(strings.NewReader(mappingTestDB))
+	for _, id := range []string{
+		"Wireless Controller (STANDARD GAMEPAD Vendor: 054c Product: 05c4)",
+		"54c-5c4-Wireless Controller",
+	} {
+		if m, ok := gm.mappingByID(id); !ok || m.Name != "PS4 Controller" {
+			t.Errorf("Mapping was not found for %q", id)
+		}
+	}
+	if _, ok := gm.mappingByID("Xbox 360 Controller (XInput STANDARD GAMEPAD)"); ok {
+		t.Error("Mapping was found for a gamepad without vendor and product ids")
+	}
+}
+
+func TestAxisGamepadDeadZone(t *testing.T) {
+	g := &Gamepad{}
+	g.SetStickDeadZones(0.2, 0)
+	g.LeftX.set(0.1)
+	g.RightX.set(0.1)
+	if g.LeftX.Value() != 0 || g.LeftX.Raw() != 0.1 {
+		t.Errorf("Axis within the dead zone was %v, raw %v", g.LeftX.Value(), g.LeftX.Raw())
+	}
+	if g.RightX.Value() != 0.1 {
+		t.Errorf("Axis without a dead zone was %v, expected 0.1", g.RightX.Value())
+	}
+	g.LeftX.set(-0.6)
+	if v := g.LeftX.Value(); v < -0.5001 || v > -0.4999 {
+		t.Errorf("Axis outside the dead zone was %v, expected it to be rescaled to -0.5", v)
+	}
+	g.LeftX.set(1)
+	if g.LeftX.Value() != 1 {
+		t.Errorf("Fully tilted axis was %v, expected 1", g.LeftX.Value())
+	}
+
+	// The dead zone of the stick is round: tilted diagonally by 0.17 on both axes, the stick is outside of it
+	g.LeftX.set(0.17)
+	g.LeftY.set(0.17)
+	if g.LeftX.Value() <= 0 || g.LeftY.Value() <= 0 {
+		t.Errorf("Stick tilted diagonally out of the dead zone was %v, %v", g.LeftX.Value(), g.LeftY.Value())
+	}
+	if g.LeftX.Value() != g.LeftY.Value() {
+		t.Errorf("Stick tilted diagonally changed direction to %v, %v", g.LeftX.Value(), g.LeftY.Value())
+	}
+	g.LeftX.set(0.1)
+	g.LeftY.set(-0.1)
+	if g.LeftX.Value() != 0 || g.LeftY.Value() != 0 {
+		t.Errorf("Stick tilted within the dead zone was %v, %v", g.LeftX.Value(), g.LeftY.Value())
+	}
+	g.LeftX.set(0.6)
+	g.LeftY.set(-0.8)
+	x, y := g.LeftX.Value(), g.LeftY.Value()
+	if x < 0.5999 || x > 0.6001 || y < -0.8001 || y > -0.7999 {
+		t.Errorf("Fully tilted stick was %v, %v, expected 0.6, -0.8", x, y)
+	}
+}
+
+func TestGamepadConnectionMessages(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &testScene{})
+	var msgs []Message
+	Mailbox.Listen("GamepadConnectedMessage", func(msg Message) { msgs = append(msgs, msg) })
+	Mailbox.Listen("GamepadDisconnectedMessage", func(msg Message) { msgs = append(msgs, msg) })
+
+	gm := NewGamepadManager()
+	gm.mutex.Lock()
+	gm.connected("player1", "guid")
+	gm.disconnected("player1", "guid")
+	gm.mutex.Unlock()
+	if len(msgs) != 0 {
+		t.Fatal("Connection messages were dispatched before the update")
+	}
+	gm.update()
+	if len(msgs) != 2 || msgs[0] != (GamepadConnectedMessage{Name: "player1", ID: "guid"}) || msgs[1] != (GamepadDisconnectedMessage{Name: "player1", ID: "guid"}) {
+		t.Errorf("Dispatched %v, expected the connection and the disconnection", msgs)
+	}
+	gm.update()
+	if len(msgs) != 2 {
+		t.Error("Connection messages were dispatched twice")
+	}
+}
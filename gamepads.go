@@ -51,6 +51,10 @@ func (b GamepadButton) Down() bool {
 type GamepadManager struct {
 	mutex    sync.RWMutex
 	gamepads map[string]*Gamepad
+
+	// mappings are the SDL_GameControllerDB mappings by GUID, events are the queued connection messages
+	mappings map[string]GamepadMapping
+	events   []Message
 }
 
 // NewGamepadManager creates a new GamepadManager
@@ -73,4 +77,32 @@ func (gm *GamepadManager) GetGamepad(name string) *Gamepad {
 
 func (gm *GamepadManager) update() {
 	gm.updateImpl()
+	gm.dispatchEvents()
+}
+
+// buttons returns the buttons of the Gamepad, in a fixed order.
+func (g *Gamepad) buttons() []*GamepadButton {
+	return []*GamepadButton{
+		&g.A, &g.B, &g.X, &g.Y,
+		&g.Back, &g.Start, &g.Guide,
+		&g.DpadUp, &g.DpadRight, &g.DpadDown, &g.DpadLeft,
+		&g.LeftBumper, &g.RightBumper,
+		&g.LeftThumb, &g.RightThumb,
+	}
+}
+
+// SetStickDeadZones sets the dead zones of the left and the right stick, see `AxisGamepad.SetDeadZone`. Unlike the dead
+// zones of single axes, they apply to how far the stick is tilted in any direction, so the dead zone is round.
+func (g *Gamepad) SetStickDeadZones(left, right float32) {
+	g.LeftX.stick, g.LeftY.stick = &g.LeftY, &g.LeftX
+	g.RightX.stick, g.RightY.stick = &g.RightY, &g.RightX
+	g.LeftX.SetDeadZone(left)
+	g.LeftY.SetDeadZone(left)
+	g.RightX.SetDeadZone(right)
+	g.RightY.SetDeadZone(right)
+}
+
+// axes returns the axes of the Gamepad, in a fixed order.
+func (g *Gamepad) axes() []*AxisGamepad {
+	return []*AxisGamepad{&g.LeftX, &g.LeftY, &g.RightX, &g.RightY, &g.LeftTrigger, &g.RightTrigger}
 }
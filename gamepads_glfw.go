@@ -47,7 +47,7 @@ joyLoop:
 				continue joyLoop
 			}
 		}
-		if joy.IsGamepad() {
+		if _, mapped := gm.mapping(joy.GetGUID()); joy.IsGamepad() || (mapped && joy.Present()) {
 			gm.gamepads[name] = &Gamepad{
 				joystick:  joy,
 				id:        joy.GetGUID(),
@@ -75,6 +75,12 @@ func (gm *GamepadManager) updateImpl() {
 			continue
 		}
 		if gamepad.joystick.Present() {
+			// Joysticks with a mapping use it rather than the mappings of glfw
+			if m, ok := gm.mapping(gamepad.id); ok {
+				m.apply(gamepad, joystickStateOf(gamepad.joystick))
+				continue
+			}
+
 			state := gamepad.joystick.GetGamepadState()
 
 			if state.Buttons[glfw.ButtonA] == glfw.Press {
@@ -179,3 +185,16 @@ func (gm *GamepadManager) updateImpl() {
 		}
 	}
 }
+
+// joystickStateOf returns the raw state of the joystick.
+func joystickStateOf(joy glfw.Joystick) joystickState {
+	var js joystickState
+	for _, b := range joy.GetButtons() {
+		js.buttons = append(js.buttons, b == glfw.Press)
+	}
+	js.axes = joy.GetAxes()
+	for _, h := range joy.GetHats() {
+		js.hats = append(js.hats, int(h))
+	}
+	return js
+}
@@ -2,7 +2,10 @@
 
 package engo
 
-import "errors"
+import (
+	"errors"
+	"syscall/js"
+)
 
 // Gampad is a configuration of a joystick that is able to be mapped to the
 // SDL_GameControllerDB.
@@ -32,10 +35,10 @@ func (gm *GamepadManager) registerGamepadImpl(name string) error {
 		if gpds.Index(i).IsNull() {
 			continue
 		}
-		if gpds.Index(i).Get("mapping").String() != "standard" {
+		gpid := gpds.Index(i).Get("id").String()
+		if _, mapped := gm.mappingByID(gpid); gpds.Index(i).Get("mapping").String() != "standard" && !mapped {
 			continue
 		}
-		gpid := gpds.Index(i).Get("id").String()
 		gm.gamepads[name] = &Gamepad{
 			id:        gpid,
 			connected: true,
@@ -52,57 +55,109 @@ func (gm *GamepadManager) registerGamepadImpl(name string) error {
 
 func (gm *GamepadManager) updateImpl() {
 	if window.IsUndefined() || window.Get("navigator").IsUndefined() {
-               return // node for testing
-        }
+		return // node for testing
+	}
 	gpds := window.Get("navigator").Call("getGamepads")
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
+
+	present := make(map[string]js.Value)
+	for i := 0; i < gpds.Length(); i++ {
+		if gpds.Index(i).IsNull() || !gpds.Index(i).Get("connected").Bool() {
+			continue
+		}
+		gpid := gpds.Index(i).Get("id").String()
+		present[gpid] = gpds.Index(i)
+		if !knownGpds[gpid] {
+			knownGpds[gpid] = true
+			gm.plugged(gpid)
+		}
+	}
+	for gpid := range knownGpds {
+		if _, ok := present[gpid]; !ok {
+			delete(knownGpds, gpid)
+		}
+	}
+
 	for name, gamepad := range gm.gamepads {
-		if !gamepad.connected {
-			warning("Gamepad " + name + " was not available for update!")
+		gpd, ok := present[gamepad.id]
+		if ok != gamepad.connected {
+			gamepad.connected = ok
+			if ok {
+				gm.connected(name, gamepad.id)
+			} else {
+				gm.disconnected(name, gamepad.id)
+				warning("Gamepad " + name + " was not available to update!")
+			}
+		}
+		if !ok {
 			continue
 		}
-		for i := 0; i < gpds.Length(); i++ {
-			if gpds.Index(i).IsNull() {
+
+		if gpd.Get("mapping").String() != "standard" {
+			if m, mapped := gm.mappingByID(gamepad.id); mapped {
+				m.apply(gamepad, joystickStateOf(gpd))
 				continue
 			}
-			gpid := gpds.Index(i).Get("id").String()
-			if gpid == gamepad.id {
-				if gpds.Index(i).Get("connected").Bool() {
-					gamepad.A.set(gpds.Index(i).Get("buttons").Index(0).Get("pressed").Bool())
-					gamepad.B.set(gpds.Index(i).Get("buttons").Index(1).Get("pressed").Bool())
-					gamepad.X.set(gpds.Index(i).Get("buttons").Index(2).Get("pressed").Bool())
-					gamepad.Y.set(gpds.Index(i).Get("buttons").Index(3).Get("pressed").Bool())
-					gamepad.LeftBumper.set(gpds.Index(i).Get("buttons").Index(4).Get("pressed").Bool())
-					gamepad.RightBumper.set(gpds.Index(i).Get("buttons").Index(5).Get("pressed").Bool())
-					if gpds.Index(i).Get("buttons").Index(6).Get("pressed").Bool() {
-						gamepad.LeftTrigger.set(1.0)
-					} else {
-						gamepad.LeftTrigger.set(0.0)
-					}
-					if gpds.Index(i).Get("buttons").Index(7).Get("pressed").Bool() {
-						gamepad.RightTrigger.set(1.0)
-					} else {
-						gamepad.RightTrigger.set(0.0)
-					}
-					gamepad.Back.set(gpds.Index(i).Get("buttons").Index(8).Get("pressed").Bool())
-					gamepad.Start.set(gpds.Index(i).Get("buttons").Index(9).Get("pressed").Bool())
-					gamepad.LeftThumb.set(gpds.Index(i).Get("buttons").Index(10).Get("pressed").Bool())
-					gamepad.RightThumb.set(gpds.Index(i).Get("buttons").Index(11).Get("pressed").Bool())
-					gamepad.DpadUp.set(gpds.Index(i).Get("buttons").Index(12).Get("pressed").Bool())
-					gamepad.DpadDown.set(gpds.Index(i).Get("buttons").Index(13).Get("pressed").Bool())
-					gamepad.DpadLeft.set(gpds.Index(i).Get("buttons").Index(14).Get("pressed").Bool())
-					gamepad.DpadRight.set(gpds.Index(i).Get("buttons").Index(15).Get("pressed").Bool())
-					gamepad.Guide.set(gpds.Index(i).Get("buttons").Index(16).Get("pressed").Bool())
-					gamepad.LeftX.set(float32(gpds.Index(i).Get("axes").Index(0).Float()))
-					gamepad.LeftY.set(float32(gpds.Index(i).Get("axes").Index(1).Float()))
-					gamepad.RightX.set(float32(gpds.Index(i).Get("axes").Index(2).Float()))
-					gamepad.RightY.set(float32(gpds.Index(i).Get("axes").Index(3).Float()))
-				} else {
-					gamepad.connected = false
-					warning("Gamepad " + name + " was not available to update!")
-				}
-			}
 		}
+		gamepad.A.set(gpd.Get("buttons").Index(0).Get("pressed").Bool())
+		gamepad.B.set(gpd.Get("buttons").Index(1).Get("pressed").Bool())
+		gamepad.X.set(gpd.Get("buttons").Index(2).Get("pressed").Bool())
+		gamepad.Y.set(gpd.Get("buttons").Index(3).Get("pressed").Bool())
+		gamepad.LeftBumper.set(gpd.Get("buttons").Index(4).Get("pressed").Bool())
+		gamepad.RightBumper.set(gpd.Get("buttons").Index(5).Get("pressed").Bool())
+		if gpd.Get("buttons").Index(6).Get("pressed").Bool() {
+			gamepad.LeftTrigger.set(1.0)
+		} else {
+			gamepad.LeftTrigger.set(0.0)
+		}
+		if gpd.Get("buttons").Index(7).Get("pressed").Bool() {
+			gamepad.RightTrigger.set(1.0)
+		} else {
+			gamepad.RightTrigger.set(0.0)
+		}
+		gamepad.Back.set(gpd.Get("buttons").Index(8).Get("pressed").Bool())
+		gamepad.Start.set(gpd.Get("buttons").Index(9).Get("pressed").Bool())
+		gamepad.LeftThumb.set(gpd.Get("buttons").Index(10).Get("pressed").Bool())
+		gamepad.RightThumb.set(gpd.Get("buttons").Index(11).Get("pressed").Bool())
+		gamepad.DpadUp.set(gpd.Get("buttons").Index(12).Get("pressed").Bool())
+		gamepad.DpadDown.set(gpd.Get("buttons").Index(13).Get("pressed").Bool())
+		gamepad.DpadLeft.set(gpd.Get("buttons").Index(14).Get("pressed").Bool())
+		gamepad.DpadRight.set(gpd.Get("buttons").Index(15).Get("pressed").Bool())
+		gamepad.Guide.set(gpd.Get("buttons").Index(16).Get("pressed").Bool())
+		gamepad.LeftX.set(float32(gpd.Get("axes").Index(0).Float()))
+		gamepad.LeftY.set(float32(gpd.Get("axes").Index(1).Float()))
+		gamepad.RightX.set(float32(gpd.Get("axes").Index(2).Float()))
+		gamepad.RightY.set(float32(gpd.Get("axes").Index(3).Float()))
+	}
+}
+
+// knownGpds are the ids of the gamepads that are plugged in.
+var knownGpds = make(map[string]bool)
+
+// plugged gives a newly plugged in gamepad to a registered Gamepad that has none, gm.mutex has to be held.
+func (gm *GamepadManager) plugged(gpid string) {
+	for _, gamepad := range gm.gamepads {
+		if gamepad.id == gpid {
+			return
+		}
+		if gamepad.id == "" && !gamepad.connected {
+			gamepad.id = gpid
+			return
+		}
+	}
+	gm.connected("", gpid)
+}
+
+// joystickStateOf returns the raw state of the gamepad.
+func joystickStateOf(gpd js.Value) joystickState {
+	var state joystickState
+	buttons, axes := gpd.Get("buttons"), gpd.Get("axes")
+	for i := 0; i < buttons.Length(); i++ {
+		state.buttons = append(state.buttons, buttons.Index(i).Get("pressed").Bool())
+	}
+	for i := 0; i < axes.Length(); i++ {
+		state.axes = append(state.axes, float32(axes.Index(i).Float()))
 	}
+	return state
 }
@@ -0,0 +1,337 @@
+package engo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often the files are checked for modifications when hot reloading can't be notified by
+// the operating system.
+const DefaultPollInterval = 500 * time.Millisecond
+
+// ResourceReloadedMessage is dispatched to the Mailboxes of all Scenes when a loaded file has been modified and
+// loaded again by hot reloading. Systems holding on to values created from the resource, such as Textures, Levels,
+// Fonts or shader programs, can listen for it to refresh them.
+type ResourceReloadedMessage struct {
+	URL string
+	// Resource is the resource after it has been loaded again.
+	Resource Resource
+	// Err is the error that occurred while loading the file again. The previous resource may have been kept.
+	Err error
+}
+
+// Type returns the type of the message, "ResourceReloadedMessage"
+func (ResourceReloadedMessage) Type() string { return "ResourceReloadedMessage" }
+
+// FileReloader is implemented by FileLoaders that can load a modified file in place, so that the values previously
+// created from the resource show the new contents. FileLoaders that don't implement it are given the modified file
+// through their Load method.
+type FileReloader interface {
+	// Reload loads the modified file, replacing the given resource.
+	Reload(url string, data io.Reader) error
+}
+
+// fileWatcher reports files that have been modified within the directories it watches.
+type fileWatcher interface {
+	add(dir string) error
+	close()
+}
+
+// fileStamp identifies the contents of a file while polling.
+type fileStamp struct {
+	modified, size int64
+	sum            uint64
+}
+
+// hotReloader reloads the loaded files of Formats when they are modified.
+type hotReloader struct {
+	formats  *Formats
+	root     string
+	interval time.Duration
+
+	// watcher is nil when the files are polled
+	watcher fileWatcher
+	dirs    map[string]bool
+	stop    chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]bool
+	// passes is the number of times the files have been polled
+	passes int
+}
+
+// Watch enables hot reloading: every file loaded from the root is loaded again by its FileLoader when it has been
+// modified, and a ResourceReloadedMessage is dispatched. It is meant to be used during development, for example to
+// see changes made to sprites, TMX maps or shaders without restarting the game.
+//
+// The operating system is asked to report modified files when that's supported, otherwise they are checked every
+// pollInterval. A pollInterval of 0 uses DefaultPollInterval.
+func (formats *Formats) Watch(pollInterval time.Duration) {
+	formats.watch(pollInterval, true)
+}
+
+func (formats *Formats) watch(pollInterval time.Duration, notify bool) {
+	formats.StopWatching()
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	r := &hotReloader{
+		formats:  formats,
+		root:     formats.root,
+		interval: pollInterval,
+		dirs:     make(map[string]bool),
+		stop:     make(chan struct{}),
+		pending:  make(map[string]bool),
+	}
+	if notify {
+		if w, err := newFileWatcher(r.changed); err == nil {
+			r.watcher = w
+			if err := r.watchDir(r.root); err != nil {
+				w.close()
+				r.watcher = nil
+			}
+		}
+	}
+
+	formats.mu.Lock()
+	formats.reloader = r
+	files := formats.loadedFiles()
+	formats.mu.Unlock()
+
+	if r.watcher == nil {
+		go r.poll()
+		return
+	}
+	for _, file := range files {
+		r.watch(file)
+	}
+}
+
+// StopWatching disables hot reloading.
+func (formats *Formats) StopWatching() {
+	formats.mu.Lock()
+	r := formats.reloader
+	formats.reloader = nil
+	formats.mu.Unlock()
+
+	if r == nil {
+		return
+	}
+	close(r.stop)
+	if r.watcher != nil {
+		r.watcher.close()
+	}
+}
+
+// Reload loads the given resource again from its file, and dispatches a ResourceReloadedMessage.
+func (formats *Formats) Reload(url string) error {
+	return formats.reloadFile(loadedFile{url: url, root: formats.root})
+}
+
+func (formats *Formats) reloadFile(file loadedFile) error {
+	url := file.url
+	msg := ResourceReloadedMessage{URL: url, Err: formats.reload(file)}
+	if msg.Err == nil {
+		msg.Resource, msg.Err = formats.Resource(url)
+	}
+	// The handlers may register Scenes, so the lock is not held while dispatching
+	var mailboxes []*MessageManager
+	sceneMutex.RLock()
+	for _, w := range scenes {
+		if w.mailbox != nil {
+			mailboxes = append(mailboxes, w.mailbox)
+		}
+	}
+	sceneMutex.RUnlock()
+	for _, m := range mailboxes {
+		m.Dispatch(msg)
+	}
+	return msg.Err
+}
+
+func (formats *Formats) reload(file loadedFile) error {
+	url := file.url
+	ext := getExt(url)
+	loader, ok := formats.loader(ext)
+	if !ok {
+		return fmt.Errorf("no `FileLoader` associated with this extension: %q in url %q", ext, url)
+	}
+	f, err := openFile(file.path())
+	if err != nil {
+		return fmt.Errorf("unable to open resource: %s", err)
+	}
+	defer f.Close()
+
+	size := readerSize(f)
+	if rl, ok := loader.(FileReloader); ok {
+		err = rl.Reload(url, f)
+	} else {
+		// This specific loader needs to be given the root
+		if rl, ok := loader.(FileLoaderRooter); ok {
+			rl.SetRoot(file.root)
+		}
+		err = loader.Load(url, f)
+	}
+	if err != nil {
+		return err
+	}
+	formats.resident(url, loader, size)
+	return nil
+}
+
+// loadedFile is a file that has been loaded from a root.
+type loadedFile struct {
+	url, root string
+}
+
+func (f loadedFile) path() string {
+	return filepath.Clean(filepath.Join(f.root, f.url))
+}
+
+// track remembers the url has been loaded from the root, so that it's reloaded when it's modified.
+func (formats *Formats) track(url, root string) {
+	file := loadedFile{url: url, root: root}
+	formats.mu.Lock()
+	if formats.loaded == nil {
+		formats.loaded = make(map[string]loadedFile)
+	}
+	formats.loaded[file.path()] = file
+	r := formats.reloader
+	formats.mu.Unlock()
+
+	if r != nil && r.watcher != nil {
+		r.watch(file)
+	}
+}
+
+// untrack forgets the url after it has been unloaded.
+func (formats *Formats) untrack(url string) {
+	formats.mu.Lock()
+	for path, file := range formats.loaded {
+		if file.url == url {
+			delete(formats.loaded, path)
+		}
+	}
+	formats.mu.Unlock()
+}
+
+// loadedFiles returns the files that have been loaded, formats.mu has to be held.
+func (formats *Formats) loadedFiles() []loadedFile {
+	files := make([]loadedFile, 0, len(formats.loaded))
+	for _, file := range formats.loaded {
+		files = append(files, file)
+	}
+	return files
+}
+
+// loadedFile returns the file at the path, if it has been loaded.
+func (formats *Formats) loadedFile(path string) (loadedFile, bool) {
+	formats.mu.RLock()
+	defer formats.mu.RUnlock()
+	file, ok := formats.loaded[filepath.Clean(path)]
+	return file, ok
+}
+
+// watch makes sure the directory of the file is watched.
+func (r *hotReloader) watch(file loadedFile) {
+	r.watchDir(filepath.Dir(file.path()))
+}
+
+func (r *hotReloader) watchDir(dir string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dirs[dir] {
+		return nil
+	}
+	if err := r.watcher.add(dir); err != nil {
+		return err
+	}
+	r.dirs[dir] = true
+	return nil
+}
+
+// changed is called by the fileWatcher with the path of a modified file.
+func (r *hotReloader) changed(path string) {
+	if file, ok := r.formats.loadedFile(path); ok {
+		r.queue(file)
+	}
+}
+
+// queue reloads the file on the main thread, once even if it's modified several times in the meantime.
+func (r *hotReloader) queue(file loadedFile) {
+	path := file.path()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending[path] {
+		return
+	}
+	r.pending[path] = true
+	runOnMainThread(func() {
+		r.mu.Lock()
+		delete(r.pending, path)
+		r.mu.Unlock()
+
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		if file, ok := r.formats.loadedFile(path); ok {
+			r.formats.reloadFile(file)
+		}
+	})
+}
+
+// poll checks the loaded files for modifications every interval.
+func (r *hotReloader) poll() {
+	stamps := make(map[string]fileStamp)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.formats.mu.RLock()
+		files := r.formats.loadedFiles()
+		r.formats.mu.RUnlock()
+
+		for _, file := range files {
+			path := file.path()
+			stamp := fileStampOf(path)
+			if old, ok := stamps[path]; ok && old != stamp {
+				r.queue(file)
+			}
+			stamps[path] = stamp
+		}
+		r.mu.Lock()
+		r.passes++
+		r.mu.Unlock()
+
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fileStampOf returns the modification time and size of the file, or the checksum of its contents where the file
+// system can't be queried, such as for the web and mobile.
+func fileStampOf(path string) fileStamp {
+	if info, err := os.Stat(path); err == nil {
+		return fileStamp{modified: info.ModTime().UnixNano(), size: info.Size()}
+	}
+
+	f, err := openFile(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	defer f.Close()
+	h := fnv.New64a()
+	size, _ := io.Copy(h, f)
+	return fileStamp{size: size, sum: h.Sum64()}
+}
@@ -0,0 +1,94 @@
+// +build linux
+
+package engo
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyWatcher is notified of modified files by the inotify API of Linux.
+type inotifyWatcher struct {
+	fd      int
+	changed func(file string)
+
+	mu      sync.Mutex
+	dirs    map[int]string
+	running bool
+	closed  bool
+}
+
+func newFileWatcher(changed func(file string)) (fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	return &inotifyWatcher{fd: fd, changed: changed, dirs: make(map[int]string)}, nil
+}
+
+func (w *inotifyWatcher) add(dir string) error {
+	// Editors either write the file directly, or move a temporary file over it
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs[wd] = dir
+	if !w.running {
+		w.running = true
+		go w.run()
+	}
+	return nil
+}
+
+func (w *inotifyWatcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if !w.running {
+		syscall.Close(w.fd)
+		return
+	}
+	// Removing the watches wakes up the blocked read, which closes the file descriptor
+	for wd := range w.dirs {
+		syscall.InotifyRmWatch(w.fd, uint32(wd))
+	}
+}
+
+func (w *inotifyWatcher) run() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := syscall.Read(w.fd, buf)
+		w.mu.Lock()
+		closed := w.closed
+		w.mu.Unlock()
+		if err == syscall.EINTR && !closed {
+			continue
+		}
+		if err != nil || closed {
+			syscall.Close(w.fd)
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			offset = start + int(event.Len)
+			if event.Mask&(syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) == 0 {
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.dirs[int(event.Wd)]
+			w.mu.Unlock()
+			if ok {
+				w.changed(filepath.Join(dir, strings.TrimRight(string(buf[start:offset]), "\x00")))
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// +build !linux
+
+package engo
+
+import "errors"
+
+// newFileWatcher returns an error, the files are polled on this platform.
+func newFileWatcher(changed func(file string)) (fileWatcher, error) {
+	return nil, errors.New("watching files is not supported on this platform")
+}
@@ -0,0 +1,149 @@
+package engo
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// reloadTestLoader records the contents it was given through Reload.
+type reloadTestLoader struct {
+	asyncTestLoader
+	reloaded map[string]string
+}
+
+func (l *reloadTestLoader) Reload(url string, data io.Reader) error {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	l.reloaded[url] = string(b)
+	return nil
+}
+
+// waitForReload runs the main thread calls until the condition is met.
+func waitForReload(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Modified file was not reloaded in time")
+		}
+		runMainThreadCalls()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForPolls waits until the files have been polled twice more while polling, so that the modifications made
+// before have been seen, and reloads them. Modifications reported by the operating system are not waited for.
+func waitForPolls(t *testing.T) {
+	Files.mu.RLock()
+	r := Files.reloader
+	Files.mu.RUnlock()
+	if r == nil || r.watcher != nil {
+		return
+	}
+	passes := func() int {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.passes
+	}
+	// the pass running when called may have checked the files before they were modified
+	target := passes() + 2
+	deadline := time.Now().Add(5 * time.Second)
+	for passes() < target {
+		if time.Now().After(deadline) {
+			t.Fatal("Files were not polled in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	runMainThreadCalls()
+}
+
+func testHotReload(t *testing.T, notify bool) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &assetTestScene{})
+	loader := &asyncTestLoader{decoded: make(map[string]string), finished: make(map[string]bool)}
+	reloader := &reloadTestLoader{
+		asyncTestLoader: asyncTestLoader{decoded: make(map[string]string), finished: make(map[string]bool)},
+		reloaded:        make(map[string]string),
+	}
+	Files.Register(".asynctest", loader)
+	Files.Register(".reloadtest", reloader)
+
+	dir := asyncTestDir(t)
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatalf("failed to create test directory, error: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "sub", "map.reloadtest"), []byte("a"), 0644); err != nil {
+		t.Fatalf("failed to create test file, error: %v", err)
+	}
+	Files.SetRoot(dir)
+	if err := Files.Load("first.asynctest", "sub/map.reloadtest"); err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []ResourceReloadedMessage
+	Mailbox.Listen("ResourceReloadedMessage", func(msg Message) {
+		msgs = append(msgs, msg.(ResourceReloadedMessage))
+	})
+
+	Files.watch(10*time.Millisecond, notify)
+	defer Files.StopWatching()
+	// The poller has to see the files before they are modified
+	waitForPolls(t)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "first.asynctest"), []byte("modified"), 0644); err != nil {
+		t.Fatalf("failed to modify test file, error: %v", err)
+	}
+	waitForReload(t, func() bool { return loader.decoded["first.asynctest"] == "modified" })
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "sub", "map.reloadtest"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to modify test file, error: %v", err)
+	}
+	waitForReload(t, func() bool { return reloader.reloaded["sub/map.reloadtest"] == "changed" })
+	// Let the poller see the final state of the files
+	waitForPolls(t)
+	if len(reloader.decoded) != 1 {
+		t.Error("FileReloader was given the modified file through Load")
+	}
+
+	// Writing a file may be seen as several modifications while polling
+	reloaded := make(map[string]bool)
+	for _, msg := range msgs {
+		if msg.Err != nil || msg.Resource.URL() != msg.URL {
+			t.Errorf("ResourceReloadedMessage was %+v", msg)
+		}
+		reloaded[msg.URL] = true
+	}
+	if len(reloaded) != 2 || !reloaded["first.asynctest"] || !reloaded["sub/map.reloadtest"] {
+		t.Errorf("ResourceReloadedMessages were dispatched for %v, expected both modified files", reloaded)
+	}
+
+	// Unloaded and unmodified files are not reloaded
+	Files.Unload("first.asynctest")
+	ioutil.WriteFile(filepath.Join(dir, "first.asynctest"), []byte("unloaded"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "second.asynctest"), []byte("not loaded"), 0644)
+	n := len(msgs)
+	waitForPolls(t)
+	if notify {
+		// the operating system reports the modifications in order
+		ioutil.WriteFile(filepath.Join(dir, "sub", "map.reloadtest"), []byte("last"), 0644)
+		waitForReload(t, func() bool { return reloader.reloaded["sub/map.reloadtest"] == "last" })
+	}
+	for _, msg := range msgs[n:] {
+		if msg.URL != "sub/map.reloadtest" {
+			t.Errorf("File that is not loaded was reloaded: %+v", msg)
+		}
+	}
+}
+
+func TestHotReloadPolling(t *testing.T) {
+	testHotReload(t, false)
+}
+
+func TestHotReloadNotify(t *testing.T) {
+	testHotReload(t, true)
+}
@@ -11,13 +11,15 @@ const (
 
 // NewInputManager holds onto anything input related for engo
 func NewInputManager() *InputManager {
-	return &InputManager{
+	im := &InputManager{
 		Touches:  make(map[int]Point),
 		axes:     make(map[string]Axis),
 		buttons:  make(map[string]Button),
 		keys:     NewKeyManager(),
 		gamepads: NewGamepadManager(),
 	}
+	im.actions = &ActionMap{input: im, actions: make(map[string]*inputAction)}
+	return im
 }
 
 // InputManager contains information about all forms of input.
@@ -37,6 +39,14 @@ type InputManager struct {
 	buttons  map[string]Button
 	keys     *KeyManager
 	gamepads *GamepadManager
+
+	// recorder and player are set while the input is recorded or replayed
+	recorder *InputRecorder
+	player   *InputPlayer
+
+	// mouseButtons tracks which mouse buttons are held down, actions are the rebindable Buttons and Axes
+	mouseButtons [MouseButtonLast + 1]KeyState
+	actions      *ActionMap
 }
 
 func (im *InputManager) update() {
@@ -67,13 +77,25 @@ func (im *InputManager) RegisterGamepad(name string) error {
 	return im.gamepads.Register(name)
 }
 
-// Axis retrieves an Axis with a specified name.
+// Axis retrieves an Axis with a specified name. Axis actions of the ActionMap are found first.
 func (im *InputManager) Axis(name string) Axis {
+	if a, ok := im.actions.actions[name]; ok && a.axis {
+		return Axis{Name: name, Pairs: []AxisPair{actionAxisPair{actions: im.actions, action: a}}}
+	}
 	return im.axes[name]
 }
 
-// Button retrieves a Button with a specified name.
+// Button retrieves a Button with a specified name. Button actions of the ActionMap are found first.
 func (im *InputManager) Button(name string) Button {
+	if a, ok := im.actions.actions[name]; ok && !a.axis {
+		b := Button{Name: name, action: a}
+		for _, binding := range a.bindings {
+			if binding.Device == DeviceKeyboard {
+				b.Triggers = append(b.Triggers, binding.Key)
+			}
+		}
+		return b
+	}
 	return im.buttons[name]
 }
 
@@ -0,0 +1,677 @@
+package engo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ActionAxisThreshold is how far a gamepad axis has to be pushed to press a button action it's bound to, or to be
+// captured by `ActionMap.Capture`.
+const ActionAxisThreshold float32 = 0.5
+
+// InputDevice is the kind of device a Binding is for.
+type InputDevice uint8
+
+const (
+	// DeviceKeyboard is the keyboard
+	DeviceKeyboard InputDevice = iota
+	// DeviceMouse is the mouse
+	DeviceMouse
+	// DeviceGamepad is a registered Gamepad
+	DeviceGamepad
+)
+
+// GamepadControl is a button or an axis of a Gamepad.
+type GamepadControl uint8
+
+// Gamepad controls, the buttons first and then the axes
+const (
+	GamepadA GamepadControl = iota
+	GamepadB
+	GamepadX
+	GamepadY
+	GamepadBack
+	GamepadStart
+	GamepadGuide
+	GamepadDpadUp
+	GamepadDpadRight
+	GamepadDpadDown
+	GamepadDpadLeft
+	GamepadLeftBumper
+	GamepadRightBumper
+	GamepadLeftThumb
+	GamepadRightThumb
+	GamepadLeftX
+	GamepadLeftY
+	GamepadRightX
+	GamepadRightY
+	GamepadLeftTrigger
+	GamepadRightTrigger
+)
+
+var gamepadControlNames = []string{
+	"A", "B", "X", "Y", "Back", "Start", "Guide", "DpadUp", "DpadRight", "DpadDown", "DpadLeft",
+	"LeftBumper", "RightBumper", "LeftThumb", "RightThumb",
+	"LeftX", "LeftY", "RightX", "RightY", "LeftTrigger", "RightTrigger",
+}
+
+var mouseButtonNames = []string{"Left", "Right", "Middle", "Button4", "Button5", "Button6", "Button7", "Button8"}
+
+// IsAxis indicates whether the control is an axis, rather than a button.
+func (c GamepadControl) IsAxis() bool {
+	return c >= GamepadLeftX
+}
+
+// Binding binds a key, a mouse button or a control of a Gamepad to an action of an ActionMap.
+type Binding struct {
+	Device      InputDevice
+	Key         Key
+	MouseButton MouseButton
+	// Gamepad is the name the Gamepad has been registered with.
+	Gamepad string
+	Control GamepadControl
+	// Negative makes the input count towards the negative direction of axis actions. Gamepad axes bound with it press
+	// button actions when they are pushed in the negative direction.
+	Negative bool
+}
+
+// KeyBinding binds a key.
+func KeyBinding(k Key) Binding {
+	return Binding{Device: DeviceKeyboard, Key: k}
+}
+
+// MouseBinding binds a mouse button.
+func MouseBinding(b MouseButton) Binding {
+	return Binding{Device: DeviceMouse, MouseButton: b}
+}
+
+// GamepadBinding binds a button or an axis of the Gamepad registered with the given name.
+func GamepadBinding(gamepad string, c GamepadControl) Binding {
+	return Binding{Device: DeviceGamepad, Gamepad: gamepad, Control: c}
+}
+
+// Inverted returns the Binding counting towards the negative direction.
+func (b Binding) Inverted() Binding {
+	b.Negative = !b.Negative
+	return b
+}
+
+// String returns the Binding as it's saved, such as "Key:Space", "Mouse:Left", or "-Gamepad:LeftX:player1" for the
+// negative direction of an axis.
+func (b Binding) String() string {
+	var s string
+	switch b.Device {
+	case DeviceKeyboard:
+		s = "Key:" + keyName(b.Key)
+	case DeviceMouse:
+		if int(b.MouseButton) >= 0 && int(b.MouseButton) < len(mouseButtonNames) {
+			s = "Mouse:" + mouseButtonNames[b.MouseButton]
+		} else {
+			s = fmt.Sprintf("Mouse:%d", b.MouseButton)
+		}
+	case DeviceGamepad:
+		name := fmt.Sprint(int(b.Control))
+		if int(b.Control) < len(gamepadControlNames) {
+			name = gamepadControlNames[b.Control]
+		}
+		s = "Gamepad:" + name + ":" + b.Gamepad
+	}
+	if b.Negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// ParseBinding parses a Binding returned by its String method.
+func ParseBinding(s string) (Binding, error) {
+	var b Binding
+	if strings.HasPrefix(s, "-") {
+		b.Negative = true
+		s = s[1:]
+	}
+	parts := strings.SplitN(s, ":", 3)
+	switch {
+	case len(parts) == 2 && parts[0] == "Key":
+		b.Device = DeviceKeyboard
+		for _, k := range keyNames {
+			if k.name == parts[1] {
+				b.Key = k.key
+				return b, nil
+			}
+		}
+		var code int
+		if _, err := fmt.Sscan(parts[1], &code); err == nil {
+			b.Key = Key(code)
+			return b, nil
+		}
+	case len(parts) == 2 && parts[0] == "Mouse":
+		b.Device = DeviceMouse
+		for i, name := range mouseButtonNames {
+			if name == parts[1] {
+				b.MouseButton = MouseButton(i)
+				return b, nil
+			}
+		}
+	case len(parts) == 3 && parts[0] == "Gamepad":
+		b.Device = DeviceGamepad
+		b.Gamepad = parts[2]
+		for i, name := range gamepadControlNames {
+			if name == parts[1] {
+				b.Control = GamepadControl(i)
+				return b, nil
+			}
+		}
+	}
+	return Binding{}, fmt.Errorf("invalid input binding: %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler, so that Bindings are saved as strings.
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Binding) UnmarshalText(text []byte) error {
+	parsed, err := ParseBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// keyName returns the name of the key, or its code if it has none.
+func keyName(k Key) string {
+	for _, n := range keyNames {
+		if n.key == k {
+			return n.name
+		}
+	}
+	return fmt.Sprint(int(k))
+}
+
+// value returns how far the input is pressed, from 0 to 1, or the value of a gamepad axis from -1 to 1.
+func (b Binding) value(im *InputManager) float32 {
+	pressed := false
+	switch b.Device {
+	case DeviceKeyboard:
+		pressed = im.keys.Get(b.Key).currentState
+	case DeviceMouse:
+		if b.MouseButton >= 0 && b.MouseButton <= MouseButtonLast {
+			pressed = im.mouseButtons[b.MouseButton].currentState
+		}
+	case DeviceGamepad:
+		im.gamepads.mutex.RLock()
+		defer im.gamepads.mutex.RUnlock()
+		g, ok := im.gamepads.gamepads[b.Gamepad]
+		if !ok {
+			return 0
+		}
+		if b.Control.IsAxis() {
+			axes := g.axes()
+			if i := int(b.Control - GamepadLeftX); i < len(axes) {
+				return axes[i].Value()
+			}
+			return 0
+		}
+		buttons := g.buttons()
+		if int(b.Control) < len(buttons) {
+			pressed = buttons[b.Control].currentState
+		}
+	}
+	if pressed {
+		return 1
+	}
+	return 0
+}
+
+// held indicates whether the input presses a button action.
+func (b Binding) held(im *InputManager) bool {
+	v := b.value(im)
+	if b.Negative {
+		v = -v
+	}
+	return v >= ActionAxisThreshold
+}
+
+// conflicts indicates whether both Bindings are triggered by the same input.
+func (b Binding) conflicts(other Binding) bool {
+	if b.Device != other.Device {
+		return false
+	}
+	switch b.Device {
+	case DeviceKeyboard:
+		return b.Key == other.Key
+	case DeviceMouse:
+		return b.MouseButton == other.MouseButton
+	}
+	if b.Gamepad != other.Gamepad || b.Control != other.Control {
+		return false
+	}
+	// The directions of an axis can be bound to different actions
+	return !b.Control.IsAxis() || b.Negative == other.Negative
+}
+
+// inputAction is an action of an ActionMap.
+type inputAction struct {
+	name     string
+	axis     bool
+	bindings []Binding
+	defaults []Binding
+	state    KeyState
+}
+
+// BindingConflict is an input bound to more than one action.
+type BindingConflict struct {
+	Binding Binding
+	Actions []string
+}
+
+// ActionMap maps actions, such as "jump" or "horizontal", to the keys, mouse buttons and gamepad controls bound to
+// them. The bindings can be changed at runtime, captured from the next input for options menus, and saved to a file.
+//
+// Actions are looked up like the other Buttons and Axes, through `InputManager.Button` and `InputManager.Axis`.
+type ActionMap struct {
+	input   *InputManager
+	actions map[string]*inputAction
+
+	// capture is called with the next input while capturing, baseline are the values of the gamepad axes when
+	// capturing started
+	capture  func(Binding)
+	baseline map[string][]float32
+}
+
+// Actions returns the ActionMap of the InputManager.
+func (im *InputManager) Actions() *ActionMap {
+	return im.actions
+}
+
+// AddButton registers a button action, with the given bindings as its defaults. Registering an action again replaces
+// it.
+func (m *ActionMap) AddButton(name string, bindings ...Binding) {
+	m.add(name, false, bindings)
+}
+
+// AddAxis registers an axis action, with the given bindings as its defaults. Its value is the sum of the values of
+// its bindings, from AxisMin to AxisMax: keys and buttons count as AxisMax, or AxisMin if they are Negative.
+func (m *ActionMap) AddAxis(name string, bindings ...Binding) {
+	m.add(name, true, bindings)
+}
+
+func (m *ActionMap) add(name string, axis bool, bindings []Binding) {
+	m.actions[name] = &inputAction{
+		name:     name,
+		axis:     axis,
+		bindings: append([]Binding(nil), bindings...),
+		defaults: append([]Binding(nil), bindings...),
+	}
+}
+
+// Remove unregisters the action.
+func (m *ActionMap) Remove(name string) {
+	delete(m.actions, name)
+}
+
+// Names returns the names of the registered actions, sorted.
+func (m *ActionMap) Names() []string {
+	names := make([]string, 0, len(m.actions))
+	for name := range m.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *ActionMap) action(name string) (*inputAction, error) {
+	a, ok := m.actions[name]
+	if !ok {
+		return nil, fmt.Errorf("action not registered: %s", name)
+	}
+	return a, nil
+}
+
+// Bindings returns the bindings of the action.
+func (m *ActionMap) Bindings(name string) []Binding {
+	if a, ok := m.actions[name]; ok {
+		return append([]Binding(nil), a.bindings...)
+	}
+	return nil
+}
+
+// SetBindings replaces the bindings of the action.
+func (m *ActionMap) SetBindings(name string, bindings ...Binding) error {
+	a, err := m.action(name)
+	if err != nil {
+		return err
+	}
+	a.bindings = append([]Binding(nil), bindings...)
+	return nil
+}
+
+// Bind adds the binding to the action.
+func (m *ActionMap) Bind(name string, b Binding) error {
+	a, err := m.action(name)
+	if err != nil {
+		return err
+	}
+	for _, existing := range a.bindings {
+		if existing == b {
+			return nil
+		}
+	}
+	a.bindings = append(a.bindings, b)
+	return nil
+}
+
+// Unbind removes the binding from the action.
+func (m *ActionMap) Unbind(name string, b Binding) error {
+	a, err := m.action(name)
+	if err != nil {
+		return err
+	}
+	for i, existing := range a.bindings {
+		if existing == b {
+			a.bindings = append(a.bindings[:i], a.bindings[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Rebind replaces the old binding of the action with the new one, keeping its position. The new binding is added if
+// the action didn't have the old one.
+func (m *ActionMap) Rebind(name string, old, b Binding) error {
+	a, err := m.action(name)
+	if err != nil {
+		return err
+	}
+	for i, existing := range a.bindings {
+		if existing == old {
+			a.bindings[i] = b
+			return nil
+		}
+	}
+	a.bindings = append(a.bindings, b)
+	return nil
+}
+
+// Reset restores the default bindings of the action.
+func (m *ActionMap) Reset(name string) error {
+	a, err := m.action(name)
+	if err != nil {
+		return err
+	}
+	a.bindings = append([]Binding(nil), a.defaults...)
+	return nil
+}
+
+// ResetAll restores the default bindings of all actions.
+func (m *ActionMap) ResetAll() {
+	for _, a := range m.actions {
+		a.bindings = append([]Binding(nil), a.defaults...)
+	}
+}
+
+// ConflictsWith returns the names of the actions, other than the given one, the binding is bound to. It can be used to
+// warn about a binding before it's set.
+func (m *ActionMap) ConflictsWith(name string, b Binding) []string {
+	var names []string
+	for _, other := range m.Names() {
+		if other == name {
+			continue
+		}
+		for _, existing := range m.actions[other].bindings {
+			if existing.conflicts(b) {
+				names = append(names, other)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// Conflicts returns the inputs bound to more than one action.
+func (m *ActionMap) Conflicts() []BindingConflict {
+	var conflicts []BindingConflict
+	names := m.Names()
+	for i, name := range names {
+	bindings:
+		for _, b := range m.actions[name].bindings {
+			for _, c := range conflicts {
+				if c.Binding.conflicts(b) {
+					continue bindings
+				}
+			}
+			c := BindingConflict{Binding: b, Actions: []string{name}}
+			for _, other := range names[i+1:] {
+				for _, existing := range m.actions[other].bindings {
+					if existing.conflicts(b) {
+						c.Actions = append(c.Actions, other)
+						break
+					}
+				}
+			}
+			if len(c.Actions) > 1 {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	return conflicts
+}
+
+// Capture calls the function with the next key, mouse button or gamepad control that is pressed, such as to let the
+// player choose a binding in an options menu. The actions are not triggered while capturing, nor by the captured
+// input. Capturing again replaces the function.
+func (m *ActionMap) Capture(f func(Binding)) {
+	m.capture = f
+	m.baseline = nil
+}
+
+// CancelCapture stops capturing without calling the function.
+func (m *ActionMap) CancelCapture() {
+	m.capture = nil
+	m.baseline = nil
+}
+
+// Capturing indicates whether the next input is being captured.
+func (m *ActionMap) Capturing() bool {
+	return m.capture != nil
+}
+
+// Save writes the bindings of all actions as JSON.
+func (m *ActionMap) Save(w io.Writer) error {
+	bindings := make(map[string][]Binding, len(m.actions))
+	for name, a := range m.actions {
+		bindings[name] = a.bindings
+		if bindings[name] == nil {
+			bindings[name] = []Binding{}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bindings)
+}
+
+// Load reads the bindings saved by Save, and replaces the bindings of the registered actions with them. Actions that
+// are not registered are ignored, so they have to be registered before loading.
+func (m *ActionMap) Load(r io.Reader) error {
+	var bindings map[string][]Binding
+	if err := json.NewDecoder(r).Decode(&bindings); err != nil {
+		return err
+	}
+	for name, b := range bindings {
+		if a, ok := m.actions[name]; ok {
+			a.bindings = b
+		}
+	}
+	return nil
+}
+
+// SaveFile saves the bindings to the file at path.
+func (m *ActionMap) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile loads the bindings from the file at path.
+func (m *ActionMap) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Load(f)
+}
+
+// update updates the states of the button actions, or captures the input, once per frame.
+func (m *ActionMap) update() {
+	if m.capture != nil {
+		if b, ok := m.captured(); ok {
+			f := m.capture
+			m.CancelCapture()
+			f(b)
+		}
+		for _, a := range m.actions {
+			a.state.set(false)
+		}
+		return
+	}
+
+	for _, a := range m.actions {
+		held := false
+		for _, b := range a.bindings {
+			if b.held(m.input) {
+				held = true
+				break
+			}
+		}
+		a.state.set(held)
+	}
+}
+
+// captured returns the input that has been pressed since capturing started.
+func (m *ActionMap) captured() (Binding, bool) {
+	im := m.input
+	im.gamepads.mutex.RLock()
+	names := make([]string, 0, len(im.gamepads.gamepads))
+	for name := range im.gamepads.gamepads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	axes := make(map[string][]float32, len(names))
+	for _, name := range names {
+		for _, a := range im.gamepads.gamepads[name].axes() {
+			axes[name] = append(axes[name], a.Value())
+		}
+	}
+	im.gamepads.mutex.RUnlock()
+
+	// Gamepad axes are compared to their values when capturing started, since some of them rest at AxisMin
+	if m.baseline == nil {
+		m.baseline = axes
+	}
+
+	im.keys.mutex.RLock()
+	var keys []Key
+	for k, ks := range im.keys.mapper {
+		if ks.JustPressed() {
+			keys = append(keys, k)
+		}
+	}
+	im.keys.mutex.RUnlock()
+	if len(keys) > 0 {
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		return KeyBinding(keys[0]), true
+	}
+
+	for i, ks := range im.mouseButtons {
+		if ks.JustPressed() {
+			return MouseBinding(MouseButton(i)), true
+		}
+	}
+
+	for _, name := range names {
+		im.gamepads.mutex.RLock()
+		g, ok := im.gamepads.gamepads[name]
+		pressed := -1
+		if ok {
+			for i, b := range g.buttons() {
+				if b.JustPressed() {
+					pressed = i
+					break
+				}
+			}
+		}
+		im.gamepads.mutex.RUnlock()
+		if pressed >= 0 {
+			return GamepadBinding(name, GamepadControl(pressed)), true
+		}
+
+		for i, v := range axes[name] {
+			if i >= len(m.baseline[name]) {
+				continue
+			}
+			delta := v - m.baseline[name][i]
+			if delta >= ActionAxisThreshold || -delta >= ActionAxisThreshold {
+				b := GamepadBinding(name, GamepadLeftX+GamepadControl(i))
+				b.Negative = delta < 0
+				return b, true
+			}
+		}
+	}
+	return Binding{}, false
+}
+
+// actionAxisPair is the AxisPair of an axis action.
+type actionAxisPair struct {
+	actions *ActionMap
+	action  *inputAction
+}
+
+// Value returns the sum of the values of the bindings of the action, from AxisMin to AxisMax.
+func (p actionAxisPair) Value() float32 {
+	if p.actions.capture != nil {
+		return AxisNeutral
+	}
+	var v float32
+	for _, b := range p.action.bindings {
+		bv := b.value(p.actions.input)
+		if b.Negative {
+			bv = -bv
+		}
+		v += bv
+	}
+	if v > AxisMax {
+		return AxisMax
+	}
+	if v < AxisMin {
+		return AxisMin
+	}
+	return v
+}
+
+// updateMouseButtons tracks which mouse buttons are held down, from the mouse action of the frame.
+func (im *InputManager) updateMouseButtons() {
+	for i := range im.mouseButtons {
+		im.mouseButtons[i].lastState = im.mouseButtons[i].currentState
+	}
+	if b := im.Mouse.Button; b >= 0 && b <= MouseButtonLast {
+		switch im.Mouse.Action {
+		case Press:
+			im.mouseButtons[b].currentState = true
+		case Release:
+			im.mouseButtons[b].currentState = false
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package engo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// actionTestFrame runs a frame of the actions, after the keys have been updated.
+func actionTestFrame() {
+	Input.frame(0)
+	Input.update()
+}
+
+func TestActionMapButton(t *testing.T) {
+	Input = NewInputManager()
+	Input.gamepads.gamepads["pad"] = &Gamepad{}
+	Input.Actions().AddButton("jump", KeyBinding(KeySpace), MouseBinding(MouseButtonLeft), GamepadBinding("pad", GamepadA))
+	Input.Actions().AddButton("left", GamepadBinding("pad", GamepadLeftX).Inverted())
+
+	Input.keys.Set(KeySpace, true)
+	actionTestFrame()
+	if b := Input.Button("jump"); !b.JustPressed() || b.Down() {
+		t.Error("Button action was not just pressed by its key")
+	}
+	if b := Input.Button("jump"); len(b.Triggers) != 1 || b.Triggers[0] != KeySpace {
+		t.Errorf("Button action had the triggers %v, expected its key", b.Triggers)
+	}
+	actionTestFrame()
+	if !Input.Button("jump").Down() {
+		t.Error("Button action was not down while its key was held")
+	}
+
+	Input.keys.Set(KeySpace, false)
+	actionTestFrame()
+	if !Input.Button("jump").JustReleased() {
+		t.Error("Button action was not just released with its key")
+	}
+
+	Input.Mouse.Action, Input.Mouse.Button = Press, MouseButtonLeft
+	actionTestFrame()
+	Input.Mouse.Action = Neutral
+	actionTestFrame()
+	if !Input.Button("jump").Down() {
+		t.Error("Button action was not down while its mouse button was held")
+	}
+	Input.Mouse.Action = Release
+	actionTestFrame()
+	if !Input.Button("jump").JustReleased() {
+		t.Error("Button action was not just released with its mouse button")
+	}
+
+	Input.gamepads.gamepads["pad"].A.set(true)
+	actionTestFrame()
+	if !Input.Button("jump").JustPressed() {
+		t.Error("Button action was not just pressed by its gamepad button")
+	}
+
+	Input.gamepads.gamepads["pad"].LeftX.set(0.8)
+	actionTestFrame()
+	if Input.Button("left").JustPressed() {
+		t.Error("Button action was pressed by the opposite direction of its axis")
+	}
+	Input.gamepads.gamepads["pad"].LeftX.set(-0.8)
+	actionTestFrame()
+	if !Input.Button("left").JustPressed() {
+		t.Error("Button action was not pressed by the direction of its axis")
+	}
+}
+
+func TestActionMapAxis(t *testing.T) {
+	Input = NewInputManager()
+	Input.gamepads.gamepads["pad"] = &Gamepad{}
+	Input.Actions().AddAxis("horizontal", KeyBinding(KeyA).Inverted(), KeyBinding(KeyD), GamepadBinding("pad", GamepadLeftX))
+
+	if v := Input.Axis("horizontal").Value(); v != AxisNeutral {
+		t.Errorf("Axis action was %v without input, expected %v", v, AxisNeutral)
+	}
+	Input.keys.Set(KeyA, true)
+	if v := Input.Axis("horizontal").Value(); v != AxisMin {
+		t.Errorf("Axis action was %v with its negative key, expected %v", v, AxisMin)
+	}
+	Input.keys.Set(KeyD, true)
+	if v := Input.Axis("horizontal").Value(); v != AxisNeutral {
+		t.Errorf("Axis action was %v with both keys, expected %v", v, AxisNeutral)
+	}
+	Input.keys.Set(KeyA, false)
+	Input.gamepads.gamepads["pad"].LeftX.set(0.5)
+	if v := Input.Axis("horizontal").Value(); v != AxisMax {
+		t.Errorf("Axis action was %v with its key and axis, expected it to be clamped to %v", v, AxisMax)
+	}
+	Input.keys.Set(KeyD, false)
+	if v := Input.Axis("horizontal").Value(); v != 0.5 {
+		t.Errorf("Axis action was %v with its axis, expected 0.5", v)
+	}
+
+	Input.RegisterAxis("vertical", AxisKeyPair{KeyS, KeyW})
+	Input.keys.Set(KeyW, true)
+	Input.update()
+	if v := Input.Axis("vertical").Value(); v != AxisMax {
+		t.Errorf("Registered Axis was %v, expected %v", v, AxisMax)
+	}
+}
+
+func TestActionMapRebind(t *testing.T) {
+	Input = NewInputManager()
+	actions := Input.Actions()
+	actions.AddButton("jump", KeyBinding(KeySpace))
+	actions.AddButton("fire", KeyBinding(KeyF), MouseBinding(MouseButtonLeft))
+
+	if err := actions.Rebind("jump", KeyBinding(KeySpace), KeyBinding(KeyF)); err != nil {
+		t.Fatalf("Rebind returned an error: %v", err)
+	}
+	if got := actions.ConflictsWith("jump", KeyBinding(KeyF)); !reflect.DeepEqual(got, []string{"fire"}) {
+		t.Errorf("ConflictsWith returned %v, expected [fire]", got)
+	}
+	conflicts := actions.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].Binding != KeyBinding(KeyF) || !reflect.DeepEqual(conflicts[0].Actions, []string{"fire", "jump"}) {
+		t.Errorf("Conflicts returned %+v, expected KeyF bound to fire and jump", conflicts)
+	}
+
+	actions.AddButton("left", GamepadBinding("pad", GamepadLeftX).Inverted())
+	actions.AddButton("right", GamepadBinding("pad", GamepadLeftX))
+	if got := actions.ConflictsWith("left", GamepadBinding("pad", GamepadLeftX)); len(got) != 1 || got[0] != "right" {
+		t.Errorf("ConflictsWith returned %v for an axis direction, expected [right]", got)
+	}
+
+	if err := actions.Reset("jump"); err != nil {
+		t.Fatalf("Reset returned an error: %v", err)
+	}
+	if got := actions.Bindings("jump"); !reflect.DeepEqual(got, []Binding{KeyBinding(KeySpace)}) {
+		t.Errorf("Reset restored %v, expected the default bindings", got)
+	}
+	if len(actions.Conflicts()) != 0 {
+		t.Error("Conflicts were reported after restoring the defaults")
+	}
+	if err := actions.Bind("missing", KeyBinding(KeyA)); err == nil {
+		t.Error("Binding an action that is not registered did not return an error")
+	}
+}
+
+func TestActionMapCapture(t *testing.T) {
+	Input = NewInputManager()
+	Input.gamepads.gamepads["pad"] = &Gamepad{}
+	Input.gamepads.gamepads["pad"].LeftTrigger.set(AxisMin)
+	actions := Input.Actions()
+	actions.AddButton("jump", KeyBinding(KeySpace))
+
+	var captured []Binding
+	capture := func(b Binding) { captured = append(captured, b) }
+
+	actions.Capture(capture)
+	actionTestFrame()
+	if len(captured) != 0 {
+		t.Errorf("Capture captured %v without input", captured)
+	}
+	Input.keys.Set(KeySpace, true)
+	actionTestFrame()
+	if len(captured) != 1 || captured[0] != KeyBinding(KeySpace) {
+		t.Fatalf("Capture captured %v, expected the space key", captured)
+	}
+	if Input.Button("jump").JustPressed() {
+		t.Error("The captured input triggered an action")
+	}
+	if actions.Capturing() {
+		t.Error("Still capturing after an input was captured")
+	}
+
+	actions.Capture(capture)
+	actionTestFrame()
+	Input.gamepads.gamepads["pad"].LeftTrigger.set(AxisMax)
+	actionTestFrame()
+	if len(captured) != 2 || captured[1] != GamepadBinding("pad", GamepadLeftTrigger) {
+		t.Errorf("Capture captured %v, expected the left trigger", captured)
+	}
+
+	actions.Capture(capture)
+	actions.CancelCapture()
+	Input.keys.Set(KeyEnter, true)
+	actionTestFrame()
+	if len(captured) != 2 {
+		t.Error("Capture captured an input after it was cancelled")
+	}
+}
+
+func TestActionMapSaveLoad(t *testing.T) {
+	Input = NewInputManager()
+	actions := Input.Actions()
+	actions.AddButton("jump", KeyBinding(KeySpace))
+	actions.AddAxis("horizontal", KeyBinding(KeyA).Inverted(), KeyBinding(KeyD))
+	actions.SetBindings("jump", KeyBinding(KeyW), MouseBinding(MouseButtonRight), GamepadBinding("player 1", GamepadB))
+	actions.SetBindings("horizontal", GamepadBinding("player 1", GamepadLeftX).Inverted())
+
+	buf := &bytes.Buffer{}
+	if err := actions.Save(buf); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	saved := buf.String()
+
+	actions.ResetAll()
+	if err := actions.Load(bytes.NewBufferString(saved)); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if got, exp := actions.Bindings("jump"), []Binding{KeyBinding(KeyW), MouseBinding(MouseButtonRight), GamepadBinding("player 1", GamepadB)}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Loaded %v, expected %v", got, exp)
+	}
+	if got, exp := actions.Bindings("horizontal"), []Binding{GamepadBinding("player 1", GamepadLeftX).Inverted()}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Loaded %v, expected %v", got, exp)
+	}
+
+	if err := actions.Load(bytes.NewBufferString(`{"jump": ["Key:NotAKey"]}`)); err == nil {
+		t.Error("Loading an invalid binding did not return an error")
+	}
+}
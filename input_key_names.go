@@ -0,0 +1,111 @@
+package engo
+
+// keyNames are the names of the keys, used to save Bindings independently of the platform.
+var keyNames = []struct {
+	key  Key
+	name string
+}{
+	{KeyGrave, "Grave"},
+	{KeyDash, "Dash"},
+	{KeyApostrophe, "Apostrophe"},
+	{KeySemicolon, "Semicolon"},
+	{KeyEquals, "Equals"},
+	{KeyComma, "Comma"},
+	{KeyPeriod, "Period"},
+	{KeySlash, "Slash"},
+	{KeyBackslash, "Backslash"},
+	{KeyBackspace, "Backspace"},
+	{KeyTab, "Tab"},
+	{KeyCapsLock, "CapsLock"},
+	{KeySpace, "Space"},
+	{KeyEnter, "Enter"},
+	{KeyEscape, "Escape"},
+	{KeyInsert, "Insert"},
+	{KeyPrintScreen, "PrintScreen"},
+	{KeyDelete, "Delete"},
+	{KeyPageUp, "PageUp"},
+	{KeyPageDown, "PageDown"},
+	{KeyHome, "Home"},
+	{KeyEnd, "End"},
+	{KeyPause, "Pause"},
+	{KeyScrollLock, "ScrollLock"},
+	{KeyArrowLeft, "ArrowLeft"},
+	{KeyArrowRight, "ArrowRight"},
+	{KeyArrowDown, "ArrowDown"},
+	{KeyArrowUp, "ArrowUp"},
+	{KeyLeftBracket, "LeftBracket"},
+	{KeyLeftShift, "LeftShift"},
+	{KeyLeftControl, "LeftControl"},
+	{KeyLeftSuper, "LeftSuper"},
+	{KeyLeftAlt, "LeftAlt"},
+	{KeyRightBracket, "RightBracket"},
+	{KeyRightShift, "RightShift"},
+	{KeyRightControl, "RightControl"},
+	{KeyRightSuper, "RightSuper"},
+	{KeyRightAlt, "RightAlt"},
+	{KeyZero, "Zero"},
+	{KeyOne, "One"},
+	{KeyTwo, "Two"},
+	{KeyThree, "Three"},
+	{KeyFour, "Four"},
+	{KeyFive, "Five"},
+	{KeySix, "Six"},
+	{KeySeven, "Seven"},
+	{KeyEight, "Eight"},
+	{KeyNine, "Nine"},
+	{KeyF1, "F1"},
+	{KeyF2, "F2"},
+	{KeyF3, "F3"},
+	{KeyF4, "F4"},
+	{KeyF5, "F5"},
+	{KeyF6, "F6"},
+	{KeyF7, "F7"},
+	{KeyF8, "F8"},
+	{KeyF9, "F9"},
+	{KeyF10, "F10"},
+	{KeyF11, "F11"},
+	{KeyF12, "F12"},
+	{KeyA, "A"},
+	{KeyB, "B"},
+	{KeyC, "C"},
+	{KeyD, "D"},
+	{KeyE, "E"},
+	{KeyF, "F"},
+	{KeyG, "G"},
+	{KeyH, "H"},
+	{KeyI, "I"},
+	{KeyJ, "J"},
+	{KeyK, "K"},
+	{KeyL, "L"},
+	{KeyM, "M"},
+	{KeyN, "N"},
+	{KeyO, "O"},
+	{KeyP, "P"},
+	{KeyQ, "Q"},
+	{KeyR, "R"},
+	{KeyS, "S"},
+	{KeyT, "T"},
+	{KeyU, "U"},
+	{KeyV, "V"},
+	{KeyW, "W"},
+	{KeyX, "X"},
+	{KeyY, "Y"},
+	{KeyZ, "Z"},
+	{KeyNumLock, "NumLock"},
+	{KeyNumMultiply, "NumMultiply"},
+	{KeyNumDivide, "NumDivide"},
+	{KeyNumAdd, "NumAdd"},
+	{KeyNumSubtract, "NumSubtract"},
+	{KeyNumZero, "NumZero"},
+	{KeyNumOne, "NumOne"},
+	{KeyNumTwo, "NumTwo"},
+	{KeyNumThree, "NumThree"},
+	{KeyNumFour, "NumFour"},
+	{KeyNumFive, "NumFive"},
+	{KeyNumSix, "NumSix"},
+	{KeyNumSeven, "NumSeven"},
+	{KeyNumEight, "NumEight"},
+	{KeyNumNine, "NumNine"},
+	{KeyNumDecimal, "NumDecimal"},
+	{KeyNumEnter, "NumEnter"},
+}
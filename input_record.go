@@ -0,0 +1,627 @@
+package engo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// recordingMagic starts every input recording, it's followed by the version of the format.
+const (
+	recordingMagic   = "ENGOINPUT"
+	recordingVersion = 1
+)
+
+// maxRecordedItems limits the amount of keys, touches, gamepads or characters a frame of a recording can contain, so
+// that corrupt recordings are detected.
+const maxRecordedItems = 1 << 16
+
+// The sections of a recorded frame. A frame only contains the sections that changed since the previous frame.
+const (
+	frameKeys = 1 << iota
+	frameMouse
+	frameModifier
+	frameTouches
+	frameGamepads
+	frameText
+)
+
+// ReplayFinishedMessage is dispatched when an InputPlayer has replayed all frames of its recording, or failed to read
+// one of them. The real input is used again from the next frame on.
+type ReplayFinishedMessage struct {
+	Player *InputPlayer
+	// Err is the error that occurred while reading the recording, if any.
+	Err error
+}
+
+// Type returns the type of the message, "ReplayFinishedMessage"
+func (ReplayFinishedMessage) Type() string { return "ReplayFinishedMessage" }
+
+// inputState is the state of the input during a frame.
+type inputState struct {
+	// delta is the amount of nanoseconds between the tick of the frame and the previous one
+	delta    int64
+	keys     map[Key]uint8
+	mouse    Mouse
+	modifier Modifier
+	touches  map[int]Point
+	gamepads map[string]gamepadState
+	// text are the characters typed since the previous frame
+	text []rune
+}
+
+// gamepadState is the state of a Gamepad, with two bits per button.
+type gamepadState struct {
+	buttons uint32
+	axes    [6]float32
+}
+
+// stateBits packs the state of a key or button, 0 meaning it's up.
+func stateBits(last, current bool) uint8 {
+	var b uint8
+	if last {
+		b |= 1
+	}
+	if current {
+		b |= 2
+	}
+	return b
+}
+
+// InputRecorder records the input of every frame along with the frame deltas, so that an InputPlayer can replay them
+// exactly. Recordings can be used to reproduce bugs, or for demos.
+type InputRecorder struct {
+	input  *InputManager
+	w      *bufio.Writer
+	prev   inputState
+	text   []rune
+	frames int
+	err    error
+}
+
+// Record starts recording the state of the keys, the mouse, the touches, the gamepads and the text input of every
+// frame to w, until Stop is called. Starting a recording stops the current recording or replay.
+//
+// The frames are stored compactly, containing only what changed since the previous frame.
+func (im *InputManager) Record(w io.Writer) (*InputRecorder, error) {
+	r := &InputRecorder{input: im, w: bufio.NewWriter(w)}
+	r.w.WriteString(recordingMagic)
+	r.w.WriteByte(recordingVersion)
+	if err := r.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	im.stopInput()
+	im.recorder = r
+	return r, nil
+}
+
+// Stop stops recording, and writes the remaining frames. It returns the first error that occurred while writing.
+func (r *InputRecorder) Stop() error {
+	if r.input.recorder == r {
+		r.input.recorder = nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// Frames returns the amount of frames that have been recorded.
+func (r *InputRecorder) Frames() int {
+	return r.frames
+}
+
+// Err returns the first error that occurred while writing the recording.
+func (r *InputRecorder) Err() error {
+	return r.err
+}
+
+// record writes the frame, containing what changed since the previous frame.
+func (r *InputRecorder) record(s inputState) {
+	if r.err != nil {
+		return
+	}
+	var e frameEncoder
+	e.uvarint(uint64(s.delta))
+	flagsAt := len(e.buf)
+	e.buf = append(e.buf, 0)
+	var flags byte
+
+	var keys []Key
+	for k, b := range s.keys {
+		if r.prev.keys[k] != b {
+			keys = append(keys, k)
+		}
+	}
+	for k := range r.prev.keys {
+		if _, ok := s.keys[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		flags |= frameKeys
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		e.uvarint(uint64(len(keys)))
+		for _, k := range keys {
+			e.varint(int64(k))
+			e.buf = append(e.buf, s.keys[k])
+		}
+	}
+
+	if s.mouse != r.prev.mouse {
+		flags |= frameMouse
+		e.float32(s.mouse.X)
+		e.float32(s.mouse.Y)
+		e.float32(s.mouse.ScrollX)
+		e.float32(s.mouse.ScrollY)
+		e.varint(int64(s.mouse.Action))
+		e.varint(int64(s.mouse.Button))
+		e.varint(int64(s.mouse.Modifer))
+	}
+
+	if s.modifier != r.prev.modifier {
+		flags |= frameModifier
+		e.varint(int64(s.modifier))
+	}
+
+	if !touchesEqual(s.touches, r.prev.touches) {
+		flags |= frameTouches
+		ids := make([]int, 0, len(s.touches))
+		for id := range s.touches {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		e.uvarint(uint64(len(ids)))
+		for _, id := range ids {
+			e.varint(int64(id))
+			e.float32(s.touches[id].X)
+			e.float32(s.touches[id].Y)
+		}
+	}
+
+	var names []string
+	for name, gs := range s.gamepads {
+		if prev, ok := r.prev.gamepads[name]; !ok || prev != gs {
+			names = append(names, name)
+		}
+	}
+	for name := range r.prev.gamepads {
+		if _, ok := s.gamepads[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		flags |= frameGamepads
+		sort.Strings(names)
+		e.uvarint(uint64(len(names)))
+		for _, name := range names {
+			e.uvarint(uint64(len(name)))
+			e.buf = append(e.buf, name...)
+			// gamepads that were removed only have their name, followed by 0
+			gs, ok := s.gamepads[name]
+			if !ok {
+				e.buf = append(e.buf, 0)
+				continue
+			}
+			e.buf = append(e.buf, 1)
+			e.uvarint(uint64(gs.buttons))
+			for _, v := range gs.axes {
+				e.float32(v)
+			}
+		}
+	}
+
+	if len(s.text) > 0 {
+		flags |= frameText
+		e.uvarint(uint64(len(s.text)))
+		for _, char := range s.text {
+			e.varint(int64(char))
+		}
+	}
+
+	e.buf[flagsAt] = flags
+	if _, err := r.w.Write(e.buf); err != nil {
+		r.err = err
+		return
+	}
+	r.prev = s
+	r.frames++
+}
+
+func touchesEqual(a, b map[int]Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, p := range a {
+		if q, ok := b[id]; !ok || p != q {
+			return false
+		}
+	}
+	return true
+}
+
+// InputPlayer replays a recording made by an InputRecorder, feeding the recorded input and frame deltas to the game in
+// place of the real ones.
+type InputPlayer struct {
+	input  *InputManager
+	r      *bufio.Reader
+	state  inputState
+	frames int
+	done   bool
+	err    error
+}
+
+// Replay starts replaying the recording read from r: from the next frame on, the input and the frame deltas are the
+// recorded ones, until all frames have been replayed or Stop is called. A ReplayFinishedMessage is dispatched once
+// the recording has ended. Starting a replay stops the current recording or replay.
+//
+// The game has to be in the same state as when the recording started for the replay to be deterministic, such as
+// by setting the same Scene and seeding random numbers the same way.
+func (im *InputManager) Replay(r io.Reader) (*InputPlayer, error) {
+	p := &InputPlayer{input: im, r: bufio.NewReader(r)}
+	header := make([]byte, len(recordingMagic)+1)
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(recordingMagic)]) != recordingMagic {
+		return nil, errors.New("not an input recording")
+	}
+	if header[len(recordingMagic)] != recordingVersion {
+		return nil, errors.New("unsupported input recording version")
+	}
+	p.state = inputState{
+		keys:     make(map[Key]uint8),
+		touches:  make(map[int]Point),
+		gamepads: make(map[string]gamepadState),
+	}
+
+	im.stopInput()
+	im.player = p
+	return p, nil
+}
+
+// Stop stops the replay, and the real input is used again. No ReplayFinishedMessage is dispatched.
+func (p *InputPlayer) Stop() {
+	p.done = true
+	if p.input.player == p {
+		p.input.player = nil
+		p.input.reset()
+	}
+}
+
+// Frames returns the amount of frames that have been replayed.
+func (p *InputPlayer) Frames() int {
+	return p.frames
+}
+
+// Done indicates whether the replay has ended.
+func (p *InputPlayer) Done() bool {
+	return p.done
+}
+
+// Err returns the error that occurred while reading the recording, if any.
+func (p *InputPlayer) Err() error {
+	return p.err
+}
+
+// next reads the next frame, and returns false once the recording has ended.
+func (p *InputPlayer) next() bool {
+	if p.done {
+		return false
+	}
+	d := frameDecoder{r: p.r}
+	delta := d.uvarint()
+	if d.err == io.EOF {
+		p.done = true
+		return false
+	}
+	flags := d.byte()
+
+	s := &p.state
+	s.delta = int64(delta)
+	s.text = nil
+	if flags&frameKeys != 0 {
+		for i, n := 0, d.count(); i < n; i++ {
+			k := Key(d.varint())
+			if b := d.byte(); b != 0 {
+				s.keys[k] = b
+			} else {
+				delete(s.keys, k)
+			}
+		}
+	}
+	if flags&frameMouse != 0 {
+		s.mouse.X, s.mouse.Y = d.float32(), d.float32()
+		s.mouse.ScrollX, s.mouse.ScrollY = d.float32(), d.float32()
+		s.mouse.Action = Action(d.varint())
+		s.mouse.Button = MouseButton(d.varint())
+		s.mouse.Modifer = Modifier(d.varint())
+	}
+	if flags&frameModifier != 0 {
+		s.modifier = Modifier(d.varint())
+	}
+	if flags&frameTouches != 0 {
+		s.touches = make(map[int]Point)
+		for i, n := 0, d.count(); i < n; i++ {
+			id := int(d.varint())
+			s.touches[id] = Point{X: d.float32(), Y: d.float32()}
+		}
+	}
+	if flags&frameGamepads != 0 {
+		for i, n := 0, d.count(); i < n; i++ {
+			name := d.string()
+			if d.byte() == 0 {
+				delete(s.gamepads, name)
+				continue
+			}
+			var gs gamepadState
+			gs.buttons = uint32(d.uvarint())
+			for j := range gs.axes {
+				gs.axes[j] = d.float32()
+			}
+			s.gamepads[name] = gs
+		}
+	}
+	if flags&frameText != 0 {
+		for i, n := 0, d.count(); i < n; i++ {
+			s.text = append(s.text, rune(d.varint()))
+		}
+	}
+
+	if d.err != nil {
+		if d.err == io.EOF {
+			d.err = io.ErrUnexpectedEOF
+		}
+		p.err = d.err
+		p.done = true
+		return false
+	}
+	p.frames++
+	return true
+}
+
+// frame records or replays the input of the frame that is about to be updated, then updates the actions. It returns
+// the delta to update the frame with.
+func (im *InputManager) frame(dt float32) float32 {
+	if p := im.player; p != nil {
+		dt = im.replayFrame(p, dt)
+	} else if r := im.recorder; r != nil {
+		s := im.capture()
+		s.text, r.text = r.text, nil
+		if Time != nil {
+			s.delta = Time.deltaStamp
+		}
+		r.record(s)
+	}
+
+	im.updateMouseButtons()
+	im.actions.update()
+	return dt
+}
+
+// replayFrame applies the next frame of the replay, and returns its delta.
+func (im *InputManager) replayFrame(p *InputPlayer, dt float32) float32 {
+	if !p.next() {
+		im.player = nil
+		im.reset()
+		if Mailbox != nil {
+			Mailbox.Dispatch(ReplayFinishedMessage{Player: p, Err: p.err})
+		}
+		return dt
+	}
+	im.apply(p.state)
+	if Time != nil {
+		Time.deltaStamp = p.state.delta
+		dt = Time.Delta()
+	}
+	if Mailbox != nil {
+		for _, char := range p.state.text {
+			Mailbox.Dispatch(TextMessage{char})
+		}
+	}
+	return dt
+}
+
+// typed dispatches a TextMessage for a character typed by the player. It's recorded, and ignored during a replay.
+func (im *InputManager) typed(char rune) {
+	if im.player != nil {
+		return
+	}
+	if im.recorder != nil {
+		im.recorder.text = append(im.recorder.text, char)
+	}
+	Mailbox.Dispatch(TextMessage{char})
+}
+
+// stopInput stops the current recording or replay.
+func (im *InputManager) stopInput() {
+	if im.recorder != nil {
+		im.recorder.Stop()
+	}
+	if im.player != nil {
+		im.player.Stop()
+	}
+}
+
+// capture returns the current state of the input.
+func (im *InputManager) capture() inputState {
+	s := inputState{
+		keys:     make(map[Key]uint8),
+		mouse:    im.Mouse,
+		modifier: im.Modifier,
+		touches:  make(map[int]Point, len(im.Touches)),
+		gamepads: make(map[string]gamepadState),
+	}
+
+	im.keys.mutex.RLock()
+	for k, ks := range im.keys.mapper {
+		if b := stateBits(ks.lastState, ks.currentState); b != 0 {
+			s.keys[k] = b
+		}
+	}
+	im.keys.mutex.RUnlock()
+
+	for id, p := range im.Touches {
+		s.touches[id] = p
+	}
+
+	im.gamepads.mutex.RLock()
+	for name, g := range im.gamepads.gamepads {
+		var gs gamepadState
+		for i, b := range g.buttons() {
+			gs.buttons |= uint32(stateBits(b.lastState, b.currentState)) << (2 * uint(i))
+		}
+		for i, a := range g.axes() {
+			gs.axes[i] = a.value
+		}
+		s.gamepads[name] = gs
+	}
+	im.gamepads.mutex.RUnlock()
+	return s
+}
+
+// apply replaces the current state of the input. Gamepads that are not part of the state are released, as they were
+// removed while recording.
+func (im *InputManager) apply(s inputState) {
+	im.keys.mutex.Lock()
+	im.keys.mapper = make(map[Key]KeyState, len(s.keys))
+	for k, b := range s.keys {
+		im.keys.mapper[k] = KeyState{lastState: b&1 != 0, currentState: b&2 != 0}
+	}
+	im.keys.mutex.Unlock()
+
+	im.Mouse = s.mouse
+	im.Modifier = s.modifier
+	for id := range im.Touches {
+		delete(im.Touches, id)
+	}
+	for id, p := range s.touches {
+		im.Touches[id] = p
+	}
+
+	im.gamepads.mutex.Lock()
+	for name, g := range im.gamepads.gamepads {
+		if _, ok := s.gamepads[name]; ok {
+			continue
+		}
+		for _, b := range g.buttons() {
+			b.lastState, b.currentState = false, false
+		}
+		for _, a := range g.axes() {
+			a.value = 0
+		}
+	}
+	for name, gs := range s.gamepads {
+		g, ok := im.gamepads.gamepads[name]
+		if !ok {
+			g = &Gamepad{}
+			im.gamepads.gamepads[name] = g
+		}
+		for i, b := range g.buttons() {
+			bits := gs.buttons >> (2 * uint(i))
+			b.lastState, b.currentState = bits&1 != 0, bits&2 != 0
+		}
+		for i, a := range g.axes() {
+			a.value = gs.axes[i]
+		}
+	}
+	im.gamepads.mutex.Unlock()
+}
+
+// reset releases all keys, buttons and touches after a replay, the real input takes over from there.
+func (im *InputManager) reset() {
+	im.apply(inputState{})
+	im.mouseButtons = [MouseButtonLast + 1]KeyState{}
+}
+
+// frameEncoder encodes the values of a recorded frame.
+type frameEncoder struct {
+	buf []byte
+}
+
+func (e *frameEncoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (e *frameEncoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func (e *frameEncoder) float32(v float32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+// frameDecoder decodes the values of a recorded frame, keeping the first error that occurred.
+type frameDecoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *frameDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var v uint64
+	v, d.err = binary.ReadUvarint(d.r)
+	return v
+}
+
+func (d *frameDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	var v int64
+	v, d.err = binary.ReadVarint(d.r)
+	return v
+}
+
+func (d *frameDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	var b byte
+	b, d.err = d.r.ReadByte()
+	return b
+}
+
+func (d *frameDecoder) float32() float32 {
+	if d.err != nil {
+		return 0
+	}
+	var b [4]byte
+	if _, d.err = io.ReadFull(d.r, b[:]); d.err != nil {
+		return 0
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b[:]))
+}
+
+// count reads the amount of items of a section.
+func (d *frameDecoder) count() int {
+	n := d.uvarint()
+	if n > maxRecordedItems {
+		d.err = errors.New("corrupt input recording")
+		return 0
+	}
+	return int(n)
+}
+
+func (d *frameDecoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	if _, d.err = io.ReadFull(d.r, b); d.err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,141 @@
+package engo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// recordTestFrame sets the input of the frame i, as a backend would.
+func recordTestFrame(i int) {
+	switch i {
+	case 1:
+		Input.keys.Set(KeyA, true)
+		Input.Mouse.X, Input.Mouse.Y = 10, 20
+		Input.Mouse.Action = Press
+		Input.Modifier = Shift
+		Input.typed('h')
+		Input.typed('i')
+	case 2:
+		Input.Touches[3] = Point{X: 5, Y: 6}
+		Input.gamepads.gamepads["pad"].A.set(true)
+		Input.gamepads.gamepads["pad"].LeftX.set(-0.5)
+	case 4:
+		Input.keys.Set(KeyA, false)
+		delete(Input.Touches, 3)
+		Input.Mouse.Action = Release
+	case 5:
+		// the gamepad is removed while its button is held
+		delete(Input.gamepads.gamepads, "pad")
+	}
+}
+
+func TestInputRecordReplay(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &testScene{})
+	theTimer = testTime{0}
+	Time = NewClock()
+	defer func() {
+		theTimer = realTime{}
+		Time = NewClock()
+	}()
+
+	var text []rune
+	Mailbox.Listen("TextMessage", func(msg Message) {
+		text = append(text, msg.(TextMessage).Char)
+	})
+
+	Input.gamepads.gamepads["pad"] = &Gamepad{}
+	buf := &bytes.Buffer{}
+	rec, err := Input.Record(buf)
+	if err != nil {
+		t.Fatalf("Record returned an error: %v", err)
+	}
+
+	var states []inputState
+	var deltas []float32
+	for i := 0; i < 6; i++ {
+		theTimer = testTime{int64(i+1) * 16000000}
+		Time.Tick()
+		Input.update()
+		recordTestFrame(i)
+		updateScenes(Time.Delta())
+		states = append(states, Input.capture())
+		deltas = append(deltas, Time.Delta())
+		Input.Mouse.Action = Neutral
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop returned an error: %v", err)
+	}
+	if rec.Frames() != 6 {
+		t.Errorf("Recorder recorded %d frames, expected 6", rec.Frames())
+	}
+	if string(text) != "hi" {
+		t.Errorf("Text input while recording was %q, expected %q", string(text), "hi")
+	}
+
+	Input = NewInputManager()
+	text = nil
+	finished := 0
+	Mailbox.Listen("ReplayFinishedMessage", func(Message) { finished++ })
+	player, err := Input.Replay(buf)
+	if err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		theTimer = testTime{int64(i+1) * 50000000}
+		Time.Tick()
+		Input.update()
+		Input.keys.Set(KeyB, true)
+		Input.typed('x')
+		updateScenes(Time.Delta())
+		got := Input.capture()
+		// removed gamepads are released rather than removed
+		for name, gs := range got.gamepads {
+			if _, ok := states[i].gamepads[name]; !ok && gs == (gamepadState{}) {
+				delete(got.gamepads, name)
+			}
+		}
+		if !reflect.DeepEqual(got, states[i]) {
+			t.Errorf("Replayed input of frame %d was %+v, expected %+v", i, got, states[i])
+		}
+		if Time.Delta() != deltas[i] {
+			t.Errorf("Replayed delta of frame %d was %v, expected %v", i, Time.Delta(), deltas[i])
+		}
+	}
+	if string(text) != "hi" {
+		t.Errorf("Text input while replaying was %q, expected %q", string(text), "hi")
+	}
+
+	updateScenes(Time.Delta())
+	if !player.Done() || player.Err() != nil || player.Frames() != 6 {
+		t.Errorf("Player was done: %v, error: %v, frames: %d after the last frame", player.Done(), player.Err(), player.Frames())
+	}
+	if finished != 1 {
+		t.Errorf("ReplayFinishedMessage was dispatched %d times, expected 1", finished)
+	}
+	if Input.keys.Get(KeyA).currentState || len(Input.Touches) != 0 || Input.gamepads.gamepads["pad"].A.currentState {
+		t.Error("Input was not reset after the replay")
+	}
+}
+
+func TestInputReplayCorrupt(t *testing.T) {
+	Input = NewInputManager()
+	if _, err := Input.Replay(bytes.NewBufferString("not a recording")); err == nil {
+		t.Error("Replaying something else than a recording did not return an error")
+	}
+
+	buf := &bytes.Buffer{}
+	rec, _ := Input.Record(buf)
+	Input.keys.Set(KeyA, true)
+	Input.frame(0)
+	rec.Stop()
+
+	player, err := Input.Replay(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	if err != nil {
+		t.Fatalf("Replay returned an error: %v", err)
+	}
+	Input.frame(0)
+	if !player.Done() || player.Err() == nil {
+		t.Error("Replaying a truncated recording did not return an error")
+	}
+}
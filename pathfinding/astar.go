@@ -0,0 +1,122 @@
+package pathfinding
+
+import (
+	"container/heap"
+)
+
+// node is a cell in the open set of a search.
+type node struct {
+	index    int
+	priority float32
+}
+
+// openSet is a priority queue of nodes with the lowest priority first.
+type openSet []node
+
+func (s openSet) Len() int            { return len(s) }
+func (s openSet) Less(i, j int) bool  { return s[i].priority < s[j].priority }
+func (s openSet) Swap(i, j int)       { s[i], s[j] = s[j], s[i] }
+func (s *openSet) Push(x interface{}) { *s = append(*s, x.(node)) }
+func (s *openSet) Pop() interface{} {
+	old := *s
+	n := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return n
+}
+
+// AStar finds the cheapest path between two cells using the A* algorithm. The
+// path starts with from and ends with to. It returns false if there is no path
+// or one of the cells can't be walked on. If h is nil, the grid's Heuristic is
+// used.
+func (g *Grid) AStar(from, to Cell, h Heuristic) ([]Cell, bool) {
+	if !g.Walkable(from) || !g.Walkable(to) {
+		return nil, false
+	}
+	if h == nil {
+		h = g.Heuristic()
+	}
+
+	start, goal := g.index(from), g.index(to)
+	costs := make([]float32, len(g.walkable))
+	parents := make([]int, len(g.walkable))
+	closed := make([]bool, len(g.walkable))
+	for i := range parents {
+		parents[i] = -1
+	}
+	visited := make([]bool, len(g.walkable))
+	visited[start] = true
+
+	open := &openSet{{index: start, priority: h(from, to)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(node).index
+		if current == goal {
+			return g.path(parents, goal), true
+		}
+		// nodes are pushed again instead of being updated when a cheaper path
+		// to them is found, so the more expensive ones are skipped here
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		g.neighbours(g.cell(current), func(n Cell, dist float32) {
+			i := g.index(n)
+			if closed[i] {
+				return
+			}
+			cost := costs[current] + dist*g.costs[i]
+			if visited[i] && cost >= costs[i] {
+				return
+			}
+			visited[i] = true
+			costs[i] = cost
+			parents[i] = current
+			heap.Push(open, node{index: i, priority: cost + h(n, to)})
+		})
+	}
+	return nil, false
+}
+
+// path follows the parents from the cell with the index back to the start of
+// the search, and returns the cells from the start to it.
+func (g *Grid) path(parents []int, i int) []Cell {
+	var ret []Cell
+	for ; i >= 0; i = parents[i] {
+		ret = append(ret, g.cell(i))
+	}
+	for l, r := 0, len(ret)-1; l < r; l, r = l+1, r-1 {
+		ret[l], ret[r] = ret[r], ret[l]
+	}
+	return ret
+}
+
+// PathCost returns the cost of walking along the path.
+func (g *Grid) PathCost(path []Cell) float32 {
+	var cost float32
+	for i := 1; i < len(path); i++ {
+		dist := float32(1)
+		if g.diagonal(path[i-1], path[i]) {
+			dist = sqrt2
+		}
+		cost += dist * g.Cost(path[i])
+	}
+	return cost
+}
+
+// diagonal returns whether the move between the neighbouring cells is a
+// diagonal one.
+func (g *Grid) diagonal(a, b Cell) bool {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	switch g.Orientation {
+	case Hexagonal:
+		return false
+	case Staggered:
+		// moves to the cells sharing an edge always change the row or column
+		// along the staggered axis by one
+		if g.StaggerAxis == "x" {
+			return dx%2 == 0
+		}
+		return dy%2 == 0
+	}
+	return dx != 0 && dy != 0
+}
@@ -0,0 +1,72 @@
+package pathfinding
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo/math"
+)
+
+// wallGrid returns a grid with a wall in the middle, which has a gap at the
+// bottom.
+func wallGrid() *Grid {
+	g := NewGrid(7, 5)
+	for y := 0; y < 4; y++ {
+		g.SetWalkable(Cell{X: 3, Y: y}, false)
+	}
+	return g
+}
+
+func TestAStar(t *testing.T) {
+	g := wallGrid()
+	g.Diagonal = false
+	path, ok := g.AStar(Cell{X: 0, Y: 0}, Cell{X: 6, Y: 0}, Manhattan)
+	if !ok {
+		t.Fatal("No path was found around the wall")
+	}
+	if len(path) != 15 || path[0] != (Cell{X: 0, Y: 0}) || path[len(path)-1] != (Cell{X: 6, Y: 0}) {
+		t.Fatalf("Path was %v, expected 14 moves", path)
+	}
+	for i := 1; i < len(path); i++ {
+		if !g.Walkable(path[i]) || Manhattan(path[i-1], path[i]) != 1 {
+			t.Errorf("Move from %v to %v was not allowed", path[i-1], path[i])
+		}
+	}
+
+	g.SetWalkable(Cell{X: 3, Y: 4}, false)
+	if _, ok := g.AStar(Cell{X: 0, Y: 0}, Cell{X: 6, Y: 0}, nil); ok {
+		t.Error("Path was found through a closed wall")
+	}
+	if _, ok := g.AStar(Cell{X: 0, Y: 0}, Cell{X: 3, Y: 0}, nil); ok {
+		t.Error("Path was found to a blocked cell")
+	}
+}
+
+func TestAStarCosts(t *testing.T) {
+	g := NewGrid(5, 3)
+	g.Diagonal = false
+	for x := 1; x < 4; x++ {
+		g.SetCost(Cell{X: x, Y: 1}, 5)
+	}
+	path, ok := g.AStar(Cell{X: 0, Y: 1}, Cell{X: 4, Y: 1}, nil)
+	if !ok || g.PathCost(path) != 6 {
+		t.Errorf("Path %v cost %v, expected a path around the expensive cells", path, g.PathCost(path))
+	}
+}
+
+func TestAStarDiagonal(t *testing.T) {
+	g := wallGrid()
+	path, ok := g.AStar(Cell{X: 0, Y: 0}, Cell{X: 6, Y: 0}, Octile)
+	if !ok {
+		t.Fatal("No path was found around the wall")
+	}
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		if a.X != b.X && a.Y != b.Y && (!g.Walkable(Cell{X: b.X, Y: a.Y}) || !g.Walkable(Cell{X: a.X, Y: b.Y})) {
+			t.Errorf("Move from %v to %v cut the corner of a blocked cell", a, b)
+		}
+	}
+	// down to 2,4, through the gap and up again, without cutting its corners
+	if exp := float32(6 + 4*sqrt2); math.Abs(g.PathCost(path)-exp) > 1e-4 {
+		t.Errorf("Path cost %v, expected %v", g.PathCost(path), exp)
+	}
+}
@@ -0,0 +1,107 @@
+package pathfinding
+
+import (
+	"container/heap"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+// FlowField holds the cost of the cheapest path to the nearest goal for every
+// cell of a grid, and the neighbour to move to next. It is used to move lots of
+// entities to the same goals, without finding a path for each of them.
+type FlowField struct {
+	grid  *Grid
+	costs []float32
+	next  []int
+}
+
+// FlowField creates the flow field leading to the nearest of the goals. It has
+// to be created again when the walkability or costs of the grid change.
+func (g *Grid) FlowField(goals ...Cell) *FlowField {
+	f := &FlowField{
+		grid:  g,
+		costs: make([]float32, len(g.walkable)),
+		next:  make([]int, len(g.walkable)),
+	}
+	inf := math.Inf(1)
+	for i := range f.costs {
+		f.costs[i] = inf
+		f.next[i] = -1
+	}
+
+	open := &openSet{}
+	for _, goal := range goals {
+		if !g.Walkable(goal) {
+			continue
+		}
+		i := g.index(goal)
+		f.costs[i] = 0
+		heap.Push(open, node{index: i})
+	}
+	closed := make([]bool, len(g.walkable))
+	for open.Len() > 0 {
+		current := heap.Pop(open).(node).index
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		// moves between neighbours can be made both ways, so the cost of
+		// leaving a cell towards the goal is the one of entering the cell
+		// which is closer to it
+		g.neighbours(g.cell(current), func(n Cell, dist float32) {
+			i := g.index(n)
+			cost := f.costs[current] + dist*g.costs[current]
+			if closed[i] || cost >= f.costs[i] {
+				return
+			}
+			f.costs[i] = cost
+			f.next[i] = current
+			heap.Push(open, node{index: i, priority: cost})
+		})
+	}
+	return f
+}
+
+// Cost returns the cost of the cheapest path from the cell to the nearest
+// goal, or +Inf if no goal can be reached from it.
+func (f *FlowField) Cost(c Cell) float32 {
+	if !f.grid.Contains(c) {
+		return math.Inf(1)
+	}
+	return f.costs[f.grid.index(c)]
+}
+
+// Direction returns the neighbour to move to from the cell to reach the
+// nearest goal. It returns false for the goals and cells from which no goal can
+// be reached.
+func (f *FlowField) Direction(c Cell) (Cell, bool) {
+	if !f.grid.Contains(c) {
+		return Cell{}, false
+	}
+	next := f.next[f.grid.index(c)]
+	if next < 0 {
+		return Cell{}, false
+	}
+	return f.grid.cell(next), true
+}
+
+// Vector returns the unit vector pointing from the point (in space / render
+// coordinates) to the center of the next cell on the way to the nearest goal.
+// It points to the center of the cell containing the point for goals, and is
+// zero if no goal can be reached or the point is at the center of a goal.
+func (f *FlowField) Vector(pt engo.Point) engo.Point {
+	c := f.grid.CellAt(pt)
+	target, ok := f.Direction(c)
+	if !ok {
+		if f.Cost(c) != 0 {
+			return engo.Point{}
+		}
+		target = c
+	}
+	v := f.grid.Center(target)
+	v.Subtract(pt)
+	v, _ = v.Normalize()
+	return v
+}
@@ -0,0 +1,45 @@
+package pathfinding
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/math"
+)
+
+func TestFlowField(t *testing.T) {
+	g := wallGrid()
+	g.Diagonal = false
+	goal := Cell{X: 6, Y: 0}
+	f := g.FlowField(goal)
+
+	if f.Cost(goal) != 0 || f.Cost(Cell{X: 0, Y: 0}) != 14 {
+		t.Errorf("Cost to the goal was %v, expected 14", f.Cost(Cell{X: 0, Y: 0}))
+	}
+	if !math.IsInf(f.Cost(Cell{X: 3, Y: 0}), 1) {
+		t.Error("Blocked cell had a cost")
+	}
+	if _, ok := f.Direction(goal); ok {
+		t.Error("Goal had a direction")
+	}
+
+	// following the directions leads to the goal
+	c := Cell{X: 0, Y: 0}
+	for i := 0; i < 14; i++ {
+		next, ok := f.Direction(c)
+		if !ok || Manhattan(c, next) != 1 || f.Cost(next) >= f.Cost(c) {
+			t.Fatalf("Direction of %v was %v", c, next)
+		}
+		c = next
+	}
+	if c != goal {
+		t.Errorf("Directions led to %v, expected the goal", c)
+	}
+
+	if v := f.Vector(engo.Point{X: 2.5, Y: 0.5}); v != (engo.Point{X: 0, Y: 1}) {
+		t.Errorf("Vector was %v, expected it to point down", v)
+	}
+	if v := f.Vector(engo.Point{X: 6.5, Y: 0.5}); v != (engo.Point{}) {
+		t.Errorf("Vector at the center of the goal was %v", v)
+	}
+}
@@ -0,0 +1,379 @@
+// Package pathfinding finds paths on grids of cells, like the tiles of a
+// common.Level. It provides A* with pluggable heuristics, Jump Point Search
+// for grids with uniform costs, flow fields for moving crowds to a goal, and
+// smoothing of paths against collision lines.
+package pathfinding
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"github.com/EngoEngine/engo/math"
+)
+
+// The orientations of grids, which are the same as the ones of levels.
+const (
+	Orthogonal = "orthogonal"
+	Isometric  = "isometric"
+	Hexagonal  = "hexagonal"
+	Staggered  = "staggered"
+)
+
+// Cell is the position of a cell of a Grid, which is the position of a tile of
+// a level in tiles.
+type Cell struct {
+	X, Y int
+}
+
+// Grid holds which cells can be walked on, and the cost of entering them.
+type Grid struct {
+	// X and Y are the position of the top-left cell
+	X, Y int
+	// Width and Height are the number of cells of the grid
+	Width, Height int
+	// Orientation is how the cells are laid out, which is Orthogonal,
+	// Isometric, Hexagonal or Staggered
+	Orientation string
+	// StaggerAxis is the axis, "x" or "y", which is staggered in hexagonal and
+	// staggered grids
+	StaggerAxis string
+	// StaggerIndex is whether the "even" or "odd" indexes along the StaggerAxis
+	// are shifted in hexagonal and staggered grids
+	StaggerIndex string
+	// Diagonal is whether paths can move diagonally on orthogonal, isometric
+	// and staggered grids. They never cut the corners of blocked cells.
+	Diagonal bool
+	// CellWidth and CellHeight are the size of the cells in space / render
+	// coordinates for grids which are not built from a level
+	CellWidth, CellHeight float32
+
+	level    *common.Level
+	walkable []bool
+	costs    []float32
+}
+
+// NewGrid creates an orthogonal grid of cells of 1 by 1, which can all be
+// walked on with a cost of 1, and allows diagonal moves.
+func NewGrid(width, height int) *Grid {
+	g := &Grid{
+		Width:       width,
+		Height:      height,
+		Orientation: Orthogonal,
+		Diagonal:    true,
+		CellWidth:   1,
+		CellHeight:  1,
+		walkable:    make([]bool, width*height),
+		costs:       make([]float32, width*height),
+	}
+	for i := range g.walkable {
+		g.walkable[i] = true
+		g.costs[i] = 1
+	}
+	return g
+}
+
+// LevelOptions are used by FromLevel to decide which tiles block their cell,
+// and how expensive it is to walk on them.
+type LevelOptions struct {
+	// WalkableProperty is the name of the bool tile property which blocks the
+	// cell of the tile when it is false. It is "walkable" if empty.
+	WalkableProperty string
+	// CostProperty is the name of the float tile property with the cost of
+	// entering the cell of the tile, which has to be greater than 0. It is
+	// "cost" if empty. Cells without it have a cost of 1.
+	CostProperty string
+	// CollisionLayers are the names of the tile layers whose tiles all block
+	// their cell.
+	CollisionLayers []string
+}
+
+// FromLevel builds a grid with the size and orientation of the level. A cell
+// is blocked if a tile of it is in one of the CollisionLayers, or has its
+// WalkableProperty set to false. The cost of a cell is the CostProperty of its
+// topmost tile having one.
+func FromLevel(l *common.Level, opts LevelOptions) *Grid {
+	if opts.WalkableProperty == "" {
+		opts.WalkableProperty = "walkable"
+	}
+	if opts.CostProperty == "" {
+		opts.CostProperty = "cost"
+	}
+	g := NewGrid(l.Width(), l.Height())
+	g.X, g.Y = l.Origin()
+	g.Orientation = l.Orientation
+	g.StaggerAxis = l.StaggerAxis
+	g.StaggerIndex = l.StaggerIndex
+	g.CellWidth = float32(l.TileWidth)
+	g.CellHeight = float32(l.TileHeight)
+	g.level = l
+
+	collision := make(map[string]bool)
+	for _, name := range opts.CollisionLayers {
+		collision[name] = true
+	}
+	for y := g.Y; y < g.Y+g.Height; y++ {
+		for x := g.X; x < g.X+g.Width; x++ {
+			i := g.index(Cell{X: x, Y: y})
+			for _, layer := range l.TileLayers {
+				t := layer.Tile(x, y)
+				if t == nil || t.GID() == 0 {
+					continue
+				}
+				if collision[layer.Name] {
+					g.walkable[i] = false
+				}
+				if walkable, ok := t.Properties.Bool(opts.WalkableProperty); ok && !walkable {
+					g.walkable[i] = false
+				}
+				if cost, ok := t.Properties.Float(opts.CostProperty); ok && cost > 0 {
+					g.costs[i] = cost
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Contains returns whether the cell is part of the grid.
+func (g *Grid) Contains(c Cell) bool {
+	return c.X >= g.X && c.Y >= g.Y && c.X < g.X+g.Width && c.Y < g.Y+g.Height
+}
+
+// Walkable returns whether the cell is part of the grid and not blocked.
+func (g *Grid) Walkable(c Cell) bool {
+	return g.Contains(c) && g.walkable[g.index(c)]
+}
+
+// SetWalkable sets whether the cell is blocked.
+func (g *Grid) SetWalkable(c Cell, walkable bool) {
+	if g.Contains(c) {
+		g.walkable[g.index(c)] = walkable
+	}
+}
+
+// Cost returns the cost of entering the cell.
+func (g *Grid) Cost(c Cell) float32 {
+	if !g.Contains(c) {
+		return 0
+	}
+	return g.costs[g.index(c)]
+}
+
+// SetCost sets the cost of entering the cell, which has to be greater than 0.
+func (g *Grid) SetCost(c Cell, cost float32) {
+	if g.Contains(c) && cost > 0 {
+		g.costs[g.index(c)] = cost
+	}
+}
+
+// uniform returns whether all cells have the same cost.
+func (g *Grid) uniform() bool {
+	for _, c := range g.costs {
+		if c != g.costs[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Grid) index(c Cell) int {
+	return (c.Y-g.Y)*g.Width + c.X - g.X
+}
+
+func (g *Grid) cell(i int) Cell {
+	return Cell{X: g.X + i%g.Width, Y: g.Y + i/g.Width}
+}
+
+// shifted returns whether the row or column i is shifted in hexagonal and
+// staggered grids.
+func (g *Grid) shifted(i int) bool {
+	odd := i%2 != 0
+	if g.StaggerIndex == "even" {
+		return !odd
+	}
+	return odd
+}
+
+// sqrt2 is the distance of diagonal moves.
+const sqrt2 = 1.4142135
+
+// step is a move to a neighbouring cell, with the distance it covers.
+type step struct {
+	dx, dy int
+	dist   float32
+}
+
+var (
+	straightSteps = []step{{0, -1, 1}, {1, 0, 1}, {0, 1, 1}, {-1, 0, 1}}
+	diagonalSteps = []step{{1, -1, sqrt2}, {1, 1, sqrt2}, {-1, 1, sqrt2}, {-1, -1, sqrt2}}
+)
+
+// Neighbours returns the cells which can be walked on that can be reached
+// from the cell in one move.
+func (g *Grid) Neighbours(c Cell) []Cell {
+	var ret []Cell
+	g.neighbours(c, func(n Cell, _ float32) {
+		ret = append(ret, n)
+	})
+	return ret
+}
+
+// neighbours calls fn for the cells which can be walked on that can be reached
+// from the cell in one move, with the distance of the move.
+func (g *Grid) neighbours(c Cell, fn func(n Cell, dist float32)) {
+	switch g.Orientation {
+	case Hexagonal, Staggered:
+		g.staggeredNeighbours(c, fn)
+		return
+	}
+	for _, s := range straightSteps {
+		if n := (Cell{X: c.X + s.dx, Y: c.Y + s.dy}); g.Walkable(n) {
+			fn(n, s.dist)
+		}
+	}
+	if !g.Diagonal {
+		return
+	}
+	for _, s := range diagonalSteps {
+		n := Cell{X: c.X + s.dx, Y: c.Y + s.dy}
+		if g.Walkable(n) && g.Walkable(Cell{X: c.X + s.dx, Y: c.Y}) && g.Walkable(Cell{X: c.X, Y: c.Y + s.dy}) {
+			fn(n, s.dist)
+		}
+	}
+}
+
+// staggeredNeighbours calls fn for the neighbours of a cell in a hexagonal or
+// staggered grid. These are the six cells around it for hexagonal grids, and
+// the four cells sharing an edge with it for staggered ones, plus the four
+// sharing a corner if Diagonal is set.
+func (g *Grid) staggeredNeighbours(c Cell, fn func(n Cell, dist float32)) {
+	staggerX := g.StaggerAxis == "x"
+	i := c.Y
+	if staggerX {
+		i = c.X
+	}
+	// the offset of the neighbouring rows or columns along the other axis
+	lo, hi := -1, 0
+	if g.shifted(i) {
+		lo, hi = 0, 1
+	}
+	var cells []Cell
+	if staggerX {
+		cells = []Cell{{c.X - 1, c.Y + lo}, {c.X - 1, c.Y + hi}, {c.X + 1, c.Y + lo}, {c.X + 1, c.Y + hi}}
+	} else {
+		cells = []Cell{{c.X + lo, c.Y - 1}, {c.X + hi, c.Y - 1}, {c.X + lo, c.Y + 1}, {c.X + hi, c.Y + 1}}
+	}
+	for _, n := range cells {
+		if g.Walkable(n) {
+			fn(n, 1)
+		}
+	}
+
+	if g.Orientation == Hexagonal {
+		sides := []Cell{{c.X - 1, c.Y}, {c.X + 1, c.Y}}
+		if staggerX {
+			sides = []Cell{{c.X, c.Y - 1}, {c.X, c.Y + 1}}
+		}
+		for _, n := range sides {
+			if g.Walkable(n) {
+				fn(n, 1)
+			}
+		}
+		return
+	}
+	if !g.Diagonal {
+		return
+	}
+	// the corners of staggered cells are shared with the cells next to them
+	// along the other axis, and the ones two rows or columns away; both cells
+	// sharing the edges next to the corner have to be walkable
+	corners := []struct{ n, a, b Cell }{
+		{Cell{c.X, c.Y - 2}, cells[0], cells[1]},
+		{Cell{c.X, c.Y + 2}, cells[2], cells[3]},
+		{Cell{c.X - 1, c.Y}, cells[0], cells[2]},
+		{Cell{c.X + 1, c.Y}, cells[1], cells[3]},
+	}
+	if staggerX {
+		corners = []struct{ n, a, b Cell }{
+			{Cell{c.X - 2, c.Y}, cells[0], cells[1]},
+			{Cell{c.X + 2, c.Y}, cells[2], cells[3]},
+			{Cell{c.X, c.Y - 1}, cells[0], cells[2]},
+			{Cell{c.X, c.Y + 1}, cells[1], cells[3]},
+		}
+	}
+	for _, corner := range corners {
+		if g.Walkable(corner.n) && g.Walkable(corner.a) && g.Walkable(corner.b) {
+			fn(corner.n, sqrt2)
+		}
+	}
+}
+
+// Center returns the center of the cell in space / render coordinates.
+func (g *Grid) Center(c Cell) engo.Point {
+	if g.level != nil {
+		return g.level.TileCenter(c.X, c.Y)
+	}
+	return engo.Point{X: (float32(c.X) + 0.5) * g.CellWidth, Y: (float32(c.Y) + 0.5) * g.CellHeight}
+}
+
+// CellAt returns the cell containing the point (in space / render
+// coordinates).
+func (g *Grid) CellAt(pt engo.Point) Cell {
+	if g.level != nil {
+		x, y := g.level.TileCoordinates(pt)
+		return Cell{X: x, Y: y}
+	}
+	return Cell{X: int(math.Floor(pt.X / g.CellWidth)), Y: int(math.Floor(pt.Y / g.CellHeight))}
+}
+
+// Points returns the centers of the cells of the path in space / render
+// coordinates.
+func (g *Grid) Points(path []Cell) []engo.Point {
+	ret := make([]engo.Point, len(path))
+	for i, c := range path {
+		ret[i] = g.Center(c)
+	}
+	return ret
+}
+
+// outline returns the corners of the cell in space / render coordinates.
+func (g *Grid) outline(c Cell) []engo.Point {
+	center := g.Center(c)
+	w, h := g.CellWidth/2, g.CellHeight/2
+	var offsets []engo.Point
+	switch g.Orientation {
+	case Isometric, Staggered:
+		offsets = []engo.Point{{X: 0, Y: -h}, {X: w, Y: 0}, {X: 0, Y: h}, {X: -w, Y: 0}}
+	case Hexagonal:
+		var s float32
+		if g.level != nil {
+			s = float32(g.level.HexSideLength) / 2
+		}
+		if g.StaggerAxis == "x" {
+			offsets = []engo.Point{{X: -w, Y: 0}, {X: -s, Y: -h}, {X: s, Y: -h}, {X: w, Y: 0}, {X: s, Y: h}, {X: -s, Y: h}}
+		} else {
+			offsets = []engo.Point{{X: 0, Y: -h}, {X: w, Y: -s}, {X: w, Y: s}, {X: 0, Y: h}, {X: -w, Y: s}, {X: -w, Y: -s}}
+		}
+	default:
+		offsets = []engo.Point{{X: -w, Y: -h}, {X: w, Y: -h}, {X: w, Y: h}, {X: -w, Y: h}}
+	}
+	for i := range offsets {
+		offsets[i].Add(center)
+	}
+	return offsets
+}
+
+// BlockedLines returns the outlines of the blocked cells in space / render
+// coordinates, so paths can be smoothed without cutting through them.
+func (g *Grid) BlockedLines() []engo.Line {
+	var ret []engo.Line
+	for i, walkable := range g.walkable {
+		if walkable {
+			continue
+		}
+		pts := g.outline(g.cell(i))
+		for j, p := range pts {
+			ret = append(ret, engo.Line{P1: p, P2: pts[(j+1)%len(pts)]})
+		}
+	}
+	return ret
+}
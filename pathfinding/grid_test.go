@@ -0,0 +1,168 @@
+package pathfinding
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+)
+
+var testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.5" tiledversion="1.5.0" orientation="orthogonal" renderorder="right-down" width="4" height="3" tilewidth="16" tileheight="16" infinite="0" nextlayerid="4" nextobjectid="2">
+ <tileset firstgid="1" name="test" tilewidth="16" tileheight="16" spacing="1" tilecount="468" columns="26">
+  <image source="test.png" width="457" height="305"/>
+  <tile id="1">
+   <properties>
+    <property name="walkable" type="bool" value="false"/>
+   </properties>
+  </tile>
+  <tile id="2">
+   <properties>
+    <property name="cost" type="float" value="3"/>
+   </properties>
+  </tile>
+ </tileset>
+ <layer id="1" name="Ground" width="4" height="3">
+  <data encoding="csv">
+1,2,1,1,
+1,3,1,1,
+1,1,1,1
+</data>
+ </layer>
+ <layer id="2" name="Walls" width="4" height="3">
+  <data encoding="csv">
+0,0,0,1,
+0,0,0,0,
+0,0,0,0
+</data>
+ </layer>
+ <objectgroup id="3" name="Collisions">
+  <object id="1" x="32" y="32" width="16" height="16"/>
+ </objectgroup>
+</map>`
+
+type testScene struct{}
+
+func (*testScene) Preload() {}
+
+func (*testScene) Setup(engo.Updater) {}
+
+func (*testScene) Type() string { return "testScene" }
+
+func loadTestLevel(t *testing.T) *common.Level {
+	engo.Run(engo.RunOptions{NoRun: true, HeadlessMode: true}, &testScene{})
+	imgbuf := bytes.NewBuffer([]byte{})
+	if err := png.Encode(imgbuf, image.NewRGBA(image.Rect(0, 0, 457, 305))); err != nil {
+		t.Fatal("Unable to encode png from image")
+	}
+	if err := engo.Files.LoadReaderData("test.png", imgbuf); err != nil {
+		t.Fatalf("Unable to load test png. Error was: %v", err)
+	}
+	if err := engo.Files.LoadReaderData("test.tmx", bytes.NewBufferString(testTMX)); err != nil {
+		t.Fatalf("Unable to load the tmx file. Error was: %v", err)
+	}
+	res, err := engo.Files.Resource("test.tmx")
+	if err != nil {
+		t.Fatalf("Unable to get the tmx resource. Error was: %v", err)
+	}
+	return res.(common.TMXResource).Level
+}
+
+func TestFromLevel(t *testing.T) {
+	level := loadTestLevel(t)
+	g := FromLevel(level, LevelOptions{CollisionLayers: []string{"Walls"}})
+	if g.Width != 4 || g.Height != 3 || g.Orientation != Orthogonal || g.CellWidth != 16 {
+		t.Fatalf("Grid was %dx%d, expected 4x3", g.Width, g.Height)
+	}
+	if g.Walkable(Cell{X: 1, Y: 0}) {
+		t.Error("Cell with a tile which is not walkable was walkable")
+	}
+	if g.Walkable(Cell{X: 3, Y: 0}) {
+		t.Error("Cell with a tile in a collision layer was walkable")
+	}
+	if !g.Walkable(Cell{X: 0, Y: 0}) || g.Walkable(Cell{X: 4, Y: 0}) {
+		t.Error("Walkable cells were not returned correctly")
+	}
+	if g.Cost(Cell{X: 1, Y: 1}) != 3 || g.Cost(Cell{X: 0, Y: 1}) != 1 {
+		t.Errorf("Cost of the cell was %v, expected 3", g.Cost(Cell{X: 1, Y: 1}))
+	}
+	if c := g.Center(Cell{X: 1, Y: 2}); c != (engo.Point{X: 24, Y: 40}) {
+		t.Errorf("Center of the cell was %v", c)
+	}
+	if c := g.CellAt(engo.Point{X: 24, Y: 40}); c != (Cell{X: 1, Y: 2}) {
+		t.Errorf("Cell at the point was %v", c)
+	}
+	if lines := CollisionLines(level); len(lines) != 4 {
+		t.Errorf("Level had %d collision lines, expected 4", len(lines))
+	}
+	if lines := CollisionLines(level, "Other"); len(lines) != 0 {
+		t.Error("Collision lines were returned for a different layer")
+	}
+}
+
+func TestHexagonalGrid(t *testing.T) {
+	g := NewGrid(5, 5)
+	g.Orientation = Hexagonal
+	g.StaggerAxis = "y"
+	g.StaggerIndex = "odd"
+
+	// odd rows are shifted to the right
+	exp := map[Cell]bool{{1, 0}: true, {2, 0}: true, {1, 2}: true, {2, 2}: true, {0, 1}: true, {2, 1}: true}
+	n := g.Neighbours(Cell{X: 1, Y: 1})
+	if len(n) != len(exp) {
+		t.Fatalf("Cell had %d neighbours, expected 6", len(n))
+	}
+	for _, c := range n {
+		if !exp[c] {
+			t.Errorf("Cell %v was not a neighbour", c)
+		}
+	}
+	n = g.Neighbours(Cell{X: 1, Y: 2})
+	for _, c := range n {
+		if c == (Cell{X: 2, Y: 1}) {
+			t.Error("Cell of the shifted row was a neighbour of an unshifted row")
+		}
+	}
+
+	for _, test := range []struct {
+		to   Cell
+		dist float32
+	}{
+		{Cell{X: 1, Y: 1}, 0},
+		{Cell{X: 2, Y: 0}, 1},
+		{Cell{X: 3, Y: 1}, 2},
+		{Cell{X: 1, Y: 3}, 2},
+		{Cell{X: 2, Y: 4}, 3},
+		{Cell{X: 4, Y: 4}, 4},
+	} {
+		if d := g.HexDistance(Cell{X: 1, Y: 1}, test.to); d != test.dist {
+			t.Errorf("Distance to %v was %v, expected %v", test.to, d, test.dist)
+		}
+		path, ok := g.AStar(Cell{X: 1, Y: 1}, test.to, nil)
+		if !ok || float32(len(path)-1) != test.dist {
+			t.Errorf("Path to %v had %d moves, expected %v", test.to, len(path)-1, test.dist)
+		}
+	}
+}
+
+func TestStaggeredGrid(t *testing.T) {
+	g := NewGrid(5, 6)
+	g.Orientation = Staggered
+	g.StaggerAxis = "y"
+	g.StaggerIndex = "odd"
+	g.Diagonal = false
+	if n := g.Neighbours(Cell{X: 2, Y: 2}); len(n) != 4 {
+		t.Errorf("Cell had %d neighbours, expected 4", len(n))
+	}
+	g.Diagonal = true
+	if n := g.Neighbours(Cell{X: 2, Y: 2}); len(n) != 8 {
+		t.Errorf("Cell had %d neighbours, expected 8", len(n))
+	}
+	path, ok := g.AStar(Cell{X: 2, Y: 0}, Cell{X: 2, Y: 4}, nil)
+	if !ok || len(path) != 3 || g.PathCost(path) != 2*sqrt2 {
+		t.Errorf("Path was %v, expected two diagonal moves", path)
+	}
+}
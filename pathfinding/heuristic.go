@@ -0,0 +1,92 @@
+package pathfinding
+
+import (
+	"github.com/EngoEngine/engo/math"
+)
+
+// Heuristic estimates the cost of the path between two cells. A* finds the
+// shortest path if it never overestimates the cost, which is the case for the
+// heuristics of this package on grids whose cells all cost 1 or more.
+type Heuristic func(from, to Cell) float32
+
+// Manhattan is the number of moves between the cells on grids without
+// diagonal moves.
+func Manhattan(from, to Cell) float32 {
+	return math.Abs(float32(to.X-from.X)) + math.Abs(float32(to.Y-from.Y))
+}
+
+// Octile is the distance between the cells on grids with diagonal moves,
+// which cost the square root of 2.
+func Octile(from, to Cell) float32 {
+	dx, dy := math.Abs(float32(to.X-from.X)), math.Abs(float32(to.Y-from.Y))
+	return math.Max(dx, dy) + (sqrt2-1)*math.Min(dx, dy)
+}
+
+// Euclidean is the straight distance between the cells.
+func Euclidean(from, to Cell) float32 {
+	dx, dy := float32(to.X-from.X), float32(to.Y-from.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// Chebyshev is the number of moves between the cells on grids with diagonal
+// moves, which cost the same as straight ones.
+func Chebyshev(from, to Cell) float32 {
+	return math.Max(math.Abs(float32(to.X-from.X)), math.Abs(float32(to.Y-from.Y)))
+}
+
+// Heuristic returns the heuristic fitting the orientation of the grid.
+func (g *Grid) Heuristic() Heuristic {
+	switch g.Orientation {
+	case Hexagonal:
+		return g.HexDistance
+	case Staggered:
+		return g.staggeredDistance
+	}
+	if g.Diagonal {
+		return Octile
+	}
+	return Manhattan
+}
+
+// doubled returns the cell in doubled coordinates, in which the position along
+// the axis which is not staggered is doubled, and 1 is added for the shifted
+// rows or columns.
+func (g *Grid) doubled(c Cell) (float32, float32) {
+	if g.StaggerAxis == "x" {
+		y := 2 * c.Y
+		if g.shifted(c.X) {
+			y++
+		}
+		return float32(c.X), float32(y)
+	}
+	x := 2 * c.X
+	if g.shifted(c.Y) {
+		x++
+	}
+	return float32(x), float32(c.Y)
+}
+
+// HexDistance is the number of moves between the cells of a hexagonal grid.
+func (g *Grid) HexDistance(from, to Cell) float32 {
+	x1, y1 := g.doubled(from)
+	x2, y2 := g.doubled(to)
+	dx, dy := math.Abs(x2-x1), math.Abs(y2-y1)
+	if g.StaggerAxis == "x" {
+		return dx + math.Max(0, (dy-dx)/2)
+	}
+	return dy + math.Max(0, (dx-dy)/2)
+}
+
+// staggeredDistance is the distance between the cells of a staggered grid,
+// which is the one between them on the isometric grid the staggered grid is
+// a part of.
+func (g *Grid) staggeredDistance(from, to Cell) float32 {
+	x1, y1 := g.doubled(from)
+	x2, y2 := g.doubled(to)
+	dx, dy := x2-x1, y2-y1
+	du, dv := math.Abs((dx+dy)/2), math.Abs((dy-dx)/2)
+	if !g.Diagonal {
+		return du + dv
+	}
+	return math.Max(du, dv) + (sqrt2-1)*math.Min(du, dv)
+}
@@ -0,0 +1,200 @@
+package pathfinding
+
+import (
+	"container/heap"
+)
+
+// JPS finds the shortest path between two cells using Jump Point Search, which
+// is a lot faster than A* on large open grids. It only skips cells on
+// orthogonal and isometric grids with diagonal moves whose cells all have the
+// same cost, and uses AStar with the grid's Heuristic for the others. The path
+// contains every cell from from to to, like the ones of AStar.
+func (g *Grid) JPS(from, to Cell) ([]Cell, bool) {
+	if g.Orientation == Hexagonal || g.Orientation == Staggered || !g.Diagonal || !g.uniform() {
+		return g.AStar(from, to, nil)
+	}
+	if !g.Walkable(from) || !g.Walkable(to) {
+		return nil, false
+	}
+
+	cost := g.costs[0]
+	start, goal := g.index(from), g.index(to)
+	costs := make([]float32, len(g.walkable))
+	parents := make([]int, len(g.walkable))
+	closed := make([]bool, len(g.walkable))
+	for i := range parents {
+		parents[i] = -1
+	}
+	visited := make([]bool, len(g.walkable))
+	visited[start] = true
+
+	open := &openSet{{index: start, priority: cost * Octile(from, to)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(node).index
+		if current == goal {
+			return g.expand(g.path(parents, goal)), true
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		c := g.cell(current)
+		var parent *Cell
+		if parents[current] >= 0 {
+			p := g.cell(parents[current])
+			parent = &p
+		}
+		for _, n := range g.prunedNeighbours(c, parent) {
+			jp, ok := g.jump(n, sign(n.X-c.X), sign(n.Y-c.Y), to)
+			if !ok {
+				continue
+			}
+			i := g.index(jp)
+			if closed[i] {
+				continue
+			}
+			d := costs[current] + cost*Octile(c, jp)
+			if visited[i] && d >= costs[i] {
+				continue
+			}
+			visited[i] = true
+			costs[i] = d
+			parents[i] = current
+			heap.Push(open, node{index: i, priority: d + cost*Octile(jp, to)})
+		}
+	}
+	return nil, false
+}
+
+// prunedNeighbours returns the neighbours of the cell which have to be
+// searched when it was reached from the parent. These are all neighbours for
+// the start of the search.
+func (g *Grid) prunedNeighbours(c Cell, parent *Cell) []Cell {
+	if parent == nil {
+		return g.Neighbours(c)
+	}
+	var ret []Cell
+	add := func(x, y int) {
+		ret = append(ret, Cell{X: x, Y: y})
+	}
+	dx, dy := sign(c.X-parent.X), sign(c.Y-parent.Y)
+	switch {
+	case dx != 0 && dy != 0:
+		vertical := g.Walkable(Cell{X: c.X, Y: c.Y + dy})
+		horizontal := g.Walkable(Cell{X: c.X + dx, Y: c.Y})
+		if vertical {
+			add(c.X, c.Y+dy)
+		}
+		if horizontal {
+			add(c.X+dx, c.Y)
+		}
+		if vertical && horizontal {
+			add(c.X+dx, c.Y+dy)
+		}
+	case dx != 0:
+		next := g.Walkable(Cell{X: c.X + dx, Y: c.Y})
+		below := g.Walkable(Cell{X: c.X, Y: c.Y + 1})
+		above := g.Walkable(Cell{X: c.X, Y: c.Y - 1})
+		if next {
+			add(c.X+dx, c.Y)
+			if below {
+				add(c.X+dx, c.Y+1)
+			}
+			if above {
+				add(c.X+dx, c.Y-1)
+			}
+		}
+		if below {
+			add(c.X, c.Y+1)
+		}
+		if above {
+			add(c.X, c.Y-1)
+		}
+	default:
+		next := g.Walkable(Cell{X: c.X, Y: c.Y + dy})
+		right := g.Walkable(Cell{X: c.X + 1, Y: c.Y})
+		left := g.Walkable(Cell{X: c.X - 1, Y: c.Y})
+		if next {
+			add(c.X, c.Y+dy)
+			if right {
+				add(c.X+1, c.Y+dy)
+			}
+			if left {
+				add(c.X-1, c.Y+dy)
+			}
+		}
+		if right {
+			add(c.X+1, c.Y)
+		}
+		if left {
+			add(c.X-1, c.Y)
+		}
+	}
+	return ret
+}
+
+// jump moves from the cell in the direction until it reaches the goal or a
+// cell with neighbours which can only be reached through it, and returns that
+// cell. It returns false if it runs into a blocked cell first.
+func (g *Grid) jump(c Cell, dx, dy int, goal Cell) (Cell, bool) {
+	for {
+		if !g.Walkable(c) {
+			return Cell{}, false
+		}
+		if c == goal {
+			return c, true
+		}
+		switch {
+		case dx != 0 && dy != 0:
+			if _, ok := g.jump(Cell{X: c.X + dx, Y: c.Y}, dx, 0, goal); ok {
+				return c, true
+			}
+			if _, ok := g.jump(Cell{X: c.X, Y: c.Y + dy}, 0, dy, goal); ok {
+				return c, true
+			}
+		case dx != 0:
+			if g.Walkable(Cell{X: c.X, Y: c.Y - 1}) && !g.Walkable(Cell{X: c.X - dx, Y: c.Y - 1}) ||
+				g.Walkable(Cell{X: c.X, Y: c.Y + 1}) && !g.Walkable(Cell{X: c.X - dx, Y: c.Y + 1}) {
+				return c, true
+			}
+		default:
+			if g.Walkable(Cell{X: c.X - 1, Y: c.Y}) && !g.Walkable(Cell{X: c.X - 1, Y: c.Y - dy}) ||
+				g.Walkable(Cell{X: c.X + 1, Y: c.Y}) && !g.Walkable(Cell{X: c.X + 1, Y: c.Y - dy}) {
+				return c, true
+			}
+		}
+		// diagonal moves can't cut the corners of blocked cells
+		if !g.Walkable(Cell{X: c.X + dx, Y: c.Y}) || !g.Walkable(Cell{X: c.X, Y: c.Y + dy}) {
+			return Cell{}, false
+		}
+		c = Cell{X: c.X + dx, Y: c.Y + dy}
+	}
+}
+
+// expand adds the cells between the jump points of the path.
+func (g *Grid) expand(jumps []Cell) []Cell {
+	if len(jumps) == 0 {
+		return nil
+	}
+	ret := []Cell{jumps[0]}
+	for i := 1; i < len(jumps); i++ {
+		c, to := jumps[i-1], jumps[i]
+		dx, dy := sign(to.X-c.X), sign(to.Y-c.Y)
+		for c != to {
+			c = Cell{X: c.X + dx, Y: c.Y + dy}
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
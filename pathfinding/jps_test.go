@@ -0,0 +1,67 @@
+package pathfinding
+
+import (
+	"testing"
+)
+
+var jpsMaze = []string{
+	"..........",
+	".####.###.",
+	".#......#.",
+	".#.####.#.",
+	"...#..#...",
+	"##.#.##.##",
+	"...#......",
+	".###.####.",
+	"..........",
+}
+
+func mazeGrid(rows []string) *Grid {
+	g := NewGrid(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			g.SetWalkable(Cell{X: x, Y: y}, c != '#')
+		}
+	}
+	return g
+}
+
+func TestJPS(t *testing.T) {
+	g := mazeGrid(jpsMaze)
+	for _, test := range []struct{ from, to Cell }{
+		{Cell{X: 0, Y: 0}, Cell{X: 9, Y: 8}},
+		{Cell{X: 5, Y: 4}, Cell{X: 0, Y: 8}},
+		{Cell{X: 2, Y: 2}, Cell{X: 9, Y: 0}},
+		{Cell{X: 4, Y: 6}, Cell{X: 4, Y: 6}},
+	} {
+		exp, ok := g.AStar(test.from, test.to, nil)
+		if !ok {
+			t.Fatalf("No path was found from %v to %v", test.from, test.to)
+		}
+		path, ok := g.JPS(test.from, test.to)
+		if !ok {
+			t.Errorf("JPS found no path from %v to %v", test.from, test.to)
+			continue
+		}
+		if path[0] != test.from || path[len(path)-1] != test.to {
+			t.Errorf("Path %v did not lead from %v to %v", path, test.from, test.to)
+		}
+		for i := 1; i < len(path); i++ {
+			found := false
+			for _, n := range g.Neighbours(path[i-1]) {
+				found = found || n == path[i]
+			}
+			if !found {
+				t.Errorf("Move from %v to %v was not allowed", path[i-1], path[i])
+			}
+		}
+		if d := g.PathCost(path) - g.PathCost(exp); d > 1e-4 || d < -1e-4 {
+			t.Errorf("Path from %v to %v cost %v, expected %v", test.from, test.to, g.PathCost(path), g.PathCost(exp))
+		}
+	}
+
+	g.SetWalkable(Cell{X: 9, Y: 8}, false)
+	if _, ok := g.JPS(Cell{X: 0, Y: 0}, Cell{X: 9, Y: 8}); ok {
+		t.Error("Path was found to a blocked cell")
+	}
+}
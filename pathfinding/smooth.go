@@ -0,0 +1,49 @@
+package pathfinding
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+)
+
+// Smooth removes the points of the path which can be skipped by walking in a
+// straight line to a later point without crossing any of the lines. The first
+// and last point are always kept.
+func Smooth(path []engo.Point, lines []engo.Line) []engo.Point {
+	if len(path) < 3 {
+		return path
+	}
+	ret := []engo.Point{path[0]}
+	for i := 0; i < len(path)-1; {
+		// the furthest point that can be seen from the current one
+		next := i + 1
+		for j := len(path) - 1; j > next; j-- {
+			if visible(path[i], path[j], lines) {
+				next = j
+				break
+			}
+		}
+		ret = append(ret, path[next])
+		i = next
+	}
+	return ret
+}
+
+// visible returns whether the line between the points crosses none of the
+// lines.
+func visible(from, to engo.Point, lines []engo.Line) bool {
+	return engo.LineTrace(engo.Line{P1: from, P2: to}, lines).Fraction >= 1
+}
+
+// Smooth returns the centers of the cells of the path, without the ones which
+// can be skipped by walking in a straight line to a later cell without
+// crossing a blocked cell or any of the lines, like the ones returned by
+// CollisionLines.
+func (g *Grid) Smooth(path []Cell, lines []engo.Line) []engo.Point {
+	return Smooth(g.Points(path), append(g.BlockedLines(), lines...))
+}
+
+// CollisionLines returns the outlines of the objects of the level's object
+// layers in space / render coordinates, as returned by Level.ObjectLines.
+func CollisionLines(l *common.Level, names ...string) []engo.Line {
+	return l.ObjectLines(names...)
+}
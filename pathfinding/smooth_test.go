@@ -0,0 +1,31 @@
+package pathfinding
+
+import (
+	"testing"
+
+	"github.com/EngoEngine/engo"
+)
+
+func TestSmooth(t *testing.T) {
+	path := []engo.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}}
+	if s := Smooth(path, nil); len(s) != 2 || s[0] != path[0] || s[1] != path[4] {
+		t.Errorf("Path without lines was %v, expected a straight line", s)
+	}
+	wall := []engo.Line{{P1: engo.Point{X: 1.5, Y: 0.5}, P2: engo.Point{X: 1.5, Y: 3}}}
+	if s := Smooth(path, wall); len(s) != 3 || s[1] != path[2] {
+		t.Errorf("Path was %v, expected it to go around the wall", s)
+	}
+
+	g := wallGrid()
+	cells, _ := g.AStar(Cell{X: 0, Y: 0}, Cell{X: 6, Y: 0}, nil)
+	s := g.Smooth(cells, nil)
+	if len(s) >= len(cells) || s[0] != g.Center(cells[0]) || s[len(s)-1] != g.Center(cells[len(cells)-1]) {
+		t.Fatalf("Smoothed path was %v", s)
+	}
+	lines := g.BlockedLines()
+	for i := 1; i < len(s); i++ {
+		if !visible(s[i-1], s[i], lines) {
+			t.Errorf("Line from %v to %v crossed a blocked cell", s[i-1], s[i])
+		}
+	}
+}
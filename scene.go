@@ -56,6 +56,12 @@ type sceneWrapper struct {
 	scene   Scene
 	update  Updater
 	mailbox *MessageManager
+	// handles are the resources acquired by the Scene, they are released when it's removed from the stack
+	handles []*Handle
+	// scheduler runs the timers and Sequences of the Scene
+	scheduler *Scheduler
+	// preload is set once the handles have been released, the Scene is preloaded again when it's shown
+	preload bool
 }
 
 // CurrentScene returns the SceneWorld that is currently active
@@ -65,7 +71,10 @@ func CurrentScene() Scene {
 
 // SetScene sets the currentScene to the given Scene, and
 // optionally forcing to create a new ecs.World that goes with it.
+// The scene stack is replaced by the given Scene.
 func SetScene(s Scene, forceNewWorld bool) {
+	finishTransition()
+
 	// Break down currentScene
 	if currentScene != nil {
 		if hider, ok := currentScene.(Hider); ok {
@@ -73,6 +82,21 @@ func SetScene(s Scene, forceNewWorld bool) {
 		}
 	}
 
+	old := sceneStack
+	wrapper := enterScene(s, forceNewWorld)
+	sceneStack = []stackEntry{{wrapper: wrapper}}
+
+	// The resources of the previous Scenes are released once the new Scene has acquired its own, so that the shared
+	// ones stay loaded
+	for _, e := range old {
+		if e.wrapper != wrapper {
+			releaseHandles(e.wrapper)
+		}
+	}
+}
+
+// enterScene makes the given Scene the current one, setting it up if needed or showing it again otherwise.
+func enterScene(s Scene, forceNewWorld bool) *sceneWrapper {
 	// Register Scene if needed
 	sceneMutex.RLock()
 	wrapper, registered := scenes[s.Type()]
@@ -85,6 +109,8 @@ func SetScene(s Scene, forceNewWorld bool) {
 		wrapper = scenes[s.Type()]
 		sceneMutex.RUnlock()
 	}
+	// The Scene given is the one that becomes current, even if another instance of its type was registered before
+	wrapper.scene = s
 
 	// Initialize new Scene / World if needed
 	var doSetup bool
@@ -94,27 +120,32 @@ func SetScene(s Scene, forceNewWorld bool) {
 		v := reflect.New(t)
 		wrapper.update = v.Interface().(Updater)
 		wrapper.mailbox = &MessageManager{}
+		wrapper.scheduler = &Scheduler{mailbox: wrapper.mailbox}
 
 		doSetup = true
 	}
 
 	// Do the switch
-	currentScene = s
-	currentUpdater = wrapper.update
-	Mailbox = wrapper.mailbox
+	activate(wrapper)
 
 	// doSetup is true whenever we're (re)initializing the Scene
 	if doSetup {
+		wrapper.preload = false
 		s.Preload()
 
 		wrapper.mailbox.listeners = make(map[string][]HandlerIDPair)
 
 		s.Setup(wrapper.update)
 	} else {
+		if wrapper.preload {
+			wrapper.preload = false
+			s.Preload()
+		}
 		if shower, ok := currentScene.(Shower); ok {
 			shower.Show()
 		}
 	}
+	return wrapper
 }
 
 // RegisterScene registers the `Scene`, so it can later be used by `SetSceneByName`
@@ -0,0 +1,360 @@
+package engo
+
+import (
+	"errors"
+	"image/color"
+
+	"github.com/EngoEngine/ecs"
+)
+
+// SceneFlags control what happens to a Scene on the stack while other Scenes are pushed on top of it. The Scene on
+// top of the stack is always updated and drawn.
+type SceneFlags struct {
+	// Update keeps updating the Scene while it is covered.
+	Update bool
+	// Render keeps drawing the Scene underneath the Scenes on top of it. If Update is false, the Scene is drawn
+	// frozen: only its Renderers are called.
+	Render bool
+}
+
+// Renderer is an optional interface for Updaters and ecs Systems that draw. When a Scene on the stack is drawn but
+// not updated, only the Render method of its Updater is called, or, for an `*ecs.World`, the Render method of every
+// System implementing it.
+type Renderer interface {
+	Render()
+}
+
+// TransitionEffect is the visual effect of a Transition between Scenes.
+type TransitionEffect uint8
+
+const (
+	// TransitionNone switches Scenes immediately.
+	TransitionNone TransitionEffect = iota
+	// TransitionFade fades the screen to a color and back, switching Scenes halfway.
+	TransitionFade
+	// TransitionSlide moves the Scene onto or off the screen.
+	TransitionSlide
+	// TransitionWipe reveals or hides the Scene behind a straight edge moving across the screen.
+	TransitionWipe
+)
+
+// TransitionDirection is the direction in which slides and wipes move.
+type TransitionDirection uint8
+
+const (
+	// TransitionLeft moves towards the left of the screen.
+	TransitionLeft TransitionDirection = iota
+	// TransitionRight moves towards the right of the screen.
+	TransitionRight
+	// TransitionUp moves towards the top of the screen.
+	TransitionUp
+	// TransitionDown moves towards the bottom of the screen.
+	TransitionDown
+)
+
+// Transition describes how switching between Scenes is animated. The zero value switches immediately.
+//
+// Pushing or replacing a Scene transitions the new Scene in, popping a Scene transitions it out. While a transition
+// runs, the Scenes involved are only drawn; they are updated again once it has finished.
+type Transition struct {
+	Effect TransitionEffect
+	// Duration is the length of the transition, in seconds.
+	Duration float32
+	// Direction is the direction slides and wipes move in.
+	Direction TransitionDirection
+	// Color is the color faded to. Defaults to black.
+	Color color.Color
+}
+
+// TransitionStartedMessage is dispatched to the Mailboxes of both Scenes when a transition starts.
+type TransitionStartedMessage struct {
+	From, To   Scene
+	Transition Transition
+}
+
+// Type returns the type of the message, "TransitionStartedMessage"
+func (TransitionStartedMessage) Type() string { return "TransitionStartedMessage" }
+
+// TransitionFinishedMessage is dispatched to the Mailboxes of both Scenes when a transition has finished. A Scene
+// that was popped or replaced receives it as well.
+type TransitionFinishedMessage struct {
+	From, To   Scene
+	Transition Transition
+}
+
+// Type returns the type of the message, "TransitionFinishedMessage"
+func (TransitionFinishedMessage) Type() string { return "TransitionFinishedMessage" }
+
+// SceneLayer describes the Scene of the stack that is currently being updated or drawn, so that Systems drawing it
+// know how to combine it with the Scenes drawn before it in the same frame.
+type SceneLayer struct {
+	// Index is the position of the Scene among the Scenes drawn this frame, starting at 0 for the bottom one. Only
+	// the first layer should clear the screen.
+	Index int
+	// Count is the amount of Scenes drawn this frame.
+	Count int
+
+	// Transition is the transition that is running, its Effect is TransitionNone otherwise.
+	Transition Transition
+	// Progress is the progress of the transition, from 0 to 1.
+	Progress float32
+	// Entering and Leaving indicate the transition applies to this layer, bringing its Scene in or taking it out.
+	Entering, Leaving bool
+}
+
+// CurrentSceneLayer returns the layer that is being updated or drawn.
+func CurrentSceneLayer() SceneLayer {
+	return currentLayer
+}
+
+type stackEntry struct {
+	wrapper *sceneWrapper
+	flags   SceneFlags
+}
+
+type sceneTransition struct {
+	Transition
+	elapsed  float32
+	from, to *sceneWrapper
+	// leaving is set when the effect takes the removed Scene out, instead of bringing the new Scene in
+	leaving bool
+	// removed is the Scene that was popped or replaced, it is drawn until the transition has finished
+	removed *sceneWrapper
+}
+
+var (
+	sceneStack   []stackEntry
+	transition   *sceneTransition
+	currentLayer SceneLayer
+)
+
+// SceneStack returns the Scenes on the stack, from the bottom to the top. The last one is the `CurrentScene`.
+func SceneStack() []Scene {
+	stack := make([]Scene, len(sceneStack))
+	for i, e := range sceneStack {
+		stack[i] = e.wrapper.scene
+	}
+	return stack
+}
+
+// PushScene puts the Scene on top of the stack, making it the current Scene. The Scene underneath is hidden, and is
+// updated and drawn according to its SceneFlags. The flags given here apply to the pushed Scene once other Scenes
+// are pushed on top of it.
+func PushScene(s Scene, flags SceneFlags, t Transition) {
+	finishTransition()
+
+	from := topWrapper()
+	if hider, ok := currentScene.(Hider); ok {
+		hider.Hide()
+	}
+
+	to := enterScene(s, false)
+	sceneStack = append(sceneStack, stackEntry{wrapper: to, flags: flags})
+	startTransition(t, &sceneTransition{from: from, to: to})
+}
+
+// PopScene removes the current Scene from the top of the stack, showing the Scene underneath again.
+func PopScene(t Transition) error {
+	if len(sceneStack) < 2 {
+		return errors.New("unable to pop the last scene of the stack")
+	}
+	finishTransition()
+
+	from := sceneStack[len(sceneStack)-1].wrapper
+	if hider, ok := from.scene.(Hider); ok {
+		hider.Hide()
+	}
+	sceneStack = sceneStack[:len(sceneStack)-1]
+
+	to := sceneStack[len(sceneStack)-1].wrapper
+	activate(to)
+	if shower, ok := to.scene.(Shower); ok {
+		shower.Show()
+	}
+	startTransition(t, &sceneTransition{from: from, to: to, leaving: true, removed: from})
+	return nil
+}
+
+// ReplaceScene replaces the current Scene on top of the stack by the given Scene.
+func ReplaceScene(s Scene, flags SceneFlags, t Transition) {
+	if len(sceneStack) == 0 {
+		SetScene(s, false)
+		return
+	}
+	finishTransition()
+
+	from := sceneStack[len(sceneStack)-1].wrapper
+	if hider, ok := from.scene.(Hider); ok {
+		hider.Hide()
+	}
+
+	to := enterScene(s, false)
+	sceneStack[len(sceneStack)-1] = stackEntry{wrapper: to, flags: flags}
+	startTransition(t, &sceneTransition{from: from, to: to, removed: from})
+}
+
+// SetSceneFlags changes the SceneFlags of the current Scene.
+func SetSceneFlags(flags SceneFlags) {
+	if len(sceneStack) > 0 {
+		sceneStack[len(sceneStack)-1].flags = flags
+	}
+}
+
+func topWrapper() *sceneWrapper {
+	if len(sceneStack) == 0 {
+		return nil
+	}
+	return sceneStack[len(sceneStack)-1].wrapper
+}
+
+// activate makes the Scene the current one, without showing it.
+func activate(w *sceneWrapper) {
+	currentScene = w.scene
+	currentUpdater = w.update
+	Mailbox = w.mailbox
+}
+
+func startTransition(t Transition, st *sceneTransition) {
+	st.Transition = t
+	dispatchTransitionMessage(st, TransitionStartedMessage{From: sceneOf(st.from), To: sceneOf(st.to), Transition: t})
+	if t.Effect == TransitionNone || t.Duration <= 0 {
+		dispatchTransitionMessage(st, TransitionFinishedMessage{From: sceneOf(st.from), To: sceneOf(st.to), Transition: t})
+		releaseRemoved(st)
+		return
+	}
+	transition = st
+}
+
+// finishTransition ends the running transition immediately.
+func finishTransition() {
+	if transition == nil {
+		return
+	}
+	st := transition
+	transition = nil
+	dispatchTransitionMessage(st, TransitionFinishedMessage{From: sceneOf(st.from), To: sceneOf(st.to), Transition: st.Transition})
+	releaseRemoved(st)
+}
+
+// releaseRemoved releases the resources of the Scene that was popped or replaced, once it's not drawn anymore.
+func releaseRemoved(st *sceneTransition) {
+	if st.removed == nil {
+		return
+	}
+	for _, e := range sceneStack {
+		if e.wrapper == st.removed {
+			return
+		}
+	}
+	releaseHandles(st.removed)
+}
+
+func dispatchTransitionMessage(st *sceneTransition, msg Message) {
+	if st.from != nil && st.from.mailbox != nil {
+		st.from.mailbox.Dispatch(msg)
+	}
+	if st.to != nil && st.to != st.from && st.to.mailbox != nil {
+		st.to.mailbox.Dispatch(msg)
+	}
+}
+
+func sceneOf(w *sceneWrapper) Scene {
+	if w == nil {
+		return nil
+	}
+	return w.scene
+}
+
+type sceneLayer struct {
+	wrapper           *sceneWrapper
+	update            bool
+	entering, leaving bool
+}
+
+// updateScenes finishes the work queued for the main thread and records or replays the input, then updates and draws
+// the Scenes of the stack for one frame, from the bottom to the top. The Scenes that are updated run their fixed
+// updates first, then their Scheduler.
+func updateScenes(dt float32) {
+	runMainThreadCalls()
+	if Input != nil {
+		dt = Input.frame(dt)
+	}
+	steps := fixedSteps(dt)
+
+	if transition == nil && len(sceneStack) <= 1 {
+		currentLayer = SceneLayer{Count: 1}
+		fixedUpdate(currentUpdater, steps)
+		if w := topWrapper(); w != nil && w.scheduler != nil {
+			w.scheduler.Update(dt)
+		}
+		update(currentUpdater, dt)
+		return
+	}
+
+	var layers []sceneLayer
+	for i, e := range sceneStack {
+		top := i == len(sceneStack)-1
+		if transition != nil {
+			// While transitioning, the Scenes being covered or uncovered are always drawn
+			if top || e.wrapper == transition.from || e.wrapper == transition.to || e.flags.Render {
+				layers = append(layers, sceneLayer{wrapper: e.wrapper, entering: top && !transition.leaving})
+			}
+			continue
+		}
+		if top || e.flags.Update || e.flags.Render {
+			layers = append(layers, sceneLayer{wrapper: e.wrapper, update: top || e.flags.Update})
+		}
+	}
+	if transition != nil && transition.removed != nil {
+		// A replaced Scene is drawn below the Scene replacing it, a popped Scene above all others
+		removed := sceneLayer{wrapper: transition.removed, leaving: transition.leaving}
+		if transition.leaving {
+			layers = append(layers, removed)
+		} else {
+			layers = append(layers[:len(layers)-1], removed, layers[len(layers)-1])
+		}
+	}
+
+	for i, l := range layers {
+		currentLayer = SceneLayer{Index: i, Count: len(layers), Entering: l.entering, Leaving: l.leaving}
+		if transition != nil {
+			currentLayer.Transition = transition.Transition
+			currentLayer.Progress = transition.elapsed / transition.Duration
+		}
+
+		Mailbox = l.wrapper.mailbox
+		if l.update {
+			fixedUpdate(l.wrapper.update, steps)
+			if l.wrapper.scheduler != nil {
+				l.wrapper.scheduler.Update(dt)
+			}
+			update(l.wrapper.update, dt)
+		} else {
+			render(l.wrapper.update)
+		}
+	}
+	Mailbox = sceneStack[len(sceneStack)-1].wrapper.mailbox
+	currentLayer = SceneLayer{Count: 1}
+
+	if transition != nil {
+		transition.elapsed += dt
+		if transition.elapsed >= transition.Duration {
+			finishTransition()
+		}
+	}
+}
+
+// render draws the Updater without updating it.
+func render(u Updater) {
+	if r, ok := u.(Renderer); ok {
+		r.Render()
+		return
+	}
+	if w, ok := u.(*ecs.World); ok {
+		for _, sys := range w.Systems() {
+			if r, ok := sys.(Renderer); ok {
+				r.Render()
+			}
+		}
+	}
+}
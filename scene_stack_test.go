@@ -0,0 +1,171 @@
+package engo
+
+import (
+	"testing"
+)
+
+// stackUpdater counts how often it is updated and rendered, and records the layers it was drawn in.
+type stackUpdater struct {
+	updates, renders int
+	layers           []SceneLayer
+}
+
+func (u *stackUpdater) Update(float32) {
+	u.updates++
+	u.layers = append(u.layers, CurrentSceneLayer())
+}
+
+func (u *stackUpdater) Render() {
+	u.renders++
+	u.layers = append(u.layers, CurrentSceneLayer())
+}
+
+type stackScene struct {
+	name          string
+	updater       *stackUpdater
+	hides, shows  int
+	started, done []Message
+}
+
+func (*stackScene) Preload() {}
+
+func (s *stackScene) Setup(u Updater) {
+	s.updater = u.(*stackUpdater)
+	Mailbox.Listen("TransitionStartedMessage", func(msg Message) {
+		s.started = append(s.started, msg)
+	})
+	Mailbox.Listen("TransitionFinishedMessage", func(msg Message) {
+		s.done = append(s.done, msg)
+	})
+}
+
+func (s *stackScene) Type() string { return s.name }
+
+func (s *stackScene) Hide() { s.hides++ }
+
+func (s *stackScene) Show() { s.shows++ }
+
+// resetScenes forgets the Scenes registered by previous tests, so that their Worlds are not reused.
+func resetScenes() {
+	finishTransition()
+	sceneMutex.Lock()
+	scenes = make(map[string]*sceneWrapper)
+	sceneMutex.Unlock()
+	sceneStack = nil
+	currentScene = nil
+}
+
+func TestSceneStack(t *testing.T) {
+	resetScenes()
+	game := &stackScene{name: "stackGame"}
+	pause := &stackScene{name: "stackPause"}
+	Run(RunOptions{NoRun: true, HeadlessMode: true, Update: &stackUpdater{}}, game)
+
+	SetSceneFlags(SceneFlags{Render: true})
+	PushScene(pause, SceneFlags{}, Transition{})
+	if CurrentScene() != pause {
+		t.Fatalf("CurrentScene was %v after push, expected the pause scene", CurrentScene().Type())
+	}
+	if stack := SceneStack(); len(stack) != 2 || stack[0] != game || stack[1] != pause {
+		t.Errorf("SceneStack was %v, expected the game and pause scenes", stack)
+	}
+	if game.hides != 1 {
+		t.Errorf("Covered scene was hidden %d times, expected 1", game.hides)
+	}
+	if len(pause.started) != 1 || len(pause.done) != 1 {
+		t.Errorf("Immediate transition dispatched %d started and %d finished messages, expected 1 each", len(pause.started), len(pause.done))
+	}
+
+	updateScenes(0.1)
+	if game.updater.updates != 0 || game.updater.renders != 1 {
+		t.Errorf("Covered scene was updated %d and rendered %d times, expected it to be drawn frozen", game.updater.updates, game.updater.renders)
+	}
+	if pause.updater.updates != 1 {
+		t.Errorf("Top scene was updated %d times, expected 1", pause.updater.updates)
+	}
+	if l := pause.updater.layers[0]; l.Index != 1 || l.Count != 2 {
+		t.Errorf("Top scene was drawn as layer %d of %d, expected 1 of 2", l.Index, l.Count)
+	}
+
+	if err := PopScene(Transition{}); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentScene() != game || Mailbox != scenes["stackGame"].mailbox {
+		t.Errorf("Popping did not make the game scene current again")
+	}
+	if game.shows != 1 || pause.hides != 1 {
+		t.Errorf("Popping showed the game %d times and hid the pause scene %d times, expected 1 each", game.shows, pause.hides)
+	}
+	if err := PopScene(Transition{}); err == nil {
+		t.Error("Popping the last scene did not return an error")
+	}
+}
+
+func TestSceneStackTransition(t *testing.T) {
+	resetScenes()
+	menu := &stackScene{name: "transitionMenu"}
+	level := &stackScene{name: "transitionLevel"}
+	Run(RunOptions{NoRun: true, HeadlessMode: true, Update: &stackUpdater{}}, menu)
+
+	slide := Transition{Effect: TransitionSlide, Duration: 1}
+	ReplaceScene(level, SceneFlags{}, slide)
+	if stack := SceneStack(); len(stack) != 1 || stack[0] != level {
+		t.Fatalf("SceneStack was %v after replacing, expected only the level", stack)
+	}
+	if len(menu.started) != 1 || len(level.started) != 1 || len(level.done) != 0 {
+		t.Errorf("Transition start was not dispatched to both scenes")
+	}
+
+	updateScenes(0.5)
+	if menu.updater.renders != 1 || level.updater.renders != 1 || level.updater.updates != 0 {
+		t.Errorf("Scenes were not only drawn during the transition")
+	}
+	if l := menu.updater.layers[0]; l.Index != 0 || l.Entering || l.Leaving {
+		t.Errorf("Replaced scene was drawn as %+v, expected the first layer without effect", l)
+	}
+	if l := level.updater.layers[0]; l.Index != 1 || !l.Entering || l.Progress != 0 {
+		t.Errorf("New scene was drawn as %+v, expected the second layer entering", l)
+	}
+
+	updateScenes(0.5)
+	if l := level.updater.layers[1]; l.Progress != 0.5 {
+		t.Errorf("Transition progress was %v, expected 0.5", l.Progress)
+	}
+	if len(menu.done) != 1 || len(level.done) != 1 {
+		t.Fatalf("Transition did not finish after its duration")
+	}
+	if msg := level.done[0].(TransitionFinishedMessage); msg.From != menu || msg.To != level || msg.Transition.Effect != TransitionSlide {
+		t.Errorf("TransitionFinishedMessage was %+v", msg)
+	}
+
+	updateScenes(0.5)
+	if menu.updater.renders != 2 || level.updater.updates != 1 {
+		t.Errorf("Replaced scene was still drawn, or the new scene was not updated after the transition")
+	}
+}
+
+func TestSceneStackNewInstance(t *testing.T) {
+	resetScenes()
+	first := &stackScene{name: "instanceGame"}
+	Run(RunOptions{NoRun: true, HeadlessMode: true, Update: &stackUpdater{}}, first)
+	pause := &stackScene{name: "instancePause"}
+	PushScene(pause, SceneFlags{}, Transition{})
+
+	// a new instance of a registered type becomes current, keeping the World of the type
+	second := &stackScene{name: "instanceGame"}
+	SetScene(second, false)
+	if CurrentScene() != second {
+		t.Fatalf("CurrentScene was not the Scene that was set")
+	}
+	if stack := SceneStack(); len(stack) != 1 || stack[0] != second {
+		t.Errorf("SceneStack was %v, expected only the new instance", stack)
+	}
+	if second.shows != 1 || second.updater != nil {
+		t.Errorf("New instance was shown %d times, expected it to be shown once without being set up", second.shows)
+	}
+
+	PushScene(pause, SceneFlags{}, Transition{})
+	if second.hides != 1 || first.hides != 1 {
+		t.Errorf("Pushing hid the new instance %d and the old one %d times, expected 1 each", second.hides, first.hides)
+	}
+}
@@ -0,0 +1,347 @@
+package engo
+
+// Scheduler calls functions after a delay or at an interval, and runs Sequences. Every Scene has its own Scheduler,
+// which is updated with the delta of the Scene on the update goroutine, so nothing runs while the Clock is paused.
+type Scheduler struct {
+	timeline string
+	mailbox  *MessageManager
+	tasks    []*TimerHandle
+}
+
+// NewScheduler creates a Scheduler. It has to be updated every frame by calling its Update method.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// CurrentScheduler returns the Scheduler of the current Scene, or nil if no Scene has been set.
+func CurrentScheduler() *Scheduler {
+	sceneMutex.RLock()
+	defer sceneMutex.RUnlock()
+	if currentScene == nil {
+		return nil
+	}
+	w, ok := scenes[currentScene.Type()]
+	if !ok {
+		return nil
+	}
+	return w.scheduler
+}
+
+// After calls the function once after the given amount of seconds, using the Scheduler of the current Scene. Without
+// a current Scene, nothing is scheduled and the returned handle is cancelled.
+func After(seconds float32, f func()) *TimerHandle {
+	s := CurrentScheduler()
+	if s == nil {
+		return unscheduled()
+	}
+	return s.After(seconds, f)
+}
+
+// Every calls the function every time the given amount of seconds has passed, using the Scheduler of the current
+// Scene. Without a current Scene, nothing is scheduled and the returned handle is cancelled.
+func Every(seconds float32, f func()) *TimerHandle {
+	s := CurrentScheduler()
+	if s == nil {
+		return unscheduled()
+	}
+	return s.Every(seconds, f)
+}
+
+// StartSequence runs the Sequence, using the Scheduler of the current Scene. Without a current Scene, it doesn't run
+// and the returned handle is cancelled.
+func StartSequence(seq *Sequence) *TimerHandle {
+	s := CurrentScheduler()
+	if s == nil {
+		return unscheduled()
+	}
+	return s.Start(seq)
+}
+
+// unscheduled returns the handle of something that couldn't be scheduled, since there is no current Scene.
+func unscheduled() *TimerHandle {
+	warning("No current Scene to schedule with, set a Scene first")
+	return &TimerHandle{cancelled: true}
+}
+
+// SetTimeline makes the Scheduler use the delta of the named Timeline of `Time`, instead of the delta it's updated
+// with. An empty name uses the delta it's updated with again.
+func (s *Scheduler) SetTimeline(name string) {
+	s.timeline = name
+}
+
+// After calls the function once after the given amount of seconds.
+func (s *Scheduler) After(seconds float32, f func()) *TimerHandle {
+	return s.add(&TimerHandle{remaining: seconds, fn: f})
+}
+
+// Every calls the function every time the given amount of seconds has passed. If more than one interval passed
+// during a frame, the function is called once for each of them. An interval of 0 calls it every frame.
+func (s *Scheduler) Every(seconds float32, f func()) *TimerHandle {
+	return s.add(&TimerHandle{remaining: seconds, interval: seconds, repeat: true, fn: f})
+}
+
+// Start runs the Sequence.
+func (s *Scheduler) Start(seq *Sequence) *TimerHandle {
+	return s.add(&TimerHandle{seq: seq})
+}
+
+func (s *Scheduler) add(t *TimerHandle) *TimerHandle {
+	t.scheduler = s
+	s.tasks = append(s.tasks, t)
+	return t
+}
+
+// Clear cancels everything that has been scheduled.
+func (s *Scheduler) Clear() {
+	for _, t := range s.tasks {
+		t.Cancel()
+	}
+	s.tasks = nil
+}
+
+// Update advances the Scheduler by dt seconds, calling the functions that are due. Nothing happens when dt is 0, such
+// as while the Clock is paused. Functions scheduled during the Update are first considered during the next one.
+func (s *Scheduler) Update(dt float32) {
+	if s.timeline != "" && Time != nil {
+		dt = Time.Timeline(s.timeline).Delta()
+	}
+	if dt <= 0 {
+		return
+	}
+
+	tasks := s.tasks
+	for _, t := range tasks {
+		if t.Active() && !t.paused {
+			t.advance(dt)
+		}
+	}
+
+	active := s.tasks[:0]
+	for _, t := range s.tasks {
+		if t.Active() {
+			active = append(active, t)
+		}
+	}
+	for i := len(active); i < len(s.tasks); i++ {
+		s.tasks[i] = nil
+	}
+	s.tasks = active
+}
+
+// TimerHandle is returned when something is scheduled, so that it can be paused or cancelled.
+type TimerHandle struct {
+	scheduler *Scheduler
+
+	remaining, interval float32
+	repeat              bool
+	fn                  func()
+
+	seq *Sequence
+	// step is the current step of the Sequence, entered is set once it has been started
+	step     int
+	entered  bool
+	frames   int
+	received bool
+	listener MessageHandlerId
+	mailbox  *MessageManager
+
+	paused, cancelled, done bool
+}
+
+// Cancel stops the timer, its function won't be called anymore.
+func (t *TimerHandle) Cancel() {
+	if t.cancelled || t.done {
+		return
+	}
+	t.cancelled = true
+	if t.mailbox != nil {
+		t.mailbox.StopListen(t.seq.steps[t.step].messageType, t.listener)
+		t.mailbox = nil
+	}
+}
+
+// Pause pauses the timer until Resume is called.
+func (t *TimerHandle) Pause() {
+	t.paused = true
+}
+
+// Resume resumes the timer after it has been paused.
+func (t *TimerHandle) Resume() {
+	t.paused = false
+}
+
+// Paused indicates whether the timer is paused.
+func (t *TimerHandle) Paused() bool {
+	return t.paused
+}
+
+// Active indicates whether the timer has neither been cancelled nor finished.
+func (t *TimerHandle) Active() bool {
+	return !t.cancelled && !t.done
+}
+
+// Remaining returns the amount of seconds until the function is called next, or until the Sequence continues if it
+// waits for a duration.
+func (t *TimerHandle) Remaining() float32 {
+	if t.remaining < 0 {
+		return 0
+	}
+	return t.remaining
+}
+
+func (t *TimerHandle) advance(dt float32) {
+	if t.seq != nil {
+		t.advanceSequence(dt)
+		return
+	}
+
+	t.remaining -= dt
+	for t.remaining <= 0 && t.Active() {
+		t.fn()
+		if !t.repeat {
+			t.done = true
+			return
+		}
+		if t.interval <= 0 {
+			t.remaining = 0
+			return
+		}
+		t.remaining += t.interval
+	}
+}
+
+type sequenceStepKind uint8
+
+const (
+	stepDo sequenceStepKind = iota
+	stepWait
+	stepWaitFrames
+	stepWaitUntil
+	stepWaitMessage
+)
+
+type sequenceStep struct {
+	kind        sequenceStepKind
+	fn          func()
+	seconds     float32
+	frames      int
+	cond        func() bool
+	messageType string
+	filter      func(Message) bool
+}
+
+// Sequence is a coroutine-style script: a list of steps run one after the other by a Scheduler, which can wait for
+// some time, some frames, a condition or a message before continuing. All steps run on the update goroutine.
+//
+//	engo.StartSequence(engo.NewSequence().
+//		Do(openDoor).
+//		Wait(2).
+//		WaitMessage("PlayerEnteredMessage", nil).
+//		Do(closeDoor))
+type Sequence struct {
+	steps []sequenceStep
+}
+
+// NewSequence creates an empty Sequence.
+func NewSequence() *Sequence {
+	return &Sequence{}
+}
+
+// Do calls the function, and continues immediately.
+func (seq *Sequence) Do(f func()) *Sequence {
+	seq.steps = append(seq.steps, sequenceStep{kind: stepDo, fn: f})
+	return seq
+}
+
+// Wait waits for the given amount of seconds.
+func (seq *Sequence) Wait(seconds float32) *Sequence {
+	seq.steps = append(seq.steps, sequenceStep{kind: stepWait, seconds: seconds})
+	return seq
+}
+
+// WaitFrames waits for the given amount of frames, 1 continues during the next frame.
+func (seq *Sequence) WaitFrames(frames int) *Sequence {
+	seq.steps = append(seq.steps, sequenceStep{kind: stepWaitFrames, frames: frames})
+	return seq
+}
+
+// WaitUntil waits until the condition is true. It is checked once per frame.
+func (seq *Sequence) WaitUntil(cond func() bool) *Sequence {
+	seq.steps = append(seq.steps, sequenceStep{kind: stepWaitUntil, cond: cond})
+	return seq
+}
+
+// WaitMessage waits until a message of the given type is dispatched through the Mailbox of the Scene, and the filter
+// returns true for it. A nil filter accepts any message of that type. The Sequence continues during the next update
+// of the Scheduler.
+func (seq *Sequence) WaitMessage(messageType string, filter func(Message) bool) *Sequence {
+	seq.steps = append(seq.steps, sequenceStep{kind: stepWaitMessage, messageType: messageType, filter: filter})
+	return seq
+}
+
+// advanceSequence runs the steps of the Sequence until one of them has to wait. The delta only counts towards the
+// step that was waiting when the update started, the steps entered during the update start waiting from the next one.
+func (t *TimerHandle) advanceSequence(dt float32) {
+	carried := true
+	for t.step < len(t.seq.steps) {
+		st := &t.seq.steps[t.step]
+		if !t.entered {
+			t.enter(st)
+		}
+		if carried {
+			t.remaining -= dt
+			t.frames--
+		}
+		if !t.ready(st) {
+			return
+		}
+		if t.mailbox != nil {
+			t.mailbox.StopListen(st.messageType, t.listener)
+			t.mailbox = nil
+		}
+		if !t.Active() {
+			return
+		}
+		t.step++
+		t.entered = false
+		carried = false
+	}
+	t.done = true
+}
+
+func (t *TimerHandle) enter(st *sequenceStep) {
+	t.entered = true
+	t.remaining = st.seconds
+	t.frames = st.frames
+	if st.kind != stepWaitMessage {
+		return
+	}
+
+	t.received = false
+	t.mailbox = t.scheduler.mailbox
+	if t.mailbox == nil {
+		t.mailbox = Mailbox
+	}
+	t.listener = t.mailbox.Listen(st.messageType, func(msg Message) {
+		if st.filter == nil || st.filter(msg) {
+			t.received = true
+		}
+	})
+}
+
+func (t *TimerHandle) ready(st *sequenceStep) bool {
+	switch st.kind {
+	case stepDo:
+		st.fn()
+		return true
+	case stepWait:
+		return t.remaining <= 0
+	case stepWaitFrames:
+		return t.frames <= 0
+	case stepWaitUntil:
+		return st.cond()
+	case stepWaitMessage:
+		return t.received
+	}
+	return true
+}
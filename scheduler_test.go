@@ -0,0 +1,180 @@
+package engo
+
+import "testing"
+
+type schedulerTestMessage struct {
+	value int
+}
+
+func (schedulerTestMessage) Type() string { return "schedulerTestMessage" }
+
+func TestSchedulerAfter(t *testing.T) {
+	s := NewScheduler()
+	calls := 0
+	h := s.After(1, func() { calls++ })
+
+	s.Update(0.5)
+	if calls != 0 {
+		t.Errorf("After called the function after 0.5 seconds")
+	}
+	if h.Remaining() != 0.5 {
+		t.Errorf("Remaining was %v, expected 0.5", h.Remaining())
+	}
+	s.Update(0)
+	s.Update(0.6)
+	if calls != 1 {
+		t.Errorf("After called the function %d times after 1.1 seconds, expected 1", calls)
+	}
+	if h.Active() {
+		t.Error("Handle was still active after the function was called")
+	}
+	s.Update(2)
+	if calls != 1 {
+		t.Errorf("After called the function %d times, expected 1", calls)
+	}
+
+	h = s.After(1, func() { calls++ })
+	h.Cancel()
+	s.Update(2)
+	if calls != 1 {
+		t.Error("After called the function after it was cancelled")
+	}
+}
+
+func TestSchedulerWithoutScene(t *testing.T) {
+	previous := currentScene
+	defer func() { currentScene = previous }()
+
+	calls := 0
+	for _, scene := range []Scene{nil, &cacheTestScene{name: "schedulerUnregistered"}} {
+		// the second Scene is current without having been registered
+		currentScene = scene
+		if CurrentScheduler() != nil {
+			t.Errorf("CurrentScheduler returned a Scheduler for Scene %v", scene)
+		}
+		handles := []*TimerHandle{
+			After(1, func() { calls++ }),
+			Every(1, func() { calls++ }),
+			StartSequence(NewSequence().Do(func() { calls++ })),
+		}
+		for _, h := range handles {
+			if h == nil || h.Active() {
+				t.Errorf("Handle %v was active without a Scheduler", h)
+			}
+			h.Cancel()
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Functions were called %d times without a Scheduler", calls)
+	}
+}
+
+func TestSchedulerEvery(t *testing.T) {
+	s := NewScheduler()
+	calls := 0
+	h := s.Every(0.25, func() { calls++ })
+
+	s.Update(0.3)
+	if calls != 1 {
+		t.Errorf("Every called the function %d times after 0.3 seconds, expected 1", calls)
+	}
+	s.Update(0.5)
+	if calls != 3 {
+		t.Errorf("Every called the function %d times after 0.8 seconds, expected 3", calls)
+	}
+
+	h.Pause()
+	s.Update(1)
+	if calls != 3 {
+		t.Errorf("Every called the function while it was paused")
+	}
+	h.Resume()
+	s.Update(0.25)
+	if calls != 4 {
+		t.Errorf("Every called the function %d times after it was resumed, expected 4", calls)
+	}
+
+	h.Cancel()
+	s.Update(1)
+	if calls != 4 {
+		t.Error("Every called the function after it was cancelled")
+	}
+	if len(s.tasks) != 0 {
+		t.Errorf("Scheduler kept %d tasks after they were cancelled", len(s.tasks))
+	}
+}
+
+func TestSchedulerSequence(t *testing.T) {
+	s := NewScheduler()
+	s.mailbox = &MessageManager{}
+	var steps []string
+	done := false
+	h := s.Start(NewSequence().
+		Do(func() { steps = append(steps, "start") }).
+		WaitFrames(2).
+		Do(func() { steps = append(steps, "frames") }).
+		Wait(1).
+		Do(func() { steps = append(steps, "wait") }).
+		WaitMessage("schedulerTestMessage", func(msg Message) bool {
+			return msg.(schedulerTestMessage).value == 2
+		}).
+		Do(func() { steps = append(steps, "message") }).
+		WaitUntil(func() bool { return done }).
+		Do(func() { steps = append(steps, "done") }))
+
+	expect := func(n int) {
+		t.Helper()
+		if len(steps) != n {
+			t.Fatalf("Sequence ran %d steps, expected %d: %v", len(steps), n, steps)
+		}
+	}
+
+	s.Update(0.1)
+	expect(1)
+	s.Update(0.1)
+	expect(1)
+	s.Update(0.1)
+	expect(2)
+	s.Update(0.5)
+	expect(2)
+	s.Update(0.5)
+	expect(3)
+
+	s.mailbox.Dispatch(schedulerTestMessage{1})
+	s.Update(0.1)
+	expect(3)
+	s.mailbox.Dispatch(schedulerTestMessage{2})
+	s.Update(0.1)
+	expect(4)
+
+	s.Update(0.1)
+	expect(4)
+	done = true
+	s.Update(0.1)
+	expect(5)
+	if h.Active() {
+		t.Error("Sequence was still active after its last step")
+	}
+	// Listeners are removed during the next Dispatch
+	s.mailbox.Dispatch(schedulerTestMessage{2})
+	if n := len(s.mailbox.listeners["schedulerTestMessage"]); n != 0 {
+		t.Errorf("Sequence left %d message listeners behind", n)
+	}
+}
+
+func TestSchedulerClockPause(t *testing.T) {
+	Run(RunOptions{NoRun: true, HeadlessMode: true}, &testScene{})
+	calls := 0
+	After(1, func() { calls++ })
+
+	Time.Pause()
+	updateScenes(Time.Delta())
+	if calls != 0 {
+		t.Error("Scheduler called the function while the Clock was paused")
+	}
+	Time.Unpause()
+	updateScenes(2)
+	if calls != 1 {
+		t.Errorf("Scheduler called the function %d times, expected 1", calls)
+	}
+}
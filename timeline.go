@@ -0,0 +1,137 @@
+package engo
+
+import "github.com/EngoEngine/ecs"
+
+// Timeline is a named time line on top of a Clock, with its own time scale, freezes and pausing. Systems on different
+// Timelines receive different deltas, so that gameplay can be paused or slowed down while the UI and audio keep
+// running, for example.
+type Timeline struct {
+	clock *Clock
+	name  string
+
+	scale        float32
+	paused       bool
+	frozen       bool
+	freezeFrames int
+	unscaled     bool
+}
+
+// Timeline returns the Timeline with the given name, creating it if needed.
+func (c *Clock) Timeline(name string) *Timeline {
+	if t, ok := c.timelines[name]; ok {
+		return t
+	}
+	if c.timelines == nil {
+		c.timelines = make(map[string]*Timeline)
+	}
+	t := &Timeline{clock: c, name: name, scale: 1}
+	c.timelines[name] = t
+	return t
+}
+
+// Name returns the name of the Timeline.
+func (t *Timeline) Name() string {
+	return t.name
+}
+
+// Delta is the delta of the Clock multiplied by the time scale of the Timeline. It is 0 while the Timeline is paused
+// or frozen.
+func (t *Timeline) Delta() float32 {
+	if t.paused || t.frozen {
+		return 0
+	}
+	dt := t.clock.Delta()
+	if t.unscaled {
+		dt = t.clock.RealDelta()
+	}
+	return dt * t.scale
+}
+
+// SetTimeScale sets the factor the delta of the Clock is multiplied by for this Timeline.
+func (t *Timeline) SetTimeScale(scale float32) {
+	if scale < 0 {
+		scale = 0
+	}
+	t.scale = scale
+}
+
+// TimeScale returns the factor the delta of the Clock is multiplied by for this Timeline.
+func (t *Timeline) TimeScale() float32 {
+	return t.scale
+}
+
+// SetUnscaled makes the Timeline ignore the time scale, freezes and pausing of the Clock, such as for a UI that keeps
+// running during bullet time.
+func (t *Timeline) SetUnscaled(unscaled bool) {
+	t.unscaled = unscaled
+}
+
+// Unscaled indicates whether the Timeline ignores the time scale, freezes and pausing of the Clock.
+func (t *Timeline) Unscaled() bool {
+	return t.unscaled
+}
+
+// Pause pauses the Timeline.
+func (t *Timeline) Pause() {
+	t.paused = true
+}
+
+// Unpause unpauses the Timeline.
+func (t *Timeline) Unpause() {
+	t.paused = false
+}
+
+// Paused indicates whether the Timeline is paused.
+func (t *Timeline) Paused() bool {
+	return t.paused
+}
+
+// Freeze makes the delta of the Timeline 0 for the given amount of frames, starting with the next tick.
+func (t *Timeline) Freeze(frames int) {
+	if frames > t.freezeFrames {
+		t.freezeFrames = frames
+	}
+}
+
+// Frozen indicates whether the current frame is frozen for the Timeline.
+func (t *Timeline) Frozen() bool {
+	return t.frozen
+}
+
+// stopped indicates whether time doesn't pass for the Timeline at all.
+func (t *Timeline) stopped() bool {
+	if t.paused || t.frozen {
+		return true
+	}
+	return !t.unscaled && (t.clock.paused || t.clock.frozen)
+}
+
+// TimelineSystem is an optional interface for ecs Systems that are updated with the delta of a Timeline of `Time`,
+// instead of the delta of the Clock. Their fixed updates are skipped while the Timeline is paused or frozen.
+type TimelineSystem interface {
+	// Timeline returns the name of the Timeline of the System.
+	Timeline() string
+}
+
+// update updates the Updater with the delta of the frame. The Systems of an `*ecs.World` that implement
+// TimelineSystem are given the delta of their Timeline instead.
+func update(u Updater, dt float32) {
+	w, ok := u.(*ecs.World)
+	if !ok || Time == nil {
+		u.Update(dt)
+		return
+	}
+	for _, sys := range w.Systems() {
+		if ts, ok := sys.(TimelineSystem); ok {
+			sys.Update(Time.Timeline(ts.Timeline()).Delta())
+			continue
+		}
+		sys.Update(dt)
+	}
+}
+
+// timelineStopped indicates whether the System is on a Timeline that is paused or frozen.
+func timelineStopped(sys ecs.System) bool {
+	ts, ok := sys.(TimelineSystem)
+	return ok && Time != nil && Time.Timeline(ts.Timeline()).stopped()
+}